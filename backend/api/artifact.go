@@ -90,6 +90,7 @@ type ArtifactHubDeployRequest struct {
 	Values        map[string]string  `json:"values"`        // Custom values
 	ConfigMaps    []k8s.ConfigMapRef `json:"configMaps"`    // ConfigMap references
 	WorkloadLabel string             `json:"workloadLabel"` // KubeStellar workload label
+	Context       string             `json:"context"`       // WDS context, defaults to the one selected in the UI
 }
 
 // ArtifactHubSearchRequest represents search parameters for Artifact Hub
@@ -219,6 +220,12 @@ func DeployFromArtifactHub(c *gin.Context) {
 		Values:        req.Values,
 		ConfigMaps:    req.ConfigMaps,
 		WorkloadLabel: req.WorkloadLabel,
+		Context:       req.Context,
+	}
+	if helmReq.Context == "" {
+		if cookieContext, err := c.Cookie("ui-wds-context"); err == nil {
+			helmReq.Context = cookieContext
+		}
 	}
 
 	// Parse the "store" parameter from the query string
@@ -229,7 +236,7 @@ func DeployFromArtifactHub(c *gin.Context) {
 	}
 
 	// Deploy using existing Helm deployment function
-	release, err := k8s.DeployHelmChart(helmReq, store)
+	release, err := k8s.DeployHelmChart(c.Request.Context(), helmReq, store)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Deployment failed", "details": err.Error()})
 		return
@@ -1,23 +1,23 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
-	"os/exec"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/gitops"
 	"github.com/kubestellar/ui/k8s"
-	"github.com/kubestellar/ui/redis"
 	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/cli"
 )
 
 type DeployRequest struct {
@@ -52,20 +52,6 @@ type GitHubDirectoryResponse []struct {
 	Type        string `json:"type"`
 }
 
-// GitHubWebhookPayload defines the expected structure of the webhook request
-type GitHubWebhookPayload struct {
-	Repository struct {
-		CloneURL string `json:"clone_url"`
-	} `json:"repository"`
-	Ref     string `json:"ref"` // Format: "refs/heads/main"
-	Commits []struct {
-		ID       string   `json:"id"`
-		Message  string   `json:"message"`
-		URL      string   `json:"url"`
-		Modified []string `json:"modified"`
-	} `json:"commits"`
-}
-
 // Fetches YAML files from a GitHub repository directory without cloning
 func fetchGitHubYAMLs(repoURL, folderPath, branch, gitUsername, gitToken string) (map[string][]byte, error) {
 	// Extract owner and repo from the GitHub URL
@@ -188,7 +174,8 @@ func fetchGitHubYAMLs(repoURL, folderPath, branch, gitUsername, gitToken string)
 	return yamlFiles, nil
 }
 
-// DeployHandler handles deployment requests
+// DeployHandler handles deployment requests. The repository is registered as a
+// GitOps source so that webhooks and the reconcile loop keep it applied.
 func DeployHandler(c *gin.Context) {
 	var request DeployRequest
 
@@ -212,58 +199,77 @@ func DeployHandler(c *gin.Context) {
 	deploymentID := c.Query("id")
 
 	if branch == "" {
-		branch = "main" // Default branch
+		branch = gitops.DefaultBranch
+	}
+
+	// Fields left empty keep the values of an existing source, or get their defaults
+	source, err := gitops.SourceForDeploy(&gitops.Source{
+		Name:              c.DefaultQuery("source", gitops.SourceNameFor(request.RepoURL)),
+		RepoURL:           request.RepoURL,
+		Branch:            branch,
+		Path:              request.FolderPath,
+		Username:          gitUsername,
+		Token:             gitToken,
+		WorkloadLabel:     request.WorkloadLabel,
+		ReconcileInterval: c.Query("reconcile_interval"),
+	})
+	var mismatch *gitops.SourceMismatchError
+	if errors.As(err, &mismatch) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Source tracks another repository, branch or path", "details": err.Error()})
+		return
 	}
-
-	// If workload label is not provided, use the GitHub project name from the repo URL
-	if request.WorkloadLabel == "" {
-		// Extract project name from repo URL
-		// Example: from https://github.com/org/project.git to project
-		repoBase := filepath.Base(request.RepoURL)
-		projectName := strings.TrimSuffix(repoBase, filepath.Ext(repoBase))
-		request.WorkloadLabel = projectName
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load GitOps source", "details": err.Error()})
+		return
 	}
-
-	// Save deployment configuration in Redis for webhook usage
-	redis.SetFilePath(request.FolderPath)
-	redis.SetRepoURL(request.RepoURL)
-	redis.SetBranch(branch)
-	redis.SetGitToken(gitToken)
-	redis.SetWorkloadLabel(request.WorkloadLabel) // Store workload label in Redis
-
-	tempDir := fmt.Sprintf("/tmp/%d", time.Now().Unix())
-	cloneURL := request.RepoURL
-
-	if gitUsername != "" && gitToken != "" {
-		cloneURL = fmt.Sprintf("https://%s:%s@%s", gitUsername, gitToken, request.RepoURL[8:])
+	if err := source.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	request.WorkloadLabel = source.WorkloadLabel
 
 	// Clone the repository
-	cmd := exec.Command("git", "clone", "-b", branch, cloneURL, tempDir)
-	if err := cmd.Run(); err != nil {
+	checkout, err := gitops.Clone(source)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clone repo", "details": err.Error()})
 		return
 	}
-	defer os.RemoveAll(tempDir)
+	defer checkout.Cleanup()
 
-	deployPath := tempDir
-	if request.FolderPath != "" {
-		deployPath = filepath.Join(tempDir, request.FolderPath)
-	}
-
-	if _, err := os.Stat(deployPath); os.IsNotExist(err) {
+	deployPath, err := checkout.DeployPath(source.Path)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Specified folder does not exist"})
 		return
 	}
 
 	// Deploy the manifests with workload label
-	deploymentTree, err := k8s.DeployManifests(deployPath, dryRun, dryRunStrategy, request.WorkloadLabel)
+	deploymentTree, err := k8s.DeployManifests(c.Request.Context(), deployPath, dryRun, dryRunStrategy, request.WorkloadLabel, k8s.ApplyOptionsFromRequest(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Deployment failed", "details": err.Error()})
+		response := gin.H{"error": "Deployment failed", "details": err.Error()}
+		var phaseErr *k8s.PhaseError
+		if errors.As(err, &phaseErr) {
+			response["failed_phase"] = phaseErr.Phase
+			response["resource"] = phaseErr
+		}
+		c.JSON(http.StatusInternalServerError, response)
 		return
 	}
 
-	// Store deployment data in ConfigMap if it's created by the user
+	// Save the source for webhook and reconcile usage once the repository deployed successfully
+	sourceSaved, secretGenerated := false, false
+	if !dryRun {
+		if secretGenerated, err = gitops.SaveSource(source); err != nil {
+			log.Printf("Warning: failed to save GitOps source %s: %v", source.Name, err)
+		} else {
+			sourceSaved = true
+			// Later syncs prune against the objects this deploy produced
+			if err := gitops.RecordInventory(source.Name, checkout.Commit, deploymentTree); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+
+	// Record the deployment in the history if it's created by the user
 	if createdByMe {
 		// Create timestamp for deployment ID if not provided
 		timestamp := time.Now().Format("20060102150405")
@@ -271,52 +277,21 @@ func DeployHandler(c *gin.Context) {
 			deploymentID = fmt.Sprintf("github-%s-%s", filepath.Base(request.RepoURL), timestamp)
 		}
 
-		// Prepare deployment data for ConfigMap
-		deploymentData := map[string]string{
-			"id":               deploymentID,
-			"timestamp":        time.Now().Format(time.RFC3339),
-			"repo_url":         request.RepoURL,
-			"folder_path":      request.FolderPath,
-			"branch":           branch,
-			"dry_run":          fmt.Sprintf("%v", dryRun),
-			"dry_run_strategy": dryRunStrategy,
-			"created_by_me":    "true",
-			"workload_label":   request.WorkloadLabel, // Store workload label in deployment data
-		}
-
-		// Convert deployment tree to JSON string for storage
-		deploymentTreeJSON, _ := json.Marshal(deploymentTree)
-		deploymentData["deployment_tree"] = string(deploymentTreeJSON)
-
-		// Get existing deployments
-		existingDeployments, err := k8s.GetGithubDeployments("its1")
-		if err != nil {
-			// If error, start with empty deployments array
-			existingDeployments = []any{}
-		}
-
-		// Add new deployment to existing ones
-		newDeployment := map[string]interface{}{
+		deployment := map[string]interface{}{
 			"id":             deploymentID,
-			"timestamp":      deploymentData["timestamp"],
-			"repo_url":       deploymentData["repo_url"],
-			"folder_path":    deploymentData["folder_path"],
-			"branch":         deploymentData["branch"],
-			"dry_run":        deploymentData["dry_run"],
-			"created_by_me":  deploymentData["created_by_me"],
-			"workload_label": deploymentData["workload_label"], // Include workload label
+			"timestamp":      time.Now().Format(time.RFC3339),
+			"context":        k8s.WDSContext(),
+			"repo_url":       request.RepoURL,
+			"folder_path":    request.FolderPath,
+			"branch":         branch,
+			"dry_run":        fmt.Sprintf("%v", dryRun),
+			"created_by_me":  "true",
+			"workload_label": request.WorkloadLabel, // Include workload label
+			"source":         source.Name,
+			"commit_refs":    checkout.Commit,
 		}
 
-		existingDeployments = append(existingDeployments, newDeployment)
-		deploymentsJSON, _ := json.Marshal(existingDeployments)
-
-		// Store in ConfigMap
-		cmData := map[string]string{
-			"deployments": string(deploymentsJSON),
-		}
-
-		err = k8s.StoreGitHubDeployment(cmData)
-		if err != nil {
+		if err := k8s.RecordGitHubDeployment(deployment); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store deployment data", "details": err.Error()})
 			return
 		}
@@ -334,6 +309,20 @@ func DeployHandler(c *gin.Context) {
 		"stored":          createdByMe,
 		"id":              deploymentID,
 		"workload_label":  request.WorkloadLabel,
+		"commit":          checkout.Commit,
+	}
+	if sourceSaved {
+		response["source"] = source.Name
+		response["webhook_url"] = "/api/webhook/" + source.Name
+		// Shown once: webhooks must be signed with it, and it is redacted from then on
+		if secretGenerated {
+			response["webhook_secret"] = source.WebhookSecret
+		}
+	}
+
+	if len(deploymentTree.Conflicts) > 0 {
+		response["message"] = "Deployment completed, but some resources were skipped because other managers own their fields. Retry with force=true to take ownership."
+		response["conflicts"] = deploymentTree.Conflicts
 	}
 
 	if createdByMe {
@@ -344,175 +333,211 @@ func DeployHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
-func GitHubWebhookHandler(c *gin.Context) {
+
+// webhookRequest is a received webhook: the raw body that providers sign and the unwrapped push payload
+type webhookRequest struct {
+	provider string
+	header   http.Header
+	body     []byte
+	payload  []byte
+}
+
+// readWebhook reads the request body once so it can be both verified and parsed.
+// GitHub sends the payload either as the JSON body or, for form-encoded webhooks,
+// wrapped in a "payload" field.
+func readWebhook(c *gin.Context) (*webhookRequest, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %v", err)
+	}
+
+	request := &webhookRequest{
+		provider: gitops.DetectProvider(c.Request.Header),
+		header:   c.Request.Header,
+		body:     body,
+		payload:  body,
+	}
+
+	if strings.HasPrefix(c.ContentType(), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid form body: %v", err)
+		}
+		payload := form.Get("payload")
+		if payload == "" {
+			return nil, fmt.Errorf("missing payload form field")
+		}
+		request.payload = []byte(payload)
+		return request, nil
+	}
+
 	// Create a wrapper for the nested JSON structure
 	var webhookWrapper struct {
 		Payload string `json:"payload"`
 	}
-
-	if err := c.ShouldBindJSON(&webhookWrapper); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook wrapper", "details": err.Error()})
-		return
+	if err := json.Unmarshal(body, &webhookWrapper); err == nil && webhookWrapper.Payload != "" {
+		request.payload = []byte(webhookWrapper.Payload)
 	}
+	return request, nil
+}
 
-	// Parse the inner payload JSON string
-	var request GitHubWebhookPayload
-	if err := json.Unmarshal([]byte(webhookWrapper.Payload), &request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse webhook payload", "details": err.Error()})
-		return
+// storeWebhookDeployment records a webhook-driven sync in the GitHub deployment history
+func storeWebhookDeployment(source *gitops.Source, event *gitops.PushEvent, result *gitops.PushResult) error {
+	// Create timestamp for deployment ID
+	timestamp := time.Now().Format("20060102150405")
+	deploymentID := fmt.Sprintf("github-webhook-%s-%s", filepath.Base(source.RepoURL), timestamp)
+
+	deployment := map[string]interface{}{
+		"id":              deploymentID,
+		"timestamp":       time.Now().Format(time.RFC3339),
+		"context":         k8s.WDSContext(),
+		"repo_url":        source.RepoURL,
+		"folder_path":     source.Path,
+		"branch":          source.Branch,
+		"changed_files":   result.ChangedFiles,
+		"file_changes":    result.Changes,
+		"objects":         result.Record.DeploymentTree.Objects,
+		"pruned":          result.Record.Pruned,
+		"webhook":         true,
+		"commit_refs":     event.HeadCommit(),
+		"workload_label":  source.WorkloadLabel,
+		"source":          source.Name,
+		"deployment_tree": result.Record.DeploymentTree,
+	}
+
+	return k8s.RecordGitHubDeployment(deployment)
+}
+
+// handlePushForSource syncs one source for a push event and records the deployment
+func handlePushForSource(source *gitops.Source, event *gitops.PushEvent) (*gitops.PushResult, error) {
+	result, err := gitops.HandlePush(source, event)
+	if err != nil {
+		return result, err
+	}
+	if result.Synced {
+		if err := storeWebhookDeployment(source, event, result); err != nil {
+			return result, fmt.Errorf("failed to store deployment data: %v", err)
+		}
 	}
+	return result, nil
+}
 
-	// Get deployment configuration from Redis
-	folderPath, err := redis.GetFilePath()
+// SourceWebhookHandler handles push webhooks sent to /api/webhook/:source
+func SourceWebhookHandler(c *gin.Context) {
+	source, err := gitops.GetSource(c.Param("source"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No deployment configured for this repository"})
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get the configured branch from Redis
-	storedBranch, err := redis.GetBranch()
+	request, err := readWebhook(c)
 	if err != nil {
-		storedBranch = "main" // Default branch if not set
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload", "details": err.Error()})
+		return
 	}
 
-	// Check if the webhook is for the configured branch
-	branchFromRef := strings.TrimPrefix(request.Ref, "refs/heads/")
-	if branchFromRef != storedBranch {
-		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Ignoring push to branch '%s'. Configured branch is '%s'", branchFromRef, storedBranch)})
+	if err := gitops.VerifyWebhook(source, request.provider, request.header, request.body); err != nil {
+		respondWebhookRejected(c, err)
 		return
 	}
 
-	// Get workload label from Redis
-	workloadLabel, err := redis.GetWorkloadLabel()
-	if err != nil || workloadLabel == "" {
-		// If no workload label is stored, extract project name from repository URL
-		repoUrl := request.Repository.CloneURL
-		repoBase := filepath.Base(repoUrl)
-		projectName := strings.TrimSuffix(repoBase, filepath.Ext(repoBase))
-		workloadLabel = projectName
+	if !gitops.IsPushEvent(request.provider, request.header) {
+		c.JSON(http.StatusOK, gin.H{"message": "Ignoring non-push event"})
+		return
 	}
 
-	// Check if any changes occurred in the specified folder path
-	relevantChanges := false
-	var changedFiles []string
-
-	// If folderPath is empty, any change is relevant
-	if folderPath == "" {
-		relevantChanges = len(request.Commits) > 0
-	} else {
-		// Check each commit for changes in the relevant folder
-		for _, commit := range request.Commits {
-			for _, file := range commit.Modified {
-				if strings.HasPrefix(file, folderPath) {
-					relevantChanges = true
-					changedFiles = append(changedFiles, file)
-				}
-			}
-		}
+	event, err := gitops.ParsePushEvent(request.provider, request.payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse webhook payload", "details": err.Error()})
+		return
 	}
 
-	if !relevantChanges {
-		c.JSON(http.StatusOK, gin.H{"message": "No relevant changes detected in the specified folder path"})
+	result, err := handlePushForSource(source, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Deployment failed", "details": err.Error(), "result": result})
 		return
 	}
+	c.JSON(http.StatusOK, result)
+}
 
-	// Get repository URL from webhook payload
-	repoUrl := request.Repository.CloneURL
-	tempDir := fmt.Sprintf("/tmp/%d", time.Now().Unix())
-
-	// Get access token from Redis
-	gitToken, _ := redis.GetGitToken()
-
-	// Always use false for dryRun and empty string for dryRunStrategy
-	dryRun := false
-	dryRunStrategy := ""
-
-	// Clone the repository using token if available
-	cloneURL := repoUrl
-	if gitToken != "" {
-		cloneURL = fmt.Sprintf("https://x-access-token:%s@%s", gitToken, repoUrl[8:])
+// respondWebhookRejected maps a webhook verification error to its HTTP status
+func respondWebhookRejected(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gitops.ErrInvalidSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, gitops.ErrReplayedDelivery):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
+}
 
-	// Clone the specific branch
-	cmd := exec.Command("git", "clone", "-b", storedBranch, cloneURL, tempDir)
-	if err := cmd.Run(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clone repo", "details": err.Error()})
+// GitHubWebhookHandler handles push webhooks sent to /api/webhook and syncs every
+// source that tracks the pushed repository and branch. Sources are only synced when the
+// delivery is signed with their webhook secret.
+func GitHubWebhookHandler(c *gin.Context) {
+	request, err := readWebhook(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook wrapper", "details": err.Error()})
 		return
 	}
-	defer os.RemoveAll(tempDir)
 
-	deployPath := tempDir
-	if folderPath != "" {
-		deployPath = filepath.Join(tempDir, folderPath)
-	}
-
-	if _, err := os.Stat(deployPath); os.IsNotExist(err) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Specified folder does not exist"})
+	if !gitops.IsPushEvent(request.provider, request.header) {
+		c.JSON(http.StatusOK, gin.H{"message": "Ignoring non-push event"})
 		return
 	}
 
-	// For webhook deployments, always deploy and store the data
-	deploymentTree, err := k8s.DeployManifests(deployPath, dryRun, dryRunStrategy, workloadLabel)
+	event, err := gitops.ParsePushEvent(request.provider, request.payload)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Deployment failed", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse webhook payload", "details": err.Error()})
 		return
 	}
 
-	// Create timestamp for deployment ID
-	timestamp := time.Now().Format("20060102150405")
-	deploymentID := fmt.Sprintf("github-webhook-%s-%s", filepath.Base(repoUrl), timestamp)
-
-	// Convert deployment tree to JSON string for storage
-	deploymentTreeJSON, _ := json.Marshal(deploymentTree)
-
-	// Get existing deployments
-	existingDeployments, err := k8s.GetGithubDeployments("its1")
+	sources, err := gitops.FindSourcesForRepo(event.RepoURL, event.Branch)
 	if err != nil {
-		// If error, start with empty deployments array
-		existingDeployments = []any{}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-
-	// Add new deployment to existing ones
-	newDeployment := map[string]interface{}{
-		"id":             deploymentID,
-		"timestamp":      time.Now().Format(time.RFC3339),
-		"repo_url":       repoUrl,
-		"folder_path":    folderPath,
-		"branch":         storedBranch,
-		"changed_files":  changedFiles,
-		"webhook":        true,
-		"commit_refs":    request.Commits[0].ID,
-		"workload_label": workloadLabel,
+	if len(sources) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("No source configured for %s on branch '%s'", event.RepoURL, event.Branch)})
+		return
 	}
 
-	existingDeployments = append(existingDeployments, newDeployment)
-	deploymentsJSON, _ := json.Marshal(existingDeployments)
-
-	// Store in ConfigMap
-	cmData := map[string]string{
-		"deployments":          string(deploymentsJSON),
-		"last_deployment_tree": string(deploymentTreeJSON),
+	var results []*gitops.PushResult
+	var failures []string
+	var rejected error
+	for i := range sources {
+		if err := gitops.VerifyWebhook(&sources[i], request.provider, request.header, request.body); err != nil {
+			log.Printf("Rejected webhook for source %s: %v", sources[i].Name, err)
+			rejected = err
+			continue
+		}
+		result, err := handlePushForSource(&sources[i], event)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sources[i].Name, err))
+		}
+		results = append(results, result)
 	}
 
-	err = k8s.StoreGitHubDeployment(cmData)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store deployment data", "details": err.Error()})
+	if len(results) == 0 && rejected != nil {
+		respondWebhookRejected(c, rejected)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":         "Webhook deployment successful",
-		"deployment":      deploymentTree,
-		"changed_files":   changedFiles,
-		"storage_details": "Deployment data stored in ConfigMap",
-		"workload_label":  workloadLabel,
-	})
+	if len(failures) > 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Deployment failed", "details": strings.Join(failures, "; "), "results": results})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook processed", "results": results})
 }
 
-// createHelmActionConfig initializes the Helm action configuration using WDS1 context
-func CreateHelmActionConfig(namespace string) (*action.Configuration, error) {
+// CreateHelmActionConfig initializes the Helm action configuration for a kubeconfig context
+// and namespace, acting as the user of ctx, without switching the current context of the kubeconfig
+func CreateHelmActionConfig(ctx context.Context, contextName, namespace string) (*action.Configuration, error) {
 	actionConfig := new(action.Configuration)
-	helmSettings := cli.New()
+	helmSettings, err := k8s.HelmSettingsForRequest(ctx, contextName)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := actionConfig.Init(helmSettings.RESTClientGetter(), namespace, "secret", log.Printf); err != nil {
 		return nil, fmt.Errorf("failed to initialize Helm: %v", err)
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/audit"
 	"github.com/kubestellar/ui/k8s"
 	"k8s.io/client-go/kubernetes"
 )
@@ -51,6 +52,7 @@ func DetachClusterHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cluster name is required"})
 		return
 	}
+	audit.SetTarget(c, audit.Target{Verb: "delete", Context: k8s.ITSContext(), Kind: "managedclusters", Name: clusterName})
 
 	// Check if the cluster exists in the OCM hub
 	mutex.RLock()
@@ -59,8 +61,8 @@ func DetachClusterHandler(c *gin.Context) {
 
 	if !exists {
 		// Check directly with the OCM hub
-		itsContext := "its1" // Could be parameterized
-		hubClientset, _, err := k8s.GetClientSetWithConfigContext(itsContext)
+		itsContext := k8s.ITSContext()
+		hubClientset, _, err := k8s.GetClientSetWithConfigForRequest(c.Request.Context(), itsContext)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": fmt.Sprintf("Failed to connect to OCM hub: %v", err),
@@ -94,8 +96,10 @@ func DetachClusterHandler(c *gin.Context) {
 	clusterStatuses[clusterName] = "Detaching"
 	mutex.Unlock()
 
+	// The detachment outlives the request but keeps acting as its user
+	detachCtx := context.WithoutCancel(c.Request.Context())
 	go func() {
-		err := DetachCluster(clusterName)
+		err := DetachCluster(detachCtx, clusterName)
 		mutex.Lock()
 		if err != nil {
 			log.Printf("Cluster '%s' detachment failed: %v", clusterName, err)
@@ -115,17 +119,17 @@ func DetachClusterHandler(c *gin.Context) {
 	})
 }
 
-// DetachCluster handles the process of detaching a cluster from the OCM hub
-func DetachCluster(clusterName string) error {
+// DetachCluster handles the process of detaching a cluster from the OCM hub as the user of ctx
+func DetachCluster(ctx context.Context, clusterName string) error {
 	// Log the start of detachment
 	LogOnboardingEvent(clusterName, "Detaching", "Starting cluster detachment process")
 
 	// 1. Get the ITS hub context
-	itsContext := "its1" // Could be parameterized
+	itsContext := k8s.ITSContext()
 	LogOnboardingEvent(clusterName, "Connecting", "Connecting to ITS hub context: "+itsContext)
 
 	// 2. Get clients for the hub
-	hubClientset, _, err := k8s.GetClientSetWithConfigContext(itsContext)
+	hubClientset, _, err := k8s.GetClientSetWithConfigForRequest(ctx, itsContext)
 	if err != nil {
 		LogOnboardingEvent(clusterName, "Error", "Failed to get hub clientset: "+err.Error())
 		return fmt.Errorf("failed to get hub clientset: %w", err)
@@ -147,7 +151,7 @@ func DetachCluster(clusterName string) error {
 
 	// 4. Delete the managed cluster
 	LogOnboardingEvent(clusterName, "Executing", "Executing detachment operation via Kubernetes API")
-	if err := executeDetachCommand(itsContext, clusterName); err != nil {
+	if err := executeDetachCommand(hubClientset, clusterName); err != nil {
 		LogOnboardingEvent(clusterName, "Error", "Failed to execute detach operation: "+err.Error())
 		return fmt.Errorf("failed to execute detach operation: %w", err)
 	}
@@ -186,13 +190,7 @@ func checkManagedClusterExists(clientset *kubernetes.Clientset, clusterName stri
 }
 
 // executeDetachCommand executes the detachment operation using the Kubernetes SDK
-func executeDetachCommand(itsContext, clusterName string) error {
-	// Get the hub client
-	hubClientset, _, err := k8s.GetClientSetWithConfigContext(itsContext)
-	if err != nil {
-		return fmt.Errorf("failed to get hub clientset: %w", err)
-	}
-
+func executeDetachCommand(hubClientset *kubernetes.Clientset, clusterName string) error {
 	// Delete the managed cluster resource directly using the Kubernetes API
 	result := hubClientset.RESTClient().Delete().
 		AbsPath("/apis/cluster.open-cluster-management.io/v1").
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/gitops"
+)
+
+// sourceResponse is a source together with its last sync, with credentials redacted
+type sourceResponse struct {
+	gitops.Source
+	WebhookURL string             `json:"webhook_url"`
+	Status     *gitops.SyncRecord `json:"status,omitempty"`
+}
+
+// newSourceResponse redacts the credentials of the source. A webhook secret that was just
+// generated is shown, since it has to be configured on the Git provider and is never shown again.
+func newSourceResponse(source gitops.Source, showWebhookSecret bool) sourceResponse {
+	status, _ := gitops.GetStatus(source.Name)
+	response := sourceResponse{
+		Source:     source.Redacted(),
+		WebhookURL: "/api/webhook/" + source.Name,
+		Status:     status,
+	}
+	if showWebhookSecret {
+		response.WebhookSecret = source.WebhookSecret
+	}
+	return response
+}
+
+func sourceNotFound(err error) bool {
+	return strings.Contains(err.Error(), "not found")
+}
+
+// ListGitOpsSourcesHandler lists all GitOps sources with their last sync status
+func ListGitOpsSourcesHandler(c *gin.Context) {
+	sources, err := gitops.ListSources()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]sourceResponse, 0, len(sources))
+	for _, source := range sources {
+		response = append(response, newSourceResponse(source, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(response), "sources": response})
+}
+
+// CreateGitOpsSourceHandler registers a new GitOps source
+func CreateGitOpsSourceHandler(c *gin.Context) {
+	var source gitops.Source
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if _, err := gitops.GetSource(source.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("source %s already exists", source.Name)})
+		return
+	}
+
+	secretGenerated, err := gitops.SaveSource(&source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, newSourceResponse(source, secretGenerated))
+}
+
+// GetGitOpsSourceHandler returns a single GitOps source
+func GetGitOpsSourceHandler(c *gin.Context) {
+	source, err := gitops.GetSource(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newSourceResponse(*source, false))
+}
+
+// UpdateGitOpsSourceHandler replaces the settings of an existing GitOps source.
+// The stored token and webhook secret are kept when the request does not send them,
+// unless the repository URL changes.
+func UpdateGitOpsSourceHandler(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := gitops.GetSource(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var source gitops.Source
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	source.Name = name
+
+	secretGenerated, err := gitops.SaveSource(&source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newSourceResponse(source, secretGenerated))
+}
+
+// DeleteGitOpsSourceHandler removes a GitOps source and stops its reconcile loop.
+// Resources already applied to the WDS are left in place.
+func DeleteGitOpsSourceHandler(c *gin.Context) {
+	name := c.Param("name")
+	if err := gitops.DeleteSource(name); err != nil {
+		status := http.StatusInternalServerError
+		if sourceNotFound(err) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Source %s deleted successfully", name)})
+}
+
+// SyncGitOpsSourceHandler syncs a source immediately
+func SyncGitOpsSourceHandler(c *gin.Context) {
+	source, err := gitops.GetSource(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := gitops.SyncSource(source, gitops.TriggerManual)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Sync failed", "details": err.Error(), "sync": record})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sync successful", "sync": record})
+}
+
+// GetGitOpsSourceStatusHandler returns the last sync of a source
+func GetGitOpsSourceStatusHandler(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := gitops.GetSource(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := gitops.GetStatus(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if status == nil {
+		c.JSON(http.StatusOK, gin.H{"source": name, "message": "Source has not been synced yet"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetGitOpsSourceHistoryHandler returns the recent syncs of a source, newest first
+func GetGitOpsSourceHistoryHandler(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := gitops.GetSource(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	history, err := gitops.GetHistory(name, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"source": name, "count": len(history), "history": history})
+}
+
+// GetGitOpsSourceCommitHandler returns the objects a commit of a source produced and the objects its sync pruned
+func GetGitOpsSourceCommitHandler(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := gitops.GetSource(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := gitops.FindCommit(name, c.Param("commit"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no sync of commit %s found for source %s", c.Param("commit"), name)})
+		return
+	}
+
+	response := gin.H{
+		"source":    name,
+		"commit":    record.Commit,
+		"phase":     record.Phase,
+		"synced_at": record.FinishedAt,
+		"objects":   []interface{}{},
+		"pruned":    record.Pruned,
+	}
+	if record.DeploymentTree != nil {
+		response["objects"] = record.DeploymentTree.Objects
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -130,9 +130,10 @@ func OnboardClusterHandler(c *gin.Context) {
 	ClearOnboardingEvents(clusterName)
 	LogOnboardingEvent(clusterName, "Initiated", "Onboarding process initiated by API request")
 
-	// Start asynchronous onboarding
+	// Start asynchronous onboarding; it outlives the request but keeps acting as its user
+	onboardCtx := context.WithoutCancel(c.Request.Context())
 	go func() {
-		err := OnboardCluster(kubeconfigData, clusterName)
+		err := OnboardCluster(onboardCtx, kubeconfigData, clusterName)
 		mutex.Lock()
 		if err != nil {
 			log.Printf("Cluster '%s' onboarding failed: %v", clusterName, err)
@@ -270,7 +271,7 @@ func approveClusterCSRs(clientset *kubernetes.Clientset, clusterName string) err
 		LogOnboardingEvent(clusterName, "Approving", fmt.Sprintf("Approving %d CSRs", len(pendingCSRs)))
 
 		// Method 1: Use kubectl directly (more reliable based on your experience)
-		approveCmd := exec.Command("kubectl", append([]string{"--context", "its1", "certificate", "approve"}, pendingCSRs...)...)
+		approveCmd := exec.Command("kubectl", append([]string{"--context", k8s.ITSContext(), "certificate", "approve"}, pendingCSRs...)...)
 		output, err := approveCmd.CombinedOutput()
 		if err != nil {
 			LogOnboardingEvent(clusterName, "Error", fmt.Sprintf("Failed to approve CSRs using kubectl: %v, %s", err, string(output)))
@@ -302,7 +303,7 @@ func approveClusterCSRs(clientset *kubernetes.Clientset, clusterName string) err
 	}
 
 	// Also try using clusteradm to accept the cluster (with skip-approve-check)
-	acceptCmd := exec.Command("clusteradm", "--context", "its1", "accept", "--clusters", clusterName, "--skip-approve-check")
+	acceptCmd := exec.Command("clusteradm", "--context", k8s.ITSContext(), "accept", "--clusters", clusterName, "--skip-approve-check")
 	acceptOutput, acceptErr := acceptCmd.CombinedOutput()
 	if acceptErr != nil {
 		LogOnboardingEvent(clusterName, "Warning", fmt.Sprintf("clusteradm accept had issues: %v, %s", acceptErr, string(acceptOutput)))
@@ -462,7 +463,7 @@ func UpdateManagedClusterLabelsHandler(c *gin.Context) {
 		return
 	}
 
-	clientset, restConfig, err := k8s.GetClientSetWithConfigContext(req.ContextName)
+	clientset, restConfig, err := k8s.GetClientSetWithConfigForRequest(c.Request.Context(), req.ContextName)
 	if err != nil {
 		log.Printf("Error getting clientset: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -615,7 +616,7 @@ func waitForManagedCluster(clientset *kubernetes.Clientset, clusterName string)
 }
 
 // OnboardCluster handles the entire process of onboarding a cluster
-func OnboardCluster(kubeconfigData []byte, clusterName string) error {
+func OnboardCluster(ctx context.Context, kubeconfigData []byte, clusterName string) error {
 	// Register the start of onboarding and log it
 	RegisterOnboardingStart(clusterName)
 
@@ -629,11 +630,11 @@ func OnboardCluster(kubeconfigData []byte, clusterName string) error {
 	LogOnboardingEvent(clusterName, "Validated", "Cluster connectivity validated successfully")
 
 	// 2. Get the ITS hub context (OCM hub)
-	itsContext := "its1" // Can be parameterized
+	itsContext := k8s.ITSContext()
 	LogOnboardingEvent(clusterName, "Connecting", "Connecting to ITS hub context: "+itsContext)
 
 	// 3. Get clients for the hub
-	hubClientset, hubConfig, err := k8s.GetClientSetWithConfigContext(itsContext)
+	hubClientset, hubConfig, err := k8s.GetClientSetWithConfigForRequest(ctx, itsContext)
 	if err != nil {
 		LogOnboardingEvent(clusterName, "Error", "Failed to get hub clientset: "+err.Error())
 		RegisterOnboardingComplete(clusterName, err)
@@ -932,12 +933,5 @@ func UpdateManagedClusterLabels(clientset *kubernetes.Clientset, config *rest.Co
 
 // kubeconfigPath returns the path to the kubeconfig file
 func kubeconfigPath() string {
-	if path := os.Getenv("KUBECONFIG"); path != "" {
-		return path
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("Unable to get user home directory: %v", err)
-	}
-	return fmt.Sprintf("%s/.kube/config", home)
+	return k8s.KubeconfigPath()
 }
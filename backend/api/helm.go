@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/k8s"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// helmDeploymentStatus returns 404 for unknown deployments and 500 otherwise
+func helmDeploymentStatus(err error) int {
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// loadHelmDeployment loads the stored Helm deployment named by the :id parameter
+func loadHelmDeployment(c *gin.Context) (*k8s.HelmDeploymentData, bool) {
+	deployment, err := k8s.GetHelmDeploymentByID(c.Param("id"))
+	if err != nil {
+		c.JSON(helmDeploymentStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return deployment, true
+}
+
+func releaseResponse(message string, rel *release.Release, deployment *k8s.HelmDeploymentData) gin.H {
+	response := gin.H{
+		"message":   message,
+		"release":   rel.Name,
+		"namespace": rel.Namespace,
+		"revision":  rel.Version,
+		"version":   rel.Chart.Metadata.Version,
+		"status":    rel.Info.Status.String(),
+	}
+	if deployment != nil {
+		response["deployment"] = deployment.Redacted()
+	}
+	return response
+}
+
+// UpgradeHelmDeploymentHandler upgrades the release of a stored Helm deployment
+func UpgradeHelmDeploymentHandler(c *gin.Context) {
+	deployment, ok := loadHelmDeployment(c)
+	if !ok {
+		return
+	}
+
+	var req k8s.HelmUpgradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload", "details": err.Error()})
+		return
+	}
+
+	actionConfig, err := CreateHelmActionConfig(c.Request.Context(), deployment.HelmContext(), deployment.Namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rel, updated, err := k8s.UpgradeHelmDeployment(actionConfig, deployment.ID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upgrade failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, releaseResponse("Helm release upgraded successfully", rel, updated))
+}
+
+// HelmDeploymentHistoryHandler returns the Helm revisions of a stored deployment together with
+// the revision chain recorded by the UI
+func HelmDeploymentHistoryHandler(c *gin.Context) {
+	deployment, ok := loadHelmDeployment(c)
+	if !ok {
+		return
+	}
+
+	actionConfig, err := CreateHelmActionConfig(c.Request.Context(), deployment.HelmContext(), deployment.Namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, _, err := k8s.HelmDeploymentHistory(actionConfig, deployment.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":        deployment.ID,
+		"release":   deployment.ReleaseName,
+		"namespace": deployment.Namespace,
+		"revision":  deployment.Revision,
+		"revisions": deployment.Redacted().Revisions,
+		"history":   history,
+	})
+}
+
+// RollbackHelmDeploymentHandler rolls the release of a stored deployment back to a revision
+func RollbackHelmDeploymentHandler(c *gin.Context) {
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive number"})
+		return
+	}
+
+	deployment, ok := loadHelmDeployment(c)
+	if !ok {
+		return
+	}
+
+	actionConfig, err := CreateHelmActionConfig(c.Request.Context(), deployment.HelmContext(), deployment.Namespace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rel, updated, err := k8s.RollbackHelmDeployment(actionConfig, deployment.ID, revision)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Rollback failed", "details": err.Error()})
+		return
+	}
+	response := releaseResponse("Helm release rolled back successfully", rel, updated)
+	response["rolledBackTo"] = revision
+	c.JSON(http.StatusOK, response)
+}
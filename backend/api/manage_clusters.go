@@ -45,10 +45,10 @@ type ManagedClusterInfo struct {
 // GetManagedClustersHandler returns a list of all managed clusters
 func GetManagedClustersHandler(c *gin.Context) {
 	// Get the hub context
-	hubContext := c.DefaultQuery("context", "its1")
+	hubContext := c.DefaultQuery("context", k8s.ITSContext())
 
 	// Get client config for the hub
-	_, restConfig, err := k8s.GetClientSetWithConfigContext(hubContext)
+	_, restConfig, err := k8s.GetClientSetWithConfigForRequest(c.Request.Context(), hubContext)
 	if err != nil {
 		c.JSON(500, gin.H{
 			"error": fmt.Sprintf("Failed to create client: %v", err),
@@ -98,10 +98,10 @@ func GetManagedClusterHandler(c *gin.Context) {
 	}
 
 	// Get the hub context
-	hubContext := c.DefaultQuery("context", "its1")
+	hubContext := c.DefaultQuery("context", k8s.ITSContext())
 
 	// Get client config for the hub
-	_, restConfig, err := k8s.GetClientSetWithConfigContext(hubContext)
+	_, restConfig, err := k8s.GetClientSetWithConfigForRequest(c.Request.Context(), hubContext)
 	if err != nil {
 		c.JSON(500, gin.H{
 			"error": fmt.Sprintf("Failed to create client: %v", err),
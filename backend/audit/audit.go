@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/utils"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// Sink names of the audit configuration
+const (
+	SinkFile     = "file"
+	SinkPostgres = "postgres"
+	SinkWebhook  = "webhook"
+)
+
+// Target is what an action was performed on
+type Target struct {
+	Verb      string `json:"verb,omitempty"`
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Event is one audited action of a user
+type Event struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Action    string    `json:"action"`
+	Target              // Flattened into the event
+	Outcome   string    `json:"outcome"`
+	Status    int       `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Diff      []Change  `json:"diff,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	SourceIP  string    `json:"source_ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+}
+
+// Query filters audit events; empty fields match everything
+type Query struct {
+	User      string    `json:"user,omitempty"`
+	Action    string    `json:"action,omitempty"`
+	Context   string    `json:"context,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Kind      string    `json:"kind,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Outcome   string    `json:"outcome,omitempty"`
+	Since     time.Time `json:"since,omitempty"`
+	Until     time.Time `json:"until,omitempty"`
+	Limit     int       `json:"limit"`
+	Offset    int       `json:"offset"`
+}
+
+// Default and maximum page sizes of a query
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// Normalize applies the default and maximum page size
+func (q *Query) Normalize() {
+	if q.Limit <= 0 {
+		q.Limit = DefaultQueryLimit
+	}
+	if q.Limit > MaxQueryLimit {
+		q.Limit = MaxQueryLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+// Matches reports whether an event passes the filter
+func (q *Query) Matches(event Event) bool {
+	return (q.User == "" || event.User == q.User) &&
+		(q.Action == "" || event.Action == q.Action) &&
+		(q.Context == "" || event.Context == q.Context) &&
+		(q.Namespace == "" || event.Namespace == q.Namespace) &&
+		(q.Kind == "" || strings.EqualFold(event.Kind, q.Kind)) &&
+		(q.Name == "" || event.Name == q.Name) &&
+		(q.Outcome == "" || event.Outcome == q.Outcome) &&
+		(q.Since.IsZero() || !event.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || event.Time.Before(q.Until))
+}
+
+// Page is one page of audit events, newest first
+type Page struct {
+	Events []Event `json:"events"`
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
+
+// Sink receives audit events
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, event Event) error
+	Close() error
+}
+
+// Store is a sink whose events can be queried
+type Store interface {
+	Sink
+	Query(ctx context.Context, query Query) (*Page, error)
+}
+
+var (
+	sinksMu sync.RWMutex
+	sinks   []Sink
+)
+
+// Init opens the configured sinks. The file sink writes JSON lines to the file path, the
+// postgres sink to the audit_events table and the webhook sink posts each event to the
+// webhook URL.
+func Init(cfg config.AuditConfig) error {
+	var opened []Sink
+	for _, name := range cfg.Sinks {
+		sink, err := openSink(strings.TrimSpace(name), cfg)
+		if err != nil {
+			for _, s := range opened {
+				s.Close()
+			}
+			return err
+		}
+		if sink != nil {
+			opened = append(opened, sink)
+		}
+	}
+
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	for _, s := range sinks {
+		s.Close()
+	}
+	sinks = opened
+	for _, s := range sinks {
+		log.Printf("Audit sink %s enabled", s.Name())
+	}
+	return nil
+}
+
+func openSink(name string, cfg config.AuditConfig) (Sink, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case SinkFile:
+		return NewFileSink(cfg.FilePath)
+	case SinkPostgres:
+		return NewPostgresSink(context.Background())
+	case SinkWebhook:
+		return NewWebhookSink(cfg.WebhookURL, cfg.WebhookSecret)
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q, expected file, postgres or webhook", name)
+	}
+}
+
+// Record writes an event to every sink. Missing IDs and times are filled in and the error
+// message is redacted; failures are logged rather than failing the audited request.
+func Record(ctx context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = utils.NewTokenID()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.User == "" {
+		event.User = "anonymous"
+	}
+	event.Error = RedactString(event.Error)
+
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			log.Printf("Warning: failed to write audit event %s to %s: %v", event.ID, sink.Name(), err)
+		}
+	}
+}
+
+// QueryEvents searches the first queryable sink, preferring PostgreSQL over the file
+func QueryEvents(ctx context.Context, query Query) (*Page, error) {
+	query.Normalize()
+
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	var store Store
+	for _, sink := range sinks {
+		if s, ok := sink.(Store); ok && (store == nil || sink.Name() == SinkPostgres) {
+			store = s
+		}
+	}
+	if store == nil {
+		return nil, fmt.Errorf("no queryable audit sink configured, enable the file or postgres sink")
+	}
+	return store.Query(ctx, query)
+}
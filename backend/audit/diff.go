@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change is one difference between the old and new state of a target, in JSON pointer notation
+type Change struct {
+	Op   string      `json:"op"` // add, remove or replace
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// ignoredMetadata fields change on every write and only add noise to a diff
+var ignoredMetadata = []string{"managedFields", "resourceVersion", "generation"}
+
+// Diff returns the redacted changes from before to after. Either may be nil for a created or
+// deleted target; values are anything that marshals to JSON.
+func Diff(before, after interface{}) []Change {
+	var changes []Change
+	diffValues("", normalize(before), normalize(after), &changes)
+	return changes
+}
+
+// normalize converts a value to its redacted generic JSON form, without the status and the
+// bookkeeping metadata of Kubernetes objects
+func normalize(value interface{}) interface{} {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+	if object, ok := generic.(map[string]interface{}); ok {
+		delete(object, "status")
+		if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+			for _, field := range ignoredMetadata {
+				delete(metadata, field)
+			}
+		}
+	}
+	return RedactValue(generic)
+}
+
+func diffValues(path string, before, after interface{}, changes *[]Change) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	switch {
+	case before == nil:
+		*changes = append(*changes, Change{Op: "add", Path: pathOrRoot(path), To: after})
+		return
+	case after == nil:
+		*changes = append(*changes, Change{Op: "remove", Path: pathOrRoot(path), From: before})
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if !beforeIsMap || !afterIsMap {
+		*changes = append(*changes, Change{Op: "replace", Path: pathOrRoot(path), From: before, To: after})
+		return
+	}
+
+	keys := make(map[string]bool)
+	for key := range beforeMap {
+		keys[key] = true
+	}
+	for key := range afterMap {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		diffValues(path+"/"+escapePointer(key), beforeMap[key], afterMap[key], changes)
+	}
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
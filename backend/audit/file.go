@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends events as JSON lines to a file. Queries scan the whole file, so it suits
+// single instances with moderate traffic; use PostgreSQL otherwise.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileSink opens the file for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file %s: %v", path, err)
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+// Name implements Sink
+func (s *FileSink) Name() string {
+	return SinkFile
+}
+
+// Write implements Sink
+func (s *FileSink) Write(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit file %s: %v", s.path, err)
+	}
+	return nil
+}
+
+// Query implements Store
+func (s *FileSink) Query(ctx context.Context, query Query) (*Page, error) {
+	query.Normalize()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file %s: %v", s.path, err)
+	}
+	defer file.Close()
+
+	var matches []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if query.Matches(event) {
+			matches = append(matches, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit file %s: %v", s.path, err)
+	}
+
+	// The file is in write order; pages are newest first
+	page := &Page{Events: []Event{}, Total: len(matches), Limit: query.Limit, Offset: query.Offset}
+	for i := len(matches) - 1 - query.Offset; i >= 0 && len(page.Events) < query.Limit; i-- {
+		page.Events = append(page.Events, matches[i])
+	}
+	return page, nil
+}
+
+// Close implements Sink
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
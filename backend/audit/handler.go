@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryFromRequest reads the user, action, context, namespace, kind, name, outcome, since,
+// until, limit and offset query parameters; times are RFC3339
+func QueryFromRequest(c *gin.Context) (Query, error) {
+	query := Query{
+		User:      c.Query("user"),
+		Action:    c.Query("action"),
+		Context:   c.Query("context"),
+		Namespace: c.Query("namespace"),
+		Kind:      c.Query("kind"),
+		Name:      c.Query("name"),
+		Outcome:   c.Query("outcome"),
+	}
+
+	var err error
+	if since := c.Query("since"); since != "" {
+		if query.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return query, fmt.Errorf("invalid since %q, expected RFC3339", since)
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		if query.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return query, fmt.Errorf("invalid until %q, expected RFC3339", until)
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 1 {
+			return query, fmt.Errorf("invalid limit %q", limit)
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil || query.Offset < 0 {
+			return query, fmt.Errorf("invalid offset %q", offset)
+		}
+	}
+	query.Normalize()
+	return query, nil
+}
+
+// ListEventsHandler returns the audit events matching the query parameters, newest first
+func ListEventsHandler(c *gin.Context) {
+	query, err := QueryFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := QueryEvents(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit events", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"count":  len(page.Events),
+		"total":  page.Total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+		"events": page.Events,
+	})
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Keys of the gin context under which handlers describe the audited action
+const (
+	targetKey = "audit_target"
+	diffKey   = "audit_diff"
+)
+
+// maxCapturedError bounds the response body kept to extract the error of a failed action
+const maxCapturedError = 4096
+
+// SetTarget records what the action of the request is performed on, for handlers whose
+// target is not in the route parameters
+func SetTarget(c *gin.Context, target Target) {
+	c.Set(targetKey, target)
+}
+
+// SetDiff records the redacted difference between the old and new state of the target
+func SetDiff(c *gin.Context, before, after interface{}) {
+	c.Set(diffKey, Diff(before, after))
+}
+
+// Middleware records an audit event with the given action once the request completes. The
+// target comes from SetTarget, or else from the resourceKind, namespace and name parameters
+// and the context query parameter. Place it after AuthenticateMiddleware so the user is known.
+func Middleware(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		writer := &errorCapturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		event := Event{
+			Time:      start.UTC(),
+			User:      c.GetString("username"),
+			Action:    action,
+			Target:    requestTarget(c),
+			Status:    c.Writer.Status(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			SourceIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			LatencyMS: time.Since(start).Milliseconds(),
+		}
+		switch {
+		case event.Status == http.StatusUnauthorized || event.Status == http.StatusForbidden:
+			event.Outcome = OutcomeDenied
+		case event.Status >= http.StatusBadRequest:
+			event.Outcome = OutcomeFailure
+		default:
+			event.Outcome = OutcomeSuccess
+		}
+		if event.Outcome != OutcomeSuccess {
+			event.Error = responseError(writer.body.Bytes())
+		}
+		if diff, ok := c.Get(diffKey); ok && event.Outcome == OutcomeSuccess {
+			event.Diff, _ = diff.([]Change)
+		}
+
+		Record(c.Request.Context(), event)
+	}
+}
+
+func requestTarget(c *gin.Context) Target {
+	if target, ok := c.Get(targetKey); ok {
+		if t, ok := target.(Target); ok {
+			return t
+		}
+	}
+	return Target{
+		Context:   c.Query("context"),
+		Namespace: c.Param("namespace"),
+		Kind:      c.Param("resourceKind"),
+		Name:      c.Param("name"),
+	}
+}
+
+// responseError extracts the error message of a JSON error response
+func responseError(body []byte) string {
+	var response struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Error == "" {
+		return string(bytes.TrimSpace(body))
+	}
+	if response.Details != "" {
+		return response.Error + ": " + response.Details
+	}
+	return response.Error
+}
+
+// errorCapturingWriter keeps the start of error responses for the audit event
+type errorCapturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *errorCapturingWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < maxCapturedError {
+		remaining := maxCapturedError - w.body.Len()
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorCapturingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubestellar/ui/postgresql"
+)
+
+// postgresSchema creates the audit_events table and the indexes its queries use
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS audit_events (
+		id TEXT PRIMARY KEY,
+		time TIMESTAMPTZ NOT NULL,
+		username TEXT NOT NULL,
+		action TEXT NOT NULL,
+		verb TEXT NOT NULL DEFAULT '',
+		context TEXT NOT NULL DEFAULT '',
+		namespace TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL DEFAULT '',
+		outcome TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		data JSONB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_events_time ON audit_events (time DESC)`,
+	`CREATE INDEX IF NOT EXISTS audit_events_username ON audit_events (username)`,
+	`CREATE INDEX IF NOT EXISTS audit_events_target ON audit_events (context, namespace, kind, name)`,
+}
+
+// PostgresSink stores events in the audit_events table; the filterable fields are columns
+// and the full event is kept as JSON
+type PostgresSink struct {
+	db *sql.DB
+}
+
+// NewPostgresSink connects with the POSTGRES_* settings and creates the table if needed
+func NewPostgresSink(ctx context.Context) (*PostgresSink, error) {
+	if postgresql.DB == nil {
+		if err := postgresql.ConnectDB(); err != nil {
+			return nil, err
+		}
+	}
+	for _, statement := range postgresSchema {
+		if _, err := postgresql.DB.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to create audit_events table: %v", err)
+		}
+	}
+	return &PostgresSink{db: postgresql.DB}, nil
+}
+
+// Name implements Sink
+func (s *PostgresSink) Name() string {
+	return SinkPostgres
+}
+
+// Write implements Sink
+func (s *PostgresSink) Write(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %v", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO audit_events (id, time, username, action, verb, context, namespace, kind, name, outcome, status, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		event.ID, event.Time, event.User, event.Action, event.Verb, event.Context, event.Namespace,
+		strings.ToLower(event.Kind), event.Name, event.Outcome, event.Status, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert audit event: %v", err)
+	}
+	return nil
+}
+
+// Query implements Store
+func (s *PostgresSink) Query(ctx context.Context, query Query) (*Page, error) {
+	query.Normalize()
+
+	var conditions []string
+	var args []interface{}
+	add := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s $%d", column, len(args)))
+	}
+	if query.User != "" {
+		add("username =", query.User)
+	}
+	if query.Action != "" {
+		add("action =", query.Action)
+	}
+	if query.Context != "" {
+		add("context =", query.Context)
+	}
+	if query.Namespace != "" {
+		add("namespace =", query.Namespace)
+	}
+	if query.Kind != "" {
+		add("kind =", strings.ToLower(query.Kind))
+	}
+	if query.Name != "" {
+		add("name =", query.Name)
+	}
+	if query.Outcome != "" {
+		add("outcome =", query.Outcome)
+	}
+	if !query.Since.IsZero() {
+		add("time >=", query.Since)
+	}
+	if !query.Until.IsZero() {
+		add("time <", query.Until)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := &Page{Events: []Event{}, Limit: query.Limit, Offset: query.Offset}
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count audit events: %v", err)
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT data FROM audit_events%s ORDER BY time DESC LIMIT $%d OFFSET $%d",
+		where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read audit event: %v", err)
+		}
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse audit event: %v", err)
+		}
+		page.Events = append(page.Events, event)
+	}
+	return page, rows.Err()
+}
+
+// Close implements Sink; the shared connection stays open
+func (s *PostgresSink) Close() error {
+	return nil
+}
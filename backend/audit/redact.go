@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces every secret value removed from audit events and logs
+const Redacted = "[REDACTED]"
+
+// sensitiveKeyParts mark keys, headers and query parameters whose values are secrets
+var sensitiveKeyParts = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "apikey", "api_key", "api-key",
+	"privatekey", "private_key", "private-key", "client-key", "client_key", "credential", "kubeconfig",
+}
+
+var (
+	// jwtPattern matches JSON web tokens wherever they appear
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// apiTokenPattern matches API tokens wherever they appear
+	apiTokenPattern = regexp.MustCompile(`kst_[0-9a-f]+_[A-Za-z0-9_-]+`)
+	// keyValuePattern matches "key: value" and "key=value" lines of YAML, env and form bodies
+	keyValuePattern = regexp.MustCompile(`(?im)^(\s*-?\s*"?[\w.-]*(?:password|passwd|secret|token|apikey|api_key|private_key|client-key|credential)[\w.-]*"?\s*[:=]\s*).+$`)
+)
+
+// IsSensitiveKey reports whether values stored under the key must be redacted
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders returns a copy of the headers with credentials removed
+func RedactHeaders(headers http.Header) http.Header {
+	redacted := make(http.Header, len(headers))
+	for name, values := range headers {
+		if IsSensitiveKey(name) {
+			redacted[name] = []string{Redacted}
+			continue
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// RedactQuery returns a copy of the query parameters with secrets, such as WebSocket tokens, removed
+func RedactQuery(query url.Values) url.Values {
+	redacted := make(url.Values, len(query))
+	for key, values := range query {
+		if IsSensitiveKey(key) {
+			redacted[key] = []string{Redacted}
+			continue
+		}
+		redacted[key] = values
+	}
+	return redacted
+}
+
+// RedactBody removes secrets from a request body. JSON bodies are redacted by key; other
+// bodies, such as YAML manifests, line by line.
+func RedactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redacted, err := json.Marshal(RedactValue(value)); err == nil {
+			return string(redacted)
+		}
+	}
+	return RedactString(keyValuePattern.ReplaceAllString(string(body), "${1}"+Redacted))
+}
+
+// RedactString removes tokens from free text such as error messages
+func RedactString(value string) string {
+	return apiTokenPattern.ReplaceAllString(jwtPattern.ReplaceAllString(value, Redacted), Redacted)
+}
+
+// RedactValue returns a copy of a decoded JSON value with secrets removed. The data of
+// Kubernetes Secrets is removed as a whole.
+func RedactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		isSecret := v["kind"] == "Secret"
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			switch {
+			case isSecret && (key == "data" || key == "stringData"):
+				redacted[key] = redactKeys(item)
+			case IsSensitiveKey(key):
+				redacted[key] = Redacted
+			default:
+				redacted[key] = RedactValue(item)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = RedactValue(item)
+		}
+		return redacted
+	case string:
+		return RedactString(v)
+	default:
+		return v
+	}
+}
+
+// redactKeys keeps the keys of a map but redacts every value, so a diff still shows which
+// entries of a Secret changed
+func redactKeys(value interface{}) interface{} {
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return Redacted
+	}
+	redacted := make(map[string]interface{}, len(data))
+	for key := range data {
+		redacted[key] = Redacted
+	}
+	return redacted
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// webhookQueueSize bounds the events waiting for delivery; further events are dropped and logged
+const webhookQueueSize = 1000
+
+// WebhookSink posts each event as JSON to a URL, e.g. a SIEM collector. Delivery happens in
+// the background so a slow receiver does not delay requests. With a secret, the body is
+// signed with HMAC-SHA256 in the X-KubeStellar-Signature header as "sha256=<hex>".
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+	queue  chan Event
+	done   chan struct{}
+}
+
+// NewWebhookSink starts delivering events to url
+func NewWebhookSink(url, secret string) (*WebhookSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("a webhook URL is required for the webhook audit sink")
+	}
+	s := &WebhookSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan Event, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.deliver()
+	return s, nil
+}
+
+// Name implements Sink
+func (s *WebhookSink) Name() string {
+	return SinkWebhook
+}
+
+// Write implements Sink
+func (s *WebhookSink) Write(ctx context.Context, event Event) error {
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		return fmt.Errorf("webhook queue is full, event dropped")
+	}
+}
+
+func (s *WebhookSink) deliver() {
+	defer close(s.done)
+	for event := range s.queue {
+		if err := s.post(event); err != nil {
+			log.Printf("Warning: failed to deliver audit event %s to webhook: %v", event.ID, err)
+		}
+	}
+}
+
+func (s *WebhookSink) post(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body)
+		req.Header.Set("X-KubeStellar-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close implements Sink; queued events are delivered first
+func (s *WebhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+	return nil
+}
@@ -0,0 +1,445 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/ui/redis"
+	"github.com/kubestellar/ui/utils"
+)
+
+// APITokenPrefix starts every API token, which tells them apart from JWTs
+const APITokenPrefix = "kst_"
+
+// Types of API token
+const (
+	APITokenPersonal       = "personal"        // Acts as its owner, limited to the owner's access
+	APITokenServiceAccount = "service-account" // Acts as a service account that exists only as the token
+)
+
+// ServiceAccountPrefix and ServiceAccountGroup identify service account tokens in policies
+const (
+	ServiceAccountPrefix = "serviceaccount:"
+	ServiceAccountGroup  = "serviceaccounts"
+)
+
+// Lifetimes of API tokens
+const (
+	DefaultAPITokenTTL = 30 * 24 * time.Hour
+	MaxAPITokenTTL     = 365 * 24 * time.Hour
+)
+
+// apiTokenUsageKey is the Redis hash of the last use of each token, by token ID. Usage is
+// kept out of the ConfigMap so authenticating a request never writes to the cluster.
+const apiTokenUsageKey = "auth:api-token-usage"
+
+var (
+	// ErrInvalidAPIToken is returned for unknown, malformed or tampered tokens
+	ErrInvalidAPIToken = fmt.Errorf("invalid API token")
+	// ErrAPITokenExpired is returned for tokens past their expiry
+	ErrAPITokenExpired = fmt.Errorf("API token has expired")
+)
+
+// TokenRequestError is returned for token requests that are refused rather than failing to be stored
+type TokenRequestError struct {
+	Reason string
+}
+
+func (e *TokenRequestError) Error() string {
+	return e.Reason
+}
+
+// APIToken is a named, expiring credential for automation. Only the SHA-256 hash of the
+// token is stored; the token itself is shown once, when it is created. Permissions and
+// Scopes bound what the token may do: a request must be allowed by a global permission or
+// a scope of the token, and personal tokens additionally by the access of their owner.
+type APIToken struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Owner       string       `json:"owner"` // Username, or service account name
+	Permissions []string     `json:"permissions,omitempty"`
+	Scopes      []PolicyRule `json:"scopes,omitempty"`
+	Hash        string       `json:"hash"`
+	CreatedBy   string       `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+}
+
+// APITokenUsage records the last request made with a token
+type APITokenUsage struct {
+	LastUsedAt time.Time `json:"last_used_at"`
+	LastUsedIP string    `json:"last_used_ip"`
+}
+
+// APITokenInfo is a token as listed to admins, without its hash
+type APITokenInfo struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Owner       string       `json:"owner"`
+	Username    string       `json:"username"`
+	Permissions []string     `json:"permissions,omitempty"`
+	Scopes      []PolicyRule `json:"scopes,omitempty"`
+	CreatedBy   string       `json:"created_by"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	Expired     bool         `json:"expired"`
+	LastUsedAt  *time.Time   `json:"last_used_at,omitempty"`
+	LastUsedIP  string       `json:"last_used_ip,omitempty"`
+}
+
+// APITokenRequest describes a token to create
+type APITokenRequest struct {
+	Name        string       `json:"name" binding:"required"`
+	Type        string       `json:"type"`  // personal (default) or service-account
+	Owner       string       `json:"owner"` // Defaults to the creating user for personal tokens
+	Permissions []string     `json:"permissions"`
+	Scopes      []PolicyRule `json:"scopes"`
+	ExpiresIn   string       `json:"expires_in"` // Duration such as 720h, defaults to 30 days
+	ExpiresAt   *time.Time   `json:"expires_at"`
+}
+
+// Username is the identity requests made with the token act as
+func (t *APIToken) Username() string {
+	if t.Type == APITokenServiceAccount {
+		return ServiceAccountPrefix + t.Owner
+	}
+	return t.Owner
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t *APIToken) IsExpired() bool {
+	return !time.Now().Before(t.ExpiresAt)
+}
+
+// Authorize decides a request against the permissions and scopes of the token alone
+func (t *APIToken) Authorize(attrs Attributes) Decision {
+	subject := Subject{Username: t.Username(), Permissions: t.Permissions}
+	scopes := Policy{Name: "token " + t.Name, Users: []string{t.Username()}, Rules: t.Scopes}
+	decision := Authorize(subject, attrs, []Policy{scopes})
+	if !decision.Allowed {
+		decision.Reason = fmt.Sprintf("API token %s does not allow %s", t.Name, attrs)
+	}
+	return decision
+}
+
+// EffectivePermissions are the global permissions of requests made with the token; a
+// personal token never has a permission its owner has lost
+func (t *APIToken) EffectivePermissions(ownerPermissions []string) []string {
+	if t.Type != APITokenPersonal {
+		return t.Permissions
+	}
+	effective := []string{}
+	for _, permission := range t.Permissions {
+		if containsPermission(ownerPermissions, permission) || containsPermission(ownerPermissions, PermissionAdmin) {
+			effective = append(effective, permission)
+		}
+	}
+	return effective
+}
+
+// info returns the listed form of the token with its last use
+func (t *APIToken) info(usage *APITokenUsage) APITokenInfo {
+	info := APITokenInfo{
+		ID:          t.ID,
+		Name:        t.Name,
+		Type:        t.Type,
+		Owner:       t.Owner,
+		Username:    t.Username(),
+		Permissions: t.Permissions,
+		Scopes:      t.Scopes,
+		CreatedBy:   t.CreatedBy,
+		CreatedAt:   t.CreatedAt,
+		ExpiresAt:   t.ExpiresAt,
+		Expired:     t.IsExpired(),
+	}
+	if usage != nil {
+		info.LastUsedAt = &usage.LastUsedAt
+		info.LastUsedIP = usage.LastUsedIP
+	}
+	return info
+}
+
+// CreateAPIToken stores a new token and returns it together with the token string, which
+// cannot be recovered later
+func CreateAPIToken(request APITokenRequest, createdBy string) (*APITokenInfo, string, error) {
+	if request.Type == "" {
+		request.Type = APITokenPersonal
+	}
+	if request.Type == APITokenPersonal && request.Owner == "" {
+		request.Owner = createdBy
+	}
+	if err := validateAPITokenRequest(&request); err != nil {
+		return nil, "", &TokenRequestError{Reason: err.Error()}
+	}
+
+	expiresAt, err := apiTokenExpiry(request)
+	if err != nil {
+		return nil, "", &TokenRequestError{Reason: err.Error()}
+	}
+
+	var token APIToken
+	var plaintext string
+	err = UpdateConfig(func(config *Config) error {
+		if request.Type == APITokenPersonal {
+			owner, exists := config.GetUser(request.Owner)
+			if !exists {
+				return &TokenRequestError{Reason: fmt.Sprintf("user %s does not exist", request.Owner)}
+			}
+			for _, permission := range request.Permissions {
+				if !containsPermission(owner.Permissions, permission) && !containsPermission(owner.Permissions, PermissionAdmin) {
+					return &TokenRequestError{Reason: fmt.Sprintf("user %s does not have the %s permission", request.Owner, permission)}
+				}
+			}
+		}
+		for _, existing := range config.Tokens {
+			if existing.Owner == request.Owner && existing.Type == request.Type && existing.Name == request.Name {
+				return &TokenRequestError{Reason: fmt.Sprintf("%s already has a token named %s", request.Owner, request.Name)}
+			}
+		}
+
+		secret, err := newAPITokenSecret()
+		if err != nil {
+			return err
+		}
+		token = APIToken{
+			ID:          utils.NewTokenID(),
+			Name:        request.Name,
+			Type:        request.Type,
+			Owner:       request.Owner,
+			Permissions: request.Permissions,
+			Scopes:      request.Scopes,
+			CreatedBy:   createdBy,
+			CreatedAt:   time.Now().UTC(),
+			ExpiresAt:   expiresAt.UTC(),
+		}
+		plaintext = APITokenPrefix + token.ID + "_" + secret
+		token.Hash = hashAPIToken(plaintext)
+
+		config.Tokens = append(config.Tokens, token)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	info := token.info(nil)
+	return &info, plaintext, nil
+}
+
+// validateAPITokenRequest checks the type, owner, permissions and scopes of a new token
+func validateAPITokenRequest(request *APITokenRequest) error {
+	if request.Name == "" {
+		return fmt.Errorf("token name cannot be empty")
+	}
+	if request.Type != APITokenPersonal && request.Type != APITokenServiceAccount {
+		return fmt.Errorf("unknown token type %q, expected %s or %s", request.Type, APITokenPersonal, APITokenServiceAccount)
+	}
+	if request.Owner == "" {
+		return fmt.Errorf("service account tokens need an owner naming the service account")
+	}
+	if strings.Contains(request.Owner, ":") {
+		return fmt.Errorf("owner %q cannot contain ':'", request.Owner)
+	}
+
+	permissions, err := ResolvePermissions(request.Permissions)
+	if err != nil {
+		return err
+	}
+	request.Permissions = permissions
+	if len(request.Permissions) == 0 && len(request.Scopes) == 0 {
+		return fmt.Errorf("token needs at least one permission or scope")
+	}
+
+	scopes := Policy{Name: request.Name, Users: []string{request.Owner}, Rules: request.Scopes}
+	if len(request.Scopes) > 0 {
+		if err := scopes.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apiTokenExpiry resolves the expiry of a new token, which is mandatory and bounded
+func apiTokenExpiry(request APITokenRequest) (time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(DefaultAPITokenTTL)
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+	} else if request.ExpiresIn != "" {
+		ttl, err := time.ParseDuration(request.ExpiresIn)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid expires_in: %v", err)
+		}
+		expiresAt = now.Add(ttl)
+	}
+
+	if !expiresAt.After(now) {
+		return time.Time{}, fmt.Errorf("token expiry must be in the future")
+	}
+	if expiresAt.Sub(now) > MaxAPITokenTTL {
+		return time.Time{}, fmt.Errorf("tokens cannot be valid for more than %d days", int(MaxAPITokenTTL.Hours()/24))
+	}
+	return expiresAt, nil
+}
+
+// newAPITokenSecret returns the random part of a token
+func newAPITokenSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIToken hashes a token for storage. Tokens carry 256 random bits, so a fast hash
+// is enough where passwords need a slow one.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken reports whether a bearer credential is an API token rather than a JWT
+func IsAPIToken(credential string) bool {
+	return strings.HasPrefix(credential, APITokenPrefix)
+}
+
+// AuthenticateAPIToken looks up the token of a request and records its use from the client IP
+func AuthenticateAPIToken(credential, clientIP string) (*APIToken, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(credential, APITokenPrefix), "_")
+	if !IsAPIToken(credential) || !ok || id == "" {
+		return nil, ErrInvalidAPIToken
+	}
+
+	config, err := LoadK8sConfigMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	for i := range config.Tokens {
+		token := config.Tokens[i]
+		if token.ID != id {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hashAPIToken(credential))) != 1 {
+			return nil, ErrInvalidAPIToken
+		}
+		if token.IsExpired() {
+			return nil, ErrAPITokenExpired
+		}
+
+		usage := APITokenUsage{LastUsedAt: time.Now().UTC(), LastUsedIP: clientIP}
+		if err := redis.SetJSONHash(apiTokenUsageKey, token.ID, usage); err != nil {
+			log.Printf("Warning: failed to record use of API token %s: %v", token.ID, err)
+		}
+		return &token, nil
+	}
+	return nil, ErrInvalidAPIToken
+}
+
+// ListAPITokens returns the tokens with their last use, optionally of one owner only
+func ListAPITokens(owner string) ([]APITokenInfo, error) {
+	config, err := LoadK8sConfigMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	usages, err := redis.GetAllJSONHash(apiTokenUsageKey)
+	if err != nil {
+		log.Printf("Warning: failed to load API token usage: %v", err)
+	}
+
+	tokens := []APITokenInfo{}
+	for i := range config.Tokens {
+		token := &config.Tokens[i]
+		if owner != "" && token.Owner != owner {
+			continue
+		}
+		tokens = append(tokens, token.info(decodeAPITokenUsage(usages[token.ID])))
+	}
+	return tokens, nil
+}
+
+// GetAPIToken returns one token with its last use
+func GetAPIToken(id string) (*APITokenInfo, bool, error) {
+	config, err := LoadK8sConfigMap()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	for i := range config.Tokens {
+		if config.Tokens[i].ID != id {
+			continue
+		}
+		var usage APITokenUsage
+		found, err := redis.GetJSONHash(apiTokenUsageKey, id, &usage)
+		if err != nil {
+			log.Printf("Warning: failed to load usage of API token %s: %v", id, err)
+		}
+		var info APITokenInfo
+		if found {
+			info = config.Tokens[i].info(&usage)
+		} else {
+			info = config.Tokens[i].info(nil)
+		}
+		return &info, true, nil
+	}
+	return nil, false, nil
+}
+
+// DeleteAPIToken revokes a token by ID
+func DeleteAPIToken(id string) error {
+	err := UpdateConfig(func(config *Config) error {
+		for i := range config.Tokens {
+			if config.Tokens[i].ID == id {
+				config.Tokens = append(config.Tokens[:i], config.Tokens[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("API token %s does not exist", id)
+	})
+	if err != nil {
+		return err
+	}
+	forgetAPITokenUsage(id)
+	return nil
+}
+
+// removeUserTokens drops the personal tokens of a deleted user from the config
+func removeUserTokens(config *Config, username string) {
+	kept := config.Tokens[:0]
+	for _, token := range config.Tokens {
+		if token.Type == APITokenPersonal && token.Owner == username {
+			forgetAPITokenUsage(token.ID)
+			continue
+		}
+		kept = append(kept, token)
+	}
+	config.Tokens = kept
+}
+
+func forgetAPITokenUsage(id string) {
+	if err := redis.DeleteJSONHash(apiTokenUsageKey, id); err != nil {
+		log.Printf("Warning: failed to remove usage of API token %s: %v", id, err)
+	}
+}
+
+func decodeAPITokenUsage(raw json.RawMessage) *APITokenUsage {
+	if raw == nil {
+		return nil
+	}
+	var usage APITokenUsage
+	if err := json.Unmarshal(raw, &usage); err != nil {
+		return nil
+	}
+	return &usage
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashAPIToken(t *testing.T) {
+	hash := hashAPIToken("kst_abc_secret")
+	if len(hash) != 64 {
+		t.Errorf("hash %q is not a hex SHA-256", hash)
+	}
+	if hash != hashAPIToken("kst_abc_secret") {
+		t.Error("hashing the same token twice gave different hashes")
+	}
+	if hash == hashAPIToken("kst_abc_secreT") {
+		t.Error("different tokens have the same hash")
+	}
+	if strings.Contains(hash, "secret") {
+		t.Error("hash contains the token")
+	}
+}
+
+func TestParseAPIToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		credential string
+		want       bool
+	}{
+		{"api token", "kst_abc_secret", true},
+		{"jwt", "eyJhbGciOiJIUzI1NiJ9.e30.sig", false},
+		{"empty", "", false},
+		{"prefix only", "kst_", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAPIToken(tt.credential); got != tt.want {
+				t.Errorf("IsAPIToken(%q) = %v, want %v", tt.credential, got, tt.want)
+			}
+		})
+	}
+
+	// Malformed tokens are rejected before the config is loaded
+	for _, credential := range []string{"kst_", "kst_abc", "kst__secret", "jwt.token.here"} {
+		if _, err := AuthenticateAPIToken(credential, "10.0.0.1"); !errors.Is(err, ErrInvalidAPIToken) {
+			t.Errorf("AuthenticateAPIToken(%q) error = %v, want ErrInvalidAPIToken", credential, err)
+		}
+	}
+}
+
+func TestNewAPITokenSecret(t *testing.T) {
+	first, err := newAPITokenSecret()
+	if err != nil {
+		t.Fatalf("newAPITokenSecret failed: %v", err)
+	}
+	second, _ := newAPITokenSecret()
+	if len(first) != 43 || first == second {
+		t.Errorf("secrets %q and %q are not distinct 256-bit values", first, second)
+	}
+}
+
+func TestValidateAPITokenRequest(t *testing.T) {
+	scope := PolicyRule{Verbs: []string{VerbCreate}, Contexts: []string{"wds1"}, Kinds: []string{"bindingpolicies"}}
+	tests := []struct {
+		name    string
+		request APITokenRequest
+		wantErr bool
+	}{
+		{"personal with permission", APITokenRequest{Name: "ci", Type: APITokenPersonal, Owner: "alice", Permissions: []string{PermissionWrite}}, false},
+		{"service account with scope", APITokenRequest{Name: "ci", Type: APITokenServiceAccount, Owner: "deployer", Scopes: []PolicyRule{scope}}, false},
+		{"empty name", APITokenRequest{Type: APITokenPersonal, Owner: "alice", Permissions: []string{PermissionRead}}, true},
+		{"unknown type", APITokenRequest{Name: "ci", Type: "robot", Owner: "alice", Permissions: []string{PermissionRead}}, true},
+		{"service account without owner", APITokenRequest{Name: "ci", Type: APITokenServiceAccount, Permissions: []string{PermissionRead}}, true},
+		{"owner with colon", APITokenRequest{Name: "ci", Type: APITokenServiceAccount, Owner: "ns:deployer", Permissions: []string{PermissionRead}}, true},
+		{"unknown permission", APITokenRequest{Name: "ci", Type: APITokenPersonal, Owner: "alice", Permissions: []string{"superuser"}}, true},
+		{"no permission or scope", APITokenRequest{Name: "ci", Type: APITokenPersonal, Owner: "alice"}, true},
+		{"invalid scope", APITokenRequest{Name: "ci", Type: APITokenPersonal, Owner: "alice", Scopes: []PolicyRule{{Verbs: []string{"fly"}, Contexts: []string{"*"}, Kinds: []string{"*"}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAPITokenRequest(&tt.request); (err != nil) != tt.wantErr {
+				t.Errorf("validateAPITokenRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPITokenExpiry(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tooLate := time.Now().Add(MaxAPITokenTTL + 24*time.Hour)
+	tests := []struct {
+		name    string
+		request APITokenRequest
+		wantTTL time.Duration
+		wantErr bool
+	}{
+		{"default", APITokenRequest{}, DefaultAPITokenTTL, false},
+		{"expires in", APITokenRequest{ExpiresIn: "720h"}, 720 * time.Hour, false},
+		{"invalid expires in", APITokenRequest{ExpiresIn: "a month"}, 0, true},
+		{"negative expires in", APITokenRequest{ExpiresIn: "-1h"}, 0, true},
+		{"expires at in the past", APITokenRequest{ExpiresAt: &past}, 0, true},
+		{"longer than the maximum", APITokenRequest{ExpiresAt: &tooLate}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt, err := apiTokenExpiry(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apiTokenExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if ttl := time.Until(expiresAt); ttl > tt.wantTTL || ttl < tt.wantTTL-time.Minute {
+					t.Errorf("token valid for %v, want %v", ttl, tt.wantTTL)
+				}
+			}
+		})
+	}
+}
+
+func TestAPITokenPermissions(t *testing.T) {
+	personal := &APIToken{Name: "ci", Type: APITokenPersonal, Owner: "alice", Permissions: []string{PermissionRead, PermissionWrite}}
+	service := &APIToken{Name: "deploy", Type: APITokenServiceAccount, Owner: "deployer", Scopes: []PolicyRule{{
+		Verbs: []string{VerbCreate}, Contexts: []string{"wds1"}, Kinds: []string{"bindingpolicies"},
+	}}}
+
+	if got := service.Username(); got != ServiceAccountPrefix+"deployer" {
+		t.Errorf("service account username = %q", got)
+	}
+	if got := personal.Username(); got != "alice" {
+		t.Errorf("personal token username = %q", got)
+	}
+
+	tests := []struct {
+		name  string
+		owner []string
+		want  []string
+	}{
+		{"owner keeps both", []string{PermissionRead, PermissionWrite}, []string{PermissionRead, PermissionWrite}},
+		{"owner lost write", []string{PermissionRead}, []string{PermissionRead}},
+		{"admin owner", []string{PermissionAdmin}, []string{PermissionRead, PermissionWrite}},
+		{"owner lost everything", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := personal.EffectivePermissions(tt.owner); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EffectivePermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := service.EffectivePermissions(nil); len(got) != 0 {
+		t.Errorf("service account token gained permissions %v", got)
+	}
+
+	if d := service.Authorize(Attributes{Verb: VerbCreate, Context: "wds1", Kind: "BindingPolicies"}); !d.Allowed {
+		t.Errorf("scoped token denied create in its context: %s", d.Reason)
+	}
+	if d := service.Authorize(Attributes{Verb: VerbDelete, Context: "wds1", Kind: "BindingPolicies"}); d.Allowed || !strings.Contains(d.Reason, "deploy") {
+		t.Errorf("scoped token allowed delete or gave reason %q", d.Reason)
+	}
+	if d := personal.Authorize(Attributes{Verb: VerbCreate, Context: "wds2", Namespace: "team-a", Kind: "Deployments"}); !d.Allowed {
+		t.Errorf("token with write permission denied create: %s", d.Reason)
+	}
+
+	if !(&APIToken{ExpiresAt: time.Now().Add(-time.Second)}).IsExpired() || (&APIToken{ExpiresAt: time.Now().Add(time.Hour)}).IsExpired() {
+		t.Error("IsExpired does not compare the expiry with now")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"reflect"
+	"time"
 
 	jwtconfig "github.com/kubestellar/ui/jwt"
 	"github.com/kubestellar/ui/k8s"
@@ -12,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 )
 
 // ConfigMapName and Namespace
@@ -20,16 +23,36 @@ const (
 	Namespace     = "kubestellar"
 )
 
-// UserConfig holds configuration for a single user
+// UserConfig holds configuration for a single user. Password is a bcrypt or argon2id hash;
+// plaintext entries from older versions are hashed on the user's next login. Single sign-on
+// users have no password and are bound to the issuer and subject of their first login.
 type UserConfig struct {
-	Password    string   `json:"password"`
-	Permissions []string `json:"permissions"`
+	Password           string   `json:"password"`
+	Permissions        []string `json:"permissions"`
+	MustChangePassword bool     `json:"must_change_password,omitempty"`
+	PasswordChangedAt  string   `json:"password_changed_at,omitempty"`
+	Source             string   `json:"source,omitempty"`
+	Issuer             string   `json:"issuer,omitempty"`
+	Subject            string   `json:"subject,omitempty"`
+	Email              string   `json:"email,omitempty"`
+	Groups             []string `json:"groups,omitempty"`
+	LastLoginAt        string   `json:"last_login_at,omitempty"`
+}
+
+// IsSSO reports whether the user signs in through single sign-on
+func (u UserConfig) IsSSO() bool {
+	return u.Source == UserSourceOIDC
 }
 
 // Config struct to hold global and per-user configuration data
 type Config struct {
 	JWTSecret string                `json:"jwt_secret"`
 	Users     map[string]UserConfig `json:"users"`
+	Policies  []Policy              `json:"policies,omitempty"`
+	Tokens    []APIToken            `json:"tokens,omitempty"`
+
+	// resourceVersion is the version of the ConfigMap the configuration was loaded from
+	resourceVersion string
 }
 
 // GetUser retrieves a specific user's configuration
@@ -38,15 +61,16 @@ func (c *Config) GetUser(username string) (UserConfig, bool) {
 	return userConfig, exists
 }
 
-// AddUser adds or updates a user in the configuration
-func (c *Config) AddUser(username string, password string, permissions []string) {
+// AddUser adds or updates a user in the configuration; passwordHash must already be hashed
+func (c *Config) AddUser(username string, passwordHash string, permissions []string) {
 	if c.Users == nil {
 		c.Users = make(map[string]UserConfig)
 	}
 
 	c.Users[username] = UserConfig{
-		Password:    password,
-		Permissions: permissions,
+		Password:          passwordHash,
+		Permissions:       permissions,
+		PasswordChangedAt: time.Now().UTC().Format(time.RFC3339),
 	}
 }
 
@@ -56,11 +80,16 @@ func LoadK8sConfigMap() (*Config, error) {
 	// This prevents generating a new random secret on each restart
 
 	// Get the Kubernetes clientset
-	clientset, _, err := k8s.GetClientSetWithContext("its1")
+	clientset, _, err := k8s.GetClientSetWithContext(k8s.ITSContext())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Kubernetes clientset: %v", err)
 	}
+	return loadConfig(clientset)
+}
 
+// loadConfig reads the configuration from the ConfigMap, creating it first if needed. The
+// resource version of the ConfigMap is kept so that SaveConfig detects concurrent writes.
+func loadConfig(clientset kubernetes.Interface) (*Config, error) {
 	// Check if namespace exists, create it if it doesn't
 	if err := ensureNamespaceExists(clientset); err != nil {
 		return nil, fmt.Errorf("failed to ensure namespace exists: %v", err)
@@ -94,13 +123,11 @@ func LoadK8sConfigMap() (*Config, error) {
 	if err := json.Unmarshal([]byte(cm.Data["config"]), &configData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal ConfigMap data: %v", err)
 	}
+	configData.resourceVersion = cm.ResourceVersion
 
-	// Update JWT secret in environment to match the one from ConfigMap
+	// Update JWT secret to match the one from ConfigMap
 	// This ensures tokens remain valid after server restarts
 	jwtconfig.SetJWTSecret(configData.JWTSecret)
-
-	// Now we can safely load other configs after ensuring JWT secret is set
-	jwtconfig.LoadConfig()
 	log.Println("ConfigMap loaded successfully and JWT secret updated.")
 
 	return &configData, nil
@@ -117,13 +144,54 @@ func GetUserByUsername(username string) (UserConfig, bool, error) {
 	return userConfig, exists, nil
 }
 
-// SaveConfig saves the current configuration to the ConfigMap
+// errConfigUnchanged is returned by an UpdateConfig update that has nothing to save
+var errConfigUnchanged = fmt.Errorf("configuration unchanged")
+
+// UpdateConfig loads the configuration, applies update to it and saves the result. When the
+// ConfigMap was changed by someone else in the meantime, the whole load, update and save is
+// retried on the new content, so concurrent changes are never lost. Errors returned by
+// update are returned as they are.
+func UpdateConfig(update func(config *Config) error) error {
+	clientset, _, err := k8s.GetClientSetWithContext(k8s.ITSContext())
+	if err != nil {
+		return fmt.Errorf("failed to get Kubernetes clientset: %v", err)
+	}
+	return updateConfig(clientset, update)
+}
+
+func updateConfig(clientset kubernetes.Interface, update func(config *Config) error) error {
+	var updateErr error
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		config, err := loadConfig(clientset)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		if updateErr = update(config); updateErr != nil {
+			return nil
+		}
+		return saveConfig(clientset, config)
+	})
+	if err != nil {
+		return err
+	}
+	if updateErr == errConfigUnchanged {
+		return nil
+	}
+	return updateErr
+}
+
+// SaveConfig saves the configuration to the ConfigMap. A configuration read by
+// LoadK8sConfigMap is only saved if the ConfigMap has not changed since; otherwise a conflict
+// error is returned, see UpdateConfig.
 func SaveConfig(config *Config) error {
-	clientset, _, err := k8s.GetClientSetWithContext("its1")
+	clientset, _, err := k8s.GetClientSetWithContext(k8s.ITSContext())
 	if err != nil {
 		return fmt.Errorf("failed to get Kubernetes clientset: %v", err)
 	}
+	return saveConfig(clientset, config)
+}
 
+func saveConfig(clientset kubernetes.Interface, config *Config) error {
 	// Convert struct to JSON string
 	configDataBytes, err := json.Marshal(config)
 	if err != nil {
@@ -144,38 +212,53 @@ func SaveConfig(config *Config) error {
 					"config": string(configDataBytes),
 				},
 			}
-			_, err = clientset.CoreV1().ConfigMaps(Namespace).Create(context.TODO(), configMap, metav1.CreateOptions{})
+			created, err := clientset.CoreV1().ConfigMaps(Namespace).Create(context.TODO(), configMap, metav1.CreateOptions{})
 			if err != nil {
 				return fmt.Errorf("error creating ConfigMap: %v", err)
 			}
+			config.resourceVersion = created.ResourceVersion
 		} else {
 			return fmt.Errorf("error fetching ConfigMap: %v", err)
 		}
 	} else {
-		// Update existing ConfigMap
+		// Update existing ConfigMap, failing if it changed since the config was loaded
+		if config.resourceVersion != "" {
+			cm.ResourceVersion = config.resourceVersion
+		}
 		cm.Data = map[string]string{
 			"config": string(configDataBytes),
 		}
-		_, err = clientset.CoreV1().ConfigMaps(Namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+		updated, err := clientset.CoreV1().ConfigMaps(Namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
 		if err != nil {
+			if errors.IsConflict(err) {
+				// Returned as is so that callers can retry on conflicts
+				return err
+			}
 			return fmt.Errorf("error updating ConfigMap: %v", err)
 		}
+		config.resourceVersion = updated.ResourceVersion
 	}
 
 	return nil
 }
 
 // CreateConfigMap creates a new ConfigMap with default values.
-func CreateConfigMap(clientset *kubernetes.Clientset) error {
-	// Get JWT secret from environment
+func CreateConfigMap(clientset kubernetes.Interface) error {
+	// Get the configured JWT secret
 	jwtSecret := jwtconfig.GetJWTSecret()
 
+	adminHash, err := HashPassword(DefaultAdminPassword)
+	if err != nil {
+		return err
+	}
+
 	defaultConfig := Config{
-		JWTSecret: jwtSecret, // Use JWT secret from environment
+		JWTSecret: jwtSecret, // Use the configured JWT secret
 		Users: map[string]UserConfig{
-			"admin": {
-				Password:    "admin",
-				Permissions: []string{"read", "write", "admin"},
+			DefaultAdminUsername: {
+				Password:           adminHash,
+				Permissions:        []string{"read", "write", "admin"},
+				MustChangePassword: true, // The well-known default password must be replaced on first login
 			},
 		},
 	}
@@ -212,7 +295,7 @@ func CreateConfigMap(clientset *kubernetes.Clientset) error {
 }
 
 // ensureNamespaceExists checks if the namespace exists and creates it if it doesn't
-func ensureNamespaceExists(clientset *kubernetes.Clientset) error {
+func ensureNamespaceExists(clientset kubernetes.Interface) error {
 	_, err := clientset.CoreV1().Namespaces().Get(context.TODO(), Namespace, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -234,6 +317,15 @@ func ensureNamespaceExists(clientset *kubernetes.Clientset) error {
 	return nil
 }
 
+// ErrInvalidCurrentPassword is returned by ChangePassword when the current password is wrong
+var ErrInvalidCurrentPassword = fmt.Errorf("current password is incorrect")
+
+// ErrSamePassword is returned by ChangePassword when the new password equals the current one
+var ErrSamePassword = fmt.Errorf("new password must differ from the current password")
+
+// ErrSSOPassword is returned when a password is set for a single sign-on user
+var ErrSSOPassword = fmt.Errorf("single sign-on users have no password")
+
 // Permission constants
 const (
 	PermissionRead  = "read"
@@ -274,25 +366,152 @@ func GetAvailablePermissionSets() []PermissionSet {
 	}
 }
 
-// AddOrUpdateUser adds a new user or updates an existing user in the configuration
+// AddOrUpdateUser adds a new user or updates an existing user in the configuration.
+// The password is hashed before it is stored and must satisfy the password policy; an empty
+// password keeps the current password of an existing user.
 func AddOrUpdateUser(username, password string, permissions []string) error {
 	if username == "" {
 		return fmt.Errorf("username cannot be empty")
 	}
 
-	config, err := LoadK8sConfigMap()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %v", err)
-	}
+	return UpdateConfig(func(config *Config) error {
+		// Check if we're updating the last admin user and removing admin permissions
+		if isLastAdminUser(config, username) && !containsPermission(permissions, PermissionAdmin) {
+			return fmt.Errorf("cannot remove admin permission from the last admin user")
+		}
 
-	// Check if we're updating the last admin user and removing admin permissions
-	if isLastAdminUser(config, username) && !containsPermission(permissions, PermissionAdmin) {
-		return fmt.Errorf("cannot remove admin permission from the last admin user")
-	}
+		existing, exists := config.GetUser(username)
+		if exists && existing.IsSSO() && password != "" {
+			return ErrSSOPassword
+		}
+		if password == "" {
+			if !exists {
+				return fmt.Errorf("password cannot be empty")
+			}
+			existing.Permissions = permissions
+			config.Users[username] = existing
+			return nil
+		}
+
+		if err := GetPasswordPolicy().Validate(username, password); err != nil {
+			return err
+		}
+		passwordHash, err := HashPassword(password)
+		if err != nil {
+			return err
+		}
+		config.AddUser(username, passwordHash, permissions)
+		return nil
+	})
+}
+
+// ChangePassword replaces a user's password after checking the current one, and clears a
+// pending forced password change
+func ChangePassword(username, currentPassword, newPassword string) error {
+	return UpdateConfig(func(config *Config) error {
+		userConfig, exists := config.GetUser(username)
+		if !exists {
+			return fmt.Errorf("user %s does not exist", username)
+		}
+		if userConfig.IsSSO() {
+			return ErrSSOPassword
+		}
+		if ok, _ := VerifyPassword(userConfig.Password, currentPassword); !ok {
+			return ErrInvalidCurrentPassword
+		}
+		if newPassword == currentPassword {
+			return ErrSamePassword
+		}
+		if err := GetPasswordPolicy().Validate(username, newPassword); err != nil {
+			return err
+		}
+
+		passwordHash, err := HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+		userConfig.Password = passwordHash
+		userConfig.MustChangePassword = false
+		userConfig.PasswordChangedAt = time.Now().UTC().Format(time.RFC3339)
+		config.Users[username] = userConfig
+		return nil
+	})
+}
+
+// UpgradePasswordHash re-hashes a user's password with the current algorithm after a
+// successful login. Users still on the default admin password are asked to change it.
+func UpgradePasswordHash(username, password string) error {
+	return UpdateConfig(func(config *Config) error {
+		userConfig, exists := config.GetUser(username)
+		if !exists {
+			return fmt.Errorf("user %s does not exist", username)
+		}
+		// Another login may have upgraded the entry in the meantime
+		if ok, needsRehash := VerifyPassword(userConfig.Password, password); !ok || !needsRehash {
+			return errConfigUnchanged
+		}
+
+		passwordHash, err := HashPassword(password)
+		if err != nil {
+			return err
+		}
+		userConfig.Password = passwordHash
+		if username == DefaultAdminUsername && password == DefaultAdminPassword {
+			userConfig.MustChangePassword = true
+		}
+		config.Users[username] = userConfig
+		return nil
+	})
+}
+
+// lastLoginInterval is how often the last login of an otherwise unchanged single sign-on
+// user is recorded, so that logins do not rewrite the configuration every time
+const lastLoginInterval = time.Hour
+
+// ProvisionOIDCUser creates or updates the user record of a single sign-on login with the
+// permissions mapped from the identity. A local user of the same name is never taken over,
+// and neither is a single sign-on user bound to another subject.
+func ProvisionOIDCUser(identity *OIDCIdentity, permissions []string) error {
+	return UpdateConfig(func(config *Config) error {
+		existing, exists := config.GetUser(identity.Username)
+		if exists && !existing.IsSSO() {
+			return fmt.Errorf("user %s is a local user and cannot sign in with single sign-on", identity.Username)
+		}
+		if exists && (existing.Issuer != identity.Issuer || existing.Subject != identity.Subject) {
+			return fmt.Errorf("user %s belongs to another single sign-on account", identity.Username)
+		}
+
+		now := time.Now().UTC()
+		user := UserConfig{
+			Permissions: permissions,
+			Source:      UserSourceOIDC,
+			Issuer:      identity.Issuer,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			Groups:      identity.Groups,
+			LastLoginAt: now.Format(time.RFC3339),
+		}
+		if exists && oidcUserUnchanged(existing, user, now) {
+			return errConfigUnchanged
+		}
 
-	config.AddUser(username, password, permissions)
+		if config.Users == nil {
+			config.Users = make(map[string]UserConfig)
+		}
+		config.Users[identity.Username] = user
+		return nil
+	})
+}
 
-	return SaveConfig(config)
+// oidcUserUnchanged reports whether a login leaves the stored user as it is: nothing but the
+// last login time differs, and that was recorded less than lastLoginInterval ago
+func oidcUserUnchanged(existing, user UserConfig, now time.Time) bool {
+	lastLogin, err := time.Parse(time.RFC3339, existing.LastLoginAt)
+	if err != nil || now.Sub(lastLogin) >= lastLoginInterval {
+		return false
+	}
+	existing.LastLoginAt = user.LastLoginAt
+	return reflect.DeepEqual(existing, user)
 }
 
 // AddUserWithPermissionSet adds a new user with a predefined permission set
@@ -306,27 +525,24 @@ func RemoveUser(username string) error {
 		return fmt.Errorf("username cannot be empty")
 	}
 
-	config, err := LoadK8sConfigMap()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %v", err)
-	}
-
-	if config.Users == nil {
-		return fmt.Errorf("no users found in configuration")
-	}
-
-	if _, exists := config.Users[username]; !exists {
-		return fmt.Errorf("user %s does not exist", username)
-	}
+	return UpdateConfig(func(config *Config) error {
+		if config.Users == nil {
+			return fmt.Errorf("no users found in configuration")
+		}
 
-	// Check if this is the last admin user
-	if isLastAdminUser(config, username) {
-		return fmt.Errorf("cannot delete the last admin user")
-	}
+		if _, exists := config.Users[username]; !exists {
+			return fmt.Errorf("user %s does not exist", username)
+		}
 
-	delete(config.Users, username)
+		// Check if this is the last admin user
+		if isLastAdminUser(config, username) {
+			return fmt.Errorf("cannot delete the last admin user")
+		}
 
-	return SaveConfig(config)
+		delete(config.Users, username)
+		removeUserTokens(config, username)
+		return nil
+	})
 }
 
 // UpdateUserPermissions updates only the permissions for an existing user
@@ -335,25 +551,21 @@ func UpdateUserPermissions(username string, permissions []string) error {
 		return fmt.Errorf("username cannot be empty")
 	}
 
-	config, err := LoadK8sConfigMap()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %v", err)
-	}
-
-	userConfig, exists := config.GetUser(username)
-	if !exists {
-		return fmt.Errorf("user %s does not exist", username)
-	}
-
-	// Check if we're updating the last admin user and removing admin permissions
-	if isLastAdminUser(config, username) && !containsPermission(permissions, PermissionAdmin) {
-		return fmt.Errorf("cannot remove admin permission from the last admin user")
-	}
+	return UpdateConfig(func(config *Config) error {
+		userConfig, exists := config.GetUser(username)
+		if !exists {
+			return fmt.Errorf("user %s does not exist", username)
+		}
 
-	userConfig.Permissions = permissions
-	config.Users[username] = userConfig
+		// Check if we're updating the last admin user and removing admin permissions
+		if isLastAdminUser(config, username) && !containsPermission(permissions, PermissionAdmin) {
+			return fmt.Errorf("cannot remove admin permission from the last admin user")
+		}
 
-	return SaveConfig(config)
+		userConfig.Permissions = permissions
+		config.Users[username] = userConfig
+		return nil
+	})
 }
 
 // containsPermission checks if a permission slice contains a specific permission
@@ -411,9 +623,12 @@ func ListUsers() ([]string, error) {
 
 // UserWithPermissions holds a username and its associated permissions
 type UserWithPermissions struct {
-	Username    string   `json:"username"`
-	Password    string   `json:"password,omitempty"` // Password is omitted in responses
-	Permissions []string `json:"permissions"`
+	Username           string   `json:"username"`
+	Password           string   `json:"password,omitempty"` // Password is omitted in responses
+	Permissions        []string `json:"permissions"`
+	MustChangePassword bool     `json:"must_change_password,omitempty"`
+	Source             string   `json:"source,omitempty"`
+	LastLoginAt        string   `json:"last_login_at,omitempty"`
 }
 
 // ListUsersWithPermissions returns detailed information about all users
@@ -426,8 +641,11 @@ func ListUsersWithPermissions() ([]UserWithPermissions, error) {
 	users := make([]UserWithPermissions, 0, len(config.Users))
 	for username, userConfig := range config.Users {
 		users = append(users, UserWithPermissions{
-			Username:    username,
-			Permissions: userConfig.Permissions,
+			Username:           username,
+			Permissions:        userConfig.Permissions,
+			MustChangePassword: userConfig.MustChangePassword,
+			Source:             userConfig.Source,
+			LastLoginAt:        userConfig.LastLoginAt,
 			// Password is intentionally omitted for security
 		})
 	}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"sync"
+
+	"github.com/kubestellar/ui/config"
+)
+
+var (
+	settingsMu sync.RWMutex
+	settings   = config.Default().Auth
+)
+
+// Configure sets the single sign-on, login throttling and password settings. Single sign-on
+// is discovered again with the new settings on next use.
+func Configure(cfg config.AuthConfig) {
+	settingsMu.Lock()
+	settings = cfg
+	settingsMu.Unlock()
+
+	oidcMu.Lock()
+	oidcProvider = nil
+	oidcMu.Unlock()
+}
+
+func authSettings() config.AuthConfig {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
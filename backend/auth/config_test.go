@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// testConfigClientset holds the jwt-config ConfigMap and, like the API server, rejects
+// updates made with a stale resource version. updates counts the accepted updates.
+func testConfigClientset(t *testing.T, config Config) (*fake.Clientset, *int) {
+	t.Helper()
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: Namespace, ResourceVersion: "1"},
+		Data:       map[string]string{"config": string(data)},
+	})
+	updates := 0
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	clientset.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		cm := action.(clienttesting.UpdateAction).GetObject().(*corev1.ConfigMap).DeepCopy()
+		stored, err := clientset.Tracker().Get(gvr, Namespace, ConfigMapName)
+		if err != nil {
+			return true, nil, err
+		}
+		current := stored.(*corev1.ConfigMap).ResourceVersion
+		if cm.ResourceVersion != current {
+			return true, nil, errors.NewConflict(gvr.GroupResource(), ConfigMapName, fmt.Errorf("stale resource version"))
+		}
+		version, _ := strconv.Atoi(current)
+		cm.ResourceVersion = strconv.Itoa(version + 1)
+		updates++
+		return true, cm, clientset.Tracker().Update(gvr, cm, Namespace)
+	})
+	return clientset, &updates
+}
+
+func testConfig() Config {
+	return Config{
+		JWTSecret: "test-secret",
+		Users:     map[string]UserConfig{"admin": {Password: "hash", Permissions: []string{PermissionAdmin}}},
+	}
+}
+
+func TestSaveConfigRejectsStaleConfig(t *testing.T) {
+	clientset, _ := testConfigClientset(t, testConfig())
+	stale, err := loadConfig(clientset)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+
+	concurrent, err := loadConfig(clientset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	concurrent.Policies = append(concurrent.Policies, Policy{Name: "ops"})
+	if err := saveConfig(clientset, concurrent); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	stale.Users["dev"] = UserConfig{Permissions: []string{PermissionRead}}
+	if err := saveConfig(clientset, stale); !errors.IsConflict(err) {
+		t.Fatalf("saveConfig of a stale config returned %v, want a conflict", err)
+	}
+
+	// A saved config carries the new version and can be saved again
+	concurrent.Policies = nil
+	if err := saveConfig(clientset, concurrent); err != nil {
+		t.Errorf("second saveConfig failed: %v", err)
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		concurrent   bool
+		update       func(config *Config) error
+		wantErr      string
+		wantUpdates  int
+		wantUsers    []string
+		wantPolicies int
+	}{
+		{
+			name:        "change",
+			update:      func(config *Config) error { config.Users["dev"] = UserConfig{}; return nil },
+			wantUpdates: 1,
+			wantUsers:   []string{"admin", "dev"},
+		},
+		{
+			name:         "concurrent change",
+			concurrent:   true,
+			update:       func(config *Config) error { config.Users["dev"] = UserConfig{}; return nil },
+			wantUpdates:  2,
+			wantUsers:    []string{"admin", "dev"},
+			wantPolicies: 1,
+		},
+		{
+			name:      "unchanged",
+			update:    func(config *Config) error { return errConfigUnchanged },
+			wantUsers: []string{"admin"},
+		},
+		{
+			name:      "failed update",
+			update:    func(config *Config) error { return fmt.Errorf("user dev does not exist") },
+			wantErr:   "user dev does not exist",
+			wantUsers: []string{"admin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset, updates := testConfigClientset(t, testConfig())
+			calls := 0
+			err := updateConfig(clientset, func(config *Config) error {
+				calls++
+				if tt.concurrent && calls == 1 {
+					// Another replica adds a policy after this config was loaded
+					other, err := loadConfig(clientset)
+					if err != nil {
+						return err
+					}
+					other.Policies = append(other.Policies, Policy{Name: "ops"})
+					if err := saveConfig(clientset, other); err != nil {
+						return err
+					}
+				}
+				return tt.update(config)
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("updateConfig() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("updateConfig failed: %v", err)
+			}
+			if *updates != tt.wantUpdates {
+				t.Errorf("%d updates of the ConfigMap, want %d", *updates, tt.wantUpdates)
+			}
+
+			saved, err := loadConfig(clientset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(saved.Users) != len(tt.wantUsers) || len(saved.Policies) != tt.wantPolicies {
+				t.Errorf("saved users %v and %d policies, want %v and %d", saved.Users, len(saved.Policies), tt.wantUsers, tt.wantPolicies)
+			}
+			for _, username := range tt.wantUsers {
+				if _, ok := saved.Users[username]; !ok {
+					t.Errorf("user %s is missing", username)
+				}
+			}
+		})
+	}
+}
+
+func TestOIDCUserUnchanged(t *testing.T) {
+	now := time.Now().UTC()
+	user := UserConfig{
+		Permissions: []string{PermissionRead},
+		Source:      UserSourceOIDC,
+		Issuer:      "https://idp.example.com",
+		Subject:     "1234",
+		Email:       "dev@example.com",
+		Groups:      []string{"dev"},
+		LastLoginAt: now.Format(time.RFC3339),
+	}
+	tests := []struct {
+		name   string
+		modify func(existing *UserConfig)
+		want   bool
+	}{
+		{"recent login", func(u *UserConfig) { u.LastLoginAt = now.Add(-time.Minute).Format(time.RFC3339) }, true},
+		{"old login", func(u *UserConfig) { u.LastLoginAt = now.Add(-2 * time.Hour).Format(time.RFC3339) }, false},
+		{"no login recorded", func(u *UserConfig) { u.LastLoginAt = "" }, false},
+		{"new permissions", func(u *UserConfig) { u.Permissions = []string{PermissionRead, PermissionWrite} }, false},
+		{"new email", func(u *UserConfig) { u.Email = "old@example.com" }, false},
+		{"new groups", func(u *UserConfig) { u.Groups = nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := user
+			existing.LastLoginAt = now.Add(-time.Minute).Format(time.RFC3339)
+			tt.modify(&existing)
+			if got := oidcUserUnchanged(existing, user, now); got != tt.want {
+				t.Errorf("oidcUserUnchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
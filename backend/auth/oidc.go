@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/redis"
+	"github.com/kubestellar/ui/utils"
+	"golang.org/x/oauth2"
+)
+
+// UserSourceOIDC marks users created on their first single sign-on login. Users without a
+// source are local users that log in with a password.
+const UserSourceOIDC = "oidc"
+
+// oidcStateKeyPrefix holds the PKCE verifier and nonce of a login in progress, by state
+const oidcStateKeyPrefix = "auth:oidc-state:"
+
+// oidcLoginTimeout is how long a user has to complete the login at the identity provider
+const oidcLoginTimeout = 10 * time.Minute
+
+var (
+	// ErrOIDCDisabled is returned when single sign-on is used without being configured
+	ErrOIDCDisabled = fmt.Errorf("single sign-on is not configured")
+	// ErrOIDCLoginExpired is returned for a callback whose state is unknown or expired
+	ErrOIDCLoginExpired = fmt.Errorf("login state is invalid or expired, please sign in again")
+	// ErrOIDCNoPermissions is returned when none of the user's groups or claims grants a permission
+	ErrOIDCNoPermissions = fmt.Errorf("no permissions are mapped to this account")
+)
+
+// ClaimPermissions grants permissions to users whose ID token claim has the given value
+type ClaimPermissions = config.ClaimPermissions
+
+// OIDCConfig is the single sign-on configuration with its permission mappings checked
+type OIDCConfig struct {
+	config.OIDCConfig
+}
+
+// GetOIDCConfig returns the configured single sign-on settings after checking that the
+// permission mappings name known permissions or permission sets, such as
+// {"platform-admins":["admin"],"developers":["standard-user"]} for groups
+func GetOIDCConfig() (*OIDCConfig, error) {
+	cfg := &OIDCConfig{authSettings().OIDC}
+
+	// Catch typos in the mappings at startup rather than at someone's first login
+	for group, names := range cfg.GroupPermissions {
+		if _, err := ResolvePermissions(names); err != nil {
+			return nil, fmt.Errorf("invalid permissions for group %q: %v", group, err)
+		}
+	}
+	for _, rule := range cfg.ClaimPermissions {
+		if _, err := ResolvePermissions(rule.Permissions); err != nil {
+			return nil, fmt.Errorf("invalid permissions for claim %q: %v", rule.Claim, err)
+		}
+	}
+	if _, err := ResolvePermissions(cfg.DefaultPermissions); err != nil {
+		return nil, fmt.Errorf("invalid default permissions: %v", err)
+	}
+	return cfg, nil
+}
+
+// ResolvePermissions expands permission set names into their permissions and returns the
+// distinct permissions in a stable order
+func ResolvePermissions(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case PermissionRead, PermissionWrite, PermissionAdmin:
+			seen[name] = true
+			continue
+		}
+		found := false
+		for _, set := range GetAvailablePermissionSets() {
+			if set.Name == name {
+				for _, permission := range set.Permissions {
+					seen[permission] = true
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown permission or permission set %q", name)
+		}
+	}
+
+	permissions := make([]string, 0, len(seen))
+	for _, permission := range []string{PermissionRead, PermissionWrite, PermissionAdmin} {
+		if seen[permission] {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// OIDCIdentity is the verified identity of a single sign-on login
+type OIDCIdentity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+	Claims   map[string]interface{}
+}
+
+// MapPermissions returns the permissions granted by the groups and claims of an identity,
+// or the default permissions when nothing matches
+func (c *OIDCConfig) MapPermissions(identity *OIDCIdentity) ([]string, error) {
+	var names []string
+	for _, group := range identity.Groups {
+		names = append(names, c.GroupPermissions[group]...)
+	}
+	for _, rule := range c.ClaimPermissions {
+		if claimMatches(identity.Claims[rule.Claim], rule.Value) {
+			names = append(names, rule.Permissions...)
+		}
+	}
+	if len(names) == 0 {
+		names = c.DefaultPermissions
+	}
+
+	permissions, err := ResolvePermissions(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		return nil, ErrOIDCNoPermissions
+	}
+	return permissions, nil
+}
+
+// claimMatches compares a claim with a configured value; list claims match on any element
+func claimMatches(claim interface{}, value string) bool {
+	switch v := claim.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		for _, item := range v {
+			if claimMatches(item, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(v) == value
+	}
+}
+
+// OIDCProvider runs the authorization code flow with PKCE against the configured provider
+type OIDCProvider struct {
+	config   *OIDCConfig
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+var (
+	oidcMu       sync.Mutex
+	oidcProvider *OIDCProvider
+)
+
+// GetOIDCProvider returns the single sign-on provider, discovering it on first use.
+// A provider that cannot be reached is retried on the next call.
+func GetOIDCProvider() (*OIDCProvider, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	if oidcProvider != nil {
+		return oidcProvider, nil
+	}
+	cfg, err := GetOIDCConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %s: %v", cfg.IssuerURL, err)
+	}
+
+	oidcProvider = &OIDCProvider{
+		config: cfg,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, cfg.Scopes...),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+	log.Printf("Single sign-on enabled with issuer %s", cfg.IssuerURL)
+	return oidcProvider, nil
+}
+
+// Config returns the configuration of the provider
+func (p *OIDCProvider) Config() *OIDCConfig {
+	return p.config
+}
+
+// oidcLoginState is what the callback needs to finish a login started by AuthCodeURL
+type oidcLoginState struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+// AuthCodeURL starts a login and returns the provider URL to send the browser to, with the
+// state the browser must bring back to the callback
+func (p *OIDCProvider) AuthCodeURL() (string, string, error) {
+	state := utils.NewTokenID()
+	login := oidcLoginState{
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    utils.NewTokenID(),
+	}
+	if err := redis.SetJSONValue(oidcStateKeyPrefix+state, login, oidcLoginTimeout); err != nil {
+		return "", "", fmt.Errorf("failed to store login state: %v", err)
+	}
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(login.Verifier), oidc.Nonce(login.Nonce)), state, nil
+}
+
+// OIDCLoginTimeout is how long a login started by AuthCodeURL can be finished
+func OIDCLoginTimeout() time.Duration {
+	return oidcLoginTimeout
+}
+
+// Exchange finishes a login: it redeems the authorization code with the PKCE verifier of the
+// state and verifies the returned ID token
+func (p *OIDCProvider) Exchange(ctx context.Context, state, code string) (*OIDCIdentity, error) {
+	var login oidcLoginState
+	found, err := redis.GetJSONValue(oidcStateKeyPrefix+state, &login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login state: %v", err)
+	}
+	if !found || state == "" {
+		return nil, ErrOIDCLoginExpired
+	}
+	// A state can only be used once
+	if err := redis.DeleteKey(oidcStateKeyPrefix + state); err != nil {
+		return nil, fmt.Errorf("failed to delete login state: %v", err)
+	}
+
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(login.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %v", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("token response did not include an ID token")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+	if idToken.Nonce != login.Nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse ID token claims: %v", err)
+	}
+	return p.identity(idToken.Issuer, idToken.Subject, claims)
+}
+
+// identity extracts the username, email and groups from the claims of an ID token
+func (p *OIDCProvider) identity(issuer, subject string, claims map[string]interface{}) (*OIDCIdentity, error) {
+	identity := &OIDCIdentity{Issuer: issuer, Subject: subject, Claims: claims}
+	identity.Email, _ = claims["email"].(string)
+
+	// Fall back to the email and then the subject when the configured claim is missing
+	for _, claim := range []string{p.config.UsernameClaim, "email", "sub"} {
+		if username, ok := claims[claim].(string); ok && username != "" {
+			identity.Username = username
+			break
+		}
+	}
+	if identity.Username == "" {
+		return nil, fmt.Errorf("ID token has no %s claim", p.config.UsernameClaim)
+	}
+
+	switch groups := claims[p.config.GroupsClaim].(type) {
+	case string:
+		identity.Groups = []string{groups}
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	}
+	sort.Strings(identity.Groups)
+	return identity, nil
+}
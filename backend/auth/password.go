@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms, selected with the hashAlgorithm password setting
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// Default admin credentials created with a new ConfigMap; the password must be changed on first login
+const (
+	DefaultAdminUsername = "admin"
+	DefaultAdminPassword = "admin"
+)
+
+// argon2id parameters (RFC 9106 second recommended option)
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 2
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// bcryptMaxLength is the number of bytes bcrypt considers; longer passwords are rejected
+// rather than silently truncated
+const bcryptMaxLength = 72
+
+// dummyHash is compared against when a user does not exist so the response time does not
+// reveal which usernames are valid
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("kubestellar-dummy-password"), bcrypt.DefaultCost)
+
+// hashAlgorithm returns the configured algorithm for new hashes
+func hashAlgorithm() string {
+	if strings.EqualFold(authSettings().Passwords.HashAlgorithm, HashArgon2id) {
+		return HashArgon2id
+	}
+	return HashBcrypt
+}
+
+// HashPassword hashes a password with the configured algorithm
+func HashPassword(password string) (string, error) {
+	if hashAlgorithm() == HashArgon2id {
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %v", err)
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+
+	if len(password) > bcryptMaxLength {
+		return "", fmt.Errorf("password must be at most %d bytes", bcryptMaxLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// IsPasswordHashed reports whether a stored password is a bcrypt or argon2id hash
+func IsPasswordHashed(stored string) bool {
+	return isBcryptHash(stored) || strings.HasPrefix(stored, "$argon2id$")
+}
+
+func isBcryptHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// VerifyPassword checks a password against a stored hash. Plaintext entries written before
+// passwords were hashed are still accepted; needsRehash is set for them and for hashes made
+// with another algorithm or weaker parameters than the current ones. An empty stored
+// password never matches but takes as long as a real check, so it can be used for unknown users.
+func VerifyPassword(stored, password string) (ok bool, needsRehash bool) {
+	switch {
+	case stored == "":
+		burnPasswordCheck(password)
+		return false, false
+	case isBcryptHash(stored):
+		if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost([]byte(stored))
+		return true, hashAlgorithm() != HashBcrypt || err != nil || cost < bcrypt.DefaultCost
+	case strings.HasPrefix(stored, "$argon2id$"):
+		ok, current := verifyArgon2id(stored, password)
+		return ok, ok && (hashAlgorithm() != HashArgon2id || !current)
+	default:
+		ok := subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, ok
+	}
+}
+
+// verifyArgon2id checks a PHC-formatted argon2id hash; current reports whether it was made
+// with the current parameters
+func verifyArgon2id(stored, password string) (ok bool, current bool) {
+	// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return false, false
+	}
+	return true, memory >= argon2Memory && iterations >= argon2Time && threads >= argon2Threads && len(key) >= argon2KeyLen
+}
+
+// burnPasswordCheck spends the time of a real password check, for unknown users
+func burnPasswordCheck(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// PasswordPolicy describes the passwords users may set
+type PasswordPolicy struct {
+	MinLength      int  `json:"min_length"`
+	RequireUpper   bool `json:"require_upper"`
+	RequireLower   bool `json:"require_lower"`
+	RequireDigit   bool `json:"require_digit"`
+	RequireSymbol  bool `json:"require_symbol"`
+	RejectUsername bool `json:"reject_username"` // Reject passwords that contain the username
+}
+
+// GetPasswordPolicy returns the configured policy; upper case, lower case and digits are
+// always required
+func GetPasswordPolicy() PasswordPolicy {
+	passwords := authSettings().Passwords
+	return PasswordPolicy{
+		MinLength:      passwords.MinLength,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSymbol:  passwords.RequireSymbol,
+		RejectUsername: true,
+	}
+}
+
+// Validate returns a *PolicyError describing every rule the password breaks
+func (p PasswordPolicy) Validate(username, password string) error {
+	var problems []string
+	if len([]rune(password)) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("be at least %d characters long", p.MinLength))
+	}
+	if hashAlgorithm() == HashBcrypt && len(password) > bcryptMaxLength {
+		problems = append(problems, fmt.Sprintf("be at most %d bytes long", bcryptMaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "contain an upper case letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "contain a lower case letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "contain a symbol")
+	}
+	if p.RejectUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		problems = append(problems, "not contain the username")
+	}
+
+	if len(problems) > 0 {
+		return &PolicyError{Problems: problems}
+	}
+	return nil
+}
+
+// PolicyError lists the password policy rules a password breaks
+type PolicyError struct {
+	Problems []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password must " + strings.Join(e.Problems, ", ")
+}
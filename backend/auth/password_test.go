@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubestellar/ui/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// configurePasswords sets the password settings for the rest of the test
+func configurePasswords(t *testing.T, passwords config.PasswordConfig) {
+	t.Helper()
+	settings := config.Default().Auth
+	settings.Passwords = passwords
+	Configure(settings)
+	t.Cleanup(func() { Configure(config.Default().Auth) })
+}
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	const password = "Correct-Horse-9"
+
+	for _, algorithm := range []string{HashBcrypt, HashArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			configurePasswords(t, config.PasswordConfig{HashAlgorithm: algorithm, MinLength: 12})
+			hash, err := HashPassword(password)
+			if err != nil {
+				t.Fatalf("HashPassword failed: %v", err)
+			}
+			if !IsPasswordHashed(hash) || hash == password {
+				t.Fatalf("HashPassword returned %q, want a %s hash", hash, algorithm)
+			}
+			if ok, needsRehash := VerifyPassword(hash, password); !ok || needsRehash {
+				t.Errorf("VerifyPassword() = %v, %v; want a current match", ok, needsRehash)
+			}
+			if ok, _ := VerifyPassword(hash, "wrong-password"); ok {
+				t.Errorf("VerifyPassword accepted a wrong password")
+			}
+
+			// Switching algorithms upgrades hashes at the next login
+			other := HashArgon2id
+			if algorithm == HashArgon2id {
+				other = HashBcrypt
+			}
+			configurePasswords(t, config.PasswordConfig{HashAlgorithm: other, MinLength: 12})
+			if ok, needsRehash := VerifyPassword(hash, password); !ok || !needsRehash {
+				t.Errorf("VerifyPassword() after switching to %s = %v, %v; want a match needing a rehash", other, ok, needsRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyPasswordLegacyAndWeakHashes(t *testing.T) {
+	weak, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		stored          string
+		password        string
+		wantOK          bool
+		wantNeedsRehash bool
+	}{
+		{name: "plaintext", stored: "secret", password: "secret", wantOK: true, wantNeedsRehash: true},
+		{name: "wrong plaintext", stored: "secret", password: "other"},
+		{name: "empty stored password", stored: "", password: ""},
+		{name: "weak bcrypt", stored: string(weak), password: "secret", wantOK: true, wantNeedsRehash: true},
+		{name: "weak argon2id", stored: argon2Hash("secret", 1024, 1, 1), password: "secret", wantOK: true, wantNeedsRehash: true},
+		{name: "wrong argon2id", stored: argon2Hash("secret", 1024, 1, 1), password: "other"},
+		{name: "malformed argon2id", stored: "$argon2id$v=19$broken", password: "secret"},
+	}
+	configurePasswords(t, config.PasswordConfig{HashAlgorithm: HashArgon2id, MinLength: 12})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := VerifyPassword(tt.stored, tt.password)
+			if ok != tt.wantOK || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("VerifyPassword() = %v, %v; want %v, %v", ok, needsRehash, tt.wantOK, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+// argon2Hash hashes a password with the given argon2id parameters in the stored format
+func argon2Hash(password string, memory, iterations uint32, threads uint8) string {
+	salt := []byte("saltsaltsaltsalt")
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, iterations, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestHashPasswordBcryptLimit(t *testing.T) {
+	configurePasswords(t, config.PasswordConfig{HashAlgorithm: HashBcrypt, MinLength: 12})
+	if _, err := HashPassword(strings.Repeat("a", bcryptMaxLength+1)); err == nil {
+		t.Errorf("HashPassword accepted a password bcrypt would truncate")
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 12, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true, RejectUsername: true}
+	tests := []struct {
+		name         string
+		password     string
+		wantProblems []string
+	}{
+		{name: "valid", password: "Correct-Horse-9"},
+		{name: "short", password: "Sh0rt-pw", wantProblems: []string{"be at least 12 characters long"}},
+		{name: "missing classes", password: "alllowercaseletters", wantProblems: []string{
+			"contain an upper case letter", "contain a digit", "contain a symbol"}},
+		{name: "contains username", password: "Alice-Password-1", wantProblems: []string{"not contain the username"}},
+	}
+	configurePasswords(t, config.Default().Auth.Passwords)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate("alice", tt.password)
+			if tt.wantProblems == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) || !reflect.DeepEqual(policyErr.Problems, tt.wantProblems) {
+				t.Errorf("Validate() = %v, want problems %v", err, tt.wantProblems)
+			}
+		})
+	}
+
+	configurePasswords(t, config.PasswordConfig{HashAlgorithm: HashBcrypt, MinLength: 16, RequireSymbol: true})
+	if got := GetPasswordPolicy(); got.MinLength != 16 || !got.RequireSymbol || !got.RequireDigit || !got.RejectUsername {
+		t.Errorf("GetPasswordPolicy() = %+v, want the configured length and symbol rule", got)
+	}
+}
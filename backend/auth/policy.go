@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verbs of the access policy
+const (
+	VerbGet    = "get"
+	VerbList   = "list"
+	VerbWatch  = "watch"
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+	VerbExec   = "exec"
+	VerbLogs   = "logs"
+	VerbSync   = "sync"
+)
+
+// verbAliases expand to several verbs in a policy rule
+var verbAliases = map[string][]string{
+	PermissionRead:  {VerbGet, VerbList, VerbWatch, VerbLogs},
+	PermissionWrite: {VerbCreate, VerbUpdate, VerbDelete, VerbSync},
+	"*":             {VerbGet, VerbList, VerbWatch, VerbCreate, VerbUpdate, VerbDelete, VerbExec, VerbLogs, VerbSync},
+}
+
+// AllVerbs lists every verb a rule can grant
+func AllVerbs() []string {
+	return verbAliases["*"]
+}
+
+// PolicyRule grants verbs on resources of the given kinds in the given namespaces of the given
+// contexts. "*" matches anything; an empty namespace stands for cluster-scoped resources.
+// Kinds are resource names such as "deployments" or "bindingpolicies".
+type PolicyRule struct {
+	Verbs      []string `json:"verbs"`
+	Contexts   []string `json:"contexts"`
+	Namespaces []string `json:"namespaces"`
+	Kinds      []string `json:"kinds"`
+}
+
+// Policy binds rules to users and groups. Policies only add access: a request is allowed when
+// any policy of the user, or one of the user's global permissions, allows it.
+type Policy struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Users       []string     `json:"users,omitempty"`
+	Groups      []string     `json:"groups,omitempty"`
+	Rules       []PolicyRule `json:"rules"`
+}
+
+// Validate checks that the policy has a name, subjects and well-formed rules
+func (p *Policy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("policy name cannot be empty")
+	}
+	if len(p.Users) == 0 && len(p.Groups) == 0 {
+		return fmt.Errorf("policy %s must apply to at least one user or group", p.Name)
+	}
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("policy %s must have at least one rule", p.Name)
+	}
+	for i, rule := range p.Rules {
+		if len(rule.Verbs) == 0 || len(rule.Contexts) == 0 || len(rule.Kinds) == 0 {
+			return fmt.Errorf("rule %d of policy %s needs verbs, contexts and kinds", i, p.Name)
+		}
+		for _, verb := range rule.Verbs {
+			if !isVerb(verb) {
+				return fmt.Errorf("rule %d of policy %s has unknown verb %q", i, p.Name, verb)
+			}
+		}
+	}
+	return nil
+}
+
+// appliesTo reports whether the policy binds the user or one of the groups
+func (p *Policy) appliesTo(username string, groups []string) bool {
+	for _, user := range p.Users {
+		if user == username {
+			return true
+		}
+	}
+	for _, group := range p.Groups {
+		for _, userGroup := range groups {
+			if group == userGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Attributes describe a request to be authorized
+type Attributes struct {
+	Verb      string `json:"verb"`
+	Context   string `json:"context"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name,omitempty"`
+}
+
+func (a Attributes) String() string {
+	namespace := a.Namespace
+	if namespace == "" {
+		namespace = "(cluster-scoped)"
+	}
+	return fmt.Sprintf("%s %s in namespace %s on context %s", a.Verb, a.Kind, namespace, a.Context)
+}
+
+// Subject is the user a request is authorized for
+type Subject struct {
+	Username    string
+	Permissions []string
+	Groups      []string
+}
+
+// Decision is the outcome of an authorization with the reason for it
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+// Authorize decides whether the subject may perform the request. Global permissions keep
+// their meaning: admin allows everything, read allows reading and write allows changing
+// resources anywhere. Policies grant further access on specific contexts, namespaces and kinds.
+func Authorize(subject Subject, attrs Attributes, policies []Policy) Decision {
+	attrs.Kind = strings.ToLower(attrs.Kind)
+
+	for _, permission := range []string{PermissionAdmin, PermissionWrite, PermissionRead} {
+		if !containsPermission(subject.Permissions, permission) {
+			continue
+		}
+		if permission == PermissionAdmin || verbMatches([]string{permission}, attrs.Verb) {
+			return Decision{Allowed: true, Reason: fmt.Sprintf("granted by global %s permission", permission)}
+		}
+	}
+
+	for _, policy := range policies {
+		if !policy.appliesTo(subject.Username, subject.Groups) {
+			continue
+		}
+		for i, rule := range policy.Rules {
+			if rule.allows(attrs) {
+				return Decision{Allowed: true, Reason: fmt.Sprintf("granted by rule %d of policy %s", i, policy.Name)}
+			}
+		}
+	}
+
+	return Decision{Allowed: false, Reason: fmt.Sprintf("no permission or policy allows %s", attrs)}
+}
+
+// allows reports whether the rule matches every attribute of the request
+func (r *PolicyRule) allows(attrs Attributes) bool {
+	return verbMatches(r.Verbs, attrs.Verb) &&
+		valueMatches(r.Contexts, attrs.Context) &&
+		namespaceMatches(r.Namespaces, attrs.Namespace) &&
+		kindMatches(r.Kinds, attrs.Kind)
+}
+
+func isVerb(verb string) bool {
+	if _, ok := verbAliases[verb]; ok {
+		return true
+	}
+	for _, known := range AllVerbs() {
+		if verb == known {
+			return true
+		}
+	}
+	return false
+}
+
+// verbMatches reports whether the verbs, with aliases expanded, include verb
+func verbMatches(verbs []string, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+		for _, expanded := range verbAliases[v] {
+			if expanded == verb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func valueMatches(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceMatches treats a rule without namespaces as granting cluster-scoped resources only
+func namespaceMatches(patterns []string, namespace string) bool {
+	if len(patterns) == 0 {
+		return namespace == ""
+	}
+	return valueMatches(patterns, namespace)
+}
+
+// kindMatches compares kinds case-insensitively and accepts the singular form of a resource name
+func kindMatches(patterns []string, kind string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == kind || pattern+"s" == kind || pattern+"es" == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPolicies returns the access policies stored with the users
+func ListPolicies() ([]Policy, error) {
+	config, err := LoadK8sConfigMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+	return config.Policies, nil
+}
+
+// PoliciesFor returns the policies that apply to a user or one of the groups
+func PoliciesFor(username string, groups []string) ([]Policy, error) {
+	policies, err := ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+	var applicable []Policy
+	for _, policy := range policies {
+		if policy.appliesTo(username, groups) {
+			applicable = append(applicable, policy)
+		}
+	}
+	return applicable, nil
+}
+
+// SavePolicy creates or replaces the policy of the same name
+func SavePolicy(policy Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	return UpdateConfig(func(config *Config) error {
+		for i := range config.Policies {
+			if config.Policies[i].Name == policy.Name {
+				config.Policies[i] = policy
+				return nil
+			}
+		}
+		config.Policies = append(config.Policies, policy)
+		return nil
+	})
+}
+
+// DeletePolicy removes a policy by name
+func DeletePolicy(name string) error {
+	return UpdateConfig(func(config *Config) error {
+		for i := range config.Policies {
+			if config.Policies[i].Name == name {
+				config.Policies = append(config.Policies[:i], config.Policies[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("policy %s does not exist", name)
+	})
+}
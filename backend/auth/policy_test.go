@@ -0,0 +1,87 @@
+package auth
+
+import "testing"
+
+func TestAuthorize(t *testing.T) {
+	policies := []Policy{
+		{
+			Name:   "dev-deployments",
+			Groups: []string{"dev"},
+			Rules: []PolicyRule{{
+				Verbs:      []string{PermissionRead, VerbUpdate},
+				Contexts:   []string{"wds1"},
+				Namespaces: []string{"team-a"},
+				Kinds:      []string{"deployment"},
+			}},
+		},
+		{
+			Name:  "alice-policies",
+			Users: []string{"alice"},
+			Rules: []PolicyRule{{
+				Verbs:    []string{"*"},
+				Contexts: []string{"*"},
+				Kinds:    []string{"bindingpolicies"},
+			}},
+		},
+	}
+	deploymentIn := func(verb, context, namespace string) Attributes {
+		return Attributes{Verb: verb, Context: context, Namespace: namespace, Kind: "Deployments"}
+	}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		attrs   Attributes
+		want    bool
+	}{
+		{"admin allows anything", Subject{Username: "root", Permissions: []string{PermissionAdmin}}, deploymentIn(VerbExec, "wds2", "kube-system"), true},
+		{"global write allows create", Subject{Username: "bob", Permissions: []string{PermissionWrite}}, deploymentIn(VerbCreate, "wds2", "any"), true},
+		{"global write does not allow exec", Subject{Username: "bob", Permissions: []string{PermissionWrite}}, deploymentIn(VerbExec, "wds1", "team-a"), false},
+		{"global read allows logs", Subject{Username: "bob", Permissions: []string{PermissionRead}}, deploymentIn(VerbLogs, "wds1", "team-a"), true},
+		{"global read does not allow delete", Subject{Username: "bob", Permissions: []string{PermissionRead}}, deploymentIn(VerbDelete, "wds1", "team-a"), false},
+		{"group policy allows listed verb", Subject{Username: "carol", Groups: []string{"dev"}}, deploymentIn(VerbUpdate, "wds1", "team-a"), true},
+		{"group policy expands read alias", Subject{Username: "carol", Groups: []string{"dev"}}, deploymentIn(VerbWatch, "wds1", "team-a"), true},
+		{"group policy denies other verb", Subject{Username: "carol", Groups: []string{"dev"}}, deploymentIn(VerbDelete, "wds1", "team-a"), false},
+		{"group policy denies other namespace", Subject{Username: "carol", Groups: []string{"dev"}}, deploymentIn(VerbGet, "wds1", "team-b"), false},
+		{"group policy denies other context", Subject{Username: "carol", Groups: []string{"dev"}}, deploymentIn(VerbGet, "wds2", "team-a"), false},
+		{"group policy denies cluster scope", Subject{Username: "carol", Groups: []string{"dev"}}, deploymentIn(VerbGet, "wds1", ""), false},
+		{"policy does not apply to other groups", Subject{Username: "dave", Groups: []string{"ops"}}, deploymentIn(VerbGet, "wds1", "team-a"), false},
+		{"user policy allows cluster-scoped kind", Subject{Username: "alice"}, Attributes{Verb: VerbDelete, Context: "wds3", Kind: "BindingPolicies"}, true},
+		{"user policy denies namespaced resource", Subject{Username: "alice"}, Attributes{Verb: VerbGet, Context: "wds1", Namespace: "team-a", Kind: "bindingpolicies"}, false},
+		{"no permissions or policies", Subject{Username: "eve"}, deploymentIn(VerbGet, "wds1", "team-a"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Authorize(tt.subject, tt.attrs, policies)
+			if decision.Allowed != tt.want {
+				t.Errorf("Authorize() = %v (%s), want %v", decision.Allowed, decision.Reason, tt.want)
+			}
+			if decision.Reason == "" {
+				t.Error("Authorize() gave no reason")
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	rule := PolicyRule{Verbs: []string{VerbGet}, Contexts: []string{"*"}, Kinds: []string{"pods"}}
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr bool
+	}{
+		{"valid", Policy{Name: "p", Users: []string{"alice"}, Rules: []PolicyRule{rule}}, false},
+		{"no name", Policy{Users: []string{"alice"}, Rules: []PolicyRule{rule}}, true},
+		{"no subjects", Policy{Name: "p", Rules: []PolicyRule{rule}}, true},
+		{"no rules", Policy{Name: "p", Groups: []string{"dev"}}, true},
+		{"rule without kinds", Policy{Name: "p", Users: []string{"alice"}, Rules: []PolicyRule{{Verbs: []string{VerbGet}, Contexts: []string{"*"}}}}, true},
+		{"unknown verb", Policy{Name: "p", Users: []string{"alice"}, Rules: []PolicyRule{{Verbs: []string{"patch"}, Contexts: []string{"*"}, Kinds: []string{"pods"}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
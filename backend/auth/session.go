@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	jwtconfig "github.com/kubestellar/ui/jwt"
+	"github.com/kubestellar/ui/redis"
+	"github.com/kubestellar/ui/utils"
+)
+
+// Redis keys of the session store
+const (
+	sessionKeyPrefix      = "auth:session:"       // JSON Session by session ID
+	userSessionsKeyPrefix = "auth:user-sessions:" // Set of the session IDs of a user
+	revokedKeyPrefix      = "auth:revoked:"       // Revoked token IDs (jti), kept until the token expires
+	refreshUsedKeyPrefix  = "auth:refresh-used:"  // Refresh token IDs that were already exchanged
+)
+
+var (
+	// ErrSessionRevoked is returned when a refresh token belongs to a session that ended
+	ErrSessionRevoked = fmt.Errorf("session has been revoked")
+	// ErrRefreshTokenReused is returned when a refresh token is presented a second time; the
+	// whole session is revoked because the token has most likely been stolen
+	ErrRefreshTokenReused = fmt.Errorf("refresh token reuse detected, session revoked")
+)
+
+// Session is a login of a user. Each refresh rotates both tokens; only the latest refresh
+// token of a session can be exchanged.
+type Session struct {
+	ID                    string    `json:"id"`
+	Username              string    `json:"username"`
+	AccessTokenID         string    `json:"access_token_id"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshTokenID        string    `json:"refresh_token_id"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CreatedAt             time.Time `json:"created_at"`
+	RefreshedAt           time.Time `json:"refreshed_at,omitempty"`
+}
+
+// TokenPair is the access and refresh token handed out at login and on refresh
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// StartSession opens a session for an authenticated user and issues its first token pair
+func StartSession(username string, permissions []string) (*TokenPair, error) {
+	session := &Session{
+		ID:        utils.NewTokenID(),
+		Username:  username,
+		CreatedAt: time.Now().UTC(),
+	}
+	pair, err := issueTokens(session, permissions)
+	if err != nil {
+		return nil, err
+	}
+	// The set outlives every session it lists; expired entries are dropped by RevokeUserSessions
+	if err := redis.AddToSet(userSessionsKeyPrefix+username, session.ID, jwtconfig.GetRefreshTokenExpiration()); err != nil {
+		return nil, fmt.Errorf("failed to record session: %v", err)
+	}
+	return pair, nil
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. The previous access token is
+// revoked. Presenting a refresh token that was already exchanged revokes the session.
+func RefreshSession(refreshToken string) (*TokenPair, error) {
+	claims, err := utils.ValidateToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.IsRefresh() || claims.SessionID == "" || claims.ID == "" {
+		return nil, fmt.Errorf("not a refresh token")
+	}
+
+	session, err := getSession(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionRevoked
+	}
+
+	// Mark the refresh token as used atomically so concurrent exchanges of the same token
+	// cannot both succeed
+	first, err := redis.SetIfNotExists(refreshUsedKeyPrefix+claims.ID, session.ID, time.Until(claims.ExpiresAt.Time))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check refresh token: %v", err)
+	}
+	if !first || claims.ID != session.RefreshTokenID {
+		log.Printf("Refresh token reuse for user %s, revoking session %s", session.Username, session.ID)
+		if err := revokeSession(session); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	// Permissions may have changed since login, and the user may have been removed
+	userConfig, exists, err := GetUserByUsername(session.Username)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := revokeSession(session); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		return nil, ErrSessionRevoked
+	}
+
+	previousAccessID, previousAccessExpiry := session.AccessTokenID, session.AccessTokenExpiresAt
+	session.RefreshedAt = time.Now().UTC()
+	pair, err := issueTokens(session, userConfig.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := RevokeToken(previousAccessID, previousAccessExpiry); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return pair, nil
+}
+
+// EndSession revokes the tokens of a session, e.g. on logout
+func EndSession(sessionID string) error {
+	session, err := getSession(sessionID)
+	if err != nil || session == nil {
+		return err
+	}
+	return revokeSession(session)
+}
+
+// RevokeUserSessions revokes every session of a user except keepSessionID (which may be empty)
+// and returns the number of sessions revoked
+func RevokeUserSessions(username string, keepSessionID string) (int, error) {
+	sessionIDs, err := redis.GetSetMembers(userSessionsKeyPrefix + username)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, sessionID := range sessionIDs {
+		if sessionID == keepSessionID {
+			continue
+		}
+		session, err := getSession(sessionID)
+		if err != nil {
+			return revoked, err
+		}
+		if session == nil {
+			// Expired on its own
+			_ = redis.RemoveFromSet(userSessionsKeyPrefix+username, sessionID)
+			continue
+		}
+		if err := revokeSession(session); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// RevokeToken adds a token ID to the revocation list until the token expires
+func RevokeToken(tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if tokenID == "" || ttl <= 0 {
+		return nil
+	}
+	if _, err := redis.SetIfNotExists(revokedKeyPrefix+tokenID, "1", ttl); err != nil {
+		return fmt.Errorf("failed to revoke token %s: %v", tokenID, err)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether a token ID is on the revocation list
+func IsTokenRevoked(tokenID string) (bool, error) {
+	if tokenID == "" {
+		return false, nil
+	}
+	return redis.KeyExists(revokedKeyPrefix + tokenID)
+}
+
+// issueTokens signs a new token pair for the session and stores the session
+func issueTokens(session *Session, permissions []string) (*TokenPair, error) {
+	access, err := utils.GenerateAccessToken(session.Username, permissions, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := utils.GenerateRefreshToken(session.Username, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.AccessTokenID = access.ID
+	session.AccessTokenExpiresAt = access.ExpiresAt
+	session.RefreshTokenID = refresh.ID
+	session.RefreshTokenExpiresAt = refresh.ExpiresAt
+	if err := redis.SetJSONValue(sessionKeyPrefix+session.ID, session, time.Until(refresh.ExpiresAt)); err != nil {
+		return nil, fmt.Errorf("failed to store session: %v", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      access.Token,
+		RefreshToken:     refresh.Token,
+		ExpiresAt:        access.ExpiresAt,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+func getSession(sessionID string) (*Session, error) {
+	var session Session
+	found, err := redis.GetJSONValue(sessionKeyPrefix+sessionID, &session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %v", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// revokeSession revokes the current tokens of a session and deletes it
+func revokeSession(session *Session) error {
+	if err := RevokeToken(session.AccessTokenID, session.AccessTokenExpiresAt); err != nil {
+		return err
+	}
+	if err := RevokeToken(session.RefreshTokenID, session.RefreshTokenExpiresAt); err != nil {
+		return err
+	}
+	if err := redis.DeleteKey(sessionKeyPrefix + session.ID); err != nil {
+		return fmt.Errorf("failed to delete session %s: %v", session.ID, err)
+	}
+	return redis.RemoveFromSet(userSessionsKeyPrefix+session.Username, session.ID)
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kubestellar/ui/config"
+	jwtconfig "github.com/kubestellar/ui/jwt"
+	"github.com/kubestellar/ui/redis"
+	"github.com/kubestellar/ui/utils"
+)
+
+// testSession is a started session with the IDs of its tokens
+type testSession struct {
+	pair      *TokenPair
+	sessionID string
+	accessID  string
+}
+
+func startTestSession(t *testing.T, username string) testSession {
+	t.Helper()
+	pair, err := StartSession(username, []string{PermissionRead})
+	if err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	claims, err := utils.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	return testSession{pair: pair, sessionID: claims.SessionID, accessID: claims.ID}
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	redis.Configure(config.RedisConfig{Address: miniredis.RunT(t).Addr()})
+	jwtconfig.SetJWTSecret("test-secret")
+
+	current := startTestSession(t, "alice")
+	others := []testSession{startTestSession(t, "alice"), startTestSession(t, "alice")}
+	bob := startTestSession(t, "bob")
+
+	revoked, err := RevokeUserSessions("alice", current.sessionID)
+	if err != nil {
+		t.Fatalf("RevokeUserSessions failed: %v", err)
+	}
+	if revoked != len(others) {
+		t.Errorf("revoked %d sessions, want %d", revoked, len(others))
+	}
+
+	for _, session := range others {
+		if isRevoked, _ := IsTokenRevoked(session.accessID); !isRevoked {
+			t.Errorf("access token of session %s is not revoked", session.sessionID)
+		}
+		if _, err := RefreshSession(session.pair.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+			t.Errorf("refresh of revoked session %s returned %v, want ErrSessionRevoked", session.sessionID, err)
+		}
+	}
+	for _, session := range []testSession{current, bob} {
+		if isRevoked, _ := IsTokenRevoked(session.accessID); isRevoked {
+			t.Errorf("access token of kept session %s is revoked", session.sessionID)
+		}
+	}
+
+	if revoked, err := RevokeUserSessions("alice", ""); err != nil || revoked != 1 {
+		t.Errorf("RevokeUserSessions without a kept session = %d, %v; want 1", revoked, err)
+	}
+}
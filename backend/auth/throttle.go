@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/kubestellar/ui/redis"
+)
+
+// Redis keys of the login throttle, followed by "user:<username>" or "ip:<address>"
+const (
+	loginAttemptsKeyPrefix = "auth:login-attempts:" // Sliding window of every login attempt, by IP
+	loginFailuresKeyPrefix = "auth:login-failures:" // Sliding window of failed logins, by user and by IP
+	lockoutKeyPrefix       = "auth:lockout:"        // Present while a user or IP is locked out
+	lockoutCountKeyPrefix  = "auth:lockout-count:"  // Lockouts in a row, which double the next lockout
+	throttleUserKey        = "user:"
+	throttleIPKey          = "ip:"
+)
+
+// lockoutCountExpiration resets the lockout doubling once lockouts are this far apart
+const lockoutCountExpiration = 24 * time.Hour
+
+// ThrottleError is returned while logins are refused for too many attempts or failures
+type ThrottleError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottle limits password checks. Each IP may attempt IPAttempts logins per IPWindow.
+// UserFailures failed logins of a user, or IPFailures from an IP, within FailureWindow lock
+// the user or IP out for LockoutBase, doubling with every further lockout up to LockoutMax.
+type LoginThrottle struct {
+	IPAttempts    int64
+	IPWindow      time.Duration
+	UserFailures  int64
+	IPFailures    int64
+	FailureWindow time.Duration
+	LockoutBase   time.Duration
+	LockoutMax    time.Duration
+}
+
+// GetLoginThrottle returns the configured login throttle
+func GetLoginThrottle() LoginThrottle {
+	login := authSettings().Login
+	return LoginThrottle{
+		IPAttempts:    int64(login.AttemptsPerIP),
+		IPWindow:      login.AttemptWindow,
+		UserFailures:  int64(login.MaxFailures),
+		IPFailures:    int64(login.MaxFailuresPerIP),
+		FailureWindow: login.FailureWindow,
+		LockoutBase:   login.LockoutDuration,
+		LockoutMax:    login.LockoutMaxDuration,
+	}
+}
+
+// Check records a login attempt and returns a *ThrottleError when the user or IP is locked
+// out or the IP attempts logins too fast. The throttle fails open when Redis is unavailable.
+func (t LoginThrottle) Check(username, ip string) error {
+	for _, key := range []string{throttleUserKey + username, throttleIPKey + ip} {
+		retryAfter, err := redis.GetTTL(lockoutKeyPrefix + key)
+		if err != nil {
+			log.Printf("Warning: failed to check login lockout: %v", err)
+			return nil
+		}
+		if retryAfter > 0 {
+			return &ThrottleError{Reason: "too many failed logins", RetryAfter: retryAfter}
+		}
+	}
+
+	if t.IPAttempts <= 0 {
+		return nil
+	}
+	allowed, _, retryAfter, err := redis.SlidingWindowHit(loginAttemptsKeyPrefix+ip, t.IPAttempts, t.IPWindow)
+	if err != nil {
+		log.Printf("Warning: failed to rate limit logins: %v", err)
+		return nil
+	}
+	if !allowed {
+		return &ThrottleError{Reason: "too many login attempts", RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login of the user from the IP and locks either out once it
+// reaches its limit. It returns a *ThrottleError when this failure caused a lockout.
+func (t LoginThrottle) RecordFailure(username, ip string) error {
+	var lockout error
+	limits := map[string]int64{throttleUserKey + username: t.UserFailures, throttleIPKey + ip: t.IPFailures}
+	for key, limit := range limits {
+		if limit <= 0 {
+			continue
+		}
+		_, failures, _, err := redis.SlidingWindowHit(loginFailuresKeyPrefix+key, 0, t.FailureWindow)
+		if err != nil {
+			log.Printf("Warning: failed to record failed login: %v", err)
+			return nil
+		}
+		if failures < limit {
+			continue
+		}
+
+		duration, err := t.lockOut(key)
+		if err != nil {
+			log.Printf("Warning: failed to lock out %s: %v", key, err)
+			continue
+		}
+		log.Printf("Locked out %s for %s after %d failed logins", key, duration, failures)
+		lockout = &ThrottleError{Reason: "too many failed logins", RetryAfter: duration}
+	}
+	return lockout
+}
+
+// RecordSuccess clears the failures and lockout history of a user after a successful login
+func (t LoginThrottle) RecordSuccess(username string) {
+	for _, key := range []string{loginFailuresKeyPrefix + throttleUserKey + username, lockoutCountKeyPrefix + throttleUserKey + username} {
+		if err := redis.DeleteKey(key); err != nil {
+			log.Printf("Warning: failed to reset login throttle of user %s: %v", username, err)
+		}
+	}
+}
+
+// lockOut locks a user or IP out for the base duration, doubled for every lockout in a row
+func (t LoginThrottle) lockOut(key string) (time.Duration, error) {
+	count, err := redis.IncrementCounter(lockoutCountKeyPrefix+key, lockoutCountExpiration)
+	if err != nil {
+		return 0, err
+	}
+
+	duration := t.LockoutBase
+	for i := int64(1); i < count && duration < t.LockoutMax; i++ {
+		duration *= 2
+	}
+	if t.LockoutMax > 0 && duration > t.LockoutMax {
+		duration = t.LockoutMax
+	}
+
+	if _, err := redis.SetIfNotExists(lockoutKeyPrefix+key, strconv.FormatInt(count, 10), duration); err != nil {
+		return 0, err
+	}
+	// The next lockout needs a full set of new failures
+	if err := redis.DeleteKey(loginFailuresKeyPrefix + key); err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AuthConfig configures how users log in
+type AuthConfig struct {
+	OIDC      OIDCConfig     `yaml:"oidc"`
+	Login     LoginConfig    `yaml:"login"`
+	Passwords PasswordConfig `yaml:"passwords"`
+}
+
+// OIDCConfig configures single sign-on with the authorization code flow against an OpenID
+// Connect provider. Permissions in the mappings are permission strings (read, write, admin)
+// or names of permission sets.
+type OIDCConfig struct {
+	IssuerURL          string              `yaml:"issuerURL"`
+	ClientID           string              `yaml:"clientID"`
+	ClientSecret       string              `yaml:"clientSecret"`
+	RedirectURL        string              `yaml:"redirectURL"`
+	Scopes             []string            `yaml:"scopes"`
+	UsernameClaim      string              `yaml:"usernameClaim"`
+	GroupsClaim        string              `yaml:"groupsClaim"`
+	GroupPermissions   map[string][]string `yaml:"groupPermissions"`
+	ClaimPermissions   []ClaimPermissions  `yaml:"claimPermissions"`
+	DefaultPermissions []string            `yaml:"defaultPermissions"`
+	// PostLoginRedirectURL receives the tokens in its fragment after a login; when empty the
+	// callback responds with JSON
+	PostLoginRedirectURL string `yaml:"postLoginRedirectURL"`
+}
+
+// ClaimPermissions grants permissions to users whose ID token claim has the given value. For
+// list claims it is enough for one element to match.
+type ClaimPermissions struct {
+	Claim       string   `yaml:"claim" json:"claim"`
+	Value       string   `yaml:"value" json:"value"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+// Enabled reports whether single sign-on is configured
+func (o OIDCConfig) Enabled() bool {
+	return o.IssuerURL != "" && o.ClientID != ""
+}
+
+// LoginConfig throttles logins: attempts per IP per window, and failures per user and per IP
+// within the failure window before a lockout, which doubles with every lockout in a row up to
+// the maximum. A zero count disables its limit.
+type LoginConfig struct {
+	AttemptsPerIP      int           `yaml:"attemptsPerIP"`
+	AttemptWindow      time.Duration `yaml:"attemptWindow"`
+	MaxFailures        int           `yaml:"maxFailures"`
+	MaxFailuresPerIP   int           `yaml:"maxFailuresPerIP"`
+	FailureWindow      time.Duration `yaml:"failureWindow"`
+	LockoutDuration    time.Duration `yaml:"lockoutDuration"`
+	LockoutMaxDuration time.Duration `yaml:"lockoutMaxDuration"`
+}
+
+// PasswordConfig is the policy for local passwords; upper case, lower case and digits are
+// always required
+type PasswordConfig struct {
+	HashAlgorithm string `yaml:"hashAlgorithm"` // bcrypt or argon2id, for new hashes
+	MinLength     int    `yaml:"minLength"`
+	RequireSymbol bool   `yaml:"requireSymbol"`
+}
+
+// Validate checks the login settings that cannot be corrected at use
+func (a AuthConfig) Validate() error {
+	o := a.OIDC
+	if o.IssuerURL != "" {
+		if u, err := url.Parse(o.IssuerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("OIDC issuer URL %q must look like scheme://host[/path]", o.IssuerURL)
+		}
+	}
+	if o.Enabled() && (o.UsernameClaim == "" || o.GroupsClaim == "") {
+		return fmt.Errorf("OIDC username and groups claims cannot be empty")
+	}
+	for _, rule := range o.ClaimPermissions {
+		if rule.Claim == "" {
+			return fmt.Errorf("OIDC claim permission rule without a claim")
+		}
+	}
+
+	l := a.Login
+	if l.AttemptsPerIP < 0 || l.MaxFailures < 0 || l.MaxFailuresPerIP < 0 {
+		return fmt.Errorf("login attempt and failure limits cannot be negative")
+	}
+	if l.AttemptWindow <= 0 || l.FailureWindow <= 0 || l.LockoutDuration <= 0 || l.LockoutMaxDuration <= 0 {
+		return fmt.Errorf("login windows and lockout durations must be positive")
+	}
+
+	switch strings.ToLower(a.Passwords.HashAlgorithm) {
+	case "bcrypt", "argon2id":
+	default:
+		return fmt.Errorf("unknown password hash algorithm %q, expected bcrypt or argon2id", a.Passwords.HashAlgorithm)
+	}
+	if a.Passwords.MinLength <= 0 {
+		return fmt.Errorf("password minimum length must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when neither --config nor CONFIG_FILE is set and the file exists
+const DefaultFile = "config.yaml"
+
+// RedactedValue replaces secrets in the configuration returned by Redacted
+const RedactedValue = "[REDACTED]"
+
+// Config is the configuration of the backend. Defaults are overridden by a YAML file, then by
+// environment variables and then by command-line flags.
+type Config struct {
+	Server          ServerConfig          `yaml:"server"`
+	Kubernetes      KubernetesConfig      `yaml:"kubernetes"`
+	Redis           RedisConfig           `yaml:"redis"`
+	Postgres        PostgresConfig        `yaml:"postgres"`
+	JWT             JWTConfig             `yaml:"jwt"`
+	Auth            AuthConfig            `yaml:"auth"`
+	Audit           AuditConfig           `yaml:"audit"`
+	RateLimits      RateLimitsConfig      `yaml:"rateLimits"`
+	DeploymentStore DeploymentStoreConfig `yaml:"deploymentStore"`
+}
+
+// KubernetesConfig names the KubeStellar control planes the backend talks to
+type KubernetesConfig struct {
+	Kubeconfig   string `yaml:"kubeconfig"`   // empty uses KUBECONFIG or ~/.kube/config
+	ITSContext   string `yaml:"itsContext"`   // kubeconfig context of the Inventory and Transport Space
+	WDSContext   string `yaml:"wdsContext"`   // default kubeconfig context of the Workload Description Space
+	HubAPIServer string `yaml:"hubAPIServer"` // ITS API server URL given to clusters joining the hub
+
+	Impersonation ImpersonationConfig `yaml:"impersonation"`
+}
+
+// ImpersonationConfig makes requests reach the clusters as the logged-in user instead of the
+// kubeconfig identity, which then needs the impersonate verb on users and groups. The
+// prefixes are prepended to the impersonated user and group names.
+type ImpersonationConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	UserPrefix  string `yaml:"userPrefix"`
+	GroupPrefix string `yaml:"groupPrefix"`
+}
+
+// RedisConfig locates the Redis server used for caches, sessions and rate limits
+type RedisConfig struct {
+	Address  string `yaml:"address"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// PostgresConfig locates the PostgreSQL database used by the postgres stores
+type PostgresConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Database string `yaml:"database"`
+	SSLMode  string `yaml:"sslMode"`
+}
+
+// JWTConfig signs login tokens. The secret seeds the user ConfigMap when it is created; the
+// stored secret is used from then on so tokens stay valid across restarts.
+type JWTConfig struct {
+	Secret            string        `yaml:"secret"`
+	TokenExpiration   time.Duration `yaml:"tokenExpiration"`
+	RefreshExpiration time.Duration `yaml:"refreshExpiration"`
+}
+
+// AuditConfig lists the sinks the audit trail is written to: file, postgres and/or webhook,
+// or none. Webhook requests are signed with HMAC-SHA256 when a secret is set.
+type AuditConfig struct {
+	Sinks         []string `yaml:"sinks"`
+	FilePath      string   `yaml:"filePath"`
+	WebhookURL    string   `yaml:"webhookURL"`
+	WebhookSecret string   `yaml:"webhookSecret"`
+}
+
+// RateLimitsConfig sets the requests allowed per session or API token as <requests>/<window>,
+// such as 600/1m, for each route group (api, bp, resources, deploy); groups without a limit
+// use the default, and "off" disables a limit
+type RateLimitsConfig struct {
+	Default string            `yaml:"default"`
+	Groups  map[string]string `yaml:"groups"`
+}
+
+// For returns the limit of a route group
+func (r RateLimitsConfig) For(group string) string {
+	if limit := r.Groups[group]; limit != "" {
+		return limit
+	}
+	return r.Default
+}
+
+// DeploymentStoreConfig selects where the deployment history is kept: configmap, postgres or
+// sqlite, in the file at SQLitePath
+type DeploymentStoreConfig struct {
+	Backend    string `yaml:"backend"`
+	SQLitePath string `yaml:"sqlitePath"`
+}
+
+// Default returns the configuration used when nothing is set, which serves plain HTTP to the
+// development frontend and talks to the control planes of the KubeStellar quickstart
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address: ":4000",
+			CORS: CORSConfig{
+				AllowedOrigins:   []string{"http://localhost:5173"},
+				AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
+				AllowedHeaders:   []string{"Content-Type", "Authorization"},
+				AllowCredentials: true,
+			},
+			TLS: TLSConfig{
+				ClientAuth:     ClientAuthNone,
+				MinVersion:     "1.2",
+				ReloadInterval: 30 * time.Second,
+			},
+			HSTS: HSTSConfig{
+				Enabled:           true,
+				MaxAge:            365 * 24 * time.Hour,
+				IncludeSubDomains: true,
+			},
+			Cookies: CookieConfig{
+				SameSite: "lax",
+				HTTPOnly: true,
+			},
+		},
+		Kubernetes: KubernetesConfig{
+			ITSContext:   "its1",
+			WDSContext:   "wds1",
+			HubAPIServer: "https://its1.localtest.me:9443",
+		},
+		Redis: RedisConfig{
+			Address: "localhost:6379",
+		},
+		Postgres: PostgresConfig{
+			Host:    "localhost",
+			Port:    "5432",
+			SSLMode: "disable",
+		},
+		JWT: JWTConfig{
+			TokenExpiration:   24 * time.Hour,
+			RefreshExpiration: 7 * 24 * time.Hour,
+		},
+		Auth: AuthConfig{
+			OIDC: OIDCConfig{
+				Scopes:        []string{"profile", "email"},
+				UsernameClaim: "preferred_username",
+				GroupsClaim:   "groups",
+			},
+			Login: LoginConfig{
+				AttemptsPerIP:      20,
+				AttemptWindow:      time.Minute,
+				MaxFailures:        5,
+				MaxFailuresPerIP:   20,
+				FailureWindow:      15 * time.Minute,
+				LockoutDuration:    time.Minute,
+				LockoutMaxDuration: time.Hour,
+			},
+			Passwords: PasswordConfig{
+				HashAlgorithm: "bcrypt",
+				MinLength:     12,
+			},
+		},
+		Audit: AuditConfig{
+			Sinks:    []string{"file"},
+			FilePath: "audit.log",
+		},
+		RateLimits: RateLimitsConfig{
+			Default: "600/1m",
+		},
+		DeploymentStore: DeploymentStoreConfig{
+			Backend:    "configmap",
+			SQLitePath: "deployments.db",
+		},
+	}
+}
+
+var (
+	currentMu sync.RWMutex
+	current   = Default()
+)
+
+// Current returns the loaded configuration, or the defaults before Load
+func Current() *Config {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current
+}
+
+// Load builds the configuration from the defaults, the file named by --config or CONFIG_FILE
+// (or config.yaml when present), the environment and the command-line arguments, validates
+// it and makes it current
+func Load(args []string) (*Config, error) {
+	// Variables from .env count as environment, as everywhere else in the backend
+	_ = godotenv.Load()
+
+	cfg := Default()
+	settings := cfg.settings()
+	fs := flag.NewFlagSet("backend", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "path of the YAML configuration file")
+	flags := make(map[string]string)
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		name := s.flag
+		fs.Func(name, s.usage+" (env "+s.env+")", func(value string) error {
+			flags[name] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path == "" {
+		if _, err := os.Stat(DefaultFile); err == nil {
+			*path = DefaultFile
+		}
+	}
+	if *path != "" {
+		data, err := os.ReadFile(*path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %v", *path, err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", *path, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := flags[s.flag]; ok && s.flag != "" {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("invalid --%s: %v", s.flag, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	currentMu.Lock()
+	current = cfg
+	currentMu.Unlock()
+	return cfg, nil
+}
+
+// Validate checks the settings that cannot be corrected at use
+func (c *Config) Validate() error {
+	if err := c.Server.Validate(); err != nil {
+		return err
+	}
+
+	k := c.Kubernetes
+	if k.ITSContext == "" || k.WDSContext == "" {
+		return fmt.Errorf("the ITS and WDS contexts cannot be empty")
+	}
+	if u, err := url.Parse(k.HubAPIServer); err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("hub API server %q must look like https://host[:port]", k.HubAPIServer)
+	}
+
+	if c.Redis.Address == "" {
+		return fmt.Errorf("redis address cannot be empty")
+	}
+	if c.Redis.DB < 0 {
+		return fmt.Errorf("redis database cannot be negative")
+	}
+
+	switch c.Postgres.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("unknown postgres SSL mode %q", c.Postgres.SSLMode)
+	}
+
+	if c.JWT.TokenExpiration <= 0 || c.JWT.RefreshExpiration <= 0 {
+		return fmt.Errorf("JWT token expirations must be positive")
+	}
+
+	if err := c.Auth.Validate(); err != nil {
+		return err
+	}
+
+	for _, sink := range c.Audit.Sinks {
+		switch sink {
+		case "none", "file", "postgres":
+		case "webhook":
+			if c.Audit.WebhookURL == "" {
+				return fmt.Errorf("the webhook audit sink needs a webhook URL")
+			}
+		default:
+			return fmt.Errorf("unknown audit sink %q, expected file, postgres, webhook or none", sink)
+		}
+	}
+
+	switch c.DeploymentStore.Backend {
+	case "", "configmap", "postgres":
+	case "sqlite":
+		if c.DeploymentStore.SQLitePath == "" {
+			return fmt.Errorf("the sqlite deployment store needs a database path")
+		}
+	default:
+		return fmt.Errorf("unknown deployment store %q, expected configmap, postgres or sqlite", c.DeploymentStore.Backend)
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration with its passwords and secrets replaced
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	secrets := []*string{
+		&redacted.Redis.Password,
+		&redacted.Postgres.Password,
+		&redacted.JWT.Secret,
+		&redacted.Auth.OIDC.ClientSecret,
+		&redacted.Audit.WebhookSecret,
+	}
+	for _, secret := range secrets {
+		if *secret != "" {
+			*secret = RedactedValue
+		}
+	}
+	return &redacted
+}
+
+// Map returns the configuration with the keys and value formats of the YAML file
+func (c *Config) Map() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %v", err)
+	}
+	var result map[string]interface{}
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %v", err)
+	}
+	return result, nil
+}
+
+// DSN returns the lib/pq connection string of the database
+func (p PostgresConfig) DSN() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	dsn := fmt.Sprintf("host=%s port=%s sslmode=%s", p.Host, p.Port, p.SSLMode)
+	for _, param := range [][2]string{{"user", p.User}, {"password", p.Password}, {"dbname", p.Database}} {
+		if param[1] != "" {
+			dsn += fmt.Sprintf(" %s='%s'", param[0], quote.Replace(param[1]))
+		}
+	}
+	return dsn
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSettingSet(t *testing.T) {
+	cfg := Default()
+	tests := []struct {
+		name   string
+		target interface{}
+		value  string
+		check  func() interface{}
+		want   interface{}
+	}{
+		{"string", &cfg.Kubernetes.WDSContext, "wds2", func() interface{} { return cfg.Kubernetes.WDSContext }, "wds2"},
+		{"list", &cfg.Audit.Sinks, "file, webhook,,", func() interface{} { return cfg.Audit.Sinks }, []string{"file", "webhook"}},
+		{"bool", &cfg.Kubernetes.Impersonation.Enabled, "true", func() interface{} { return cfg.Kubernetes.Impersonation.Enabled }, true},
+		{"int", &cfg.Redis.DB, "3", func() interface{} { return cfg.Redis.DB }, 3},
+		{"duration", &cfg.Auth.Login.FailureWindow, "30m", func() interface{} { return cfg.Auth.Login.FailureWindow }, 30 * time.Minute},
+		{"address host", addressPart{&cfg.Redis.Address, false}, "redis", func() interface{} { return cfg.Redis.Address }, "redis:6379"},
+		{"address port", addressPart{&cfg.Redis.Address, true}, "6380", func() interface{} { return cfg.Redis.Address }, "redis:6380"},
+		{"hours", hours{&cfg.JWT.TokenExpiration}, "2", func() interface{} { return cfg.JWT.TokenExpiration }, 2 * time.Hour},
+		{"json map", jsonValue{&cfg.Auth.OIDC.GroupPermissions}, `{"admins":["admin"]}`,
+			func() interface{} { return cfg.Auth.OIDC.GroupPermissions }, map[string][]string{"admins": {"admin"}}},
+		{"json list", jsonValue{&cfg.Auth.OIDC.ClaimPermissions}, `[{"claim":"department","value":"platform","permissions":["write"]}]`,
+			func() interface{} { return cfg.Auth.OIDC.ClaimPermissions },
+			[]ClaimPermissions{{Claim: "department", Value: "platform", Permissions: []string{"write"}}}},
+		{"map entry", mapEntry{&cfg.RateLimits.Groups, "deploy"}, "60/1m", func() interface{} { return cfg.RateLimits.Groups }, map[string]string{"deploy": "60/1m"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (setting{target: tt.target}).set(tt.value); err != nil {
+				t.Fatalf("set(%q) failed: %v", tt.value, err)
+			}
+			if got := tt.check(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, s := range []setting{
+		{target: &cfg.Redis.DB},
+		{target: &cfg.Kubernetes.Impersonation.Enabled},
+		{target: &cfg.Auth.Login.FailureWindow},
+		{target: hours{&cfg.JWT.TokenExpiration}},
+		{target: jsonValue{&cfg.Auth.OIDC.GroupPermissions}},
+	} {
+		if err := s.set("not valid"); err == nil {
+			t.Errorf("set of %T accepted an invalid value", s.target)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{"defaults", func(c *Config) {}, false},
+		{"empty WDS context", func(c *Config) { c.Kubernetes.WDSContext = "" }, true},
+		{"plain HTTP hub", func(c *Config) { c.Kubernetes.HubAPIServer = "http://its1:9443" }, true},
+		{"unknown SSL mode", func(c *Config) { c.Postgres.SSLMode = "maybe" }, true},
+		{"invalid issuer", func(c *Config) { c.Auth.OIDC.IssuerURL = "issuer" }, true},
+		{"empty username claim", func(c *Config) {
+			c.Auth.OIDC.IssuerURL, c.Auth.OIDC.ClientID, c.Auth.OIDC.UsernameClaim = "https://idp.example.com", "ui", ""
+		}, true},
+		{"claim rule without claim", func(c *Config) { c.Auth.OIDC.ClaimPermissions = []ClaimPermissions{{Value: "x"}} }, true},
+		{"negative failures", func(c *Config) { c.Auth.Login.MaxFailures = -1 }, true},
+		{"zero lockout", func(c *Config) { c.Auth.Login.LockoutDuration = 0 }, true},
+		{"argon2id", func(c *Config) { c.Auth.Passwords.HashAlgorithm = "argon2id" }, false},
+		{"unknown hash", func(c *Config) { c.Auth.Passwords.HashAlgorithm = "md5" }, true},
+		{"webhook without URL", func(c *Config) { c.Audit.Sinks = []string{"webhook"} }, true},
+		{"webhook", func(c *Config) { c.Audit.Sinks, c.Audit.WebhookURL = []string{"webhook"}, "https://hooks.example.com" }, false},
+		{"unknown sink", func(c *Config) { c.Audit.Sinks = []string{"syslog"} }, true},
+		{"sqlite without path", func(c *Config) { c.DeploymentStore = DeploymentStoreConfig{Backend: "sqlite"} }, true},
+		{"unknown store", func(c *Config) { c.DeploymentStore.Backend = "etcd" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "kubernetes:\n  wdsContext: from-file\n  itsContext: its-file\nrateLimits:\n  groups:\n    bp: 10/1m\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WDS_CONTEXT", "from-env")
+	t.Setenv("ITS_CONTEXT", "its-env")
+	t.Setenv("RATE_LIMIT_DEPLOY", "5/1m")
+
+	cfg, err := Load([]string{"--config", path, "--its-context", "its-flag"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	t.Cleanup(func() {
+		currentMu.Lock()
+		current = Default()
+		currentMu.Unlock()
+	})
+	if cfg.Kubernetes.WDSContext != "from-env" {
+		t.Errorf("WDS context = %q, want the environment to override the file", cfg.Kubernetes.WDSContext)
+	}
+	if cfg.Kubernetes.ITSContext != "its-flag" {
+		t.Errorf("ITS context = %q, want the flag to override the environment", cfg.Kubernetes.ITSContext)
+	}
+	if got := cfg.RateLimits.For("bp"); got != "10/1m" {
+		t.Errorf("bp rate limit = %q, want the file value", got)
+	}
+	if got := cfg.RateLimits.For("deploy"); got != "5/1m" {
+		t.Errorf("deploy rate limit = %q, want the environment value", got)
+	}
+	if got := cfg.RateLimits.For("api"); got != cfg.RateLimits.Default {
+		t.Errorf("api rate limit = %q, want the default", got)
+	}
+	if Current() != cfg {
+		t.Errorf("Current() does not return the loaded configuration")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Redis.Password = "redis-password"
+	cfg.JWT.Secret = "jwt-secret"
+	cfg.Auth.OIDC.ClientSecret = "client-secret"
+	cfg.Audit.WebhookSecret = "webhook-secret"
+
+	redacted := cfg.Redacted()
+	for name, value := range map[string]string{
+		"redis password": redacted.Redis.Password,
+		"jwt secret":     redacted.JWT.Secret,
+		"client secret":  redacted.Auth.OIDC.ClientSecret,
+		"webhook secret": redacted.Audit.WebhookSecret,
+	} {
+		if value != RedactedValue {
+			t.Errorf("%s = %q, want it redacted", name, value)
+		}
+	}
+	if redacted.Postgres.Password != "" {
+		t.Errorf("empty postgres password redacted to %q", redacted.Postgres.Password)
+	}
+	if cfg.JWT.Secret != "jwt-secret" {
+		t.Errorf("Redacted modified the original configuration")
+	}
+}
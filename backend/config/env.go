@@ -0,0 +1,194 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting binds a configuration field to its environment variable and, for the settings
+// commonly changed per deployment, a command-line flag
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	target interface{}
+}
+
+// addressPart is the host or the port of a host:port address
+type addressPart struct {
+	target *string
+	port   bool
+}
+
+// hours is a duration set as a whole number of hours, as the JWT_*_HOURS variables are
+type hours struct {
+	target *time.Duration
+}
+
+// jsonValue is a map or list set as JSON, as the OIDC permission mappings are
+type jsonValue struct {
+	target interface{}
+}
+
+// mapEntry is one entry of a map, such as the limit of one rate limited route group
+type mapEntry struct {
+	target *map[string]string
+	key    string
+}
+
+func (c *Config) settings() []setting {
+	s, o, l := &c.Server, &c.Auth.OIDC, &c.Auth.Login
+	return []setting{
+		{"SERVER_ADDRESS", "address", "address to listen on", &s.Address},
+		{"CORS_ALLOWED_ORIGINS", "", "", &s.CORS.AllowedOrigins},
+		{"CORS_ALLOWED_METHODS", "", "", &s.CORS.AllowedMethods},
+		{"CORS_ALLOWED_HEADERS", "", "", &s.CORS.AllowedHeaders},
+		{"CORS_ALLOW_CREDENTIALS", "", "", &s.CORS.AllowCredentials},
+		{"CORS_MAX_AGE", "", "", &s.CORS.MaxAge},
+		{"TLS_CERT_FILE", "tls-cert-file", "TLS certificate file", &s.TLS.CertFile},
+		{"TLS_KEY_FILE", "tls-key-file", "TLS private key file", &s.TLS.KeyFile},
+		{"TLS_CLIENT_CA_FILE", "", "", &s.TLS.ClientCAFile},
+		{"TLS_CLIENT_AUTH", "", "", &s.TLS.ClientAuth},
+		{"TLS_MIN_VERSION", "", "", &s.TLS.MinVersion},
+		{"TLS_RELOAD_INTERVAL", "", "", &s.TLS.ReloadInterval},
+		{"HSTS_ENABLED", "", "", &s.HSTS.Enabled},
+		{"HSTS_MAX_AGE", "", "", &s.HSTS.MaxAge},
+		{"HSTS_INCLUDE_SUBDOMAINS", "", "", &s.HSTS.IncludeSubDomains},
+		{"HSTS_PRELOAD", "", "", &s.HSTS.Preload},
+		{"COOKIE_SECURE", "", "", &s.Cookies.Secure},
+		{"COOKIE_SAMESITE", "", "", &s.Cookies.SameSite},
+		{"COOKIE_DOMAIN", "", "", &s.Cookies.Domain},
+		{"COOKIE_HTTP_ONLY", "", "", &s.Cookies.HTTPOnly},
+		{"TRUSTED_PROXIES", "trusted-proxies", "comma-separated IPs or CIDRs of trusted reverse proxies", &s.TrustedProxies},
+
+		{"KUBECONFIG", "kubeconfig", "kubeconfig file", &c.Kubernetes.Kubeconfig},
+		{"ITS_CONTEXT", "its-context", "kubeconfig context of the ITS", &c.Kubernetes.ITSContext},
+		{"WDS_CONTEXT", "wds-context", "default kubeconfig context of the WDS", &c.Kubernetes.WDSContext},
+		{"HUB_API_SERVER", "hub-api-server", "ITS API server URL for joining clusters", &c.Kubernetes.HubAPIServer},
+		{"K8S_IMPERSONATION", "impersonation", "act on the clusters as the logged-in user", &c.Kubernetes.Impersonation.Enabled},
+		{"K8S_IMPERSONATION_USER_PREFIX", "", "", &c.Kubernetes.Impersonation.UserPrefix},
+		{"K8S_IMPERSONATION_GROUP_PREFIX", "", "", &c.Kubernetes.Impersonation.GroupPrefix},
+
+		{"REDIS_HOST", "", "", addressPart{&c.Redis.Address, false}},
+		{"REDIS_PORT", "", "", addressPart{&c.Redis.Address, true}},
+		{"REDIS_ADDRESS", "redis-address", "Redis host:port", &c.Redis.Address},
+		{"REDIS_PASSWORD", "", "", &c.Redis.Password},
+		{"REDIS_DB", "", "", &c.Redis.DB},
+
+		{"POSTGRES_HOST", "postgres-host", "PostgreSQL host", &c.Postgres.Host},
+		{"POSTGRES_PORT", "postgres-port", "PostgreSQL port", &c.Postgres.Port},
+		{"POSTGRES_USER", "", "", &c.Postgres.User},
+		{"POSTGRES_PASSWORD", "", "", &c.Postgres.Password},
+		{"POSTGRES_DB", "postgres-db", "PostgreSQL database", &c.Postgres.Database},
+		{"POSTGRES_SSLMODE", "", "", &c.Postgres.SSLMode},
+
+		{"JWT_SECRET", "", "", &c.JWT.Secret},
+		{"JWT_TOKEN_EXPIRATION_HOURS", "", "", hours{&c.JWT.TokenExpiration}},
+		{"JWT_REFRESH_EXPIRATION_HOURS", "", "", hours{&c.JWT.RefreshExpiration}},
+
+		{"OIDC_ISSUER_URL", "oidc-issuer-url", "OpenID Connect issuer URL", &o.IssuerURL},
+		{"OIDC_CLIENT_ID", "oidc-client-id", "OpenID Connect client ID", &o.ClientID},
+		{"OIDC_CLIENT_SECRET", "", "", &o.ClientSecret},
+		{"OIDC_REDIRECT_URL", "", "", &o.RedirectURL},
+		{"OIDC_SCOPES", "", "", &o.Scopes},
+		{"OIDC_USERNAME_CLAIM", "", "", &o.UsernameClaim},
+		{"OIDC_GROUPS_CLAIM", "", "", &o.GroupsClaim},
+		{"OIDC_GROUP_PERMISSIONS", "", "", jsonValue{&o.GroupPermissions}},
+		{"OIDC_CLAIM_PERMISSIONS", "", "", jsonValue{&o.ClaimPermissions}},
+		{"OIDC_DEFAULT_PERMISSIONS", "", "", &o.DefaultPermissions},
+		{"OIDC_POST_LOGIN_REDIRECT_URL", "", "", &o.PostLoginRedirectURL},
+
+		{"LOGIN_RATE_LIMIT_PER_IP", "", "", &l.AttemptsPerIP},
+		{"LOGIN_RATE_LIMIT_WINDOW", "", "", &l.AttemptWindow},
+		{"LOGIN_MAX_FAILURES", "", "", &l.MaxFailures},
+		{"LOGIN_MAX_FAILURES_PER_IP", "", "", &l.MaxFailuresPerIP},
+		{"LOGIN_FAILURE_WINDOW", "", "", &l.FailureWindow},
+		{"LOGIN_LOCKOUT_DURATION", "", "", &l.LockoutDuration},
+		{"LOGIN_LOCKOUT_MAX_DURATION", "", "", &l.LockoutMaxDuration},
+
+		{"PASSWORD_HASH_ALGORITHM", "", "", &c.Auth.Passwords.HashAlgorithm},
+		{"PASSWORD_MIN_LENGTH", "", "", &c.Auth.Passwords.MinLength},
+		{"PASSWORD_REQUIRE_SYMBOL", "", "", &c.Auth.Passwords.RequireSymbol},
+
+		{"AUDIT_SINKS", "audit-sinks", "comma-separated audit sinks: file, postgres, webhook or none", &c.Audit.Sinks},
+		{"AUDIT_FILE_PATH", "", "", &c.Audit.FilePath},
+		{"AUDIT_WEBHOOK_URL", "", "", &c.Audit.WebhookURL},
+		{"AUDIT_WEBHOOK_SECRET", "", "", &c.Audit.WebhookSecret},
+
+		{"RATE_LIMIT_DEFAULT", "", "", &c.RateLimits.Default},
+		{"RATE_LIMIT_API", "", "", mapEntry{&c.RateLimits.Groups, "api"}},
+		{"RATE_LIMIT_BP", "", "", mapEntry{&c.RateLimits.Groups, "bp"}},
+		{"RATE_LIMIT_RESOURCES", "", "", mapEntry{&c.RateLimits.Groups, "resources"}},
+		{"RATE_LIMIT_DEPLOY", "", "", mapEntry{&c.RateLimits.Groups, "deploy"}},
+
+		{"DEPLOYMENT_STORE", "deployment-store", "deployment history backend: configmap, postgres or sqlite", &c.DeploymentStore.Backend},
+		{"DEPLOYMENT_STORE_SQLITE_PATH", "", "", &c.DeploymentStore.SQLitePath},
+	}
+}
+
+// set parses a value into the setting's field
+func (s setting) set(value string) error {
+	switch target := s.target.(type) {
+	case *string:
+		*target = value
+	case *[]string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*target = items
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+	case *time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+	case addressPart:
+		host, port, err := net.SplitHostPort(*target.target)
+		if err != nil {
+			return err
+		}
+		if target.port {
+			port = value
+		} else {
+			host = value
+		}
+		*target.target = net.JoinHostPort(host, port)
+	case hours:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target.target = time.Duration(parsed) * time.Hour
+	case jsonValue:
+		if err := json.Unmarshal([]byte(value), target.target); err != nil {
+			return err
+		}
+	case mapEntry:
+		if *target.target == nil {
+			*target.target = make(map[string]string)
+		}
+		(*target.target)[target.key] = value
+	default:
+		return fmt.Errorf("unsupported setting type %T", s.target)
+	}
+	return nil
+}
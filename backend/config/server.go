@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ServerConfig configures how the API is served
+type ServerConfig struct {
+	Address string       `yaml:"address"`
+	CORS    CORSConfig   `yaml:"cors"`
+	TLS     TLSConfig    `yaml:"tls"`
+	HSTS    HSTSConfig   `yaml:"hsts"`
+	Cookies CookieConfig `yaml:"cookies"`
+	// TrustedProxies lists the IPs and CIDRs of the reverse proxies whose X-Forwarded-For is
+	// believed when resolving the client IP; empty trusts none and uses the peer address
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API and open WebSockets
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins"` // "*" allows any origin
+	AllowedMethods   []string      `yaml:"allowedMethods"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders"`
+	AllowCredentials bool          `yaml:"allowCredentials"`
+	MaxAge           time.Duration `yaml:"maxAge"`
+}
+
+// TLSConfig enables HTTPS when a certificate and key are set. Both files, and the client CA
+// bundle, are reloaded when they change.
+type TLSConfig struct {
+	CertFile       string        `yaml:"certFile"`
+	KeyFile        string        `yaml:"keyFile"`
+	ClientCAFile   string        `yaml:"clientCAFile"`
+	ClientAuth     string        `yaml:"clientAuth"` // none, request, verify-if-given or require
+	MinVersion     string        `yaml:"minVersion"` // 1.2 or 1.3
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
+
+// HSTSConfig sets Strict-Transport-Security on responses served over HTTPS
+type HSTSConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	MaxAge            time.Duration `yaml:"maxAge"`
+	IncludeSubDomains bool          `yaml:"includeSubDomains"`
+	Preload           bool          `yaml:"preload"`
+}
+
+// CookieConfig holds the security attributes of the cookies the backend sets
+type CookieConfig struct {
+	Secure   bool   `yaml:"secure"`
+	SameSite string `yaml:"sameSite"` // lax, strict or none
+	Domain   string `yaml:"domain"`
+	HTTPOnly bool   `yaml:"httpOnly"`
+}
+
+// Client authentication modes of TLSConfig.ClientAuth
+const (
+	ClientAuthNone          = "none"
+	ClientAuthRequest       = "request"
+	ClientAuthVerifyIfGiven = "verify-if-given"
+	ClientAuthRequire       = "require"
+)
+
+// Validate checks the server settings that cannot be corrected at use
+func (s ServerConfig) Validate() error {
+	if s.Address == "" {
+		return fmt.Errorf("server address cannot be empty")
+	}
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		return fmt.Errorf("TLS needs both a certificate and a key file")
+	}
+	switch s.TLS.ClientAuth {
+	case "", ClientAuthNone, ClientAuthRequest:
+	case ClientAuthVerifyIfGiven, ClientAuthRequire:
+		if s.TLS.ClientCAFile == "" {
+			return fmt.Errorf("TLS client auth %s needs a client CA file", s.TLS.ClientAuth)
+		}
+	default:
+		return fmt.Errorf("unknown TLS client auth %q, expected none, request, verify-if-given or require", s.TLS.ClientAuth)
+	}
+	if s.TLS.ClientAuth != "" && s.TLS.ClientAuth != ClientAuthNone && !s.TLS.Enabled() {
+		return fmt.Errorf("TLS client auth needs a TLS certificate and key")
+	}
+	if _, err := s.TLS.minVersion(); err != nil {
+		return err
+	}
+	if _, err := s.Cookies.sameSite(); err != nil {
+		return err
+	}
+	if s.Cookies.SameSite == "none" && !s.Cookies.Secure {
+		return fmt.Errorf("SameSite=None cookies must be Secure")
+	}
+	for _, proxy := range s.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("trusted proxy %q must be an IP address or CIDR", proxy)
+		}
+	}
+	for _, origin := range s.CORS.AllowedOrigins {
+		if origin == "*" {
+			if s.CORS.AllowCredentials {
+				return fmt.Errorf("CORS cannot allow credentials from any origin")
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("CORS origin %q must look like scheme://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether the API is served over HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// AllowsOrigin reports whether a browser origin may call the API
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin is the WebSocket origin check: requests without an Origin header (non-browser
+// clients), from the backend's own host or from an allowed origin are accepted
+func CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return Current().Server.CORS.AllowsOrigin(origin)
+}
+
+// Cookie builds a cookie with the configured security attributes; a negative maxAge deletes it
+func (c CookieConfig) Cookie(name, value string, maxAge int) *http.Cookie {
+	sameSite, _ := c.sameSite()
+	return &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     "/",
+		Domain:   c.Domain,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+		SameSite: sameSite,
+	}
+}
+
+func (c CookieConfig) sameSite() (http.SameSite, error) {
+	switch strings.ToLower(c.SameSite) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unknown cookie SameSite %q, expected lax, strict or none", c.SameSite)
+	}
+}
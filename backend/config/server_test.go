@@ -0,0 +1,202 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServerValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(s *ServerConfig)
+		wantErr bool
+	}{
+		{"defaults", func(s *ServerConfig) {}, false},
+		{"empty address", func(s *ServerConfig) { s.Address = "" }, true},
+		{"certificate without key", func(s *ServerConfig) { s.TLS.CertFile = "tls.crt" }, true},
+		{"tls", func(s *ServerConfig) { s.TLS.CertFile, s.TLS.KeyFile = "tls.crt", "tls.key" }, false},
+		{"client auth without CA", func(s *ServerConfig) {
+			s.TLS.CertFile, s.TLS.KeyFile, s.TLS.ClientAuth = "tls.crt", "tls.key", ClientAuthRequire
+		}, true},
+		{"client auth without TLS", func(s *ServerConfig) { s.TLS.ClientAuth, s.TLS.ClientCAFile = ClientAuthRequire, "ca.crt" }, true},
+		{"client auth", func(s *ServerConfig) {
+			s.TLS.CertFile, s.TLS.KeyFile, s.TLS.ClientAuth, s.TLS.ClientCAFile = "tls.crt", "tls.key", ClientAuthVerifyIfGiven, "ca.crt"
+		}, false},
+		{"unknown client auth", func(s *ServerConfig) { s.TLS.ClientAuth = "always" }, true},
+		{"tls 1.3", func(s *ServerConfig) { s.TLS.MinVersion = "1.3" }, false},
+		{"tls 1.0", func(s *ServerConfig) { s.TLS.MinVersion = "1.0" }, true},
+		{"unknown SameSite", func(s *ServerConfig) { s.Cookies.SameSite = "sometimes" }, true},
+		{"SameSite none without Secure", func(s *ServerConfig) { s.Cookies.SameSite = "none" }, true},
+		{"SameSite none", func(s *ServerConfig) { s.Cookies.SameSite, s.Cookies.Secure = "none", true }, false},
+		{"trusted proxies", func(s *ServerConfig) { s.TrustedProxies = []string{"10.0.0.1", "10.1.0.0/16"} }, false},
+		{"invalid trusted proxy", func(s *ServerConfig) { s.TrustedProxies = []string{"proxy.local"} }, true},
+		{"any origin with credentials", func(s *ServerConfig) { s.CORS.AllowedOrigins = []string{"*"} }, true},
+		{"any origin", func(s *ServerConfig) { s.CORS.AllowedOrigins, s.CORS.AllowCredentials = []string{"*"}, false }, false},
+		{"origin with path", func(s *ServerConfig) { s.CORS.AllowedOrigins = []string{"https://ui.example.com/app"} }, true},
+		{"origin without scheme", func(s *ServerConfig) { s.CORS.AllowedOrigins = []string{"ui.example.com"} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := Default().Server
+			tt.modify(&server)
+			if err := server.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "localhost:4000", "", true},
+		{"same host", "ui.example.com", "https://ui.example.com", true},
+		{"allowed origin", "localhost:4000", "http://localhost:5173", true},
+		{"allowed origin in other case", "localhost:4000", "http://LOCALHOST:5173", true},
+		{"other origin", "localhost:4000", "https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://"+tt.host+"/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := CheckOrigin(r); got != tt.want {
+				t.Errorf("CheckOrigin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CookieConfig
+		want   http.SameSite
+	}{
+		{"default", CookieConfig{}, http.SameSiteLaxMode},
+		{"strict", CookieConfig{SameSite: "Strict", HTTPOnly: true}, http.SameSiteStrictMode},
+		{"none", CookieConfig{SameSite: "none", Secure: true, Domain: "example.com"}, http.SameSiteNoneMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cookie := tt.config.Cookie("ui-wds-context", "wds 2", -1)
+			if cookie.SameSite != tt.want || cookie.Secure != tt.config.Secure || cookie.HttpOnly != tt.config.HTTPOnly || cookie.Domain != tt.config.Domain {
+				t.Errorf("Cookie() = %+v, want the attributes of %+v", cookie, tt.config)
+			}
+			if cookie.Value != "wds+2" || cookie.Path != "/" || cookie.MaxAge != -1 {
+				t.Errorf("Cookie() value %q, path %q, max age %d", cookie.Value, cookie.Path, cookie.MaxAge)
+			}
+		})
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate and key for the common name
+func writeTestCertificate(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "first")
+
+	reloader, err := NewCertReloader(TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3", ClientAuth: ClientAuthRequest})
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+	served := func() (string, *tls.Config) {
+		t.Helper()
+		cfg, err := reloader.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatalf("GetConfigForClient failed: %v", err)
+		}
+		leaf, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaf.Subject.CommonName, cfg
+	}
+
+	name, cfg := served()
+	if name != "first" || cfg.MinVersion != tls.VersionTLS13 || cfg.ClientAuth != tls.RequestClientCert {
+		t.Errorf("served %s with min version %x and client auth %v", name, cfg.MinVersion, cfg.ClientAuth)
+	}
+	if reloader.changed() {
+		t.Error("changed() before the files changed")
+	}
+
+	writeTestCertificate(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reloader.changed() {
+		t.Fatal("changed() missed the new certificate")
+	}
+	if err := reloader.reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if name, _ := served(); name != "second" {
+		t.Errorf("served %s after the reload, want second", name)
+	}
+
+	// A broken certificate fails to load and the previous one stays in use
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloader.reload(); err == nil {
+		t.Error("reload accepted a broken certificate")
+	}
+	if name, _ := served(); name != "second" {
+		t.Errorf("served %s after a failed reload, want second", name)
+	}
+
+	if _, err := NewCertReloader(TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}); err == nil {
+		t.Error("NewCertReloader accepted a client CA file without certificates")
+	}
+}
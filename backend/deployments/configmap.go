@@ -0,0 +1,236 @@
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// deploymentsKey is the ConfigMap key holding the JSON array of deployments
+const deploymentsKey = "deployments"
+
+// migratedAnnotation marks a ConfigMap whose records were copied to another backend
+const migratedAnnotation = "kubestellar.io/deployment-history-migrated"
+
+// configMapNames maps each kind to the ConfigMap it has always been stored in
+var configMapNames = map[string]string{
+	KindHelm:      "kubestellar-helm",
+	KindGitHub:    "kubestellar-github",
+	KindManifests: "kubestellar-manifests",
+}
+
+// ConfigMapRepository keeps each kind of deployment as a JSON array in a ConfigMap.
+// It is the original storage format; filtering and pagination happen in memory and the
+// ConfigMaps are bounded by the 1 MiB object size limit.
+type ConfigMapRepository struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewConfigMapRepository stores deployments in ConfigMaps of the given namespace
+func NewConfigMapRepository(client kubernetes.Interface, namespace string) *ConfigMapRepository {
+	return &ConfigMapRepository{client: client, namespace: namespace}
+}
+
+func configMapName(kind string) (string, error) {
+	name, ok := configMapNames[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown deployment kind %q", kind)
+	}
+	return name, nil
+}
+
+// load returns the stored deployments of a kind; a missing ConfigMap has none
+func (r *ConfigMapRepository) load(ctx context.Context, kind string) (*corev1.ConfigMap, []json.RawMessage, error) {
+	name, err := configMapName(kind)
+	if err != nil {
+		return nil, nil, err
+	}
+	configMap, err := r.client.CoreV1().ConfigMaps(r.namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get ConfigMap %s: %v", name, err)
+	}
+
+	var items []json.RawMessage
+	if raw := configMap.Data[deploymentsKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse deployments data: %v", err)
+		}
+	}
+	return configMap, items, nil
+}
+
+// store writes the deployments of a kind, creating the ConfigMap if needed
+func (r *ConfigMapRepository) store(ctx context.Context, kind string, configMap *corev1.ConfigMap, items []json.RawMessage) error {
+	name, err := configMapName(kind)
+	if err != nil {
+		return err
+	}
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployments: %v", err)
+	}
+
+	if configMap == nil {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: r.namespace},
+			Data:       map[string]string{deploymentsKey: string(itemsJSON)},
+		}
+		_, err = r.client.CoreV1().ConfigMaps(r.namespace).Create(ctx, configMap, v1.CreateOptions{})
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[deploymentsKey] = string(itemsJSON)
+	_, err = r.client.CoreV1().ConfigMaps(r.namespace).Update(ctx, configMap, v1.UpdateOptions{})
+	return err
+}
+
+// Save implements Repository
+func (r *ConfigMapRepository) Save(ctx context.Context, record Record) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, items, err := r.load(ctx, record.Kind)
+		if err != nil {
+			return err
+		}
+		replaced := false
+		for i, item := range items {
+			if existing, err := recordFromData(record.Kind, item); err == nil && existing.ID == record.ID {
+				items[i] = record.Data
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			items = append(items, record.Data)
+		}
+		return r.store(ctx, record.Kind, configMap, items)
+	})
+}
+
+// Get implements Repository
+func (r *ConfigMapRepository) Get(ctx context.Context, kind, id string) (*Record, error) {
+	_, items, err := r.load(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if record, err := recordFromData(kind, item); err == nil && record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List implements Repository
+func (r *ConfigMapRepository) List(ctx context.Context, filter Filter) (*Page, error) {
+	filter.Normalize()
+	kinds := []string{KindHelm, KindGitHub, KindManifests}
+	if filter.Kind != "" {
+		kinds = []string{filter.Kind}
+	}
+
+	var matches []Record
+	for _, kind := range kinds {
+		records, err := r.all(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if filter.Matches(record) {
+				matches = append(matches, record)
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].CreatedAt.After(matches[j].CreatedAt) })
+
+	page := &Page{Records: []Record{}, Total: len(matches), Limit: filter.Limit, Offset: filter.Offset}
+	if filter.Offset < len(matches) {
+		end := filter.Offset + filter.Limit
+		if filter.Limit == 0 || end > len(matches) {
+			end = len(matches)
+		}
+		page.Records = matches[filter.Offset:end]
+	}
+	return page, nil
+}
+
+// all returns every record of a kind, skipping entries without an id
+func (r *ConfigMapRepository) all(ctx context.Context, kind string) ([]Record, error) {
+	_, items, err := r.load(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		if record, err := recordFromData(kind, item); err == nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// Delete implements Repository
+func (r *ConfigMapRepository) Delete(ctx context.Context, kind, id string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, items, err := r.load(ctx, kind)
+		if err != nil {
+			return err
+		}
+		for i, item := range items {
+			if record, err := recordFromData(kind, item); err == nil && record.ID == id {
+				items = append(items[:i], items[i+1:]...)
+				return r.store(ctx, kind, configMap, items)
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// Backend implements Repository
+func (r *ConfigMapRepository) Backend() string {
+	return BackendConfigMap
+}
+
+// Close implements Repository
+func (r *ConfigMapRepository) Close() error {
+	return nil
+}
+
+// migrated reports whether the ConfigMap of a kind was already copied to another backend
+func (r *ConfigMapRepository) migrated(ctx context.Context, kind string) (bool, error) {
+	configMap, _, err := r.load(ctx, kind)
+	if err != nil || configMap == nil {
+		return false, err
+	}
+	_, ok := configMap.Annotations[migratedAnnotation]
+	return ok, nil
+}
+
+// markMigrated annotates the ConfigMap of a kind with the backend its records were copied to.
+// The records themselves are kept so the ConfigMap backend can be switched back to.
+func (r *ConfigMapRepository) markMigrated(ctx context.Context, kind, backend string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, _, err := r.load(ctx, kind)
+		if err != nil || configMap == nil {
+			return err
+		}
+		if configMap.Annotations == nil {
+			configMap.Annotations = make(map[string]string)
+		}
+		configMap.Annotations[migratedAnnotation] = backend
+		_, err = r.client.CoreV1().ConfigMaps(r.namespace).Update(ctx, configMap, v1.UpdateOptions{})
+		return err
+	})
+}
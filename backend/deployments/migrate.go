@@ -0,0 +1,42 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// MigrateConfigMaps copies the deployments stored in ConfigMaps to another backend, once.
+// Each ConfigMap is annotated after its records were copied, so restarts skip it; records
+// that already exist in the target are overwritten with the same data.
+func MigrateConfigMaps(ctx context.Context, from *ConfigMapRepository, to Repository) error {
+	if to.Backend() == BackendConfigMap {
+		return nil
+	}
+	for _, kind := range []string{KindHelm, KindGitHub, KindManifests} {
+		done, err := from.migrated(ctx, kind)
+		if err != nil {
+			return err
+		}
+		if done {
+			continue
+		}
+
+		records, err := from.all(ctx, kind)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := to.Save(ctx, record); err != nil {
+				return fmt.Errorf("failed to migrate %s deployment %s: %v", kind, record.ID, err)
+			}
+		}
+		if err := from.markMigrated(ctx, kind, to.Backend()); err != nil {
+			return fmt.Errorf("failed to mark %s deployments as migrated: %v", kind, err)
+		}
+		if len(records) > 0 {
+			log.Printf("Migrated %d %s deployment(s) from ConfigMap to %s", len(records), kind, to.Backend())
+		}
+	}
+	return nil
+}
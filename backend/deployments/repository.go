@@ -0,0 +1,164 @@
+// Package deployments stores the history of Helm, GitHub and manifest deployments made
+// through the UI behind a Repository with ConfigMap, PostgreSQL and SQLite backends.
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Deployment kinds
+const (
+	KindHelm      = "helm"
+	KindGitHub    = "github"
+	KindManifests = "manifests"
+)
+
+// Backends
+const (
+	BackendConfigMap = "configmap"
+	BackendPostgres  = "postgres"
+	BackendSQLite    = "sqlite"
+)
+
+// Pagination is opt-in: DefaultLimit applies when only an offset is given
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrNotFound is returned when a deployment record does not exist
+var ErrNotFound = errors.New("deployment not found")
+
+// Record is a stored deployment. Data holds the full deployment as JSON; the other fields
+// are indexed copies of it used for filtering.
+type Record struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Context   string          `json:"context,omitempty"`
+	Namespace string          `json:"namespace,omitempty"`
+	Release   string          `json:"release,omitempty"`
+	Source    string          `json:"source,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Filter selects deployment records; zero values match everything, and a zero Limit returns
+// every match
+type Filter struct {
+	Kind      string
+	Context   string
+	Namespace string
+	Release   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+// Page is one page of records, newest first, with the total number of matches. Limit is 0
+// when the page holds every match.
+type Page struct {
+	Records []Record `json:"records"`
+	Total   int      `json:"total"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+}
+
+// Repository persists deployment records
+type Repository interface {
+	// Save inserts the record, or replaces the record of the same kind and ID
+	Save(ctx context.Context, record Record) error
+	// Get returns a record or ErrNotFound
+	Get(ctx context.Context, kind, id string) (*Record, error)
+	// List returns the records matching the filter, newest first
+	List(ctx context.Context, filter Filter) (*Page, error)
+	// Delete removes a record or returns ErrNotFound
+	Delete(ctx context.Context, kind, id string) error
+	// Backend names the storage backend
+	Backend() string
+	Close() error
+}
+
+// NewRecord builds a record from a deployment, indexing the fields the UI has always stored:
+// id, timestamp, context, namespace, releaseName and repoURL/repo_url
+func NewRecord(kind string, deployment interface{}) (Record, error) {
+	data, err := json.Marshal(deployment)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to marshal deployment: %v", err)
+	}
+	return recordFromData(kind, data)
+}
+
+func recordFromData(kind string, data json.RawMessage) (Record, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Record{}, fmt.Errorf("failed to parse deployment: %v", err)
+	}
+
+	str := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := fields[key].(string); ok && value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	record := Record{
+		ID:        str("id"),
+		Kind:      kind,
+		Context:   str("context"),
+		Namespace: str("namespace"),
+		Release:   str("releaseName"),
+		Source:    str("repoURL", "repo_url"),
+		Data:      data,
+	}
+	if record.ID == "" {
+		return Record{}, fmt.Errorf("deployment has no id")
+	}
+	if createdAt, err := time.Parse(time.RFC3339, str("timestamp")); err == nil {
+		record.CreatedAt = createdAt.UTC()
+	} else {
+		record.CreatedAt = time.Now().UTC()
+	}
+	return record, nil
+}
+
+// Normalize clamps the pagination of the filter; an offset without a limit gets DefaultLimit
+func (f *Filter) Normalize() {
+	if f.Limit < 0 {
+		f.Limit = 0
+	}
+	if f.Offset > 0 && f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+	if f.Limit > MaxLimit {
+		f.Limit = MaxLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+}
+
+// Matches reports whether the record passes the filter, ignoring pagination
+func (f *Filter) Matches(record Record) bool {
+	switch {
+	case f.Kind != "" && record.Kind != f.Kind:
+		return false
+	case f.Context != "" && record.Context != f.Context:
+		return false
+	case f.Namespace != "" && record.Namespace != f.Namespace:
+		return false
+	case f.Release != "" && record.Release != f.Release:
+		return false
+	case !f.Since.IsZero() && record.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !record.CreatedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
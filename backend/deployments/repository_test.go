@@ -0,0 +1,86 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFilterNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     Filter
+		wantLimit  int
+		wantOffset int
+	}{
+		{name: "no pagination returns everything", filter: Filter{}, wantLimit: 0},
+		{name: "limit", filter: Filter{Limit: 10}, wantLimit: 10},
+		{name: "limit above maximum", filter: Filter{Limit: MaxLimit + 1}, wantLimit: MaxLimit},
+		{name: "offset without limit", filter: Filter{Offset: 20}, wantLimit: DefaultLimit, wantOffset: 20},
+		{name: "negative values", filter: Filter{Limit: -1, Offset: -5}, wantLimit: 0, wantOffset: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Normalize()
+			if tt.filter.Limit != tt.wantLimit || tt.filter.Offset != tt.wantOffset {
+				t.Errorf("Normalize() = limit %d offset %d, want limit %d offset %d",
+					tt.filter.Limit, tt.filter.Offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestSQLiteListPagination(t *testing.T) {
+	ctx := context.Background()
+	repo, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "deployments.db"))
+	if err != nil {
+		t.Skipf("SQLite is unavailable: %v", err)
+	}
+	defer repo.Close()
+
+	const count = DefaultLimit + 10
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		record, err := NewRecord(KindHelm, map[string]interface{}{
+			"id":        fmt.Sprintf("helm-%03d", i),
+			"timestamp": start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Save(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filter    Filter
+		wantCount int
+		wantFirst string
+	}{
+		{name: "everything without a limit", filter: Filter{}, wantCount: count, wantFirst: fmt.Sprintf("helm-%03d", count-1)},
+		{name: "limit", filter: Filter{Limit: 5}, wantCount: 5, wantFirst: fmt.Sprintf("helm-%03d", count-1)},
+		{name: "offset", filter: Filter{Limit: 5, Offset: 5}, wantCount: 5, wantFirst: fmt.Sprintf("helm-%03d", count-6)},
+		{name: "offset without limit", filter: Filter{Offset: 20}, wantCount: count - 20, wantFirst: fmt.Sprintf("helm-%03d", count-21)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if page.Total != count {
+				t.Errorf("Total = %d, want %d", page.Total, count)
+			}
+			if len(page.Records) != tt.wantCount {
+				t.Fatalf("got %d records, want %d", len(page.Records), tt.wantCount)
+			}
+			if page.Records[0].ID != tt.wantFirst {
+				t.Errorf("first record = %s, want %s", page.Records[0].ID, tt.wantFirst)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package deployments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migration is one schema change; migrations run in order and each runs once
+type migration struct {
+	version    int
+	statements map[string][]string // per backend
+}
+
+// migrations is the schema history of the deployment store. Append new versions; never edit applied ones.
+var migrations = []migration{
+	{
+		version: 1,
+		statements: map[string][]string{
+			BackendPostgres: {
+				`CREATE TABLE IF NOT EXISTS deployment_history (
+					kind TEXT NOT NULL,
+					id TEXT NOT NULL,
+					context TEXT NOT NULL DEFAULT '',
+					namespace TEXT NOT NULL DEFAULT '',
+					release_name TEXT NOT NULL DEFAULT '',
+					source TEXT NOT NULL DEFAULT '',
+					created_at TIMESTAMPTZ NOT NULL,
+					data JSONB NOT NULL,
+					PRIMARY KEY (kind, id)
+				)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_created_at ON deployment_history (created_at DESC)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_context ON deployment_history (context)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_namespace ON deployment_history (namespace)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_release ON deployment_history (release_name)`,
+			},
+			BackendSQLite: {
+				`CREATE TABLE IF NOT EXISTS deployment_history (
+					kind TEXT NOT NULL,
+					id TEXT NOT NULL,
+					context TEXT NOT NULL DEFAULT '',
+					namespace TEXT NOT NULL DEFAULT '',
+					release_name TEXT NOT NULL DEFAULT '',
+					source TEXT NOT NULL DEFAULT '',
+					created_at DATETIME NOT NULL,
+					data TEXT NOT NULL,
+					PRIMARY KEY (kind, id)
+				)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_created_at ON deployment_history (created_at DESC)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_context ON deployment_history (context)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_namespace ON deployment_history (namespace)`,
+				`CREATE INDEX IF NOT EXISTS deployment_history_release ON deployment_history (release_name)`,
+			},
+		},
+	},
+}
+
+// SQLRepository stores deployments in a deployment_history table of PostgreSQL or SQLite
+type SQLRepository struct {
+	db      *sql.DB
+	backend string
+}
+
+// NewSQLRepository wraps an open database and migrates its schema to the latest version
+func NewSQLRepository(ctx context.Context, db *sql.DB, backend string) (*SQLRepository, error) {
+	if backend != BackendPostgres && backend != BackendSQLite {
+		return nil, fmt.Errorf("unsupported SQL backend %q", backend)
+	}
+	repo := &SQLRepository{db: db, backend: backend}
+	if err := repo.migrate(ctx); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// migrate applies the migrations newer than the version recorded in schema_migrations
+func (r *SQLRepository) migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS deployment_schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %v", err)
+	}
+
+	var current int
+	row := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM deployment_schema_migrations`)
+	if err := row.Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start migration %d: %v", m.version, err)
+		}
+		for _, statement := range m.statements[r.backend] {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d failed: %v", m.version, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, r.rebind(`INSERT INTO deployment_schema_migrations (version) VALUES (?)`), m.version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %v", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %v", m.version, err)
+		}
+	}
+	return nil
+}
+
+// rebind converts ? placeholders to $n for PostgreSQL
+func (r *SQLRepository) rebind(query string) string {
+	if r.backend != BackendPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// Save implements Repository
+func (r *SQLRepository) Save(ctx context.Context, record Record) error {
+	query := r.rebind(`INSERT INTO deployment_history (kind, id, context, namespace, release_name, source, created_at, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (kind, id) DO UPDATE SET
+			context = excluded.context,
+			namespace = excluded.namespace,
+			release_name = excluded.release_name,
+			source = excluded.source,
+			created_at = excluded.created_at,
+			data = excluded.data`)
+	_, err := r.db.ExecContext(ctx, query, record.Kind, record.ID, record.Context, record.Namespace,
+		record.Release, record.Source, record.CreatedAt.UTC(), string(record.Data))
+	if err != nil {
+		return fmt.Errorf("failed to save deployment %s: %v", record.ID, err)
+	}
+	return nil
+}
+
+const selectColumns = `kind, id, context, namespace, release_name, source, created_at, data`
+
+func scanRecord(scanner interface{ Scan(...interface{}) error }) (*Record, error) {
+	var record Record
+	var data string
+	if err := scanner.Scan(&record.Kind, &record.ID, &record.Context, &record.Namespace,
+		&record.Release, &record.Source, &record.CreatedAt, &data); err != nil {
+		return nil, err
+	}
+	record.Data = []byte(data)
+	return &record, nil
+}
+
+// Get implements Repository
+func (r *SQLRepository) Get(ctx context.Context, kind, id string) (*Record, error) {
+	row := r.db.QueryRowContext(ctx, r.rebind(`SELECT `+selectColumns+` FROM deployment_history WHERE kind = ? AND id = ?`), kind, id)
+	record, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment %s: %v", id, err)
+	}
+	return record, nil
+}
+
+// List implements Repository
+func (r *SQLRepository) List(ctx context.Context, filter Filter) (*Page, error) {
+	filter.Normalize()
+
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+	if filter.Kind != "" {
+		add("kind = ?", filter.Kind)
+	}
+	if filter.Context != "" {
+		add("context = ?", filter.Context)
+	}
+	if filter.Namespace != "" {
+		add("namespace = ?", filter.Namespace)
+	}
+	if filter.Release != "" {
+		add("release_name = ?", filter.Release)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= ?", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < ?", filter.Until.UTC())
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := &Page{Records: []Record{}, Limit: filter.Limit, Offset: filter.Offset}
+	if err := r.db.QueryRowContext(ctx, r.rebind(`SELECT COUNT(*) FROM deployment_history`+where), args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count deployments: %v", err)
+	}
+
+	query := `SELECT ` + selectColumns + ` FROM deployment_history` + where + ` ORDER BY created_at DESC, id`
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := r.db.QueryContext(ctx, r.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deployment: %v", err)
+		}
+		page.Records = append(page.Records, *record)
+	}
+	return page, rows.Err()
+}
+
+// Delete implements Repository
+func (r *SQLRepository) Delete(ctx context.Context, kind, id string) error {
+	result, err := r.db.ExecContext(ctx, r.rebind(`DELETE FROM deployment_history WHERE kind = ? AND id = ?`), kind, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete deployment %s: %v", id, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Backend implements Repository
+func (r *SQLRepository) Backend() string {
+	return r.backend
+}
+
+// Close implements Repository. The PostgreSQL connection is shared with the postgresql package and stays open.
+func (r *SQLRepository) Close() error {
+	if r.backend == BackendSQLite {
+		return r.db.Close()
+	}
+	return nil
+}
+
+// OpenSQLite opens (creating if needed) an embedded SQLite database at path
+func OpenSQLite(ctx context.Context, path string) (*SQLRepository, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database %s: %v", path, err)
+	}
+	// SQLite allows a single writer; one connection avoids "database is locked" errors
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(time.Hour)
+
+	repo, err := NewSQLRepository(ctx, db, BackendSQLite)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
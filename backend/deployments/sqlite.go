@@ -0,0 +1,4 @@
+package deployments
+
+// The embedded SQLite backend uses the cgo driver
+import _ "github.com/mattn/go-sqlite3"
@@ -0,0 +1,84 @@
+package gitops
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Checkout is a shallow clone of a source in a temporary directory
+type Checkout struct {
+	Dir    string
+	Commit string
+}
+
+// DeployPath returns the folder inside the checkout that holds the source's manifests
+func (co *Checkout) DeployPath(path string) (string, error) {
+	deployPath := co.Dir
+	if path != "" {
+		deployPath = filepath.Join(co.Dir, path)
+	}
+	if _, err := os.Stat(deployPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("specified folder %s does not exist", path)
+	}
+	return deployPath, nil
+}
+
+// Cleanup removes the checkout from disk
+func (co *Checkout) Cleanup() {
+	os.RemoveAll(co.Dir)
+}
+
+// Clone makes a shallow clone of the source's branch and resolves the checked out commit
+func Clone(source *Source) (*Checkout, error) {
+	cloneURL, err := authenticatedURL(source.RepoURL, source.Username, source.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "gitops-"+source.Name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %v", err)
+	}
+
+	cmd := exec.Command("git", "clone", "--depth", "1", "-b", source.Branch, cloneURL, tempDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(tempDir)
+		// Never echo the clone URL back, it may contain the token
+		return nil, fmt.Errorf("failed to clone repo: %v: %s", err, redactToken(string(out), source.Token))
+	}
+
+	out, err := exec.Command("git", "-C", tempDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return nil, fmt.Errorf("failed to resolve checked out commit: %v", err)
+	}
+
+	return &Checkout{Dir: tempDir, Commit: strings.TrimSpace(string(out))}, nil
+}
+
+// authenticatedURL embeds the credentials into an https clone URL
+func authenticatedURL(repoURL, username, token string) (string, error) {
+	if token == "" {
+		return repoURL, nil
+	}
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid repo_url: %v", err)
+	}
+	if username == "" {
+		username = "x-access-token"
+	}
+	parsed.User = url.UserPassword(username, token)
+	return parsed.String(), nil
+}
+
+func redactToken(s, token string) string {
+	if token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, token, "********")
+}
@@ -0,0 +1,222 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Supported webhook providers
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderGitea     = "gitea" // Gitea and Forgejo share the same payloads
+	ProviderBitbucket = "bitbucket"
+)
+
+// DetectProvider works out which git host sent a webhook from its headers.
+// Gitea and Forgejo also send X-GitHub-Event for compatibility, so they are checked first.
+func DetectProvider(header http.Header) string {
+	switch {
+	case header.Get("X-Gitea-Event") != "" || header.Get("X-Forgejo-Event") != "":
+		return ProviderGitea
+	case header.Get("X-Gitlab-Event") != "":
+		return ProviderGitLab
+	case header.Get("X-Event-Key") != "":
+		return ProviderBitbucket
+	default:
+		// Also covers payloads relayed without headers, which have always been GitHub pushes
+		return ProviderGitHub
+	}
+}
+
+// IsPushEvent reports whether the webhook is a push; other events such as pings are acknowledged and ignored
+func IsPushEvent(provider string, header http.Header) bool {
+	switch provider {
+	case ProviderGitea:
+		event := header.Get("X-Gitea-Event")
+		if event == "" {
+			event = header.Get("X-Forgejo-Event")
+		}
+		return event == "push"
+	case ProviderGitLab:
+		return header.Get("X-Gitlab-Event") == "Push Hook"
+	case ProviderBitbucket:
+		key := header.Get("X-Event-Key")
+		return key == "repo:push" || key == "repo:refs_changed"
+	default:
+		event := header.Get("X-GitHub-Event")
+		return event == "" || event == "push"
+	}
+}
+
+// ParsePushEvent converts a provider push payload into a provider-neutral push event
+func ParsePushEvent(provider string, payload []byte) (*PushEvent, error) {
+	switch provider {
+	case ProviderGitLab:
+		return parseGitLabPush(payload)
+	case ProviderBitbucket:
+		return parseBitbucketPush(payload)
+	case ProviderGitea, ProviderGitHub:
+		// Gitea and Forgejo push payloads follow the GitHub schema
+		return parseGitHubPush(payload)
+	default:
+		return nil, fmt.Errorf("unsupported webhook provider %q", provider)
+	}
+}
+
+// githubPushPayload is the subset of a GitHub (or Gitea/Forgejo) push payload we use
+type githubPushPayload struct {
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+	Ref     string `json:"ref"` // Format: "refs/heads/main"
+	Commits []struct {
+		ID       string   `json:"id"`
+		Message  string   `json:"message"`
+		URL      string   `json:"url"`
+		Added    []string `json:"added"`
+		Modified []string `json:"modified"`
+		Removed  []string `json:"removed"`
+	} `json:"commits"`
+}
+
+func parseGitHubPush(payload []byte) (*PushEvent, error) {
+	var request githubPushPayload
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+
+	event := &PushEvent{
+		RepoURL: request.Repository.CloneURL,
+		Branch:  strings.TrimPrefix(request.Ref, "refs/heads/"),
+	}
+	if event.RepoURL == "" {
+		event.RepoURL = request.Repository.HTMLURL
+	}
+	for _, commit := range request.Commits {
+		event.Commits = append(event.Commits, PushCommit{
+			ID:       commit.ID,
+			Message:  commit.Message,
+			URL:      commit.URL,
+			Added:    commit.Added,
+			Modified: commit.Modified,
+			Removed:  commit.Removed,
+		})
+	}
+	return event, nil
+}
+
+// gitlabPushPayload is the subset of a GitLab "Push Hook" payload we use
+type gitlabPushPayload struct {
+	Ref     string `json:"ref"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+	Commits []struct {
+		ID       string   `json:"id"`
+		Message  string   `json:"message"`
+		URL      string   `json:"url"`
+		Added    []string `json:"added"`
+		Modified []string `json:"modified"`
+		Removed  []string `json:"removed"`
+	} `json:"commits"`
+}
+
+func parseGitLabPush(payload []byte) (*PushEvent, error) {
+	var request gitlabPushPayload
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+
+	event := &PushEvent{
+		RepoURL: request.Project.GitHTTPURL,
+		Branch:  strings.TrimPrefix(request.Ref, "refs/heads/"),
+	}
+	if event.RepoURL == "" {
+		event.RepoURL = request.Project.WebURL
+	}
+	for _, commit := range request.Commits {
+		event.Commits = append(event.Commits, PushCommit{
+			ID:       commit.ID,
+			Message:  commit.Message,
+			URL:      commit.URL,
+			Added:    commit.Added,
+			Modified: commit.Modified,
+			Removed:  commit.Removed,
+		})
+	}
+	return event, nil
+}
+
+// bitbucketPushPayload is the subset of a Bitbucket Cloud "repo:push" payload we use
+type bitbucketPushPayload struct {
+	Repository struct {
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	} `json:"repository"`
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"`
+			Commits []struct {
+				Hash    string `json:"hash"`
+				Message string `json:"message"`
+				Links   struct {
+					HTML struct {
+						Href string `json:"href"`
+					} `json:"html"`
+				} `json:"links"`
+			} `json:"commits"`
+		} `json:"changes"`
+	} `json:"push"`
+}
+
+// parseBitbucketPush converts a Bitbucket push. Bitbucket does not list the changed
+// files of a push, so the event is marked as touching every path.
+func parseBitbucketPush(payload []byte) (*PushEvent, error) {
+	var request bitbucketPushPayload
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+
+	event := &PushEvent{
+		RepoURL:      request.Repository.Links.HTML.Href,
+		FilesUnknown: true,
+	}
+	for _, change := range request.Push.Changes {
+		// Deleted branches and tags have no "new" state
+		if change.New == nil || change.New.Type != "branch" {
+			continue
+		}
+		event.Branch = change.New.Name
+		// Bitbucket lists commits newest first
+		for i := len(change.Commits) - 1; i >= 0; i-- {
+			commit := change.Commits[i]
+			event.Commits = append(event.Commits, PushCommit{
+				ID:      commit.Hash,
+				Message: commit.Message,
+				URL:     commit.Links.HTML.Href,
+			})
+		}
+		if len(change.Commits) == 0 && change.New.Target.Hash != "" {
+			event.Commits = append(event.Commits, PushCommit{ID: change.New.Target.Hash})
+		}
+		break
+	}
+	if event.Branch == "" {
+		return nil, fmt.Errorf("push does not update a branch")
+	}
+	return event, nil
+}
@@ -0,0 +1,82 @@
+package gitops
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// reconciler runs one periodic sync loop per source so drift in the WDS is re-applied from git
+type reconciler struct {
+	mu    sync.Mutex
+	loops map[string]context.CancelFunc
+}
+
+var defaultReconciler = &reconciler{loops: make(map[string]context.CancelFunc)}
+
+// StartReconciler starts the reconcile loops of all stored sources
+func StartReconciler() error {
+	sources, err := ListSources()
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		defaultReconciler.schedule(source)
+	}
+	log.Printf("gitops: started reconciler for %d source(s)", len(sources))
+	return nil
+}
+
+// schedule (re)starts the loop of a source, or only stops it when its interval is zero
+func (r *reconciler) schedule(source Source) {
+	r.stop(source.Name)
+
+	interval, err := source.Interval()
+	if err != nil {
+		log.Printf("gitops: not reconciling source %s: %v", source.Name, err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	r.loops[source.Name] = cancel
+	r.mu.Unlock()
+
+	go r.run(ctx, source.Name, interval)
+}
+
+// stop cancels the loop of a source if one is running
+func (r *reconciler) stop(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.loops[name]; ok {
+		cancel()
+		delete(r.loops, name)
+	}
+}
+
+func (r *reconciler) run(ctx context.Context, name string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Reload the source so credential and path updates are picked up
+			source, err := GetSource(name)
+			if err != nil {
+				log.Printf("gitops: stopping reconcile of %s: %v", name, err)
+				return
+			}
+			if _, err := SyncSource(source, TriggerReconcile); err != nil {
+				log.Printf("gitops: reconcile of %s failed: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package gitops
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/ui/redis"
+)
+
+const (
+	// replayWindow is how long a delivery is remembered to reject replays
+	replayWindow = 24 * time.Hour
+	// webhookSecretBytes is the size of generated webhook secrets
+	webhookSecretBytes = 32
+)
+
+var (
+	// ErrInvalidSignature is returned when a webhook signature or token does not match the source's secret
+	ErrInvalidSignature = errors.New("webhook signature verification failed")
+	// ErrReplayedDelivery is returned when a webhook delivery was already processed
+	ErrReplayedDelivery = errors.New("webhook delivery was already processed")
+)
+
+// VerifyWebhook checks the webhook against the source's secret and rejects replays.
+// Sources without a secret reject every webhook; saving the source generates one.
+func VerifyWebhook(source *Source, provider string, header http.Header, body []byte) error {
+	if source.WebhookSecret == "" {
+		return fmt.Errorf("%w: source %s has no webhook secret", ErrInvalidSignature, source.Name)
+	}
+	if err := verifySignature(provider, header, body, source.WebhookSecret); err != nil {
+		return err
+	}
+	return checkReplay(source.Name, body)
+}
+
+// GenerateWebhookSecret returns a random hex encoded webhook secret
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %v", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// verifySignature validates the provider-specific signature header
+func verifySignature(provider string, header http.Header, body []byte, secret string) error {
+	switch provider {
+	case ProviderGitLab:
+		// GitLab sends the secret token itself rather than an HMAC
+		token := header.Get("X-Gitlab-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return ErrInvalidSignature
+		}
+		return nil
+	case ProviderGitea:
+		signature := header.Get("X-Gitea-Signature")
+		if signature == "" {
+			signature = header.Get("X-Forgejo-Signature")
+		}
+		return verifyHMAC(signature, body, secret)
+	case ProviderBitbucket:
+		return verifyHMAC(strings.TrimPrefix(header.Get("X-Hub-Signature"), "sha256="), body, secret)
+	default:
+		signature := header.Get("X-Hub-Signature-256")
+		if !strings.HasPrefix(signature, "sha256=") {
+			return ErrInvalidSignature
+		}
+		return verifyHMAC(strings.TrimPrefix(signature, "sha256="), body, secret)
+	}
+}
+
+// verifyHMAC compares a hex encoded HMAC-SHA256 of the body in constant time
+func verifyHMAC(signature string, body []byte, secret string) error {
+	if signature == "" {
+		return ErrInvalidSignature
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// checkReplay remembers each delivery for replayWindow and rejects it if it is seen again.
+// Deliveries are keyed by the hash of their body, which is what the providers sign; delivery
+// ID headers are not signed and can be changed by whoever replays a captured body.
+func checkReplay(sourceName string, body []byte) error {
+	sum := sha256.Sum256(body)
+	key := fmt.Sprintf("gitops:webhook:delivery:%s:%s", sourceName, hex.EncodeToString(sum[:]))
+	stored, err := redis.SetIfNotExists(key, time.Now().UTC().Format(time.RFC3339), replayWindow)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %v", err)
+	}
+	if !stored {
+		return ErrReplayedDelivery
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package gitops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/redis"
+)
+
+const testSecret = "s3cret"
+
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func headers(pairs ...string) http.Header {
+	header := http.Header{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		header.Set(pairs[i], pairs[i+1])
+	}
+	return header
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	valid := sign(body, testSecret)
+	wrong := sign(body, "other")
+
+	tests := []struct {
+		name     string
+		provider string
+		header   http.Header
+		wantErr  bool
+	}{
+		{"github valid", ProviderGitHub, headers("X-Hub-Signature-256", "sha256="+valid), false},
+		{"github wrong secret", ProviderGitHub, headers("X-Hub-Signature-256", "sha256="+wrong), true},
+		{"github missing prefix", ProviderGitHub, headers("X-Hub-Signature-256", valid), true},
+		{"github missing header", ProviderGitHub, headers(), true},
+		{"github not hex", ProviderGitHub, headers("X-Hub-Signature-256", "sha256=zz"), true},
+		{"gitlab valid token", ProviderGitLab, headers("X-Gitlab-Token", testSecret), false},
+		{"gitlab wrong token", ProviderGitLab, headers("X-Gitlab-Token", "other"), true},
+		{"gitlab missing token", ProviderGitLab, headers(), true},
+		{"gitea valid", ProviderGitea, headers("X-Gitea-Signature", valid), false},
+		{"forgejo valid", ProviderGitea, headers("X-Forgejo-Signature", valid), false},
+		{"gitea wrong secret", ProviderGitea, headers("X-Gitea-Signature", wrong), true},
+		{"bitbucket valid", ProviderBitbucket, headers("X-Hub-Signature", "sha256="+valid), false},
+		{"bitbucket wrong secret", ProviderBitbucket, headers("X-Hub-Signature", "sha256="+wrong), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.provider, tt.header, body, testSecret)
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("verifySignature() = %v, want ErrInvalidSignature", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("verifySignature() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureTamperedBody(t *testing.T) {
+	signature := sign([]byte(`{"ref":"refs/heads/main"}`), testSecret)
+	err := verifySignature(ProviderGitHub, headers("X-Hub-Signature-256", "sha256="+signature), []byte(`{"ref":"refs/heads/evil"}`), testSecret)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("verifySignature() = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func useMiniredis(t *testing.T) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	redis.Configure(config.RedisConfig{Address: server.Addr()})
+}
+
+func TestCheckReplay(t *testing.T) {
+	useMiniredis(t)
+
+	body := []byte(`{"after":"abc123"}`)
+	if err := checkReplay("app", body); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	if err := checkReplay("app", body); !errors.Is(err, ErrReplayedDelivery) {
+		t.Fatalf("replayed delivery = %v, want ErrReplayedDelivery", err)
+	}
+	if err := checkReplay("other", body); err != nil {
+		t.Fatalf("same delivery to another source: %v", err)
+	}
+	if err := checkReplay("app", []byte(`{"after":"def456"}`)); err != nil {
+		t.Fatalf("new delivery: %v", err)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	useMiniredis(t)
+
+	body := []byte(`{"after":"abc123"}`)
+	signed := headers("X-Hub-Signature-256", "sha256="+sign(body, testSecret), "X-GitHub-Delivery", "1")
+
+	if err := VerifyWebhook(&Source{Name: "unsigned"}, ProviderGitHub, headers(), body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("source without secret = %v, want ErrInvalidSignature", err)
+	}
+
+	source := &Source{Name: "app", WebhookSecret: testSecret}
+	if err := VerifyWebhook(source, ProviderGitHub, signed, body); err != nil {
+		t.Fatalf("signed delivery: %v", err)
+	}
+
+	// A replay with a new delivery ID is still the same signed body
+	replayed := signed.Clone()
+	replayed.Set("X-GitHub-Delivery", "2")
+	if err := VerifyWebhook(source, ProviderGitHub, replayed, body); !errors.Is(err, ErrReplayedDelivery) {
+		t.Errorf("replay with new delivery ID = %v, want ErrReplayedDelivery", err)
+	}
+}
+
+func TestGenerateWebhookSecret(t *testing.T) {
+	first, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateWebhookSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2*webhookSecretBytes {
+		t.Errorf("secret length = %d, want %d", len(first), 2*webhookSecretBytes)
+	}
+	if first == second {
+		t.Error("generated the same secret twice")
+	}
+}
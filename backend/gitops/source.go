@@ -0,0 +1,296 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/ui/redis"
+)
+
+const (
+	// sourcesHashKey is the Redis hash holding every GitOps source keyed by name
+	sourcesHashKey = "gitops:sources"
+	// DefaultBranch is used when a source does not set a branch
+	DefaultBranch = "main"
+	// DefaultReconcileInterval is how often a source is re-applied when it does not set an interval
+	DefaultReconcileInterval = 5 * time.Minute
+)
+
+var sourceNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Source is a named Git repository folder that is kept applied to the WDS
+type Source struct {
+	Name              string    `json:"name"`
+	RepoURL           string    `json:"repo_url"`
+	Branch            string    `json:"branch"`
+	Path              string    `json:"path"`
+	Username          string    `json:"username,omitempty"`
+	Token             string    `json:"token,omitempty"`
+	WebhookSecret     string    `json:"webhook_secret,omitempty"` // HMAC secret (or GitLab token) webhooks must be signed with
+	WorkloadLabel     string    `json:"workload_label"`
+	ReconcileInterval string    `json:"reconcile_interval"` // Go duration, "0" disables the periodic reconcile
+	Force             bool      `json:"force"`              // Take ownership of fields owned by other managers on apply
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ApplyDefaults fills in the branch, workload label and reconcile interval when they are not set
+func (s *Source) ApplyDefaults() {
+	if s.Branch == "" {
+		s.Branch = DefaultBranch
+	}
+	if s.WorkloadLabel == "" {
+		s.WorkloadLabel = ProjectName(s.RepoURL)
+	}
+	if s.ReconcileInterval == "" {
+		s.ReconcileInterval = DefaultReconcileInterval.String()
+	}
+	s.Path = strings.Trim(s.Path, "/")
+}
+
+// Validate checks that the source can be cloned and reconciled
+func (s *Source) Validate() error {
+	if !sourceNameRegex.MatchString(s.Name) {
+		return fmt.Errorf("source name %q must consist of lower case alphanumeric characters or '-'", s.Name)
+	}
+	if s.RepoURL == "" {
+		return fmt.Errorf("repo_url is required")
+	}
+	if !strings.HasPrefix(s.RepoURL, "https://") && !strings.HasPrefix(s.RepoURL, "http://") {
+		return fmt.Errorf("repo_url must be an http(s) URL")
+	}
+	if filepath.IsAbs(s.Path) || strings.HasPrefix(filepath.Clean(s.Path), "..") {
+		return fmt.Errorf("path must be relative to the repository root")
+	}
+	if _, err := s.Interval(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Interval returns the parsed reconcile interval; zero means the periodic reconcile is disabled
+func (s *Source) Interval() (time.Duration, error) {
+	if s.ReconcileInterval == "" {
+		return DefaultReconcileInterval, nil
+	}
+	interval, err := time.ParseDuration(s.ReconcileInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid reconcile_interval %q: %v", s.ReconcileInterval, err)
+	}
+	if interval < 0 {
+		return 0, fmt.Errorf("reconcile_interval must not be negative")
+	}
+	if interval > 0 && interval < 30*time.Second {
+		return 0, fmt.Errorf("reconcile_interval must be at least 30s")
+	}
+	return interval, nil
+}
+
+// Redacted returns a copy of the source that is safe to return over the API
+func (s Source) Redacted() Source {
+	if s.Token != "" {
+		s.Token = "********"
+	}
+	if s.WebhookSecret != "" {
+		s.WebhookSecret = "********"
+	}
+	return s
+}
+
+// ProjectName derives a workload label from a repository URL,
+// e.g. https://github.com/org/project.git becomes project
+func ProjectName(repoURL string) string {
+	repoBase := filepath.Base(repoURL)
+	return strings.TrimSuffix(repoBase, filepath.Ext(repoBase))
+}
+
+// GetSource loads a source by name
+func GetSource(name string) (*Source, error) {
+	var source Source
+	found, err := redis.GetJSONHash(sourcesHashKey, name, &source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load source %s: %v", name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("source %s not found", name)
+	}
+	return &source, nil
+}
+
+// ListSources returns all sources sorted by name
+func ListSources() ([]Source, error) {
+	values, err := redis.GetAllJSONHash(sourcesHashKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %v", err)
+	}
+
+	sources := make([]Source, 0, len(values))
+	for name, raw := range values {
+		var source Source
+		if err := json.Unmarshal(raw, &source); err != nil {
+			return nil, fmt.Errorf("failed to parse source %s: %v", name, err)
+		}
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources, nil
+}
+
+// SaveSource validates and stores a source, then (re)starts its reconcile loop. A webhook
+// secret is generated when the source has none; the returned flag tells the caller to show it,
+// since responses redact it afterwards.
+func SaveSource(source *Source) (bool, error) {
+	source.ApplyDefaults()
+	if err := source.Validate(); err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	if existing, err := GetSource(source.Name); err == nil {
+		source.CreatedAt = existing.CreatedAt
+		keepCredentials(source, existing)
+	} else {
+		source.CreatedAt = now
+	}
+	source.UpdatedAt = now
+
+	secretGenerated := false
+	if source.WebhookSecret == "" {
+		secret, err := GenerateWebhookSecret()
+		if err != nil {
+			return false, err
+		}
+		source.WebhookSecret = secret
+		secretGenerated = true
+	}
+
+	if err := redis.SetJSONHash(sourcesHashKey, source.Name, source); err != nil {
+		return false, fmt.Errorf("failed to save source %s: %v", source.Name, err)
+	}
+
+	defaultReconciler.schedule(*source)
+	return secretGenerated, nil
+}
+
+// SourceMismatchError is returned when a deploy names a source that tracks another repository,
+// branch or path
+type SourceMismatchError struct {
+	Existing Source
+}
+
+func (e *SourceMismatchError) Error() string {
+	return fmt.Sprintf("source %s tracks %s branch %s path %q; deploy with another source name",
+		e.Existing.Name, e.Existing.RepoURL, e.Existing.Branch, e.Existing.Path)
+}
+
+// SourceForDeploy returns the source a one-off deploy registers. A new source follows webhooks
+// only unless an interval is requested. An existing source of the same name is only updated
+// with the fields the deploy sets, and only when it tracks the same repository, branch and path.
+func SourceForDeploy(deployed *Source) (*Source, error) {
+	var existing Source
+	found, err := redis.GetJSONHash(sourcesHashKey, deployed.Name, &existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load source %s: %v", deployed.Name, err)
+	}
+	if !found {
+		source := *deployed
+		if source.ReconcileInterval == "" {
+			source.ReconcileInterval = "0"
+		}
+		source.ApplyDefaults()
+		return &source, nil
+	}
+
+	requested := *deployed
+	requested.ApplyDefaults()
+	if normalizeRepoURL(requested.RepoURL) != normalizeRepoURL(existing.RepoURL) ||
+		requested.Branch != existing.Branch || requested.Path != existing.Path {
+		return nil, &SourceMismatchError{Existing: existing}
+	}
+
+	source := existing
+	if deployed.Username != "" {
+		source.Username = deployed.Username
+	}
+	if deployed.Token != "" {
+		source.Token = deployed.Token
+	}
+	if deployed.WorkloadLabel != "" {
+		source.WorkloadLabel = deployed.WorkloadLabel
+	}
+	if deployed.ReconcileInterval != "" {
+		source.ReconcileInterval = deployed.ReconcileInterval
+	}
+	return &source, nil
+}
+
+// keepCredentials copies the stored token and webhook secret into an update that does not resend
+// them. They are only kept while the source points at the same repository, so that changing the
+// URL cannot send the stored token to another host.
+func keepCredentials(source, existing *Source) {
+	if normalizeRepoURL(source.RepoURL) != normalizeRepoURL(existing.RepoURL) {
+		return
+	}
+	if source.Token == "" {
+		source.Token = existing.Token
+	}
+	if source.WebhookSecret == "" {
+		source.WebhookSecret = existing.WebhookSecret
+	}
+}
+
+// DeleteSource stops the reconcile loop of a source and removes it with its status, inventory and history
+func DeleteSource(name string) error {
+	if _, err := GetSource(name); err != nil {
+		return err
+	}
+	defaultReconciler.stop(name)
+
+	if err := redis.DeleteJSONHash(sourcesHashKey, name); err != nil {
+		return fmt.Errorf("failed to delete source %s: %v", name, err)
+	}
+	if err := redis.DeleteJSONHash(statusHashKey, name); err != nil {
+		return fmt.Errorf("failed to delete status of source %s: %v", name, err)
+	}
+	if err := redis.DeleteJSONHash(inventoryHashKey, name); err != nil {
+		return fmt.Errorf("failed to delete inventory of source %s: %v", name, err)
+	}
+	if err := redis.DeleteKey(historyKey(name)); err != nil {
+		return fmt.Errorf("failed to delete history of source %s: %v", name, err)
+	}
+	return nil
+}
+
+// FindSourcesForRepo returns the sources that track the given repository and branch
+func FindSourcesForRepo(repoURL, branch string) ([]Source, error) {
+	sources, err := ListSources()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Source
+	for _, source := range sources {
+		if normalizeRepoURL(source.RepoURL) == normalizeRepoURL(repoURL) && source.Branch == branch {
+			matches = append(matches, source)
+		}
+	}
+	return matches, nil
+}
+
+// normalizeRepoURL strips the scheme, credentials and .git suffix so clone and html URLs compare equal
+func normalizeRepoURL(repoURL string) string {
+	normalized := strings.ToLower(strings.TrimSpace(repoURL))
+	if i := strings.Index(normalized, "://"); i >= 0 {
+		normalized = normalized[i+3:]
+	}
+	if i := strings.Index(normalized, "@"); i >= 0 {
+		normalized = normalized[i+1:]
+	}
+	normalized = strings.TrimSuffix(normalized, "/")
+	return strings.TrimSuffix(normalized, ".git")
+}
@@ -0,0 +1,248 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/redis"
+)
+
+const (
+	// statusHashKey is the Redis hash holding the last sync record of every source
+	statusHashKey = "gitops:status"
+	// inventoryHashKey is the Redis hash holding the objects the last successful sync of every source produced
+	inventoryHashKey = "gitops:inventory"
+	// historyLimit is the number of sync records kept per source
+	historyLimit = 50
+	// FieldManager is the server-side apply field manager used by GitOps syncs
+	FieldManager = "kubestellar-ui-gitops"
+)
+
+// Sync phases
+const (
+	PhaseSyncing   = "Syncing"
+	PhaseSynced    = "Synced"
+	PhaseConflicts = "SyncedWithConflicts"
+	PhaseFailed    = "Failed"
+)
+
+// Sync triggers
+const (
+	TriggerManual    = "manual"
+	TriggerWebhook   = "webhook"
+	TriggerReconcile = "reconcile"
+	TriggerDeploy    = "deploy"
+)
+
+// SyncRecord describes one sync of a source
+type SyncRecord struct {
+	Source         string              `json:"source"`
+	Trigger        string              `json:"trigger"`
+	Phase          string              `json:"phase"`
+	Commit         string              `json:"commit,omitempty"`
+	Message        string              `json:"message,omitempty"`
+	FailedPhase    string              `json:"failed_phase,omitempty"` // Apply phase the sync failed in, see k8s.PhaseError
+	StartedAt      time.Time           `json:"started_at"`
+	FinishedAt     time.Time           `json:"finished_at,omitempty"`
+	DeploymentTree *k8s.DeploymentTree `json:"deployment_tree,omitempty"`
+	Pruned         []k8s.AppliedObject `json:"pruned,omitempty"` // Objects deleted because their manifests were removed
+}
+
+// Inventory is the set of objects a commit of a source produced
+type Inventory struct {
+	Source    string              `json:"source"`
+	Commit    string              `json:"commit"`
+	Objects   []k8s.AppliedObject `json:"objects"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// syncLocks serializes syncs of the same source, e.g. a webhook arriving during a reconcile
+var syncLocks sync.Map
+
+func lockSource(name string) func() {
+	mu, _ := syncLocks.LoadOrStore(name, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+func historyKey(name string) string {
+	return "gitops:history:" + name
+}
+
+// SyncSource clones the source, applies its manifests to the WDS and prunes the objects whose
+// manifests were removed since the last sync, recording the outcome
+func SyncSource(source *Source, trigger string) (*SyncRecord, error) {
+	unlock := lockSource(source.Name)
+	defer unlock()
+
+	record := &SyncRecord{
+		Source:    source.Name,
+		Trigger:   trigger,
+		Phase:     PhaseSyncing,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := redis.SetJSONHash(statusHashKey, source.Name, record); err != nil {
+		log.Printf("gitops: failed to store status for %s: %v", source.Name, err)
+	}
+
+	tree, commit, err := applySource(source)
+	record.Commit = commit
+	if err == nil {
+		record.Pruned, err = pruneSource(source, commit, tree)
+	}
+	record.FinishedAt = time.Now().UTC()
+	switch {
+	case err != nil:
+		record.Phase = PhaseFailed
+		record.Message = err.Error()
+		var phaseErr *k8s.PhaseError
+		if errors.As(err, &phaseErr) {
+			record.FailedPhase = phaseErr.Phase
+		}
+		record.DeploymentTree = tree
+	case len(tree.Conflicts) > 0:
+		record.Phase = PhaseConflicts
+		record.Message = fmt.Sprintf("%d resource(s) not applied because other managers own their fields", len(tree.Conflicts))
+		record.DeploymentTree = tree
+	default:
+		record.Phase = PhaseSynced
+		record.DeploymentTree = tree
+	}
+
+	if storeErr := recordSync(record); storeErr != nil {
+		log.Printf("gitops: failed to record sync for %s: %v", source.Name, storeErr)
+	}
+	return record, err
+}
+
+func applySource(source *Source) (*k8s.DeploymentTree, string, error) {
+	checkout, err := Clone(source)
+	if err != nil {
+		return nil, "", err
+	}
+	defer checkout.Cleanup()
+
+	deployPath, err := checkout.DeployPath(source.Path)
+	if err != nil {
+		return nil, checkout.Commit, err
+	}
+
+	// Syncs are not tied to a UI user, so they run with the server's own identity
+	tree, err := k8s.DeployManifests(k8s.AsServer(context.Background()), deployPath, false, "", source.WorkloadLabel, k8s.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        source.Force,
+	})
+	if err != nil {
+		return nil, checkout.Commit, fmt.Errorf("deployment failed: %w", err)
+	}
+	return tree, checkout.Commit, nil
+}
+
+// pruneSource deletes the objects of the previous inventory that the new commit no longer
+// produces, then records the new inventory. The old inventory is kept when pruning fails
+// so the next sync retries it.
+func pruneSource(source *Source, commit string, tree *k8s.DeploymentTree) ([]k8s.AppliedObject, error) {
+	previous, err := GetInventory(source.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []k8s.AppliedObject
+	if previous != nil {
+		pruned, err = k8s.PruneWorkload(source.WorkloadLabel, previous.Objects, tree)
+		if err != nil {
+			return pruned, fmt.Errorf("prune failed: %v", err)
+		}
+	}
+	return pruned, RecordInventory(source.Name, commit, tree)
+}
+
+// RecordInventory stores the objects a commit of the source produced, including those skipped
+// because of field conflicts
+func RecordInventory(name, commit string, tree *k8s.DeploymentTree) error {
+	inventory := Inventory{
+		Source:    name,
+		Commit:    commit,
+		Objects:   tree.InventoryObjects(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := redis.SetJSONHash(inventoryHashKey, name, inventory); err != nil {
+		return fmt.Errorf("failed to store inventory of source %s: %v", name, err)
+	}
+	return nil
+}
+
+// GetInventory returns the objects the last successful sync of a source produced, or nil if there is none
+func GetInventory(name string) (*Inventory, error) {
+	var inventory Inventory
+	found, err := redis.GetJSONHash(inventoryHashKey, name, &inventory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inventory of source %s: %v", name, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &inventory, nil
+}
+
+func recordSync(record *SyncRecord) error {
+	if err := redis.SetJSONHash(statusHashKey, record.Source, record); err != nil {
+		return err
+	}
+	return redis.PushJSONList(historyKey(record.Source), record, historyLimit)
+}
+
+// GetStatus returns the last sync record of a source, or nil if it never synced
+func GetStatus(name string) (*SyncRecord, error) {
+	var record SyncRecord
+	found, err := redis.GetJSONHash(statusHashKey, name, &record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load status of source %s: %v", name, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// GetHistory returns up to limit sync records of a source, newest first
+func GetHistory(name string, limit int) ([]SyncRecord, error) {
+	if limit <= 0 || limit > historyLimit {
+		limit = historyLimit
+	}
+	values, err := redis.GetJSONList(historyKey(name), 0, int64(limit-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history of source %s: %v", name, err)
+	}
+
+	history := make([]SyncRecord, 0, len(values))
+	for _, raw := range values {
+		var record SyncRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse history of source %s: %v", name, err)
+		}
+		history = append(history, record)
+	}
+	return history, nil
+}
+
+// FindCommit returns the most recent sync of a commit of the source; short commit IDs are accepted
+func FindCommit(name, commit string) (*SyncRecord, error) {
+	history, err := GetHistory(name, historyLimit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		if commit != "" && strings.HasPrefix(history[i].Commit, commit) {
+			return &history[i], nil
+		}
+	}
+	return nil, nil
+}
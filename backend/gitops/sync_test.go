@@ -0,0 +1,36 @@
+package gitops
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/redis"
+)
+
+func TestRecordInventory(t *testing.T) {
+	redis.Configure(config.RedisConfig{Address: miniredis.RunT(t).Addr()})
+	web := k8s.AppliedObject{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "demo", Name: "web"}
+	tree := &k8s.DeploymentTree{
+		Objects:   []k8s.AppliedObject{web},
+		Conflicts: []k8s.ResourceConflict{{Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespace: "demo", Name: "settings"}},
+	}
+
+	if err := RecordInventory("project", "abc123", tree); err != nil {
+		t.Fatalf("RecordInventory failed: %v", err)
+	}
+	inventory, err := GetInventory("project")
+	if err != nil || inventory == nil {
+		t.Fatalf("GetInventory() = %v, %v", inventory, err)
+	}
+	want := []k8s.AppliedObject{web, {Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespace: "demo", Name: "settings"}}
+	if inventory.Commit != "abc123" || !reflect.DeepEqual(inventory.Objects, want) {
+		t.Errorf("inventory = %+v, want commit abc123 with the applied and the conflicted object", inventory)
+	}
+
+	if missing, err := GetInventory("other"); missing != nil || err != nil {
+		t.Errorf("GetInventory of a source without one = %v, %v", missing, err)
+	}
+}
@@ -0,0 +1,187 @@
+package gitops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PushEvent is a provider-neutral view of a git push webhook
+type PushEvent struct {
+	RepoURL string       `json:"repo_url"`
+	Branch  string       `json:"branch"`
+	Commits []PushCommit `json:"commits"`
+	// FilesUnknown is set when the provider does not list changed files (Bitbucket),
+	// in which case every push to the branch is treated as relevant
+	FilesUnknown bool `json:"files_unknown,omitempty"`
+}
+
+// PushCommit is a single commit of a push event
+type PushCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	URL      string   `json:"url"`
+	Added    []string `json:"added,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// FileChanges is the net effect of a push on the files of a folder
+type FileChanges struct {
+	Added    []string `json:"added,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// Empty reports whether no file changed
+func (f *FileChanges) Empty() bool {
+	return len(f.Added) == 0 && len(f.Modified) == 0 && len(f.Removed) == 0
+}
+
+// All returns every changed file
+func (f *FileChanges) All() []string {
+	all := append([]string{}, f.Added...)
+	all = append(all, f.Modified...)
+	return append(all, f.Removed...)
+}
+
+// PushResult is the outcome of handling a push event for one source
+type PushResult struct {
+	Source       string       `json:"source"`
+	Synced       bool         `json:"synced"`
+	Message      string       `json:"message"`
+	ChangedFiles []string     `json:"changed_files,omitempty"`
+	Changes      *FileChanges `json:"changes,omitempty"`
+	Record       *SyncRecord  `json:"sync,omitempty"`
+}
+
+// HeadCommit returns the ID of the last commit of the push, if any
+func (e *PushEvent) HeadCommit() string {
+	if len(e.Commits) == 0 {
+		return ""
+	}
+	return e.Commits[len(e.Commits)-1].ID
+}
+
+// FileChanges returns the net changes the push made under path. Commits are replayed in
+// order, so a file added and then removed within the same push is not reported, and a
+// rename shows up as the old name removed and the new name added.
+func (e *PushEvent) FileChanges(path string) *FileChanges {
+	const (
+		added    = "added"
+		modified = "modified"
+		removed  = "removed"
+	)
+
+	state := make(map[string]string)
+	listed := make(map[string]bool)
+	var order []string
+	mark := func(file, change string) {
+		if !inPath(file, path) {
+			return
+		}
+		if !listed[file] {
+			listed[file] = true
+			order = append(order, file)
+		}
+		previous := state[file]
+		switch {
+		case previous == added && change == removed:
+			delete(state, file)
+			return
+		case previous == added && change == modified:
+			return
+		case previous == removed && change == added:
+			change = modified
+		}
+		state[file] = change
+	}
+
+	for _, commit := range e.Commits {
+		for _, file := range commit.Removed {
+			mark(file, removed)
+		}
+		for _, file := range commit.Added {
+			mark(file, added)
+		}
+		for _, file := range commit.Modified {
+			mark(file, modified)
+		}
+	}
+
+	changes := &FileChanges{}
+	for _, file := range order {
+		switch state[file] {
+		case added:
+			changes.Added = append(changes.Added, file)
+		case modified:
+			changes.Modified = append(changes.Modified, file)
+		case removed:
+			changes.Removed = append(changes.Removed, file)
+		}
+	}
+	return changes
+}
+
+// inPath reports whether a repository file lives in the folder, or is the folder itself
+func inPath(file, path string) bool {
+	if path == "" {
+		return true
+	}
+	return file == path || strings.HasPrefix(file, path+"/")
+}
+
+// HandlePush syncs the source when the push adds, modifies or removes files in its folder.
+// The sync applies the folder as a whole and prunes objects whose manifests were removed.
+func HandlePush(source *Source, event *PushEvent) (*PushResult, error) {
+	result := &PushResult{Source: source.Name}
+
+	if event.Branch != source.Branch {
+		result.Message = fmt.Sprintf("Ignoring push to branch '%s'. Configured branch is '%s'", event.Branch, source.Branch)
+		return result, nil
+	}
+
+	if source.Path == "" || event.FilesUnknown {
+		if len(event.Commits) == 0 {
+			result.Message = "No commits in push event"
+			return result, nil
+		}
+	} else {
+		result.Changes = event.FileChanges(source.Path)
+		result.ChangedFiles = result.Changes.All()
+		if result.Changes.Empty() {
+			result.Message = "No relevant changes detected in the specified folder path"
+			return result, nil
+		}
+	}
+
+	record, err := SyncSource(source, TriggerWebhook)
+	result.Record = record
+	if err != nil {
+		return result, err
+	}
+	result.Synced = true
+	result.Message = "Webhook deployment successful"
+	return result, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// SourceNameFor derives a valid source name from the host and path of a repository URL,
+// e.g. https://github.com/org/project.git becomes github-com-org-project, so that same-named
+// repositories of different owners or hosts get different sources. Names longer than 63
+// characters are shortened and suffixed with a hash of the full name.
+func SourceNameFor(repoURL string) string {
+	name := invalidNameChars.ReplaceAllString(normalizeRepoURL(repoURL), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		sum := sha256.Sum256([]byte(name))
+		name = strings.Trim(name[:54], "-") + "-" + hex.EncodeToString(sum[:])[:8]
+	}
+	if name == "" {
+		name = "default"
+	}
+	return name
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/k8s"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -57,10 +58,7 @@ func HomeDir() string {
 }
 
 func kubeconfigPath() string {
-	if path := os.Getenv("KUBECONFIG"); path != "" {
-		return path
-	}
-	return fmt.Sprintf("%s/.kube/config", HomeDir())
+	return k8s.KubeconfigPath()
 }
 
 func GetITSInfo() ([]ManagedClusterInfo, error) {
@@ -233,11 +231,7 @@ func GetKubeInfoHandler(c *gin.Context) {
 func GetKubeInfo() ([]ContextInfo, []string, string, error, []ManagedClusterInfo) {
 	kubeconfig := kubeconfigPath()
 	// Log which kubeconfig is being used.
-	if os.Getenv("KUBECONFIG") == "" {
-		log.Printf("Using default kubeconfig path: %s", kubeconfig)
-	} else {
-		log.Printf("Using kubeconfig from environment: %s", kubeconfig)
-	}
+	log.Printf("Using kubeconfig: %s", kubeconfig)
 
 	config, err := clientcmd.LoadFromFile(kubeconfig)
 	if err != nil {
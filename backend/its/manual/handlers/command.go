@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/k8s"
 )
 
 // GenerateCommandRequest represents the request payload.
@@ -38,7 +39,7 @@ func GenerateCommandHandler(c *gin.Context) {
 	defer cancel()
 
 	// Run the command to get the token.
-	cmd := exec.CommandContext(ctx, "clusteradm", "--context", "its1", "get", "token")
+	cmd := exec.CommandContext(ctx, "clusteradm", "--context", k8s.ITSContext(), "get", "token")
 	output, err := cmd.CombinedOutput()
 	outputStr := strings.TrimSpace(string(output))
 	if err != nil {
@@ -66,12 +67,12 @@ func GenerateCommandHandler(c *gin.Context) {
 
 	// Build the join command.
 	joinCommand := fmt.Sprintf(
-		"clusteradm join --hub-token %s --hub-apiserver https://its1.localtest.me:9443 --cluster-name %s --force-internal-endpoint-lookup",
-		token, req.ClusterName,
+		"clusteradm join --hub-token %s --hub-apiserver %s --cluster-name %s --force-internal-endpoint-lookup",
+		token, k8s.HubAPIServer(), req.ClusterName,
 	)
 
 	// Build the accept command.
-	acceptCommand := fmt.Sprintf("clusteradm accept --context its1 --clusters %s", req.ClusterName)
+	acceptCommand := fmt.Sprintf("clusteradm accept --context %s --clusters %s", k8s.ITSContext(), req.ClusterName)
 
 	// Prepare the response.
 	response := GenerateCommandResponse{
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kubestellar/ui/k8s"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -37,11 +38,7 @@ func HomeDir() string {
 
 // GetITSInfo retrieves clusters already imported into ITS by querying the managedclusters API.
 func GetITSInfo() ([]ManagedClusterInfo, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = fmt.Sprintf("%s/.kube/config", HomeDir())
-	}
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	config, err := clientcmd.BuildConfigFromFlags("", k8s.KubeconfigPath())
 	if err != nil {
 		return nil, err
 	}
@@ -90,13 +87,8 @@ func GetITSInfo() ([]ManagedClusterInfo, error) {
 // GetAvailableClusters reads the kubeconfig and returns a slice of ContextInfo for clusters
 // that do NOT match the "*-kubeflex" pattern and are not already imported into ITS.
 func GetAvailableClusters() ([]ContextInfo, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = fmt.Sprintf("%s/.kube/config", HomeDir())
-		log.Printf("Using default kubeconfig path: %s", kubeconfig)
-	} else {
-		log.Printf("Using kubeconfig from environment: %s", kubeconfig)
-	}
+	kubeconfig := k8s.KubeconfigPath()
+	log.Printf("Using kubeconfig: %s", kubeconfig)
 
 	config, err := clientcmd.LoadFromFile(kubeconfig)
 	if err != nil {
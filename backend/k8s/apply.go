@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultFieldManager is the field manager name used for server-side apply when the caller does not set one
+const DefaultFieldManager = "kubestellar-ui"
+
+// ApplyOptions controls how objects are written with server-side apply
+type ApplyOptions struct {
+	FieldManager string `json:"fieldManager"`
+	Force        bool   `json:"force"`
+}
+
+// FieldConflict is a single field that another manager owns
+type FieldConflict struct {
+	Field   string `json:"field"`
+	Manager string `json:"manager"`
+	Message string `json:"message"`
+}
+
+// ResourceConflict lists the field conflicts server-side apply reported for one object. The
+// group, version and resource are set by deployments, which keep the object in their inventory.
+type ResourceConflict struct {
+	Group     string          `json:"group,omitempty"`
+	Version   string          `json:"version,omitempty"`
+	Resource  string          `json:"resource,omitempty"`
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace,omitempty"`
+	Conflicts []FieldConflict `json:"conflicts"`
+}
+
+var conflictManagerRegex = regexp.MustCompile(`conflict with "([^"]+)"`)
+
+// ApplyOptionsFromRequest reads the field_manager and force query parameters
+func ApplyOptionsFromRequest(c *gin.Context) ApplyOptions {
+	force := c.Query("force")
+	return ApplyOptions{
+		FieldManager: c.DefaultQuery("field_manager", DefaultFieldManager),
+		Force:        strings.EqualFold(force, "true") || force == "1",
+	}
+}
+
+// serverSideApply applies the object with the given field manager. When the API server
+// rejects the request because other managers own some of the fields, the conflicts are
+// returned instead of an error so callers can report them per resource.
+func serverSideApply(ctx context.Context, resource dynamic.ResourceInterface, obj *unstructured.Unstructured,
+	opts ApplyOptions, dryRun bool) (*unstructured.Unstructured, *ResourceConflict, error) {
+	fieldManager := opts.FieldManager
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	applyOpts := v1.ApplyOptions{
+		FieldManager: fieldManager,
+		Force:        opts.Force,
+	}
+	if dryRun {
+		applyOpts.DryRun = []string{v1.DryRunAll}
+	}
+
+	result, err := resource.Apply(ctx, obj.GetName(), obj, applyOpts)
+	if err != nil {
+		if conflicts := extractFieldConflicts(err); len(conflicts) > 0 {
+			return nil, &ResourceConflict{
+				Kind:      obj.GetKind(),
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+				Conflicts: conflicts,
+			}, nil
+		}
+		return nil, nil, err
+	}
+	return result, nil, nil
+}
+
+// extractFieldConflicts turns the causes of an apply conflict error into FieldConflicts
+func extractFieldConflicts(err error) []FieldConflict {
+	if !errors.IsConflict(err) {
+		return nil
+	}
+	statusErr, ok := err.(errors.APIStatus)
+	if !ok || statusErr.Status().Details == nil {
+		return nil
+	}
+
+	var conflicts []FieldConflict
+	for _, cause := range statusErr.Status().Details.Causes {
+		if cause.Type != v1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		manager := ""
+		if match := conflictManagerRegex.FindStringSubmatch(cause.Message); len(match) == 2 {
+			manager = match[1]
+		}
+		conflicts = append(conflicts, FieldConflict{
+			Field:   cause.Field,
+			Manager: manager,
+			Message: cause.Message,
+		})
+	}
+	return conflicts
+}
@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestApplyOptionsFromRequest(t *testing.T) {
+	tests := []struct {
+		query string
+		want  ApplyOptions
+	}{
+		{query: "", want: ApplyOptions{FieldManager: DefaultFieldManager}},
+		{query: "field_manager=ci&force=true", want: ApplyOptions{FieldManager: "ci", Force: true}},
+		{query: "force=1", want: ApplyOptions{FieldManager: DefaultFieldManager, Force: true}},
+		{query: "force=yes", want: ApplyOptions{FieldManager: DefaultFieldManager}},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/resources?"+tt.query, nil)
+		if got := ApplyOptionsFromRequest(c); got != tt.want {
+			t.Errorf("ApplyOptionsFromRequest(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestExtractFieldConflicts(t *testing.T) {
+	conflictErr := errors.NewApplyConflict([]v1.StatusCause{
+		{Type: v1.CauseTypeFieldManagerConflict, Field: ".spec.replicas", Message: `conflict with "kubectl-client-side-apply" using apps/v1`},
+		{Type: v1.CauseTypeFieldManagerConflict, Field: ".data.mode", Message: "conflict without a manager"},
+		{Type: v1.CauseTypeFieldValueInvalid, Field: ".spec", Message: "not a conflict"},
+	}, "Apply failed with 2 conflicts")
+
+	want := []FieldConflict{
+		{Field: ".spec.replicas", Manager: "kubectl-client-side-apply", Message: `conflict with "kubectl-client-side-apply" using apps/v1`},
+		{Field: ".data.mode", Message: "conflict without a manager"},
+	}
+	if got := extractFieldConflicts(conflictErr); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractFieldConflicts() = %+v, want %+v", got, want)
+	}
+	if got := extractFieldConflicts(fmt.Errorf("connection refused")); got != nil {
+		t.Errorf("extractFieldConflicts() of another error = %+v, want none", got)
+	}
+}
+
+func TestServerSideApply(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+	}}
+
+	tests := []struct {
+		name         string
+		err          error
+		wantConflict bool
+		wantErr      bool
+	}{
+		{name: "applied"},
+		{name: "conflict", err: errors.NewApplyConflict([]v1.StatusCause{
+			{Type: v1.CauseTypeFieldManagerConflict, Field: ".data.mode", Message: `conflict with "helm"`},
+		}, "Apply failed with 1 conflict"), wantConflict: true},
+		{name: "other error", err: errors.NewForbidden(gvr.GroupResource(), "settings", fmt.Errorf("denied")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{gvr: "ConfigMapList"})
+			var patchType types.PatchType
+			client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patchType = action.(k8stesting.PatchAction).GetPatchType()
+				if tt.err != nil {
+					return true, nil, tt.err
+				}
+				return true, obj.DeepCopy(), nil
+			})
+
+			result, conflict, err := serverSideApply(context.Background(), client.Resource(gvr).Namespace("default"), obj,
+				ApplyOptions{Force: true}, true)
+			if (err != nil) != tt.wantErr || (conflict != nil) != tt.wantConflict {
+				t.Fatalf("serverSideApply() = %v, %+v, %v", result, conflict, err)
+			}
+			if conflict != nil && (conflict.Name != "settings" || len(conflict.Conflicts) != 1 || conflict.Conflicts[0].Manager != "helm") {
+				t.Errorf("conflict = %+v, want the helm conflict on settings", conflict)
+			}
+			if patchType != types.ApplyPatchType {
+				t.Errorf("patch type = %s, want an apply patch", patchType)
+			}
+		})
+	}
+}
+
+func TestApplyOrCreateResourceStopsWhenCancelled(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+	}}
+
+	tests := []struct {
+		name      string
+		cancelled bool
+		wantCalls int
+	}{
+		{name: "retries transient errors", wantCalls: 5},
+		{name: "cancelled", cancelled: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{gvr: "ConfigMapList"})
+			calls := 0
+			client.PrependReactor("patch", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+				calls++
+				return true, nil, errors.NewServiceUnavailable("try again")
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			if _, err := applyOrCreateResource(ctx, client, gvr, obj, "default", false, "", ApplyOptions{}); err == nil {
+				t.Fatal("applyOrCreateResource() succeeded, want the apply error")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("applied %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
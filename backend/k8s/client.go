@@ -19,71 +19,46 @@ func homeDir() string {
 	return os.Getenv("USERPROFILE") // Windows
 }
 
-// GetClientSet retrieves a Kubernetes clientset and dynamic client
+// GetClientSet retrieves a Kubernetes clientset and dynamic client for the default WDS
 func GetClientSet() (*kubernetes.Clientset, dynamic.Interface, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		if home := homeDir(); home != "" {
-			kubeconfig = fmt.Sprintf("%s/.kube/config", home)
-		}
-	}
+	return GetClientSetWithContext(WDSContext())
+}
 
-	// Load the kubeconfig file
-	config, err := clientcmd.LoadFromFile(kubeconfig)
+// GetClientSetWithContext retrieves a Kubernetes clientset and dynamic client for a specified context
+func GetClientSetWithContext(contextName string) (*kubernetes.Clientset, dynamic.Interface, error) {
+	restConfig, err := restConfigForContext(contextName)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load kubeconfig: %v", err)
-	}
-
-	// Use WDS1 context specifically
-	ctxContext := config.Contexts["wds1"]
-	if ctxContext == nil {
-		return nil, nil, fmt.Errorf("failed to find context 'wds1'")
+		return nil, nil, err
 	}
+	return clientsForConfig(restConfig)
+}
 
-	// Create config for WDS cluster
-	clientConfig := clientcmd.NewDefaultClientConfig(
-		*config,
-		&clientcmd.ConfigOverrides{
-			CurrentContext: "wds1",
-		},
-	)
-
-	restConfig, err := clientConfig.ClientConfig()
+func GetClientSetWithConfigContext(contextName string) (*kubernetes.Clientset, *rest.Config, error) {
+	restConfig, err := restConfigForContext(contextName)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create restconfig: %v", err)
+		return nil, nil, err
 	}
 
 	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
 	}
-	dynamicClient, err := dynamic.NewForConfig(restConfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create dynamic client: %v", err)
-	}
 
-	return clientset, dynamicClient, nil
+	return clientset, restConfig, nil
 }
 
-// GetClientSetWithContext retrieves a Kubernetes clientset and dynamic client for a specified context
-func GetClientSetWithContext(contextName string) (*kubernetes.Clientset, dynamic.Interface, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		if home := homeDir(); home != "" {
-			kubeconfig = fmt.Sprintf("%s/.kube/config", home)
-		}
-	}
-
+// restConfigForContext loads the REST config of a kubeconfig context
+func restConfigForContext(contextName string) (*rest.Config, error) {
 	// Load the kubeconfig file
-	config, err := clientcmd.LoadFromFile(kubeconfig)
+	config, err := clientcmd.LoadFromFile(KubeconfigPath())
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
 	}
 
 	// Check if the specified context exists
 	ctxContext := config.Contexts[contextName]
 	if ctxContext == nil {
-		return nil, nil, fmt.Errorf("failed to find context '%s'", contextName)
+		return nil, fmt.Errorf("failed to find context '%s'", contextName)
 	}
 
 	// Create config for the specified context
@@ -96,9 +71,13 @@ func GetClientSetWithContext(contextName string) (*kubernetes.Clientset, dynamic
 
 	restConfig, err := clientConfig.ClientConfig()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create restconfig: %v", err)
+		return nil, fmt.Errorf("failed to create restconfig: %v", err)
 	}
+	return restConfig, nil
+}
 
+// clientsForConfig creates the clientset and dynamic client of a REST config
+func clientsForConfig(restConfig *rest.Config) (*kubernetes.Clientset, dynamic.Interface, error) {
 	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
@@ -110,44 +89,3 @@ func GetClientSetWithContext(contextName string) (*kubernetes.Clientset, dynamic
 
 	return clientset, dynamicClient, nil
 }
-
-func GetClientSetWithConfigContext(contextName string) (*kubernetes.Clientset, *rest.Config, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		if home := homeDir(); home != "" {
-			kubeconfig = fmt.Sprintf("%s/.kube/config", home)
-		}
-	}
-
-	// Load the kubeconfig file
-	config, err := clientcmd.LoadFromFile(kubeconfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load kubeconfig: %v", err)
-	}
-
-	// Check if the specified context exists
-	ctxContext := config.Contexts[contextName]
-	if ctxContext == nil {
-		return nil, nil, fmt.Errorf("failed to find context '%s'", contextName)
-	}
-
-	// Create config for the specified context
-	clientConfig := clientcmd.NewDefaultClientConfig(
-		*config,
-		&clientcmd.ConfigOverrides{
-			CurrentContext: contextName,
-		},
-	)
-
-	restConfig, err := clientConfig.ClientConfig()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create restconfig: %v", err)
-	}
-
-	clientset, err := kubernetes.NewForConfig(restConfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
-	}
-
-	return clientset, restConfig, nil
-}
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/config"
+)
+
+var (
+	clusterMu sync.RWMutex
+	cluster   = config.Default().Kubernetes
+)
+
+// Configure sets the kubeconfig and the control plane contexts the package talks to
+func Configure(cfg config.KubernetesConfig) {
+	clusterMu.Lock()
+	defer clusterMu.Unlock()
+	cluster = cfg
+}
+
+func clusterConfig() config.KubernetesConfig {
+	clusterMu.RLock()
+	defer clusterMu.RUnlock()
+	return cluster
+}
+
+// ITSContext returns the kubeconfig context of the Inventory and Transport Space
+func ITSContext() string {
+	return clusterConfig().ITSContext
+}
+
+// WDSContext returns the kubeconfig context of the default Workload Description Space
+func WDSContext() string {
+	return clusterConfig().WDSContext
+}
+
+// RequestWDSContext returns the WDS context a request selects with ?context= or the
+// ui-wds-context cookie set by the UI, defaulting to the configured WDS
+func RequestWDSContext(c *gin.Context) string {
+	if queryContext := c.Query("context"); queryContext != "" {
+		return queryContext
+	}
+	if cookieContext, err := c.Cookie("ui-wds-context"); err == nil && cookieContext != "" {
+		return cookieContext
+	}
+	return WDSContext()
+}
+
+// HubAPIServer returns the ITS API server URL given to clusters joining the hub
+func HubAPIServer() string {
+	return clusterConfig().HubAPIServer
+}
+
+// KubeconfigPath returns the configured kubeconfig file, defaulting to ~/.kube/config
+func KubeconfigPath() string {
+	if kubeconfig := clusterConfig().Kubeconfig; kubeconfig != "" {
+		return kubeconfig
+	}
+	if home := homeDir(); home != "" {
+		return fmt.Sprintf("%s/.kube/config", home)
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package k8s
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestWDSContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		cookie string
+		want   string
+	}{
+		{name: "default", want: WDSContext()},
+		{name: "cookie", cookie: "wds2", want: "wds2"},
+		{name: "query", query: "wds3", want: "wds3"},
+		{name: "query over cookie", query: "wds3", cookie: "wds2", want: "wds3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			target := "/api/bp"
+			if tt.query != "" {
+				target += "?context=" + tt.query
+			}
+			c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: "ui-wds-context", Value: tt.cookie})
+			}
+			if got := RequestWDSContext(c); got != tt.want {
+				t.Errorf("RequestWDSContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,24 +9,24 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/deployments"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
-	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/repo"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
-	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/yaml"
@@ -35,8 +35,6 @@ import (
 const (
 	// KubeStellarNamespace is the default namespace for KubeStellar deployments
 	KubeStellarNamespace = "kubestellar"
-	// DefaultContext is the default Kubernetes context for KubeStellar
-	DefaultContext = "wds1"
 	// GitHubConfigMapName is the ConfigMap name for storing GitHub repository data
 	GitHubConfigMapName = "kubestellar-github"
 	// HelmConfigMapName is the ConfigMap name for storing Helm chart data
@@ -48,7 +46,9 @@ const (
 // DeploymentTree represents the hierarchical response of deployed resources
 type DeploymentTree struct {
 	Namespace string                 `json:"namespace"`
-	Resources map[string]interface{} `json:"resources"` // Hierarchical resource mapping
+	Resources map[string]interface{} `json:"resources"`           // Hierarchical resource mapping
+	Conflicts []ResourceConflict     `json:"conflicts,omitempty"` // Resources skipped because other managers own their fields
+	Objects   []AppliedObject        `json:"objects,omitempty"`   // Every object the deployment wrote
 }
 
 // HelmDeploymentRequest represents the request payload for deploying a Helm chart
@@ -62,6 +62,7 @@ type HelmDeploymentRequest struct {
 	Version       string            `json:"version"`
 	Values        map[string]string `json:"values,omitempty"`
 	ConfigMaps    []ConfigMapRef    `json:"configMaps,omitempty"`
+	Context       string            `json:"context,omitempty"` // WDS context to install into, defaults to the configured WDS
 }
 
 // HelmDeploymentData represents data about a Helm deployment to be stored
@@ -77,6 +78,13 @@ type HelmDeploymentData struct {
 	ReleaseInfo  string                 `json:"releaseInfo"`
 	ChartVersion string                 `json:"chartVersion"`
 	Values       map[string]interface{} `json:"values,omitempty"`
+	// WorkloadLabel is re-applied to the resources of every upgrade
+	WorkloadLabel string `json:"workloadLabel,omitempty"`
+	// Context is the WDS context the release was installed into
+	Context string `json:"context,omitempty"`
+	// Revision is the current Helm revision of the release, Revisions the chain that led to it
+	Revision  int            `json:"revision,omitempty"`
+	Revisions []HelmRevision `json:"revisions,omitempty"`
 }
 
 // ConfigMapRef represents a reference to a ConfigMap for chart values
@@ -85,60 +93,47 @@ type ConfigMapRef struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
-// getResourceGVR dynamically fetches the correct GroupVersionResource (GVR) using the Discovery API
-func getResourceGVR(discoveryClient discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, error) {
-	resourceList, err := discoveryClient.ServerPreferredResources()
-	if err != nil {
-		return schema.GroupVersionResource{}, fmt.Errorf("failed to get API resources: %v", err)
-	}
-
-	for _, resourceGroup := range resourceList {
-		for _, resource := range resourceGroup.APIResources {
-			if strings.EqualFold(resource.Kind, kind) {
-				gv, err := schema.ParseGroupVersion(resourceGroup.GroupVersion)
-				if err != nil {
-					return schema.GroupVersionResource{}, err
-				}
-				return schema.GroupVersionResource{Group: gv.Group, Version: gv.Version, Resource: resource.Name}, nil
-			}
-		}
-	}
-	return schema.GroupVersionResource{}, fmt.Errorf("resource kind '%s' not found", kind)
-}
-
 // DeployManifests applies Kubernetes manifests from a directory with optional dry-run mode
-// and adds the specified workload label to all resources
-func DeployManifests(deployPath string, dryRun bool, dryRunStrategy string, workloadLabel string) (*DeploymentTree, error) {
-	clientSet, dynamicClient, err := GetClientSet()
+// and adds the specified workload label to all resources. The directory is read recursively,
+// multi-document files are split and kustomizations are rendered (see LoadManifests).
+// Objects are applied in phases: CRDs and Namespaces first, then, once the CRDs are
+// established and discovery is refreshed, RBAC, configuration and workloads. A failure is
+// returned as a *PhaseError. Objects are written with server-side apply; fields owned by
+// other managers are reported in tree.Conflicts unless applyOpts.Force is set. The objects
+// are written to the default WDS as the user of ctx.
+func DeployManifests(ctx context.Context, deployPath string, dryRun bool, dryRunStrategy string, workloadLabel string, applyOpts ApplyOptions) (*DeploymentTree, error) {
+	clientSet, dynamicClient, err := GetClientSetForRequest(ctx, WDSContext())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Kubernetes client: %v", err)
 	}
 
-	discoveryClient := clientSet.Discovery()
-	files, err := os.ReadDir(deployPath)
+	// Discovery is cached for the whole deployment and refreshed once new CRDs are established
+	discoveryClient := memory.NewMemCacheClient(clientSet.Discovery())
+	manifests, err := LoadManifests(deployPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read folder: %v", err)
+		return nil, err
 	}
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return phaseRank(manifests[i].Object.GetKind()) < phaseRank(manifests[j].Object.GetKind())
+	})
 
 	tree := &DeploymentTree{Resources: make(map[string]interface{})}
 	var detectedNamespace string
 	appliedResources := make(map[string][]string)
+	var crds crdPhase
 
-	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") {
-			continue
-		}
+	for _, manifest := range manifests {
+		obj := manifest.Object
+		phase := objectPhase(obj.GetKind())
 
-		filePath := filepath.Join(deployPath, file.Name())
-		data, err := os.ReadFile(filePath)
+		refresh, err := crds.finish(dynamicClient, obj.GetKind(), dryRun)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read manifest %s: %v", filePath, err)
+			return nil, err
 		}
-
-		var obj unstructured.Unstructured
-		if err := yaml.Unmarshal(data, &obj); err != nil {
-			return nil, fmt.Errorf("failed to parse YAML %s: %v", filePath, err)
+		if refresh {
+			discoveryClient.Invalidate()
 		}
+		crds.add(obj)
 
 		// Apply workload label to all resources if provided
 		if workloadLabel != "" {
@@ -160,45 +155,62 @@ func DeployManifests(deployPath string, dryRun bool, dryRunStrategy string, work
 		}
 
 		// Get correct resource GVR using Discovery API
-		gvr, err := getResourceGVR(discoveryClient, obj.GetKind())
+		gvr, isNamespaced, err := getGVR(discoveryClient, obj.GetKind())
 		if err != nil {
-			fmt.Printf("Skipping unsupported kind: %s\n", obj.GetKind())
-			continue
+			if dryRun && crds.definesKind(obj.GetKind()) {
+				// The CRD is not created by a dry run, so its custom resources cannot be validated
+				fmt.Printf("[Dry Run] Would apply %s %s once its CRD is established\n", obj.GetKind(), obj.GetName())
+				addToTree(tree, appliedResources, obj)
+				continue
+			}
+			return nil, newPhaseError(phase, obj, fmt.Errorf("kind %s from %s is not served by the cluster: %v", obj.GetKind(), manifest.Source, err))
 		}
 
-		// Detect namespace dynamically
-		namespace := obj.GetNamespace()
-		if namespace != "" {
-			detectedNamespace = namespace
-		}
+		// Cluster-scoped objects are applied without a namespace
+		finalNamespace := ""
+		if isNamespaced {
+			// Detect namespace dynamically
+			namespace := obj.GetNamespace()
+			if namespace != "" {
+				detectedNamespace = namespace
+			}
 
-		// Use detected namespace or fallback to "default"
-		finalNamespace := detectedNamespace
-		if finalNamespace == "" {
-			finalNamespace = "default"
-		}
+			// Use detected namespace or fallback to "default"
+			finalNamespace = detectedNamespace
+			if finalNamespace == "" {
+				finalNamespace = "default"
+			}
 
-		// Ensure namespace exists before applying resources
-		if !dryRun && obj.GetKind() != "Namespace" {
-			err = EnsureNamespaceExists(dynamicClient, finalNamespace, workloadLabel)
-			if err != nil {
-				return nil, fmt.Errorf("failed to ensure namespace %s exists: %v", finalNamespace, err)
+			// Ensure namespace exists before applying resources
+			if !dryRun {
+				err = EnsureNamespaceExists(dynamicClient, finalNamespace, workloadLabel)
+				if err != nil {
+					return nil, newPhaseError(phase, obj, fmt.Errorf("failed to ensure namespace %s exists: %v", finalNamespace, err))
+				}
 			}
 		}
 
 		// Apply or simulate resource application
-		err = applyOrCreateResource(dynamicClient, gvr, &obj, finalNamespace, dryRun, dryRunStrategy)
+		conflict, err := applyOrCreateResource(ctx, dynamicClient, gvr, obj, finalNamespace, dryRun, dryRunStrategy, applyOpts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to apply %s: %v", obj.GetKind(), err)
+			return nil, newPhaseError(phase, obj, fmt.Errorf("failed to apply %s from %s: %v", obj.GetKind(), manifest.Source, err))
 		}
-
-		// Organize in hierarchical structure
-		if _, exists := tree.Resources[obj.GetKind()]; !exists {
-			tree.Resources[obj.GetKind()] = []string{}
-			appliedResources[obj.GetKind()] = []string{}
+		if conflict != nil {
+			conflict.Group, conflict.Version, conflict.Resource = gvr.Group, gvr.Version, gvr.Resource
+			conflict.Namespace = finalNamespace
+			tree.Conflicts = append(tree.Conflicts, *conflict)
+			continue
 		}
-		tree.Resources[obj.GetKind()] = append(tree.Resources[obj.GetKind()].([]string), obj.GetName())
-		appliedResources[obj.GetKind()] = append(appliedResources[obj.GetKind()], obj.GetName())
+
+		addToTree(tree, appliedResources, obj)
+		tree.Objects = append(tree.Objects, AppliedObject{
+			Group:     gvr.Group,
+			Version:   gvr.Version,
+			Resource:  gvr.Resource,
+			Kind:      obj.GetKind(),
+			Namespace: finalNamespace,
+			Name:      obj.GetName(),
+		})
 	}
 
 	// Use detected namespace or "default" if none was found
@@ -210,6 +222,16 @@ func DeployManifests(deployPath string, dryRun bool, dryRunStrategy string, work
 	return tree, nil
 }
 
+// addToTree organizes an applied object in the hierarchical structure of the tree
+func addToTree(tree *DeploymentTree, appliedResources map[string][]string, obj *unstructured.Unstructured) {
+	if _, exists := tree.Resources[obj.GetKind()]; !exists {
+		tree.Resources[obj.GetKind()] = []string{}
+		appliedResources[obj.GetKind()] = []string{}
+	}
+	tree.Resources[obj.GetKind()] = append(tree.Resources[obj.GetKind()].([]string), obj.GetName())
+	appliedResources[obj.GetKind()] = append(appliedResources[obj.GetKind()], obj.GetName())
+}
+
 // Now applies the workload label to namespaces if provided
 func EnsureNamespaceExists(dynamicClient dynamic.Interface, namespace string, workloadLabel string) error {
 	// Skip for default namespace which always exists
@@ -282,49 +304,54 @@ func EnsureNamespaceExists(dynamicClient dynamic.Interface, namespace string, wo
 	return nil
 }
 
-// applyOrCreateResource applies or simulates applying a Kubernetes resource
-func applyOrCreateResource(dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, dryRun bool, dryRunStrategy string) error {
-	resource := dynamicClient.Resource(gvr).Namespace(namespace)
+// applyOrCreateResource applies or simulates applying a Kubernetes resource using server-side apply.
+// An empty namespace applies a cluster-scoped resource; retries stop once ctx is done.
+func applyOrCreateResource(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, namespace string, dryRun bool, dryRunStrategy string, applyOpts ApplyOptions) (*ResourceConflict, error) {
+	var resource dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+	if namespace != "" {
+		resource = dynamicClient.Resource(gvr).Namespace(namespace)
+	}
 
-	// If dry-run, simulate creation based on strategy
+	// If dry-run, simulate the apply based on strategy
 	if dryRun {
 		if dryRunStrategy == "server" {
 			fmt.Printf("[Server Dry Run] Validating %s %s on server\n", obj.GetKind(), obj.GetName())
 			// Use server-side dry run for validation
-			dryRunOpts := v1.CreateOptions{DryRun: []string{"All"}}
-			_, err := resource.Create(context.TODO(), obj, dryRunOpts)
+			_, conflict, err := serverSideApply(ctx, resource, obj, applyOpts, true)
 			if err != nil {
-				return fmt.Errorf("server validation failed for %s %s: %v", obj.GetKind(), obj.GetName(), err)
+				return nil, fmt.Errorf("server validation failed for %s %s: %v", obj.GetKind(), obj.GetName(), err)
+			}
+			if conflict != nil {
+				return conflict, nil
 			}
 			fmt.Printf("[Server Dry Run] Validated: %s %s\n", obj.GetKind(), obj.GetName())
 		} else {
 			// Client-side dry run (just log the action)
 			fmt.Printf("[Client Dry Run] Would apply %s %s in namespace %s\n", obj.GetKind(), obj.GetName(), namespace)
 		}
-		return nil
+		return nil, nil
 	}
 
-	// Retry logic for resilience
-	return retry.OnError(retry.DefaultRetry, func(err error) bool { return true }, func() error {
-		existing, err := resource.Get(context.TODO(), obj.GetName(), v1.GetOptions{})
-		if err == nil {
-			// Resource exists, update it
-			obj.SetResourceVersion(existing.GetResourceVersion()) // Keep the resource version
-			_, updateErr := resource.Update(context.TODO(), obj, v1.UpdateOptions{})
-			if updateErr != nil {
-				return fmt.Errorf("failed to update %s %s: %v", obj.GetKind(), obj.GetName(), updateErr)
-			}
-			fmt.Printf("Updated: %s %s\n", obj.GetKind(), obj.GetName())
-		} else {
-			// Resource doesn't exist, create it
-			_, createErr := resource.Create(context.TODO(), obj, v1.CreateOptions{})
-			if createErr != nil {
-				return fmt.Errorf("failed to create %s %s: %v", obj.GetKind(), obj.GetName(), createErr)
-			}
-			fmt.Printf("Created: %s %s\n", obj.GetKind(), obj.GetName())
+	// Retry logic for resilience; field conflicts are not transient so they are not retried
+	var conflict *ResourceConflict
+	retriable := func(err error) bool { return !errors.IsConflict(err) && ctx.Err() == nil }
+	err := retry.OnError(retry.DefaultRetry, retriable, func() error {
+		var applyErr error
+		_, conflict, applyErr = serverSideApply(ctx, resource, obj, applyOpts, false)
+		if applyErr != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), applyErr)
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if conflict != nil {
+		fmt.Printf("Conflict: %s %s has fields owned by other managers\n", obj.GetKind(), obj.GetName())
+		return conflict, nil
+	}
+	fmt.Printf("Applied: %s %s\n", obj.GetKind(), obj.GetName())
+	return nil, nil
 }
 
 // PrettyPrint prints JSON formatted output of DeploymentTree
@@ -337,67 +364,19 @@ func PrettyPrint(tree *DeploymentTree) {
 	fmt.Println(string(jsonData))
 }
 
-// Store Manifests deployment data to a ConfigMap
-func StoreManifestsDeployment(data map[string]string) error {
-	return storeConfigMapData("kubestellar-manifests", data)
+// StoreManifestsDeployment records a manifests deployment in the deployment history
+func StoreManifestsDeployment(deployment map[string]interface{}) error {
+	return saveDeployment(deployments.KindManifests, deployment)
 }
 
-// storeConfigMapData creates or updates a ConfigMap with the provided data
-func storeConfigMapData(configMapName string, data map[string]string) error {
-	// Ensure namespace exists first
-	clientset, dynamicClient, err := GetClientSetWithContext("its1")
-	if err != nil {
-		return fmt.Errorf("failed to get Kubernetes client: %v", err)
-	}
-
-	// Ensure the namespace exists (without workload label as this is an internal storage operation)
-	if err := EnsureNamespaceExists(dynamicClient, KubeStellarNamespace, ""); err != nil {
-		return fmt.Errorf("failed to ensure namespace for ConfigMap: %v", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Use retry for resilience against transient errors
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// Try to get existing ConfigMap
-		existing, err := clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Get(ctx, configMapName, v1.GetOptions{})
-		if err != nil {
-			if !errors.IsNotFound(err) {
-				return fmt.Errorf("failed to check if ConfigMap exists: %v", err)
-			}
-
-			// ConfigMap doesn't exist, create a new one
-			configMap := &corev1.ConfigMap{
-				ObjectMeta: v1.ObjectMeta{
-					Name:      configMapName,
-					Namespace: KubeStellarNamespace,
-				},
-				Data: data,
-			}
-
-			_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Create(ctx, configMap, v1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create ConfigMap: %v", err)
-			}
-			return nil
-		}
-
-		// ConfigMap exists, update it
-		existing.Data = data
-		_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Update(ctx, existing, v1.UpdateOptions{})
-		return err
-	})
-}
-
-// GetConfigMapData retrieves data from a ConfigMap
-func GetConfigMapData(contextName string, configMapName string) (map[string]string, error) {
-	clientset, _, err := GetClientSetWithContext(contextName)
+// GetConfigMapData retrieves data from a ConfigMap as the user of the parent context
+func GetConfigMapData(parent context.Context, contextName string, configMapName string) (map[string]string, error) {
+	clientset, _, err := GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Kubernetes client: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
 
 	configMap, err := clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Get(ctx, configMapName, v1.GetOptions{})
@@ -417,36 +396,41 @@ func mustMarshalToString(obj interface{}) string {
 	return string(jsonData)
 }
 
-// StoreHelmDeployment stores Helm deployment data as a new entry in a multi-deployment ConfigMap
+// StoreHelmDeployment records a Helm deployment as a new entry in the deployment history
 func StoreHelmDeployment(deploymentData map[string]string) error {
-	clientset, dynamicClient, err := GetClientSetWithContext("its1")
-	if err != nil {
-		return fmt.Errorf("failed to get Kubernetes client: %v", err)
-	}
-
-	// Ensure the namespace exists (without workload label for internal storage)
-	if err := EnsureNamespaceExists(dynamicClient, KubeStellarNamespace, ""); err != nil {
-		return fmt.Errorf("failed to ensure namespace for ConfigMap: %v", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	// Generate unique ID for the deployment
 	deploymentID := fmt.Sprintf("%s-%s-%s", deploymentData["releaseName"], deploymentData["namespace"], time.Now().Format("20060102150405"))
 
 	// Create proper structured deployment data
 	helmData := HelmDeploymentData{
-		ID:           deploymentID,
-		Timestamp:    deploymentData["timestamp"],
-		RepoName:     deploymentData["repoName"],
-		RepoURL:      deploymentData["repoURL"],
-		ChartName:    deploymentData["chartName"],
-		ReleaseName:  deploymentData["releaseName"],
-		Namespace:    deploymentData["namespace"],
-		Version:      deploymentData["version"],
-		ReleaseInfo:  deploymentData["releaseInfo"],
-		ChartVersion: deploymentData["chartVersion"],
+		ID:            deploymentID,
+		Timestamp:     deploymentData["timestamp"],
+		RepoName:      deploymentData["repoName"],
+		RepoURL:       deploymentData["repoURL"],
+		ChartName:     deploymentData["chartName"],
+		ReleaseName:   deploymentData["releaseName"],
+		Namespace:     deploymentData["namespace"],
+		Version:       deploymentData["version"],
+		ReleaseInfo:   deploymentData["releaseInfo"],
+		ChartVersion:  deploymentData["chartVersion"],
+		WorkloadLabel: deploymentData["workload_label"],
+		Context:       deploymentData["context"],
+	}
+
+	// Start the revision chain with the installed revision and the values it was installed with
+	if revision, err := strconv.Atoi(deploymentData["revision"]); err == nil {
+		installed := HelmRevision{
+			Revision:     revision,
+			Action:       HelmActionInstall,
+			ChartVersion: deploymentData["chartVersion"],
+			Status:       deploymentData["releaseInfo"],
+			Timestamp:    deploymentData["timestamp"],
+		}
+		if configStr := deploymentData["config"]; configStr != "" {
+			_ = json.Unmarshal([]byte(configStr), &installed.Values)
+		}
+		helmData.Revision = revision
+		helmData.Revisions = []HelmRevision{installed}
 	}
 
 	// Parse values if present
@@ -457,214 +441,61 @@ func StoreHelmDeployment(deploymentData map[string]string) error {
 		}
 	}
 
-	// Use retry for resilience against transient errors
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// Try to get existing ConfigMap
-		existing, err := clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Get(ctx, HelmConfigMapName, v1.GetOptions{})
-		if err != nil {
-			if !errors.IsNotFound(err) {
-				return fmt.Errorf("failed to check if ConfigMap exists: %v", err)
-			}
-
-			// ConfigMap doesn't exist, create a new one with initial deployment
-			deployments := []HelmDeploymentData{helmData}
-			deploymentsJSON, err := json.Marshal(deployments)
-			if err != nil {
-				return fmt.Errorf("failed to marshal deployments: %v", err)
-			}
-
-			configMap := &corev1.ConfigMap{
-				ObjectMeta: v1.ObjectMeta{
-					Name:      HelmConfigMapName,
-					Namespace: KubeStellarNamespace,
-				},
-				Data: map[string]string{
-					HelmDeploymentsKey: string(deploymentsJSON),
-				},
-			}
-
-			_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Create(ctx, configMap, v1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create ConfigMap: %v", err)
-			}
-			return nil
-		}
-
-		// ConfigMap exists, update it by adding the new deployment
-		var deployments []HelmDeploymentData
-
-		// Check if we already have deployments data
-		if deploymentsJSON, ok := existing.Data[HelmDeploymentsKey]; ok && deploymentsJSON != "" {
-			if err := json.Unmarshal([]byte(deploymentsJSON), &deployments); err != nil {
-				// If we can't parse existing data, start with an empty array
-				deployments = []HelmDeploymentData{}
-			}
-		} else {
-			// No existing deployments, initialize empty array
-			deployments = []HelmDeploymentData{}
-		}
-
-		// Add new deployment
-		deployments = append(deployments, helmData)
-
-		// Marshal updated deployments array
-		deploymentsJSON, err := json.Marshal(deployments)
-		if err != nil {
-			return fmt.Errorf("failed to marshal updated deployments: %v", err)
-		}
-
-		// Update ConfigMap
-		if existing.Data == nil {
-			existing.Data = make(map[string]string)
-		}
-		existing.Data[HelmDeploymentsKey] = string(deploymentsJSON)
-
-		_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Update(ctx, existing, v1.UpdateOptions{})
-		return err
-	})
-}
-
-func StoreGitHubDeployment(deploymentData map[string]string) error {
-	clientset, dynamicClient, err := GetClientSetWithContext("its1")
-	if err != nil {
-		return fmt.Errorf("failed to get Kubernetes client: %v", err)
-	}
-
-	// Ensure the namespace exists (without workload label for internal storage)
-	if err := EnsureNamespaceExists(dynamicClient, KubeStellarNamespace, ""); err != nil {
-		return fmt.Errorf("failed to ensure namespace for ConfigMap: %v", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Use retry for resilience against transient errors
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// Try to get existing ConfigMap
-		configMap, err := clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Get(ctx, GitHubConfigMapName, v1.GetOptions{})
-		if err != nil {
-			if !errors.IsNotFound(err) {
-				return fmt.Errorf("failed to check if ConfigMap exists: %v", err)
-			}
-
-			// ConfigMap doesn't exist, create a new one
-			configMap = &corev1.ConfigMap{
-				ObjectMeta: v1.ObjectMeta{
-					Name:      GitHubConfigMapName,
-					Namespace: KubeStellarNamespace,
-				},
-				Data: deploymentData,
-			}
-
-			_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Create(ctx, configMap, v1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create ConfigMap: %v", err)
-			}
-			return nil
-		}
-
-		// ConfigMap exists, update it
-		if configMap.Data == nil {
-			configMap.Data = make(map[string]string)
-		}
-
-		for key, value := range deploymentData {
-			configMap.Data[key] = value
-		}
-
-		_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Update(ctx, configMap, v1.UpdateOptions{})
-		return err
-	})
-}
-
-func GetGithubDeployments(contextName string) ([]any, error) {
-	configMapData, err := GetConfigMapData(contextName, GitHubConfigMapName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get GitHub ConfigMap: %v", err)
-	}
-	deploymentsJSON, ok := configMapData["deployments"]
-	if !ok || deploymentsJSON == "" {
-		return []any{}, nil // Return empty array, not an error
-	}
-	var deployments []any
-	if err := json.Unmarshal([]byte(deploymentsJSON), &deployments); err != nil {
-		return nil, fmt.Errorf("failed to parse deployments data: %v", err)
-	}
-	return deployments, nil
+	return saveDeployment(deployments.KindHelm, helmData)
 }
 
-// GetHelmDeployments retrieves all stored Helm deployments
-func GetHelmDeployments(contextName string) ([]HelmDeploymentData, error) {
-	configMapData, err := GetConfigMapData(contextName, HelmConfigMapName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get Helm ConfigMap: %v", err)
-	}
-
-	deploymentsJSON, ok := configMapData[HelmDeploymentsKey]
-	if !ok || deploymentsJSON == "" {
-		return []HelmDeploymentData{}, nil // Return empty array, not an error
-	}
-
-	var deployments []HelmDeploymentData
-	if err := json.Unmarshal([]byte(deploymentsJSON), &deployments); err != nil {
-		return nil, fmt.Errorf("failed to parse deployments data: %v", err)
-	}
-
-	return deployments, nil
+// RecordGitHubDeployment records a GitHub deployment as a new entry in the deployment history.
+// The deployment must have an id; timestamp, context, namespace and repo_url are indexed for filtering.
+func RecordGitHubDeployment(deployment map[string]interface{}) error {
+	return saveDeployment(deployments.KindGitHub, deployment)
 }
 
-// GetHelmDeploymentByID retrieves a specific Helm deployment by its ID
-func GetHelmDeploymentByID(contextName, deploymentID string) (*HelmDeploymentData, error) {
-	deployments, err := GetHelmDeployments(contextName)
+// GetGithubDeployments returns one page of the stored GitHub deployments matching the filter, newest first
+func GetGithubDeployments(filter deployments.Filter) ([]json.RawMessage, *deployments.Page, error) {
+	filter.Kind = deployments.KindGitHub
+	page, err := ListDeployments(filter)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	for _, deployment := range deployments {
-		if deployment.ID == deploymentID {
-			return &deployment, nil
-		}
+	result := make([]json.RawMessage, 0, len(page.Records))
+	for _, record := range page.Records {
+		result = append(result, record.Data)
 	}
-
-	return nil, fmt.Errorf("deployment with ID %s not found", deploymentID)
+	return result, page, nil
 }
 
-// GetHelmDeploymentsByRelease retrieves all deployments for a specific release name
-func GetHelmDeploymentsByRelease(contextName, releaseName string) ([]HelmDeploymentData, error) {
-	deployments, err := GetHelmDeployments(contextName)
+// GetHelmDeployments returns one page of the stored Helm deployments matching the filter, newest first
+func GetHelmDeployments(filter deployments.Filter) ([]HelmDeploymentData, *deployments.Page, error) {
+	filter.Kind = deployments.KindHelm
+	page, err := ListDeployments(filter)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	var filtered []HelmDeploymentData
-	for _, deployment := range deployments {
-		if deployment.ReleaseName == releaseName {
-			filtered = append(filtered, deployment)
+	result := make([]HelmDeploymentData, 0, len(page.Records))
+	for _, record := range page.Records {
+		var deployment HelmDeploymentData
+		if err := json.Unmarshal(record.Data, &deployment); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse deployment %s: %v", record.ID, err)
 		}
+		result = append(result, deployment)
 	}
-
-	return filtered, nil
+	return result, page, nil
 }
 
-// GetHelmDeploymentsByNamespace retrieves all deployments in a specific namespace
-func GetHelmDeploymentsByNamespace(contextName, namespace string) ([]HelmDeploymentData, error) {
-	deployments, err := GetHelmDeployments(contextName)
-	if err != nil {
+// GetHelmDeploymentByID retrieves a specific Helm deployment by its ID
+func GetHelmDeploymentByID(deploymentID string) (*HelmDeploymentData, error) {
+	var deployment HelmDeploymentData
+	if err := getDeployment(deployments.KindHelm, deploymentID, &deployment); err != nil {
 		return nil, err
 	}
-
-	var filtered []HelmDeploymentData
-	for _, deployment := range deployments {
-		if deployment.Namespace == namespace {
-			filtered = append(filtered, deployment)
-		}
-	}
-
-	return filtered, nil
+	return &deployment, nil
 }
 
-func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, error) {
-	ctx, cancel := context.WithCancel(context.Background())
+// DeployHelmChart installs a chart into the requested context as the user of parent
+func DeployHelmChart(parent context.Context, req HelmDeploymentRequest, store bool) (*release.Release, error) {
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
 	// If workload label is not provided, use the chart name as the default label
@@ -672,34 +503,12 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 		req.WorkloadLabel = req.ChartName
 	}
 
-	// Check current context first to avoid unnecessary switching
-	cmd := exec.CommandContext(ctx, "kubectl", "config", "current-context")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current context: %v", err)
-	}
-
-	currentContext := strings.TrimSpace(string(output))
-	needsContextSwitch := currentContext != "wds1"
-
-	// Only switch if needed
-	if needsContextSwitch {
-		cmd = exec.CommandContext(ctx, "kubectl", "config", "use-context", "wds1")
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("failed to switch to wds1 context: %v", err)
-		}
-
-		// Ensure the original context is restored after execution
-		defer func() {
-			restoreCmd := exec.CommandContext(ctx, "kubectl", "config", "use-context", currentContext)
-			if restoreErr := restoreCmd.Run(); restoreErr != nil {
-				fmt.Printf("Warning: failed to restore original context: %v\n", restoreErr)
-			}
-		}()
+	if req.Context == "" {
+		req.Context = WDSContext()
 	}
 
 	// Get Kubernetes client to check/create namespace
-	_, dynamicClient, err := GetClientSet()
+	_, dynamicClient, err := GetClientSetForRequest(parent, req.Context)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Kubernetes client: %v", err)
 	}
@@ -775,9 +584,12 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 		}
 	}
 
-	// Initialize Helm action configuration
+	// Initialize Helm action configuration against the requested context without touching the kubeconfig
 	actionConfig := new(action.Configuration)
-	settings := cli.New()
+	settings, err := HelmSettingsForRequest(parent, req.Context)
+	if err != nil {
+		return nil, err
+	}
 
 	// Use concurrent initialization where possible
 	initDone := make(chan error, 1)
@@ -856,10 +668,55 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 	}
 
 	// Prepare values for the chart
+	chartValues := buildChartValues(req.Values, req.WorkloadLabel)
+
+	// Set a reasonable timeout for the installation
+	install.Timeout = 4 * time.Minute
+
+	// Install the chart
+	release, err := install.Run(chartRes.chartObj, chartValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install chart: %v", err)
+	}
+
+	if store {
+		// Store deployment information in ConfigMap
+		helmDeployData := map[string]string{
+			"timestamp":      time.Now().Format(time.RFC3339),
+			"repoName":       req.RepoName,
+			"repoURL":        req.RepoURL,
+			"chartName":      req.ChartName,
+			"releaseName":    req.ReleaseName,
+			"namespace":      req.Namespace,
+			"version":        req.Version,
+			"releaseInfo":    release.Info.Status.String(),
+			"chartVersion":   release.Chart.Metadata.Version,
+			"values":         mustMarshalToString(release.Chart.Values),
+			"workload_label": req.WorkloadLabel,
+			"revision":       strconv.Itoa(release.Version),
+			"config":         mustMarshalToString(release.Config),
+			"context":        req.Context,
+		}
+
+		// Store deployment data in ConfigMap
+		err = StoreHelmDeployment(helmDeployData)
+		if err != nil {
+			fmt.Printf("Warning: failed to store Helm deployment data in ConfigMap: %v\n", err)
+		} else {
+			fmt.Printf("Helm deployment data stored in ConfigMap: %s\n", HelmConfigMapName)
+		}
+	}
+
+	return release, nil
+}
+
+// buildChartValues converts request values to chart values, parsing JSON objects and arrays,
+// and adds the workload label in the places common charts read labels from
+func buildChartValues(values map[string]string, workloadLabel string) map[string]interface{} {
 	chartValues := make(map[string]interface{})
 
 	// Convert string values to interface map
-	for k, v := range req.Values {
+	for k, v := range values {
 		// Try to parse JSON values
 		if strings.HasPrefix(v, "{") || strings.HasPrefix(v, "[") {
 			var jsonValue interface{}
@@ -903,7 +760,7 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 	}
 
 	// Add workload label
-	labelsMap["kubestellar.io/workload"] = req.WorkloadLabel
+	labelsMap["kubestellar.io/workload"] = workloadLabel
 	globalMap["labels"] = labelsMap
 	chartValues["global"] = globalMap
 
@@ -922,7 +779,7 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 	}
 
 	// Add workload label to commonLabels
-	commonLabelsMap["kubestellar.io/workload"] = req.WorkloadLabel
+	commonLabelsMap["kubestellar.io/workload"] = workloadLabel
 	chartValues["commonLabels"] = commonLabelsMap
 
 	// Approach 3: Add to podLabels if chart supports it
@@ -940,7 +797,7 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 	}
 
 	// Add workload label to podLabels
-	podLabelsMap["kubestellar.io/workload"] = req.WorkloadLabel
+	podLabelsMap["kubestellar.io/workload"] = workloadLabel
 	chartValues["podLabels"] = podLabelsMap
 
 	// Approach 4: Add as a top-level label
@@ -957,44 +814,10 @@ func DeployHelmChart(req HelmDeploymentRequest, store bool) (*release.Release, e
 		topLevelLabels = make(map[string]interface{})
 	}
 
-	topLevelLabels["kubestellar.io/workload"] = req.WorkloadLabel
+	topLevelLabels["kubestellar.io/workload"] = workloadLabel
 	chartValues["labels"] = topLevelLabels
 
-	// Set a reasonable timeout for the installation
-	install.Timeout = 4 * time.Minute
-
-	// Install the chart
-	release, err := install.Run(chartRes.chartObj, chartValues)
-	if err != nil {
-		return nil, fmt.Errorf("failed to install chart: %v", err)
-	}
-
-	if store {
-		// Store deployment information in ConfigMap
-		helmDeployData := map[string]string{
-			"timestamp":      time.Now().Format(time.RFC3339),
-			"repoName":       req.RepoName,
-			"repoURL":        req.RepoURL,
-			"chartName":      req.ChartName,
-			"releaseName":    req.ReleaseName,
-			"namespace":      req.Namespace,
-			"version":        req.Version,
-			"releaseInfo":    release.Info.Status.String(),
-			"chartVersion":   release.Chart.Metadata.Version,
-			"values":         mustMarshalToString(release.Chart.Values),
-			"workload_label": req.WorkloadLabel,
-		}
-
-		// Store deployment data in ConfigMap
-		err = StoreHelmDeployment(helmDeployData)
-		if err != nil {
-			fmt.Printf("Warning: failed to store Helm deployment data in ConfigMap: %v\n", err)
-		} else {
-			fmt.Printf("Helm deployment data stored in ConfigMap: %s\n", HelmConfigMapName)
-		}
-	}
-
-	return release, nil
+	return chartValues
 }
 
 // labelAddingPostRenderer is a post-renderer that adds labels to all resources
@@ -1090,6 +913,15 @@ func HelmDeployHandler(c *gin.Context) {
 		req.WorkloadLabel = req.ChartName
 	}
 
+	// Install into the WDS selected in the UI unless the request names one
+	if req.Context == "" {
+		if cookieContext, err := c.Cookie("ui-wds-context"); err == nil && cookieContext != "" {
+			req.Context = cookieContext
+		} else {
+			req.Context = WDSContext()
+		}
+	}
+
 	// Parse the "store" parameter from the query string
 	storeQuery := c.Query("store")
 	store := false
@@ -1098,7 +930,7 @@ func HelmDeployHandler(c *gin.Context) {
 	}
 
 	// Pass the parsed "store" parameter to deployHelmChart
-	release, err := DeployHelmChart(req, store)
+	release, err := DeployHelmChart(c.Request.Context(), req, store)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Deployment failed: %v", err)})
 		return
@@ -1111,68 +943,100 @@ func HelmDeployHandler(c *gin.Context) {
 		"version":        release.Chart.Metadata.Version,
 		"status":         release.Info.Status.String(),
 		"workload_label": req.WorkloadLabel,
+		"context":        req.Context,
 	}
 
 	// Include storage information in the response if "store" is true
 	if store {
-		response["stored_in"] = "kubestellar-helm ConfigMap"
+		response["stored_in"] = "deployment history"
+		if repo, err := DeploymentHistory(); err == nil {
+			response["stored_in"] = repo.Backend()
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// ListGithubDeployments handles API requests to list the stored GitHub deployments
 func ListGithubDeployments(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
-
-	deployments, err := GetGithubDeployments(contextName)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to retrieve deployments: %v", err)})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "GitHub deployments retrieved successfully",
-		"count":       len(deployments),
-		"deployments": deployments,
-	})
+	ListGithubDeploymentsHandler(c)
 }
 
 // ListHelmDeploymentsHandler handles API requests to list all Helm deployments
 func ListHelmDeploymentsHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
+	listHelmDeployments(c, "Helm deployments retrieved successfully", nil, nil)
+}
 
-	deployments, err := GetHelmDeployments(contextName)
+// ListGithubDeploymentsHandler handles API requests to list the stored GitHub deployments.
+// Results are filtered by the context, namespace, since and until query parameters and
+// paginated with limit and offset.
+func ListGithubDeploymentsHandler(c *gin.Context) {
+	filter, err := DeploymentFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, page, err := GetGithubDeployments(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to retrieve deployments: %v", err)})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":     "Helm deployments retrieved successfully",
-		"count":       len(deployments),
-		"deployments": deployments,
+		"message":     "GitHub deployments retrieved successfully",
+		"count":       len(items),
+		"total":       page.Total,
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"deployments": items,
 	})
 }
 
-func ListGithubDeploymentsHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
+// listHelmDeployments responds with one page of Helm deployments. Results are filtered by the
+// context, namespace, release, since and until query parameters, then by scope, and
+// paginated with limit and offset.
+func listHelmDeployments(c *gin.Context, message string, scope func(*deployments.Filter), extra gin.H) {
+	filter, err := DeploymentFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if scope != nil {
+		scope(&filter)
+	}
 
-	deployments, err := GetGithubDeployments(contextName)
+	items, page, err := GetHelmDeployments(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to retrieve deployments: %v", err)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "GitHub deployments retrieved successfully",
-		"count":       len(deployments),
-		"deployments": deployments,
-	})
+	response := gin.H{
+		"message":     message,
+		"count":       len(items),
+		"total":       page.Total,
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"deployments": redactHelmDeployments(items),
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// redactHelmDeployments returns copies of the deployments that are safe to return over the API
+func redactHelmDeployments(items []HelmDeploymentData) []HelmDeploymentData {
+	redacted := make([]HelmDeploymentData, len(items))
+	for i, item := range items {
+		redacted[i] = item.Redacted()
+	}
+	return redacted
 }
 
 // GetHelmDeploymentHandler handles API requests to get a specific Helm deployment by ID
 func GetHelmDeploymentHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
 	deploymentID := c.Param("id")
 
 	if deploymentID == "" {
@@ -1180,7 +1044,7 @@ func GetHelmDeploymentHandler(c *gin.Context) {
 		return
 	}
 
-	deployment, err := GetHelmDeploymentByID(contextName, deploymentID)
+	deployment, err := GetHelmDeploymentByID(deploymentID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Deployment not found: %v", err)})
 		return
@@ -1188,13 +1052,12 @@ func GetHelmDeploymentHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Helm deployment retrieved successfully",
-		"deployment": deployment,
+		"deployment": deployment.Redacted(),
 	})
 }
 
 // ListHelmDeploymentsByNamespaceHandler handles API requests to list deployments by namespace
 func ListHelmDeploymentsByNamespaceHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
 	namespace := c.Param("namespace")
 
 	if namespace == "" {
@@ -1202,23 +1065,12 @@ func ListHelmDeploymentsByNamespaceHandler(c *gin.Context) {
 		return
 	}
 
-	deployments, err := GetHelmDeploymentsByNamespace(contextName, namespace)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to retrieve deployments: %v", err)})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":     fmt.Sprintf("Helm deployments in namespace %s retrieved successfully", namespace),
-		"count":       len(deployments),
-		"namespace":   namespace,
-		"deployments": deployments,
-	})
+	listHelmDeployments(c, fmt.Sprintf("Helm deployments in namespace %s retrieved successfully", namespace),
+		func(filter *deployments.Filter) { filter.Namespace = namespace }, gin.H{"namespace": namespace})
 }
 
 // ListHelmDeploymentsByReleaseHandler handles API requests to list deployments by release name
 func ListHelmDeploymentsByReleaseHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
 	releaseName := c.Param("release")
 
 	if releaseName == "" {
@@ -1226,141 +1078,22 @@ func ListHelmDeploymentsByReleaseHandler(c *gin.Context) {
 		return
 	}
 
-	deployments, err := GetHelmDeploymentsByRelease(contextName, releaseName)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to retrieve deployments: %v", err)})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":     fmt.Sprintf("Helm deployments for release %s retrieved successfully", releaseName),
-		"count":       len(deployments),
-		"release":     releaseName,
-		"deployments": deployments,
-	})
+	listHelmDeployments(c, fmt.Sprintf("Helm deployments for release %s retrieved successfully", releaseName),
+		func(filter *deployments.Filter) { filter.Release = releaseName }, gin.H{"release": releaseName})
 }
 
 // DeleteHelmDeploymentByID deletes a specific Helm deployment by its ID
-func DeleteHelmDeploymentByID(contextName, deploymentID string) error {
-	clientset, _, err := GetClientSetWithContext(contextName)
-	if err != nil {
-		return fmt.Errorf("failed to get Kubernetes client: %v", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Use retry for resilience against transient errors
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// Try to get existing ConfigMap
-		configMap, err := clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Get(ctx, HelmConfigMapName, v1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to get ConfigMap: %v", err)
-		}
-
-		// Get current deployments
-		deploymentsJSON, ok := configMap.Data[HelmDeploymentsKey]
-		if !ok || deploymentsJSON == "" {
-			return fmt.Errorf("no deployments found in ConfigMap")
-		}
-
-		var deployments []HelmDeploymentData
-		if err := json.Unmarshal([]byte(deploymentsJSON), &deployments); err != nil {
-			return fmt.Errorf("failed to parse deployments data: %v", err)
-		}
-
-		// Find and remove the deployment with the given ID
-		foundIndex := -1
-		for i, deployment := range deployments {
-			if deployment.ID == deploymentID {
-				foundIndex = i
-				break
-			}
-		}
-
-		if foundIndex == -1 {
-			return fmt.Errorf("deployment with ID %s not found", deploymentID)
-		}
-
-		// Remove the deployment from the slice
-		deployments = append(deployments[:foundIndex], deployments[foundIndex+1:]...)
-
-		// Marshal updated deployments array
-		updatedDeploymentsJSON, err := json.Marshal(deployments)
-		if err != nil {
-			return fmt.Errorf("failed to marshal updated deployments: %v", err)
-		}
-
-		// Update ConfigMap
-		configMap.Data[HelmDeploymentsKey] = string(updatedDeploymentsJSON)
-
-		_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Update(ctx, configMap, v1.UpdateOptions{})
-		return err
-	})
+func DeleteHelmDeploymentByID(deploymentID string) error {
+	return deleteDeployment(deployments.KindHelm, deploymentID)
 }
 
 // DeleteGitHubDeploymentByID deletes a specific GitHub deployment by its ID
-func DeleteGitHubDeploymentByID(contextName, deploymentID string) error {
-	clientset, _, err := GetClientSetWithContext(contextName)
-	if err != nil {
-		return fmt.Errorf("failed to get Kubernetes client: %v", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Use retry for resilience against transient errors
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// Try to get existing ConfigMap
-		configMap, err := clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Get(ctx, GitHubConfigMapName, v1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to get ConfigMap: %v", err)
-		}
-
-		// Get current deployments
-		deploymentsJSON, ok := configMap.Data["deployments"]
-		if !ok || deploymentsJSON == "" {
-			return fmt.Errorf("no deployments found in ConfigMap")
-		}
-
-		var deployments []map[string]interface{}
-		if err := json.Unmarshal([]byte(deploymentsJSON), &deployments); err != nil {
-			return fmt.Errorf("failed to parse deployments data: %v", err)
-		}
-
-		// Find and remove the deployment with the given ID
-		foundIndex := -1
-		for i, deployment := range deployments {
-			if id, ok := deployment["id"].(string); ok && id == deploymentID {
-				foundIndex = i
-				break
-			}
-		}
-
-		if foundIndex == -1 {
-			return fmt.Errorf("deployment with ID %s not found", deploymentID)
-		}
-
-		// Remove the deployment from the slice
-		deployments = append(deployments[:foundIndex], deployments[foundIndex+1:]...)
-
-		// Marshal updated deployments array
-		updatedDeploymentsJSON, err := json.Marshal(deployments)
-		if err != nil {
-			return fmt.Errorf("failed to marshal updated deployments: %v", err)
-		}
-
-		// Update ConfigMap
-		configMap.Data["deployments"] = string(updatedDeploymentsJSON)
-
-		_, err = clientset.CoreV1().ConfigMaps(KubeStellarNamespace).Update(ctx, configMap, v1.UpdateOptions{})
-		return err
-	})
+func DeleteGitHubDeploymentByID(deploymentID string) error {
+	return deleteDeployment(deployments.KindGitHub, deploymentID)
 }
 
 // DeleteHelmDeploymentHandler handles API requests to delete a specific Helm deployment by ID
 func DeleteHelmDeploymentHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
 	deploymentID := c.Param("id")
 
 	if deploymentID == "" {
@@ -1368,7 +1101,7 @@ func DeleteHelmDeploymentHandler(c *gin.Context) {
 		return
 	}
 
-	err := DeleteHelmDeploymentByID(contextName, deploymentID)
+	err := DeleteHelmDeploymentByID(deploymentID)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
@@ -1386,7 +1119,6 @@ func DeleteHelmDeploymentHandler(c *gin.Context) {
 
 // DeleteGitHubDeploymentHandler handles API requests to delete a specific GitHub deployment by ID
 func DeleteGitHubDeploymentHandler(c *gin.Context) {
-	contextName := c.DefaultQuery("context", "its1")
 	deploymentID := c.Param("id")
 
 	if deploymentID == "" {
@@ -1394,7 +1126,7 @@ func DeleteGitHubDeploymentHandler(c *gin.Context) {
 		return
 	}
 
-	err := DeleteGitHubDeploymentByID(contextName, deploymentID)
+	err := DeleteGitHubDeploymentByID(deploymentID)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
@@ -0,0 +1,236 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// FieldChange describes a single field that differs between the live object and the dry-run result
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ResourceDiff is the preview of what applying one document would do to the WDS
+type ResourceDiff struct {
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace,omitempty"`
+	Operation string          `json:"operation"` // create, update or unchanged
+	Added     []FieldChange   `json:"added,omitempty"`
+	Changed   []FieldChange   `json:"changed,omitempty"`
+	Removed   []FieldChange   `json:"removed,omitempty"`
+	Conflicts []FieldConflict `json:"conflicts,omitempty"`
+	Message   string          `json:"message,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// ignoredDiffPaths are server-managed fields that change on every write and only add noise to a preview
+var ignoredDiffPaths = map[string]bool{
+	"status":                     true,
+	"metadata.managedFields":     true,
+	"metadata.resourceVersion":   true,
+	"metadata.generation":        true,
+	"metadata.uid":               true,
+	"metadata.creationTimestamp": true,
+}
+
+// isServerDryRun reports whether the request asks for a server-side dry-run instead of a real write
+func isServerDryRun(c *gin.Context) bool {
+	return strings.EqualFold(c.Query("dryRun"), "server")
+}
+
+// isAutoNamespaceRequested reports whether missing namespaces are created before the documents are applied
+func isAutoNamespaceRequested(c *gin.Context) bool {
+	autoNs := c.Query("auto_ns")
+	return strings.EqualFold(autoNs, "true") || autoNs == "1"
+}
+
+// isDiffRequested reports whether the caller wants a per-field diff in the dry-run response
+func isDiffRequested(c *gin.Context) bool {
+	diff := c.Query("diff")
+	return strings.EqualFold(diff, "true") || diff == "1"
+}
+
+// previewResources sends every document to the WDS as a server-side dry-run apply
+// and diffs the result against the live object. Errors are reported per document
+// so that a reviewer sees the outcome for the whole upload at once. Documents in a
+// namespace the apply would create first, from auto_ns or a Namespace document of
+// the upload, cannot be dry-run and are reported as creates.
+func previewResources(c *gin.Context, yamlDocs []map[string]interface{},
+	dynamicClient dynamic.Interface,
+	discoveryClient discovery.DiscoveryInterface) []ResourceDiff {
+	var diffs []ResourceDiff
+	opts := ApplyOptionsFromRequest(c)
+	autoNs := isAutoNamespaceRequested(c)
+
+	declared := map[string]bool{}
+	for _, resourceData := range yamlDocs {
+		docObj := &unstructured.Unstructured{Object: resourceData}
+		if docObj.GetKind() == "Namespace" && docObj.GetName() != "" {
+			declared[docObj.GetName()] = true
+		}
+	}
+	nsGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+	missing := map[string]bool{}
+	namespaceMissing := func(namespace string) bool {
+		if result, checked := missing[namespace]; checked {
+			return result
+		}
+		_, err := dynamicClient.Resource(nsGVR).Get(c, namespace, v1.GetOptions{})
+		missing[namespace] = errors.IsNotFound(err)
+		return missing[namespace]
+	}
+
+	for _, resourceData := range yamlDocs {
+		docObj := &unstructured.Unstructured{Object: resourceData}
+		if ns := docObj.GetNamespace(); ns != "" && ns != "default" && (autoNs || declared[ns]) && namespaceMissing(ns) {
+			if !declared[ns] {
+				declared[ns] = true
+				diffs = append(diffs, ResourceDiff{Kind: "Namespace", Name: ns, Operation: "create", Message: "created by auto_ns"})
+			}
+			diffs = append(diffs, ResourceDiff{
+				Kind:      docObj.GetKind(),
+				Name:      docObj.GetName(),
+				Namespace: ns,
+				Operation: "create",
+				Message:   fmt.Sprintf("namespace %s will be created first, so the object was not dry-run", ns),
+			})
+			continue
+		}
+
+		resource, resourceObj, err := prepareResource(resourceData, dynamicClient, discoveryClient)
+		if err != nil {
+			diffs = append(diffs, ResourceDiff{
+				Kind:  fmt.Sprintf("%v", resourceData["kind"]),
+				Error: err.Error(),
+			})
+			continue
+		}
+
+		diffs = append(diffs, previewResource(c, resource, resourceObj, func() (*unstructured.Unstructured, *ResourceConflict, error) {
+			return serverSideApply(c, resource, resourceObj, opts, true)
+		}))
+	}
+	return diffs
+}
+
+// previewResource runs the supplied dry-run write and diffs its result against the live object
+func previewResource(c *gin.Context, resource dynamic.ResourceInterface, obj *unstructured.Unstructured,
+	dryRunWrite func() (*unstructured.Unstructured, *ResourceConflict, error)) ResourceDiff {
+	diff := ResourceDiff{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+
+	var liveObject map[string]interface{}
+	live, err := resource.Get(c, obj.GetName(), v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			diff.Error = fmt.Sprintf("failed to get live object: %v", err)
+			return diff
+		}
+		diff.Operation = "create"
+	} else {
+		liveObject = live.Object
+		diff.Operation = "update"
+	}
+
+	result, conflict, err := dryRunWrite()
+	if err != nil {
+		diff.Error = fmt.Sprintf("server dry-run failed: %v", err)
+		return diff
+	}
+	if conflict != nil {
+		diff.Conflicts = conflict.Conflicts
+		diff.Error = "apply would conflict with fields owned by other managers; retry with force=true to take ownership"
+		return diff
+	}
+	if result.GetNamespace() != "" {
+		diff.Namespace = result.GetNamespace()
+	}
+
+	diff.Added, diff.Changed, diff.Removed = diffObjects(liveObject, result.Object)
+	if diff.Operation == "update" && len(diff.Added) == 0 && len(diff.Changed) == 0 && len(diff.Removed) == 0 {
+		diff.Operation = "unchanged"
+	}
+	return diff
+}
+
+// diffObjects walks both objects and returns the added, changed and removed field paths
+func diffObjects(live, desired map[string]interface{}) (added, changed, removed []FieldChange) {
+	diffValues("", live, desired, &added, &changed, &removed)
+	sortChanges := func(changes []FieldChange) {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	}
+	sortChanges(added)
+	sortChanges(changed)
+	sortChanges(removed)
+	return added, changed, removed
+}
+
+func diffValues(path string, oldValue, newValue interface{}, added, changed, removed *[]FieldChange) {
+	if ignoredDiffPaths[path] {
+		return
+	}
+
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		for key, newChild := range newMap {
+			childPath := joinFieldPath(path, key)
+			if oldChild, exists := oldMap[key]; exists {
+				diffValues(childPath, oldChild, newChild, added, changed, removed)
+			} else if !ignoredDiffPaths[childPath] {
+				*added = append(*added, FieldChange{Path: childPath, New: newChild})
+			}
+		}
+		for key, oldChild := range oldMap {
+			childPath := joinFieldPath(path, key)
+			if _, exists := newMap[key]; !exists && !ignoredDiffPaths[childPath] {
+				*removed = append(*removed, FieldChange{Path: childPath, Old: oldChild})
+			}
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := oldValue.([]interface{})
+	newSlice, newIsSlice := newValue.([]interface{})
+	if oldIsSlice && newIsSlice {
+		for i := 0; i < len(oldSlice) || i < len(newSlice); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldSlice):
+				*added = append(*added, FieldChange{Path: childPath, New: newSlice[i]})
+			case i >= len(newSlice):
+				*removed = append(*removed, FieldChange{Path: childPath, Old: oldSlice[i]})
+			default:
+				diffValues(childPath, oldSlice[i], newSlice[i], added, changed, removed)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*changed = append(*changed, FieldChange{Path: path, Old: oldValue, New: newValue})
+	}
+}
+
+func joinFieldPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
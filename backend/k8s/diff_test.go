@@ -0,0 +1,195 @@
+package k8s
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestDiffObjects(t *testing.T) {
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"labels":          map[string]interface{}{"app": "web", "tier": "front"},
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"ports":    []interface{}{int64(80), int64(443)},
+		},
+		"status": map[string]interface{}{"ready": true},
+	}
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"labels":          map[string]interface{}{"app": "web", "team": "a"},
+			"resourceVersion": "2",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"ports":    []interface{}{int64(80)},
+			"paused":   false,
+		},
+		"status": map[string]interface{}{"ready": false},
+	}
+
+	added, changed, removed := diffObjects(live, desired)
+	wantAdded := []FieldChange{{Path: "metadata.labels.team", New: "a"}, {Path: "spec.paused", New: false}}
+	wantChanged := []FieldChange{{Path: "spec.replicas", Old: int64(1), New: int64(3)}}
+	wantRemoved := []FieldChange{{Path: "metadata.labels.tier", Old: "front"}, {Path: "spec.ports[1]", Old: int64(443)}}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("added = %v, want %v", added, wantAdded)
+	}
+	if !reflect.DeepEqual(changed, wantChanged) {
+		t.Errorf("changed = %v, want %v", changed, wantChanged)
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("removed = %v, want %v", removed, wantRemoved)
+	}
+
+	// A new object shows every field except the server-managed ones as added
+	added, changed, removed = diffObjects(nil, desired)
+	if len(added) != 2 || len(changed) != 0 || len(removed) != 0 {
+		t.Errorf("diff against no live object = %v, %v, %v; want metadata and spec added", added, changed, removed)
+	}
+}
+
+func TestPreviewResource(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	configMap := func(name, value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"data":       map[string]interface{}{"mode": value},
+		}}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, configMap("settings", "fast"))
+	resource := client.Resource(gvr).Namespace("default")
+
+	tests := []struct {
+		name        string
+		obj         *unstructured.Unstructured
+		dryRun      *unstructured.Unstructured
+		wantOp      string
+		wantChanged int
+	}{
+		{name: "create", obj: configMap("other", "fast"), dryRun: configMap("other", "fast"), wantOp: "create"},
+		{name: "update", obj: configMap("settings", "slow"), dryRun: configMap("settings", "slow"), wantOp: "update", wantChanged: 1},
+		{name: "unchanged", obj: configMap("settings", "fast"), dryRun: configMap("settings", "fast"), wantOp: "unchanged"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/resources?dryRun=server&diff=true", nil)
+			diff := previewResource(c, resource, tt.obj, func() (*unstructured.Unstructured, *ResourceConflict, error) {
+				return tt.dryRun, nil, nil
+			})
+			if diff.Error != "" {
+				t.Fatalf("unexpected error: %s", diff.Error)
+			}
+			if diff.Operation != tt.wantOp || len(diff.Changed) != tt.wantChanged {
+				t.Errorf("operation %s with %d changes, want %s with %d", diff.Operation, len(diff.Changed), tt.wantOp, tt.wantChanged)
+			}
+		})
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/resources", nil)
+	conflict := &ResourceConflict{Conflicts: []FieldConflict{{}}}
+	diff := previewResource(c, resource, configMap("settings", "slow"), func() (*unstructured.Unstructured, *ResourceConflict, error) {
+		return nil, conflict, nil
+	})
+	if diff.Error == "" || len(diff.Conflicts) != 1 {
+		t.Errorf("conflicting dry-run = %+v, want the conflicts reported", diff)
+	}
+}
+
+func TestPreviewResourcesPendingNamespaces(t *testing.T) {
+	nsGVR := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	document := func(kind, namespace, name string) map[string]interface{} {
+		metadata := map[string]interface{}{"name": name}
+		if namespace != "" {
+			metadata["namespace"] = namespace
+		}
+		return map[string]interface{}{"apiVersion": "v1", "kind": kind, "metadata": metadata}
+	}
+	existing := &unstructured.Unstructured{Object: document("Namespace", "", "existing")}
+
+	tests := []struct {
+		name  string
+		query string
+		docs  []map[string]interface{}
+		want  []string // Kind/namespace/name and operation, or error, of each diff
+	}{
+		{
+			name:  "auto_ns",
+			query: "dryRun=server&auto_ns=true",
+			docs:  []map[string]interface{}{document("ConfigMap", "new-ns", "a"), document("ConfigMap", "new-ns", "b"), document("ConfigMap", "existing", "c")},
+			want:  []string{"Namespace//new-ns create", "ConfigMap/new-ns/a create", "ConfigMap/new-ns/b create", "ConfigMap// error"},
+		},
+		{
+			name:  "namespace in the upload",
+			query: "dryRun=server",
+			docs:  []map[string]interface{}{document("Namespace", "", "team"), document("ConfigMap", "team", "a"), document("ConfigMap", "new-ns", "b")},
+			want:  []string{"Namespace// error", "ConfigMap/team/a create", "ConfigMap// error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{nsGVR: "NamespaceList"}, existing)
+			// Discovery serves nothing, so documents that are dry-run fail as unsupported
+			disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/resources?"+tt.query, nil)
+
+			var got []string
+			for _, diff := range previewResources(c, tt.docs, client, disco) {
+				outcome := diff.Operation
+				if diff.Error != "" {
+					outcome = "error"
+				} else if diff.Message == "" {
+					t.Errorf("pending %s %s has no message", diff.Kind, diff.Name)
+				}
+				got = append(got, diff.Kind+"/"+diff.Namespace+"/"+diff.Name+" "+outcome)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("previewResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantDryRun bool
+		wantDiff   bool
+	}{
+		{query: "", wantDryRun: false, wantDiff: false},
+		{query: "dryRun=server", wantDryRun: true, wantDiff: false},
+		{query: "dryRun=Server&diff=true", wantDryRun: true, wantDiff: true},
+		{query: "dryRun=client&diff=1", wantDryRun: false, wantDiff: true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/resources?"+tt.query, nil)
+		if got := isServerDryRun(c); got != tt.wantDryRun {
+			t.Errorf("isServerDryRun(%q) = %v, want %v", tt.query, got, tt.wantDryRun)
+		}
+		if got := isDiffRequested(c); got != tt.wantDiff {
+			t.Errorf("isDiffRequested(%q) = %v, want %v", tt.query, got, tt.wantDiff)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package k8s
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/deployments"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Helm actions recorded in a deployment's revision chain
+const (
+	HelmActionInstall  = "install"
+	HelmActionUpgrade  = "upgrade"
+	HelmActionRollback = "rollback"
+)
+
+// HelmRevision is one revision of a Helm deployment and the values it was made with
+type HelmRevision struct {
+	Revision     int                    `json:"revision"`
+	Action       string                 `json:"action"`
+	ChartVersion string                 `json:"chartVersion"`
+	Status       string                 `json:"status"`
+	Timestamp    string                 `json:"timestamp"`
+	Values       map[string]interface{} `json:"values,omitempty"`
+	RolledBackTo int                    `json:"rolledBackTo,omitempty"` // Revision restored by a rollback
+}
+
+// HelmUpgradeRequest represents the request payload for upgrading a Helm deployment
+type HelmUpgradeRequest struct {
+	Version     string            `json:"version,omitempty"` // Chart version; empty upgrades to the latest
+	Values      map[string]string `json:"values,omitempty"`
+	ReuseValues bool              `json:"reuseValues"` // Merge Values into the values of the current revision
+}
+
+// HelmHistoryEntry is a revision of a release as Helm reports it
+type HelmHistoryEntry struct {
+	Revision     int                    `json:"revision"`
+	Status       string                 `json:"status"`
+	ChartVersion string                 `json:"chartVersion"`
+	AppVersion   string                 `json:"appVersion,omitempty"`
+	Updated      string                 `json:"updated"`
+	Description  string                 `json:"description"`
+	Values       map[string]interface{} `json:"values,omitempty"`
+}
+
+// HelmSettings returns Helm settings for a context of the configured kubeconfig. The context
+// is only set on the settings, the shared kubeconfig is never switched, so Helm actions
+// against different contexts can run concurrently.
+func HelmSettings(contextName string) *cli.EnvSettings {
+	settings := cli.New()
+	settings.KubeConfig = KubeconfigPath()
+	if contextName == "" {
+		contextName = WDSContext()
+	}
+	settings.KubeContext = contextName
+	return settings
+}
+
+// HelmContext returns the context the deployment was installed into; records stored before
+// the context was tracked were always installed into the default WDS
+func (d *HelmDeploymentData) HelmContext() string {
+	if d.Context == "" {
+		return WDSContext()
+	}
+	return d.Context
+}
+
+// Redacted returns a copy of the deployment with secret chart values, such as passwords and
+// tokens, removed so it is safe to return over the API
+func (d HelmDeploymentData) Redacted() HelmDeploymentData {
+	d.Values = redactChartValues(d.Values)
+	if d.Revisions != nil {
+		revisions := make([]HelmRevision, len(d.Revisions))
+		for i, revision := range d.Revisions {
+			revision.Values = redactChartValues(revision.Values)
+			revisions[i] = revision
+		}
+		d.Revisions = revisions
+	}
+	return d
+}
+
+// redactChartValues returns a copy of chart values with the values of secret-looking keys removed
+func redactChartValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	redacted, _ := audit.RedactValue(values).(map[string]interface{})
+	return redacted
+}
+
+// UpgradeHelmDeployment upgrades the release of a stored deployment and records the new revision
+func UpgradeHelmDeployment(actionConfig *action.Configuration, deploymentID string, req HelmUpgradeRequest) (*release.Release, *HelmDeploymentData, error) {
+	deployment, err := GetHelmDeploymentByID(deploymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings := HelmSettings(deployment.HelmContext())
+	upgrade := action.NewUpgrade(actionConfig)
+	upgrade.Namespace = deployment.Namespace
+	upgrade.Version = req.Version
+	upgrade.ReuseValues = req.ReuseValues
+	upgrade.Timeout = 4 * time.Minute
+	workloadLabel := deployment.WorkloadLabel
+	if workloadLabel == "" {
+		workloadLabel = deployment.ChartName
+	}
+	upgrade.PostRenderer = &labelAddingPostRenderer{workloadLabel: workloadLabel}
+
+	chartPath, err := upgrade.ChartPathOptions.LocateChart(fmt.Sprintf("%s/%s", deployment.RepoName, deployment.ChartName), settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to locate chart: %v", err)
+	}
+	chartObj, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load chart: %v", err)
+	}
+
+	rel, err := upgrade.Run(deployment.ReleaseName, chartObj, buildChartValues(req.Values, workloadLabel))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to upgrade release %s: %v", deployment.ReleaseName, err)
+	}
+
+	updated, err := recordHelmRevision(deploymentID, HelmRevision{
+		Revision:     rel.Version,
+		Action:       HelmActionUpgrade,
+		ChartVersion: rel.Chart.Metadata.Version,
+		Status:       rel.Info.Status.String(),
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Values:       rel.Config,
+	})
+	if err != nil {
+		return rel, nil, err
+	}
+	return rel, updated, nil
+}
+
+// RollbackHelmDeployment rolls the release of a stored deployment back to a previous revision.
+// Helm records a rollback as a new revision, which is appended to the revision chain.
+func RollbackHelmDeployment(actionConfig *action.Configuration, deploymentID string, revision int) (*release.Release, *HelmDeploymentData, error) {
+	deployment, err := GetHelmDeploymentByID(deploymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rollback := action.NewRollback(actionConfig)
+	rollback.Version = revision
+	rollback.Timeout = 4 * time.Minute
+	if err := rollback.Run(deployment.ReleaseName); err != nil {
+		return nil, nil, fmt.Errorf("failed to roll back release %s to revision %d: %v", deployment.ReleaseName, revision, err)
+	}
+
+	rel, err := action.NewGet(actionConfig).Run(deployment.ReleaseName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get release %s after rollback: %v", deployment.ReleaseName, err)
+	}
+
+	updated, err := recordHelmRevision(deploymentID, HelmRevision{
+		Revision:     rel.Version,
+		Action:       HelmActionRollback,
+		ChartVersion: rel.Chart.Metadata.Version,
+		Status:       rel.Info.Status.String(),
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Values:       rel.Config,
+		RolledBackTo: revision,
+	})
+	if err != nil {
+		return rel, nil, err
+	}
+	return rel, updated, nil
+}
+
+// HelmDeploymentHistory returns the revisions Helm keeps for the release of a stored deployment, newest
+// first, with secret values redacted
+func HelmDeploymentHistory(actionConfig *action.Configuration, deploymentID string) ([]HelmHistoryEntry, *HelmDeploymentData, error) {
+	deployment, err := GetHelmDeploymentByID(deploymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	releases, err := action.NewHistory(actionConfig).Run(deployment.ReleaseName)
+	if err != nil {
+		return nil, deployment, fmt.Errorf("failed to get history of release %s: %v", deployment.ReleaseName, err)
+	}
+
+	history := make([]HelmHistoryEntry, 0, len(releases))
+	for i := len(releases) - 1; i >= 0; i-- {
+		rel := releases[i]
+		entry := HelmHistoryEntry{
+			Revision: rel.Version,
+			Values:   redactChartValues(rel.Config),
+		}
+		if rel.Info != nil {
+			entry.Status = rel.Info.Status.String()
+			entry.Updated = rel.Info.LastDeployed.Format(time.RFC3339)
+			entry.Description = rel.Info.Description
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			entry.ChartVersion = rel.Chart.Metadata.Version
+			entry.AppVersion = rel.Chart.Metadata.AppVersion
+		}
+		history = append(history, entry)
+	}
+	return history, deployment, nil
+}
+
+// recordHelmRevision appends a revision to the chain of a stored deployment and makes it current
+func recordHelmRevision(deploymentID string, revision HelmRevision) (*HelmDeploymentData, error) {
+	deployment, err := GetHelmDeploymentByID(deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment.Revision = revision.Revision
+	deployment.Revisions = append(deployment.Revisions, revision)
+	deployment.ChartVersion = revision.ChartVersion
+	deployment.ReleaseInfo = revision.Status
+
+	if err := saveDeployment(deployments.KindHelm, deployment); err != nil {
+		return nil, fmt.Errorf("failed to record revision %d of deployment %s: %v", revision.Revision, deploymentID, err)
+	}
+	return deployment, nil
+}
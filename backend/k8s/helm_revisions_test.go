@@ -0,0 +1,218 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/deployments"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// useTestDeploymentHistory stores the deployment history in a temporary SQLite database for
+// the rest of the test
+func useTestDeploymentHistory(t *testing.T) {
+	t.Helper()
+	repo, err := deployments.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "deployments.db"))
+	if err != nil {
+		t.Skipf("SQLite is unavailable: %v", err)
+	}
+	historyMu.Lock()
+	previous := historyRepo
+	historyRepo = repo
+	historyMu.Unlock()
+	t.Cleanup(func() {
+		historyMu.Lock()
+		historyRepo = previous
+		historyMu.Unlock()
+		repo.Close()
+	})
+}
+
+// testHelmRelease is a revision of the web release made with the given chart version
+func testHelmRelease(version int, chartVersion string, status release.Status) *release.Release {
+	return &release.Release{
+		Name:      "web",
+		Namespace: "default",
+		Version:   version,
+		Info:      &release.Info{Status: status, LastDeployed: helmtime.Now(), Description: "revision"},
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{APIVersion: "v2", Name: "web", Version: chartVersion}},
+		Config:    map[string]interface{}{"replicas": version},
+	}
+}
+
+func TestHelmDeploymentRevisions(t *testing.T) {
+	useTestDeploymentHistory(t)
+	if err := saveDeployment(deployments.KindHelm, HelmDeploymentData{
+		ID:          "helm-web",
+		ReleaseName: "web",
+		Namespace:   "default",
+		Revision:    2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	actionConfig := &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          func(string, ...interface{}) {},
+	}
+	for _, rel := range []*release.Release{
+		testHelmRelease(1, "1.0.0", release.StatusSuperseded),
+		testHelmRelease(2, "1.1.0", release.StatusDeployed),
+	} {
+		if err := actionConfig.Releases.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, _, err := HelmDeploymentHistory(actionConfig, "helm-web")
+	if err != nil {
+		t.Fatalf("HelmDeploymentHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Revision != 2 || history[0].ChartVersion != "1.1.0" || history[1].Revision != 1 {
+		t.Fatalf("history = %+v, want revisions 2 and 1, newest first", history)
+	}
+
+	rel, updated, err := RollbackHelmDeployment(actionConfig, "helm-web", 1)
+	if err != nil {
+		t.Fatalf("RollbackHelmDeployment failed: %v", err)
+	}
+	if rel.Version != 3 || rel.Chart.Metadata.Version != "1.0.0" {
+		t.Errorf("rollback made revision %d of chart %s, want revision 3 of 1.0.0", rel.Version, rel.Chart.Metadata.Version)
+	}
+	if updated.Revision != 3 || updated.ChartVersion != "1.0.0" || len(updated.Revisions) != 1 {
+		t.Fatalf("updated deployment = %+v, want revision 3 recorded", updated)
+	}
+	if revision := updated.Revisions[0]; revision.Action != HelmActionRollback || revision.RolledBackTo != 1 {
+		t.Errorf("recorded revision = %+v, want a rollback to revision 1", revision)
+	}
+
+	stored, err := GetHelmDeploymentByID("helm-web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Revision != 3 || len(stored.Revisions) != 1 {
+		t.Errorf("stored deployment = %+v, want the rollback persisted", stored)
+	}
+
+	if _, _, err := RollbackHelmDeployment(actionConfig, "missing", 1); err == nil {
+		t.Errorf("rollback of an unknown deployment succeeded")
+	}
+}
+
+func TestHelmContext(t *testing.T) {
+	if got := HelmSettings("").KubeContext; got != WDSContext() {
+		t.Errorf("HelmSettings(\"\") context = %q, want the default WDS", got)
+	}
+	if got := HelmSettings("wds2").KubeContext; got != "wds2" {
+		t.Errorf("HelmSettings(wds2) context = %q", got)
+	}
+	if got := (&HelmDeploymentData{}).HelmContext(); got != WDSContext() {
+		t.Errorf("HelmContext() of an old record = %q, want the default WDS", got)
+	}
+	if got := (&HelmDeploymentData{Context: "wds2"}).HelmContext(); got != "wds2" {
+		t.Errorf("HelmContext() = %q, want wds2", got)
+	}
+}
+
+func TestHelmDeploymentRedacted(t *testing.T) {
+	deployment := HelmDeploymentData{
+		ReleaseName: "web",
+		Values:      map[string]interface{}{"image": "nginx", "auth": map[string]interface{}{"adminPassword": "chart-default"}},
+		Revisions: []HelmRevision{
+			{Revision: 1, Values: map[string]interface{}{"replicas": 2, "apiToken": "s3cret"}},
+			{Revision: 2},
+		},
+	}
+
+	redacted := deployment.Redacted()
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"plain chart value", redacted.Values["image"], "nginx"},
+		{"nested chart secret", redacted.Values["auth"].(map[string]interface{})["adminPassword"], audit.Redacted},
+		{"plain revision value", redacted.Revisions[0].Values["replicas"], 2},
+		{"revision secret", redacted.Revisions[0].Values["apiToken"], audit.Redacted},
+		{"revision without values", redacted.Revisions[1].Values == nil, true},
+		{"stored revision", deployment.Revisions[0].Values["apiToken"], "s3cret"},
+		{"stored chart value", deployment.Values["auth"].(map[string]interface{})["adminPassword"], "chart-default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmSettingsUseContextWithoutSwitching(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	data := `apiVersion: v1
+kind: Config
+current-context: wds1
+clusters:
+- name: wds1
+  cluster:
+    server: https://wds1.example.com
+- name: wds2
+  cluster:
+    server: https://wds2.example.com
+users:
+- name: admin
+  user:
+    token: test
+contexts:
+- name: wds1
+  context:
+    cluster: wds1
+    user: admin
+- name: wds2
+  context:
+    cluster: wds2
+    user: admin
+`
+	if err := os.WriteFile(kubeconfig, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := clusterConfig()
+	cfg := previous
+	cfg.Kubeconfig = kubeconfig
+	Configure(cfg)
+	t.Cleanup(func() { Configure(previous) })
+
+	settings := HelmSettings("wds2")
+	if settings.KubeConfig != kubeconfig {
+		t.Fatalf("Helm uses kubeconfig %q, want the configured %q", settings.KubeConfig, kubeconfig)
+	}
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		t.Fatalf("ToRESTConfig failed: %v", err)
+	}
+	if restConfig.Host != "https://wds2.example.com" {
+		t.Errorf("Helm talks to %s, want the wds2 cluster", restConfig.Host)
+	}
+
+	after, err := os.ReadFile(kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != data {
+		t.Errorf("the kubeconfig was modified")
+	}
+}
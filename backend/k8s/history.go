@@ -0,0 +1,317 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/deployments"
+	"github.com/kubestellar/ui/postgresql"
+)
+
+var (
+	historyMu    sync.Mutex
+	historyRepo  deployments.Repository
+	historyStore = config.Default().DeploymentStore
+)
+
+// ConfigureDeploymentHistory sets the backend of the deployment history store, which takes
+// effect when the store is next opened
+func ConfigureDeploymentHistory(cfg config.DeploymentStoreConfig) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	historyStore = cfg
+}
+
+// DeploymentHistory returns the deployment history store, opening it on first use in the
+// configured backend: configmap, postgres or sqlite. An unreachable backend is retried on
+// the next call.
+func DeploymentHistory() (deployments.Repository, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if historyRepo != nil {
+		return historyRepo, nil
+	}
+	repo, err := openDeploymentHistory(historyStore)
+	if err != nil {
+		return nil, err
+	}
+	historyRepo = repo
+	return historyRepo, nil
+}
+
+// InitDeploymentHistory opens the deployment history store at startup, which also copies the
+// existing ConfigMap history into a PostgreSQL or SQLite store the first time one is used
+func InitDeploymentHistory() error {
+	repo, err := DeploymentHistory()
+	if err != nil {
+		return err
+	}
+	log.Printf("Deployment history stored in %s", repo.Backend())
+	return nil
+}
+
+func openDeploymentHistory(store config.DeploymentStoreConfig) (deployments.Repository, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	switch store.Backend {
+	case "", deployments.BackendConfigMap:
+		return configMapHistory()
+	case deployments.BackendPostgres:
+		if postgresql.DB == nil {
+			if err := postgresql.ConnectDB(); err != nil {
+				return nil, err
+			}
+		}
+		repo, err := deployments.NewSQLRepository(ctx, postgresql.DB, deployments.BackendPostgres)
+		if err != nil {
+			return nil, err
+		}
+		migrateConfigMapHistory(ctx, repo)
+		return repo, nil
+	case deployments.BackendSQLite:
+		repo, err := deployments.OpenSQLite(ctx, store.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		migrateConfigMapHistory(ctx, repo)
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown deployment store %q, expected configmap, postgres or sqlite", store.Backend)
+	}
+}
+
+// configMapHistory stores the history in the kubestellar namespace of the ITS, as it always was
+func configMapHistory() (*deployments.ConfigMapRepository, error) {
+	clientset, dynamicClient, err := GetClientSetWithContext(ITSContext())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kubernetes client: %v", err)
+	}
+	// Ensure the namespace exists (without workload label for internal storage)
+	if err := EnsureNamespaceExists(dynamicClient, KubeStellarNamespace, ""); err != nil {
+		return nil, fmt.Errorf("failed to ensure namespace for ConfigMap: %v", err)
+	}
+	return deployments.NewConfigMapRepository(clientset, KubeStellarNamespace), nil
+}
+
+// migrateConfigMapHistory copies the ConfigMap history into repo once. Failures are logged
+// rather than returned so an unreachable ITS does not block the new store; the copy is
+// retried on the next start.
+func migrateConfigMapHistory(ctx context.Context, repo deployments.Repository) {
+	from, err := configMapHistory()
+	if err != nil {
+		log.Printf("Warning: skipping deployment history migration: %v", err)
+		return
+	}
+	if err := deployments.MigrateConfigMaps(ctx, from, repo); err != nil {
+		log.Printf("Warning: deployment history migration failed: %v", err)
+	}
+}
+
+// saveDeployment stores a deployment of the given kind in the history
+func saveDeployment(kind string, deployment interface{}) error {
+	repo, err := DeploymentHistory()
+	if err != nil {
+		return err
+	}
+	record, err := deployments.NewRecord(kind, deployment)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return repo.Save(ctx, record)
+}
+
+// getDeployment loads a deployment of the given kind into out
+func getDeployment(kind, deploymentID string, out interface{}) error {
+	repo, err := DeploymentHistory()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	record, err := repo.Get(ctx, kind, deploymentID)
+	if err != nil {
+		if errors.Is(err, deployments.ErrNotFound) {
+			return fmt.Errorf("deployment with ID %s not found", deploymentID)
+		}
+		return err
+	}
+	if err := json.Unmarshal(record.Data, out); err != nil {
+		return fmt.Errorf("failed to parse deployment %s: %v", deploymentID, err)
+	}
+	return nil
+}
+
+// deleteDeployment removes a deployment of the given kind from the history
+func deleteDeployment(kind, deploymentID string) error {
+	repo, err := DeploymentHistory()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := repo.Delete(ctx, kind, deploymentID); err != nil {
+		if errors.Is(err, deployments.ErrNotFound) {
+			return fmt.Errorf("deployment with ID %s not found", deploymentID)
+		}
+		return err
+	}
+	return nil
+}
+
+// ListDeployments returns one page of the deployment history matching the filter, newest first
+func ListDeployments(filter deployments.Filter) (*deployments.Page, error) {
+	repo, err := DeploymentHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return repo.List(ctx, filter)
+}
+
+// DeploymentFilterFromQuery reads the kind, context, namespace, release, since, until, limit
+// and offset query parameters. Dates are RFC3339 timestamps or YYYY-MM-DD days; a day given
+// as until includes the whole day. Without a limit or an offset every match is returned.
+func DeploymentFilterFromQuery(c *gin.Context) (deployments.Filter, error) {
+	filter := deployments.Filter{
+		Kind:      c.Query("kind"),
+		Context:   c.Query("context"),
+		Namespace: c.Query("namespace"),
+		Release:   c.Query("release"),
+	}
+
+	var err error
+	if since := c.Query("since"); since != "" {
+		if filter.Since, _, err = parseHistoryDate(since); err != nil {
+			return filter, fmt.Errorf("invalid since: %v", err)
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		var isDay bool
+		if filter.Until, isDay, err = parseHistoryDate(until); err != nil {
+			return filter, fmt.Errorf("invalid until: %v", err)
+		}
+		if isDay {
+			filter.Until = filter.Until.AddDate(0, 0, 1)
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 {
+			return filter, fmt.Errorf("invalid limit %q", limit)
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil || filter.Offset < 0 {
+			return filter, fmt.Errorf("invalid offset %q", offset)
+		}
+	}
+	filter.Normalize()
+	return filter, nil
+}
+
+func parseHistoryDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither RFC3339 nor YYYY-MM-DD", value)
+	}
+	return t, true, nil
+}
+
+// ListDeploymentHistoryHandler lists the stored deployments of every kind, filtered and paginated
+func ListDeploymentHistoryHandler(c *gin.Context) {
+	filter, err := DeploymentFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	listDeploymentHistory(c, filter)
+}
+
+// DeploymentHistoryByKindHandler lists the stored deployments of one kind, filtered and paginated
+func DeploymentHistoryByKindHandler(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := DeploymentFilterFromQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Kind = kind
+		listDeploymentHistory(c, filter)
+	}
+}
+
+func listDeploymentHistory(c *gin.Context, filter deployments.Filter) {
+	page, err := ListDeployments(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to retrieve deployments: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(page.Records),
+		"total":   page.Total,
+		"limit":   page.Limit,
+		"offset":  page.Offset,
+		"records": redactRecords(page.Records),
+	})
+}
+
+// redactRecords returns copies of the records with secrets, such as Helm chart passwords and
+// repository tokens, removed from their data
+func redactRecords(records []deployments.Record) []deployments.Record {
+	redacted := make([]deployments.Record, len(records))
+	for i, record := range records {
+		record.Data = redactRecordData(record.Data)
+		redacted[i] = record
+	}
+	return redacted
+}
+
+// redactRecordData redacts the data of a record by key. Records copied from the ConfigMap
+// history keep chart values as JSON strings, which are decoded first; data that cannot be
+// decoded is dropped.
+func redactRecordData(data json.RawMessage) json.RawMessage {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, key := range []string{"config", "values"} {
+			encoded, ok := object[key].(string)
+			if !ok {
+				continue
+			}
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+				object[key] = audit.Redacted
+				continue
+			}
+			object[key] = decoded
+		}
+	}
+	redacted, err := json.Marshal(audit.RedactValue(value))
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kubestellar/ui/deployments"
+)
+
+func TestRedactRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "helm revisions",
+			data: `{"releaseName":"web","revisions":[{"revision":1,"values":{"replicas":2,"auth":{"password":"s3cret"}}}]}`,
+			want: `{"releaseName":"web","revisions":[{"revision":1,"values":{"auth":{"password":"[REDACTED]"},"replicas":2}}]}`,
+		},
+		{
+			name: "config as a string",
+			data: `{"releaseName":"web","config":"{\"apiToken\":\"s3cret\",\"replicas\":2}"}`,
+			want: `{"config":{"apiToken":"[REDACTED]","replicas":2},"releaseName":"web"}`,
+		},
+		{
+			name: "config that is not JSON",
+			data: `{"config":"password=s3cret"}`,
+			want: `{"config":"[REDACTED]"}`,
+		},
+		{
+			name: "github token",
+			data: `{"repo_url":"https://github.com/org/repo","token":"ghp_s3cret"}`,
+			want: `{"repo_url":"https://github.com/org/repo","token":"[REDACTED]"}`,
+		},
+		{name: "not JSON", data: `{`, want: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := []deployments.Record{{ID: "1", Kind: deployments.KindHelm, Data: json.RawMessage(tt.data)}}
+			redacted := redactRecords(records)
+			if got := string(redacted[0].Data); got != tt.want {
+				t.Errorf("data = %s, want %s", got, tt.want)
+			}
+			if redacted[0].ID != "1" || string(records[0].Data) != tt.data {
+				t.Errorf("redactRecords changed the stored record or dropped its fields")
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+type impersonationKey struct{}
+
+// ImpersonationEnabled reports whether requests reach the clusters as the UI user instead of
+// the server's own kubeconfig identity. The kubeconfig identity then needs the impersonate
+// verb on users and groups.
+func ImpersonationEnabled() bool {
+	return clusterConfig().Impersonation.Enabled
+}
+
+// WithUser returns a copy of ctx carrying the authenticated UI user and their groups, to be
+// impersonated by the clients built for the request
+func WithUser(ctx context.Context, username string, groups []string) context.Context {
+	prefixes := clusterConfig().Impersonation
+	impersonate := rest.ImpersonationConfig{UserName: prefixes.UserPrefix + username}
+	for _, group := range groups {
+		impersonate.Groups = append(impersonate.Groups, prefixes.GroupPrefix+group)
+	}
+	return context.WithValue(ctx, impersonationKey{}, impersonate)
+}
+
+// ErrNoUser is returned for clients of a request without an authenticated user while
+// impersonation is enabled, instead of falling back to the server's identity
+var ErrNoUser = errors.New("impersonation is enabled but the request has no authenticated user")
+
+type serverIdentityKey struct{}
+
+// AsServer returns a copy of ctx whose clients keep the server's own identity even when
+// impersonation is enabled, for work the server does on its own behalf such as GitOps syncs
+func AsServer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serverIdentityKey{}, true)
+}
+
+// impersonation returns the identity the clients of ctx act as; ok is false when they keep
+// the server's identity
+func impersonation(ctx context.Context) (impersonate rest.ImpersonationConfig, ok bool, err error) {
+	if !ImpersonationEnabled() || ctx.Value(serverIdentityKey{}) != nil {
+		return rest.ImpersonationConfig{}, false, nil
+	}
+	impersonate, ok = ctx.Value(impersonationKey{}).(rest.ImpersonationConfig)
+	if !ok || impersonate.UserName == "" {
+		return rest.ImpersonationConfig{}, false, ErrNoUser
+	}
+	return impersonate, true, nil
+}
+
+// ImpersonatedUser returns the user the clients of ctx impersonate, or "" when they keep the
+// server's identity. Data cached from such clients must be keyed by it.
+func ImpersonatedUser(ctx context.Context) string {
+	impersonate, ok, _ := impersonation(ctx)
+	if !ok {
+		return ""
+	}
+	return impersonate.UserName
+}
+
+// Impersonate returns a copy of config that impersonates the user of the request when
+// impersonation is enabled. Requests without an authenticated user fail with ErrNoUser
+// unless ctx comes from AsServer.
+func Impersonate(ctx context.Context, config *rest.Config) (*rest.Config, error) {
+	impersonate, ok, err := impersonation(ctx)
+	if err != nil || !ok {
+		return config, err
+	}
+	impersonated := rest.CopyConfig(config)
+	impersonated.Impersonate = impersonate
+	return impersonated, nil
+}
+
+// HelmSettingsForRequest is HelmSettings acting as the user of the request
+func HelmSettingsForRequest(ctx context.Context, contextName string) (*cli.EnvSettings, error) {
+	settings := HelmSettings(contextName)
+	impersonate, ok, err := impersonation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		settings.KubeAsUser = impersonate.UserName
+		settings.KubeAsGroups = impersonate.Groups
+	}
+	return settings, nil
+}
+
+// GetClientSetForRequest is GetClientSetWithContext acting as the user of the request
+func GetClientSetForRequest(ctx context.Context, contextName string) (*kubernetes.Clientset, dynamic.Interface, error) {
+	restConfig, err := restConfigForRequest(ctx, contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientsForConfig(restConfig)
+}
+
+// GetClientSetWithConfigForRequest is GetClientSetWithConfigContext acting as the user of the request
+func GetClientSetWithConfigForRequest(ctx context.Context, contextName string) (*kubernetes.Clientset, *rest.Config, error) {
+	restConfig, err := restConfigForRequest(ctx, contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	return clientset, restConfig, nil
+}
+
+func restConfigForRequest(ctx context.Context, contextName string) (*rest.Config, error) {
+	restConfig, err := restConfigForContext(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return Impersonate(ctx, restConfig)
+}
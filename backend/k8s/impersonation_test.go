@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kubestellar/ui/config"
+	"k8s.io/client-go/rest"
+)
+
+// configureImpersonation sets the impersonation settings for the rest of the test
+func configureImpersonation(t *testing.T, impersonation config.ImpersonationConfig) {
+	t.Helper()
+	previous := clusterConfig()
+	cfg := previous
+	cfg.Impersonation = impersonation
+	Configure(cfg)
+	t.Cleanup(func() { Configure(previous) })
+}
+
+func TestImpersonate(t *testing.T) {
+	alice := rest.ImpersonationConfig{UserName: "ui:alice", Groups: []string{"ui:dev"}}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		withUser bool
+		asServer bool
+		want     rest.ImpersonationConfig
+		wantErr  error
+	}{
+		{name: "disabled without user"},
+		{name: "disabled with user", withUser: true},
+		{name: "enabled with user", enabled: true, withUser: true, want: alice},
+		{name: "enabled without user fails closed", enabled: true, wantErr: ErrNoUser},
+		{name: "enabled as server", enabled: true, asServer: true},
+		{name: "enabled as server with user", enabled: true, withUser: true, asServer: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configureImpersonation(t, config.ImpersonationConfig{Enabled: tt.enabled, UserPrefix: "ui:", GroupPrefix: "ui:"})
+			ctx := context.Background()
+			if tt.withUser {
+				ctx = WithUser(ctx, "alice", []string{"dev"})
+			}
+			if tt.asServer {
+				ctx = AsServer(ctx)
+			}
+
+			config := &rest.Config{Host: "https://example.com"}
+			got, err := Impersonate(ctx, config)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Impersonate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Impersonate, tt.want) {
+				t.Errorf("Impersonate() = %+v, want %+v", got.Impersonate, tt.want)
+			}
+			if config.Impersonate.UserName != "" {
+				t.Errorf("Impersonate() modified the original config")
+			}
+		})
+	}
+}
+
+func TestImpersonatedUser(t *testing.T) {
+	configureImpersonation(t, config.ImpersonationConfig{Enabled: true})
+	ctx := WithUser(context.Background(), "alice", nil)
+	if got := ImpersonatedUser(ctx); got != "alice" {
+		t.Errorf("ImpersonatedUser() = %q, want alice", got)
+	}
+	if got := ImpersonatedUser(AsServer(ctx)); got != "" {
+		t.Errorf("ImpersonatedUser(AsServer) = %q, want empty", got)
+	}
+	if got := ImpersonatedUser(context.Background()); got != "" {
+		t.Errorf("ImpersonatedUser() without user = %q, want empty", got)
+	}
+
+	configureImpersonation(t, config.ImpersonationConfig{})
+	if got := ImpersonatedUser(ctx); got != "" {
+		t.Errorf("ImpersonatedUser() while disabled = %q, want empty", got)
+	}
+}
+
+func TestHelmSettingsForRequest(t *testing.T) {
+	configureImpersonation(t, config.ImpersonationConfig{Enabled: true})
+
+	settings, err := HelmSettingsForRequest(WithUser(context.Background(), "alice", []string{"dev"}), "wds1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.KubeContext != "wds1" || settings.KubeAsUser != "alice" || !reflect.DeepEqual(settings.KubeAsGroups, []string{"dev"}) {
+		t.Errorf("settings = context %q, user %q, groups %v", settings.KubeContext, settings.KubeAsUser, settings.KubeAsGroups)
+	}
+	if settings.KubeConfig != KubeconfigPath() {
+		t.Errorf("settings use kubeconfig %q, want %q", settings.KubeConfig, KubeconfigPath())
+	}
+
+	if _, err := HelmSettingsForRequest(context.Background(), "wds1"); !errors.Is(err, ErrNoUser) {
+		t.Errorf("HelmSettingsForRequest() without user = %v, want ErrNoUser", err)
+	}
+}
@@ -0,0 +1,238 @@
+package k8s
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is a single object read from a deploy folder, with the file or kustomization it came from
+type Manifest struct {
+	Source string
+	Object *unstructured.Unstructured
+}
+
+// LoadManifests reads every object under deployPath. Kustomizations are rendered, other
+// .yaml and .yml files are read recursively and split into their documents. Hidden
+// directories such as .git are skipped. Kustomizations that another kustomization under
+// deployPath references (e.g. a base of an overlay) are only rendered through it. An object
+// defined twice, such as by two overlays of one base, is an error: point deployPath at a
+// single overlay instead.
+func LoadManifests(deployPath string) ([]Manifest, error) {
+	info, err := os.Stat(deployPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", deployPath)
+	}
+
+	referenced, err := referencedKustomizations(deployPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []Manifest
+	err = filepath.WalkDir(deployPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(deployPath, path)
+
+		if entry.IsDir() {
+			if path != deployPath && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !hasKustomization(path) {
+				return nil
+			}
+			if referenced[path] {
+				return filepath.SkipDir
+			}
+			objects, err := renderKustomization(path)
+			if err != nil {
+				return fmt.Errorf("failed to render kustomization %s: %v", relPath, err)
+			}
+			for _, obj := range objects {
+				manifests = append(manifests, Manifest{Source: relPath, Object: obj})
+			}
+			// Files of a kustomization are only deployed through it
+			return filepath.SkipDir
+		}
+
+		if !isManifestFile(entry.Name()) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read manifest %s: %v", relPath, err)
+		}
+		objects, err := SplitManifests(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse YAML %s: %v", relPath, err)
+		}
+		for _, obj := range objects {
+			manifests = append(manifests, Manifest{Source: relPath, Object: obj})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDuplicateManifests(manifests); err != nil {
+		return nil, err
+	}
+	return manifests, nil
+}
+
+// checkDuplicateManifests returns an error for the first object defined by two manifests,
+// which would otherwise be applied twice with the last write winning
+func checkDuplicateManifests(manifests []Manifest) error {
+	sources := map[string]string{}
+	for _, manifest := range manifests {
+		obj := manifest.Object
+		gvk := obj.GroupVersionKind()
+		key := strings.Join([]string{gvk.Group, gvk.Kind, obj.GetNamespace(), obj.GetName()}, "/")
+		if source, exists := sources[key]; exists {
+			name := obj.GetName()
+			if obj.GetNamespace() != "" {
+				name = obj.GetNamespace() + "/" + name
+			}
+			return fmt.Errorf("%s %s is defined by both %s and %s; deploy a single overlay or folder",
+				gvk.Kind, name, source, manifest.Source)
+		}
+		sources[key] = manifest.Source
+	}
+	return nil
+}
+
+// SplitManifests decodes every document of a multi-document YAML (or JSON) file.
+// Empty documents are skipped and List kinds are expanded into their items.
+func SplitManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: doc}
+		if obj.GetKind() == "" {
+			return nil, fmt.Errorf("document is missing a kind")
+		}
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func isManifestFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// kustomizationFile returns the kustomization file of dir, if it has one
+func kustomizationFile(dir string) string {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func hasKustomization(dir string) bool {
+	return kustomizationFile(dir) != ""
+}
+
+// renderKustomization runs kustomize build on dir
+func renderKustomization(dir string) ([]*unstructured.Unstructured, error) {
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := resMap.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	return SplitManifests(rendered)
+}
+
+// referencedKustomizations returns the directories under root that another kustomization
+// under root lists in its resources, bases or components
+func referencedKustomizations(root string) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		file := kustomizationFile(path)
+		if file == "" {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var kustomization types.Kustomization
+		if err := yaml.Unmarshal(data, &kustomization); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+
+		refs := append(append(append([]string{}, kustomization.Resources...), kustomization.Bases...), kustomization.Components...)
+		for _, ref := range refs {
+			// Remote bases and plain files do not matter here
+			if strings.Contains(ref, "://") || filepath.IsAbs(ref) {
+				continue
+			}
+			target := filepath.Clean(filepath.Join(path, ref))
+			if info, err := os.Stat(target); err == nil && info.IsDir() {
+				referenced[target] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kustomizations: %v", err)
+	}
+	return referenced, nil
+}
@@ -0,0 +1,150 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSplitManifests(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantNames []string
+		wantErr   bool
+	}{
+		{name: "single", data: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n", wantNames: []string{"a"}},
+		{name: "multiple with empty documents", data: "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: b\n", wantNames: []string{"a", "b"}},
+		{name: "list", data: "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: a\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: b\n", wantNames: []string{"a", "b"}},
+		{name: "json", data: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}`, wantNames: []string{"a"}},
+		{name: "missing kind", data: "apiVersion: v1\nmetadata:\n  name: a\n", wantErr: true},
+		{name: "invalid", data: "kind: [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := SplitManifests([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, obj := range objects {
+				names = append(names, obj.GetName())
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestLoadManifests(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"namespace.yaml":                   "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n",
+		"apps/web.yml":                     "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web-extra\n",
+		"apps/README.md":                   "not a manifest",
+		".git/config.yaml":                 "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: hidden\n",
+		"base/kustomization.yaml":          "resources:\n- config.yaml\n",
+		"base/config.yaml":                 "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+		"overlays/prod/kustomization.yaml": "resources:\n- ../../base\nnamePrefix: prod-\n",
+		"standalone/kustomization.yaml":    "resources:\n- config.yaml\nnamespace: tools\n",
+		"standalone/config.yaml":           "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: tool\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifests, err := LoadManifests(root)
+	if err != nil {
+		t.Fatalf("LoadManifests failed: %v", err)
+	}
+	var got []string
+	for _, manifest := range manifests {
+		got = append(got, manifest.Source+":"+manifest.Object.GetNamespace()+"/"+manifest.Object.GetName())
+	}
+	sort.Strings(got)
+	want := []string{
+		"apps/web.yml:/web",
+		"apps/web.yml:/web-extra",
+		"namespace.yaml:/app",
+		"overlays/prod:/prod-settings",
+		"standalone:tools/tool",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadManifests() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadManifests(filepath.Join(root, "namespace.yaml")); err == nil {
+		t.Errorf("LoadManifests of a file succeeded, want an error")
+	}
+}
+
+func TestLoadManifestsDuplicates(t *testing.T) {
+	const (
+		baseKustomization = "resources:\n- config.yaml\n"
+		settings          = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n"
+		overlay           = "resources:\n- ../../base\n"
+	)
+	tests := []struct {
+		name    string
+		files   map[string]string
+		deploy  string
+		wantErr bool
+	}{
+		{
+			name: "two overlays of one base",
+			files: map[string]string{"base/kustomization.yaml": baseKustomization, "base/config.yaml": settings,
+				"overlays/dev/kustomization.yaml": overlay, "overlays/prod/kustomization.yaml": overlay},
+			wantErr: true,
+		},
+		{
+			name: "single overlay",
+			files: map[string]string{"base/kustomization.yaml": baseKustomization, "base/config.yaml": settings,
+				"overlays/dev/kustomization.yaml": overlay, "overlays/prod/kustomization.yaml": overlay},
+			deploy: "overlays/prod",
+		},
+		{
+			name: "overlays with distinct names",
+			files: map[string]string{"base/kustomization.yaml": baseKustomization, "base/config.yaml": settings,
+				"overlays/dev/kustomization.yaml":  overlay + "namePrefix: dev-\n",
+				"overlays/prod/kustomization.yaml": overlay + "namePrefix: prod-\n"},
+		},
+		{
+			name:    "same object in two files",
+			files:   map[string]string{"a/config.yaml": settings, "b/config.yml": settings},
+			wantErr: true,
+		},
+		{
+			name:  "same name in another namespace",
+			files: map[string]string{"a/config.yaml": settings, "b/config.yml": strings.Replace(settings, "name: settings", "name: settings\n  namespace: other", 1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := LoadManifests(filepath.Join(root, tt.deploy))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
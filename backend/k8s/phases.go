@@ -0,0 +1,163 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// Apply phases, in the order objects are applied
+const (
+	PhaseFoundation = "crds-and-namespaces"
+	PhaseEstablish  = "wait-for-crds"
+	PhaseRBAC       = "rbac"
+	PhaseConfig     = "config"
+	PhaseWorkloads  = "workloads"
+)
+
+// crdEstablishTimeout bounds how long the apply waits for new CRDs to be served
+const crdEstablishTimeout = 60 * time.Second
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// phaseOrder ranks each phase; objects of a lower rank are applied first
+var phaseOrder = map[string]int{
+	PhaseFoundation: 0,
+	PhaseRBAC:       1,
+	PhaseConfig:     2,
+	PhaseWorkloads:  3,
+}
+
+// PhaseError reports the apply phase, and the object if any, that a deployment failed in
+type PhaseError struct {
+	Phase     string `json:"phase"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Err       error  `json:"-"`
+}
+
+func (e *PhaseError) Error() string {
+	if e.Kind == "" {
+		return fmt.Sprintf("phase %s failed: %v", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("phase %s failed on %s %s: %v", e.Phase, e.Kind, e.Name, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+func newPhaseError(phase string, obj *unstructured.Unstructured, err error) *PhaseError {
+	return &PhaseError{
+		Phase:     phase,
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Err:       err,
+	}
+}
+
+// objectPhase returns the phase an object of the given kind is applied in.
+// Anything not known to be RBAC or configuration, custom resources included, is a workload.
+func objectPhase(kind string) string {
+	switch kind {
+	case "CustomResourceDefinition", "Namespace":
+		return PhaseFoundation
+	case "ServiceAccount", "Role", "ClusterRole", "RoleBinding", "ClusterRoleBinding":
+		return PhaseRBAC
+	case "ConfigMap", "Secret", "ResourceQuota", "LimitRange", "PriorityClass", "StorageClass",
+		"PersistentVolume", "PersistentVolumeClaim", "NetworkPolicy", "IngressClass":
+		return PhaseConfig
+	default:
+		return PhaseWorkloads
+	}
+}
+
+// phaseRank returns the position of the object's phase in the apply order
+func phaseRank(kind string) int {
+	return phaseOrder[objectPhase(kind)]
+}
+
+// crdPhase tracks the CRDs applied in the foundation phase so later phases can wait for them
+type crdPhase struct {
+	names []string
+	kinds map[string]bool
+	done  bool
+}
+
+// add records a CRD that is about to be applied
+func (p *crdPhase) add(obj *unstructured.Unstructured) {
+	if obj.GetKind() != "CustomResourceDefinition" {
+		return
+	}
+	p.names = append(p.names, obj.GetName())
+	if p.kinds == nil {
+		p.kinds = make(map[string]bool)
+	}
+	if kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind"); kind != "" {
+		p.kinds[kind] = true
+	}
+}
+
+// definesKind reports whether one of the recorded CRDs defines the kind
+func (p *crdPhase) definesKind(kind string) bool {
+	return p.kinds[kind]
+}
+
+// finish waits once for the recorded CRDs to be established before the first object of a later
+// phase is applied. It returns true when discovery must be refreshed to see the new kinds.
+func (p *crdPhase) finish(dynamicClient dynamic.Interface, kind string, dryRun bool) (bool, error) {
+	if p.done || objectPhase(kind) == PhaseFoundation {
+		return false, nil
+	}
+	p.done = true
+	if dryRun || len(p.names) == 0 {
+		return false, nil
+	}
+	if err := waitForCRDsEstablished(dynamicClient, p.names); err != nil {
+		return false, &PhaseError{Phase: PhaseEstablish, Err: err}
+	}
+	return true, nil
+}
+
+// waitForCRDsEstablished polls the CRDs until the API server reports them as Established
+func waitForCRDsEstablished(dynamicClient dynamic.Interface, names []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crdEstablishTimeout)
+	defer cancel()
+
+	for _, name := range names {
+		err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+			crd, err := dynamicClient.Resource(crdGVR).Get(ctx, name, v1.GetOptions{})
+			if err != nil {
+				// The CRD may not be visible yet right after it was applied
+				return false, nil
+			}
+			return crdEstablished(crd), nil
+		})
+		if err != nil {
+			return fmt.Errorf("CRD %s was not established within %s", name, crdEstablishTimeout)
+		}
+	}
+	return nil
+}
+
+func crdEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Established" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
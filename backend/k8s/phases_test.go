@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestObjectPhase(t *testing.T) {
+	tests := map[string]string{
+		"CustomResourceDefinition": PhaseFoundation,
+		"Namespace":                PhaseFoundation,
+		"ClusterRoleBinding":       PhaseRBAC,
+		"ServiceAccount":           PhaseRBAC,
+		"Secret":                   PhaseConfig,
+		"PersistentVolumeClaim":    PhaseConfig,
+		"Deployment":               PhaseWorkloads,
+		"Widget":                   PhaseWorkloads,
+	}
+	for kind, want := range tests {
+		if got := objectPhase(kind); got != want {
+			t.Errorf("objectPhase(%s) = %s, want %s", kind, got, want)
+		}
+	}
+
+	kinds := []string{"Deployment", "Widget", "ConfigMap", "Role", "Namespace", "Service", "CustomResourceDefinition"}
+	sort.SliceStable(kinds, func(i, j int) bool { return phaseRank(kinds[i]) < phaseRank(kinds[j]) })
+	want := []string{"Namespace", "CustomResourceDefinition", "Role", "ConfigMap", "Deployment", "Widget", "Service"}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("apply order = %v, want %v", kinds, want)
+		}
+	}
+}
+
+func TestCRDPhase(t *testing.T) {
+	crd := func(name, kind string, established bool) *unstructured.Unstructured {
+		status := "False"
+		if established {
+			status = "True"
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"names": map[string]interface{}{"kind": kind}},
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Established", "status": status},
+			}},
+		}}
+	}
+	widgets := crd("widgets.example.com", "Widget", true)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdGVR: "CustomResourceDefinitionList"}, widgets)
+
+	var phase crdPhase
+	phase.add(widgets)
+	phase.add(&unstructured.Unstructured{Object: map[string]interface{}{"kind": "Namespace", "metadata": map[string]interface{}{"name": "app"}}})
+	if !phase.definesKind("Widget") || phase.definesKind("Namespace") {
+		t.Errorf("definesKind is wrong for %+v", phase.kinds)
+	}
+
+	// Objects of the foundation phase do not wait
+	if refresh, err := phase.finish(client, "Namespace", false); refresh || err != nil {
+		t.Errorf("finish before a later phase = %v, %v; want no wait", refresh, err)
+	}
+	if refresh, err := phase.finish(client, "Widget", false); !refresh || err != nil {
+		t.Errorf("finish = %v, %v; want a discovery refresh", refresh, err)
+	}
+	// Only the first object of a later phase waits
+	if refresh, err := phase.finish(client, "Deployment", false); refresh || err != nil {
+		t.Errorf("second finish = %v, %v; want no wait", refresh, err)
+	}
+
+	var dryRun crdPhase
+	dryRun.add(widgets)
+	if refresh, err := dryRun.finish(client, "Widget", true); refresh || err != nil {
+		t.Errorf("dry-run finish = %v, %v; want no wait", refresh, err)
+	}
+
+	if crdEstablished(crd("gadgets.example.com", "Gadget", false)) {
+		t.Errorf("crdEstablished accepted a CRD that is not established")
+	}
+}
+
+func TestPhaseError(t *testing.T) {
+	cause := errors.New("forbidden")
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Role",
+		"metadata": map[string]interface{}{"name": "reader", "namespace": "app"},
+	}}
+	err := error(newPhaseError(PhaseRBAC, obj, cause))
+	if err.Error() != "phase rbac failed on Role reader: forbidden" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("PhaseError does not unwrap to its cause")
+	}
+	var phaseErr *PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Namespace != "app" {
+		t.Errorf("PhaseError = %+v, want the namespace of the object", phaseErr)
+	}
+	if got := (&PhaseError{Phase: PhaseEstablish, Err: cause}).Error(); got != "phase wait-for-crds failed: forbidden" {
+		t.Errorf("Error() without an object = %q", got)
+	}
+}
@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// WorkloadLabelKey is the label that ties deployed objects to their workload
+const WorkloadLabelKey = "kubestellar.io/workload"
+
+// AppliedObject identifies an object written by a deployment
+type AppliedObject struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// GVR returns the GroupVersionResource of the object
+func (o AppliedObject) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: o.Group, Version: o.Version, Resource: o.Resource}
+}
+
+// InventoryObjects returns the objects that belong to the deployment: those it wrote and those
+// it skipped because of field conflicts, which still exist and must be pruned like the others
+func (t *DeploymentTree) InventoryObjects() []AppliedObject {
+	objects := append([]AppliedObject{}, t.Objects...)
+	for _, conflict := range t.Conflicts {
+		if conflict.Resource == "" {
+			continue
+		}
+		objects = append(objects, AppliedObject{
+			Group:     conflict.Group,
+			Version:   conflict.Version,
+			Resource:  conflict.Resource,
+			Kind:      conflict.Kind,
+			Namespace: conflict.Namespace,
+			Name:      conflict.Name,
+		})
+	}
+	return objects
+}
+
+// objectKeys indexes objects by kind, namespace and name. Cluster-scoped objects are
+// also indexed by kind and name alone since manifests may carry a namespace for them.
+type objectKeys map[string]bool
+
+func newObjectKeys(objects []AppliedObject, conflicts []ResourceConflict) objectKeys {
+	keys := objectKeys{}
+	for _, obj := range objects {
+		keys[obj.Kind+"/"+obj.Namespace+"/"+obj.Name] = true
+		keys[obj.Kind+"//"+obj.Name] = true
+	}
+	// Objects skipped because of field conflicts are still part of the workload
+	for _, conflict := range conflicts {
+		keys[conflict.Kind+"/"+conflict.Namespace+"/"+conflict.Name] = true
+		keys[conflict.Kind+"//"+conflict.Name] = true
+	}
+	return keys
+}
+
+func (k objectKeys) has(kind, namespace, name string) bool {
+	if namespace == "" {
+		return k[kind+"//"+name]
+	}
+	return k[kind+"/"+namespace+"/"+name]
+}
+
+// pruneCandidates returns the objects of the previous inventory that the latest deployment
+// (tree) no longer produced. Namespaces are never candidates because they are also labelled
+// when created on demand for the workload.
+func pruneCandidates(previous []AppliedObject, tree *DeploymentTree) []AppliedObject {
+	keep := newObjectKeys(tree.Objects, tree.Conflicts)
+	var candidates []AppliedObject
+	for _, obj := range previous {
+		if obj.Kind == "Namespace" || keep.has(obj.Kind, obj.Namespace, obj.Name) {
+			continue
+		}
+		candidates = append(candidates, obj)
+	}
+	return candidates
+}
+
+// stillOwned reports whether a live object is still the one the workload applied: it must
+// have the expected kind and still carry the workload label
+func stillOwned(obj AppliedObject, live *unstructured.Unstructured, workloadLabel string) bool {
+	return live.GetKind() == obj.Kind && live.GetLabels()[WorkloadLabelKey] == workloadLabel
+}
+
+// PruneWorkload deletes the objects of the previous inventory that the latest deployment
+// (tree) no longer produced. Objects the workload never applied are left alone even when they
+// carry its label, and each candidate is re-read before deleting it, so one that was relabelled
+// or replaced since the previous deployment is kept.
+func PruneWorkload(workloadLabel string, previous []AppliedObject, tree *DeploymentTree) ([]AppliedObject, error) {
+	if workloadLabel == "" || len(previous) == 0 {
+		return nil, nil
+	}
+
+	_, dynamicClient, err := GetClientSet()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kubernetes client: %v", err)
+	}
+
+	var pruned []AppliedObject
+	for _, obj := range pruneCandidates(previous, tree) {
+		var resource dynamic.ResourceInterface = dynamicClient.Resource(obj.GVR())
+		if obj.Namespace != "" {
+			resource = dynamicClient.Resource(obj.GVR()).Namespace(obj.Namespace)
+		}
+
+		live, err := resource.Get(context.TODO(), obj.Name, v1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// Already gone, or its resource type no longer exists, e.g. its CRD was removed
+				continue
+			}
+			return pruned, fmt.Errorf("failed to get %s %s: %v", obj.Kind, obj.Name, err)
+		}
+		if !stillOwned(obj, live, workloadLabel) {
+			continue
+		}
+
+		// The UID precondition keeps an object recreated since the read from being deleted
+		uid := live.GetUID()
+		if err := resource.Delete(context.TODO(), obj.Name, v1.DeleteOptions{Preconditions: &v1.Preconditions{UID: &uid}}); err != nil {
+			if errors.IsNotFound(err) || errors.IsConflict(err) {
+				continue
+			}
+			return pruned, fmt.Errorf("failed to prune %s %s: %v", obj.Kind, obj.Name, err)
+		}
+
+		fmt.Printf("Pruned: %s %s\n", obj.Kind, obj.Name)
+		pruned = append(pruned, obj)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	deployment := AppliedObject{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "demo", Name: "web"}
+	service := AppliedObject{Version: "v1", Resource: "services", Kind: "Service", Namespace: "demo", Name: "web"}
+	namespace := AppliedObject{Version: "v1", Resource: "namespaces", Kind: "Namespace", Name: "demo"}
+	clusterRole := AppliedObject{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles", Kind: "ClusterRole", Name: "reader"}
+
+	tests := []struct {
+		name     string
+		previous []AppliedObject
+		tree     *DeploymentTree
+		want     []AppliedObject
+	}{
+		{
+			name:     "unchanged tree prunes nothing",
+			previous: []AppliedObject{deployment, service},
+			tree:     &DeploymentTree{Objects: []AppliedObject{deployment, service}},
+		},
+		{
+			name:     "removed manifest is a candidate",
+			previous: []AppliedObject{deployment, service},
+			tree:     &DeploymentTree{Objects: []AppliedObject{deployment}},
+			want:     []AppliedObject{service},
+		},
+		{
+			name:     "namespaces are never candidates",
+			previous: []AppliedObject{namespace, deployment},
+			tree:     &DeploymentTree{},
+			want:     []AppliedObject{deployment},
+		},
+		{
+			name:     "objects skipped for conflicts are kept",
+			previous: []AppliedObject{deployment, service},
+			tree: &DeploymentTree{
+				Objects:   []AppliedObject{deployment},
+				Conflicts: []ResourceConflict{{Kind: "Service", Namespace: "demo", Name: "web"}},
+			},
+		},
+		{
+			name:     "cluster-scoped object applied with a namespace is kept",
+			previous: []AppliedObject{clusterRole},
+			tree:     &DeploymentTree{Objects: []AppliedObject{{Kind: "ClusterRole", Namespace: "demo", Name: "reader"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pruneCandidates(tt.previous, tt.tree); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pruneCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStillOwned(t *testing.T) {
+	obj := AppliedObject{Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespace: "demo", Name: "settings"}
+	live := func(kind string, labels map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetKind(kind)
+		u.SetLabels(labels)
+		return u
+	}
+
+	tests := []struct {
+		name string
+		live *unstructured.Unstructured
+		want bool
+	}{
+		{"labelled by the workload", live("ConfigMap", map[string]string{WorkloadLabelKey: "app"}), true},
+		{"label removed", live("ConfigMap", nil), false},
+		{"labelled by another workload", live("ConfigMap", map[string]string{WorkloadLabelKey: "other"}), false},
+		{"different kind", live("Secret", map[string]string{WorkloadLabelKey: "app"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stillOwned(obj, tt.live, "app"); got != tt.want {
+				t.Errorf("stillOwned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryObjects(t *testing.T) {
+	web := AppliedObject{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "demo", Name: "web"}
+	tree := &DeploymentTree{
+		Objects: []AppliedObject{web},
+		Conflicts: []ResourceConflict{
+			{Group: "", Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespace: "demo", Name: "settings"},
+			// Conflicts reported without their resource cannot be pruned and are left out
+			{Kind: "Secret", Namespace: "demo", Name: "token"},
+		},
+	}
+	want := []AppliedObject{web, {Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespace: "demo", Name: "settings"}}
+	if got := tree.InventoryObjects(); !reflect.DeepEqual(got, want) {
+		t.Errorf("InventoryObjects() = %+v, want %+v", got, want)
+	}
+	if len(tree.Objects) != 1 {
+		t.Errorf("InventoryObjects modified the applied objects: %+v", tree.Objects)
+	}
+}
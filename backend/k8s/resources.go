@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/config"
 	"gopkg.in/yaml.v3"
 	"io"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
@@ -144,58 +148,167 @@ func EnsureNamespaceExistsAndAddLabel(dynamicClient dynamic.Interface, namespace
 	return nil
 }
 
+// prepareResource resolves the GVR for a parsed document, picks the namespaced or
+// cluster-scoped client and applies the workload label
+func prepareResource(resourceData map[string]interface{},
+	dynamicClient dynamic.Interface,
+	discoveryClient discovery.DiscoveryInterface) (dynamic.ResourceInterface, *unstructured.Unstructured, error) {
+	resourceKind, ok := resourceData["kind"].(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("resource kind not found in YAML")
+	}
+	namespace := "default"
+	if metadata, ok := resourceData["metadata"].(map[string]interface{}); ok {
+		if ns, exists := metadata["namespace"].(string); exists {
+			namespace = ns
+		}
+	}
+	gvr, isNamespaced, err := getGVR(discoveryClient, resourceKind)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported resource type: %s", resourceKind)
+	}
+
+	var resource dynamic.ResourceInterface
+	var labelName string
+	if isNamespaced {
+		resource = dynamicClient.Resource(gvr).Namespace(namespace)
+	} else {
+		resource = dynamicClient.Resource(gvr)
+	}
+
+	resourceObj := &unstructured.Unstructured{Object: resourceData}
+	if isNamespaced && namespace != "default" {
+		labelName = namespace
+	} else {
+		labelName = resourceObj.GetName()
+	}
+	autoLabelling(resourceObj, labelName)
+	return resource, resourceObj, nil
+}
+
+// applyResources applies the parsed documents in phases: CRDs and Namespaces first, then,
+// once the CRDs are established and discovery is refreshed, RBAC, configuration and
+// workloads. A failure is returned as a *PhaseError.
 func applyResources(c *gin.Context, yamlDocs []map[string]interface{},
 	dynamicClient dynamic.Interface,
-	discoveryClient discovery.DiscoveryInterface) ([]interface{}, error) {
+	discoveryClient discovery.DiscoveryInterface) ([]interface{}, []ResourceConflict, error) {
 	var results []interface{}
+	var conflicts []ResourceConflict
+	opts := ApplyOptionsFromRequest(c)
+
+	docs := append([]map[string]interface{}{}, yamlDocs...)
+	sort.SliceStable(docs, func(i, j int) bool {
+		return phaseRank(documentKind(docs[i])) < phaseRank(documentKind(docs[j]))
+	})
+	cachedDiscovery := memory.NewMemCacheClient(discoveryClient)
+	var crds crdPhase
+
+	for _, resourceData := range docs {
+		docObj := &unstructured.Unstructured{Object: resourceData}
+		phase := objectPhase(docObj.GetKind())
 
-	for _, resourceData := range yamlDocs {
-		resourceKind, ok := resourceData["kind"].(string)
-		if !ok {
-			return results, fmt.Errorf("resource kind not found in YAML")
+		refresh, err := crds.finish(dynamicClient, docObj.GetKind(), false)
+		if err != nil {
+			return results, conflicts, err
+		}
+		if refresh {
+			cachedDiscovery.Invalidate()
 		}
-		autoNs := c.Query("auto_ns")
-		namespace := "default"
+		crds.add(docObj)
+
 		if metadata, ok := resourceData["metadata"].(map[string]interface{}); ok {
 			if ns, exists := metadata["namespace"].(string); exists {
-				namespace = ns
-				if strings.EqualFold(autoNs, "true") || autoNs == "1" {
-					err := EnsureNamespaceExistsAndAddLabel(dynamicClient, namespace)
+				if isAutoNamespaceRequested(c) {
+					err := EnsureNamespaceExistsAndAddLabel(dynamicClient, ns)
 					if err != nil {
-						return nil, fmt.Errorf("failed to ensure namespace %s exists: %v", namespace, err)
+						return results, conflicts, newPhaseError(phase, docObj, fmt.Errorf("failed to ensure namespace %s exists: %v", ns, err))
 					}
 				}
 
 			}
 		}
-		gvr, isNamespaced, err := getGVR(discoveryClient, resourceKind)
+		resource, resourceObj, err := prepareResource(resourceData, dynamicClient, cachedDiscovery)
 		if err != nil {
-			return results, fmt.Errorf("unsupported resource type: %s", resourceKind)
-		}
-
-		var resource dynamic.ResourceInterface
-		var labelName string
-		if isNamespaced {
-			resource = dynamicClient.Resource(gvr).Namespace(namespace)
-		} else {
-			resource = dynamicClient.Resource(gvr)
+			return results, conflicts, newPhaseError(phase, docObj, err)
 		}
 
-		resourceObj := &unstructured.Unstructured{Object: resourceData}
-		if isNamespaced && namespace != "default" {
-			labelName = namespace
-		} else {
-			labelName = resourceObj.GetName()
-		}
-		autoLabelling(resourceObj, labelName)
-		result, err := resource.Create(c, resourceObj, v1.CreateOptions{})
+		result, conflict, err := serverSideApply(c, resource, resourceObj, opts, false)
 		if err != nil {
-			return results, fmt.Errorf("failed to create resource %s: %v", resourceKind, err)
+			return results, conflicts, newPhaseError(phase, resourceObj, fmt.Errorf("failed to apply resource %s: %v", resourceObj.GetKind(), err))
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+			continue
 		}
 		results = append(results, result)
 	}
-	return results, nil
+	return results, conflicts, nil
+
+}
+
+// documentKind returns the kind of a parsed document
+func documentKind(doc map[string]interface{}) string {
+	kind, _ := doc["kind"].(string)
+	return kind
+}
+
+// respondApplyError reports a failed apply, including the phase and object it failed on
+func respondApplyError(c *gin.Context, err error) {
+	var phaseErr *PhaseError
+	if errors.As(err, &phaseErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":       err.Error(),
+			"failedPhase": phaseErr.Phase,
+			"resource":    phaseErr,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
 
+func respondApplied(c *gin.Context, results []interface{}, conflicts []ResourceConflict) {
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "some resources were not applied because their fields are owned by other managers; retry with force=true to take ownership",
+			"resources": results,
+			"conflicts": conflicts,
+		})
+		return
+	}
+	// Return all applied resources
+	c.JSON(http.StatusCreated, gin.H{"resources": results})
+}
+
+// respondDryRun answers a ?dryRun=server request with the dry-run results and, when
+// ?diff=true is set, the per-field diff against the live objects
+func respondDryRun(c *gin.Context, diffs []ResourceDiff) {
+	response := gin.H{
+		"dryRun":  "server",
+		"message": "Dry run successful. No changes applied.",
+	}
+	for _, diff := range diffs {
+		if diff.Error != "" {
+			response["message"] = "Dry run completed with errors. No changes applied."
+			break
+		}
+	}
+	if isDiffRequested(c) {
+		response["diffs"] = diffs
+	} else {
+		summary := make([]gin.H, 0, len(diffs))
+		for _, diff := range diffs {
+			summary = append(summary, gin.H{
+				"kind":      diff.Kind,
+				"name":      diff.Name,
+				"namespace": diff.Namespace,
+				"operation": diff.Operation,
+				"conflicts": diff.Conflicts,
+				"error":     diff.Error,
+			})
+		}
+		response["resources"] = summary
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func autoLabelling(obj *unstructured.Unstructured, labelName string) {
@@ -216,9 +329,9 @@ func autoLabelling(obj *unstructured.Unstructured, labelName string) {
 func CreateResource(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -232,22 +345,26 @@ func CreateResource(c *gin.Context) {
 		return
 	}
 
-	results, err := applyResources(c, yamlDocs, dynamicClient, discoveryClient)
+	if isServerDryRun(c) {
+		respondDryRun(c, previewResources(c, yamlDocs, dynamicClient, discoveryClient))
+		return
+	}
+
+	results, conflicts, err := applyResources(c, yamlDocs, dynamicClient, discoveryClient)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondApplyError(c, err)
 		return
 	}
-	// Return all created resources
-	c.JSON(http.StatusCreated, gin.H{"resources": results})
+	respondApplied(c, results, conflicts)
 }
 
 // GetResource retrieves a resource
 func GetResource(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -295,9 +412,9 @@ func GetResource(c *gin.Context) {
 func ListResources(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -345,9 +462,9 @@ func ListResources(c *gin.Context) {
 func UpdateResource(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -380,6 +497,19 @@ func UpdateResource(c *gin.Context) {
 	// Ensure the resource has a name before updating
 	resourceObj := &unstructured.Unstructured{Object: resourceData}
 	resourceObj.SetName(name)
+
+	if isServerDryRun(c) {
+		// Preview with a dry-run update so the diff matches the full replace done below
+		respondDryRun(c, []ResourceDiff{previewResource(c, resource, resourceObj, func() (*unstructured.Unstructured, *ResourceConflict, error) {
+			result, err := resource.Update(c, resourceObj, v1.UpdateOptions{DryRun: []string{v1.DryRunAll}})
+			return result, nil, err
+		})})
+		return
+	}
+
+	// Keep the previous state for the audit trail; a failed read only leaves the diff empty
+	before, _ := resource.Get(c, name, v1.GetOptions{})
+
 	// TODO: Retry Logic
 	result, err := resource.Update(c, resourceObj, v1.UpdateOptions{})
 	if err != nil {
@@ -387,6 +517,7 @@ func UpdateResource(c *gin.Context) {
 		return
 	}
 
+	audit.SetDiff(c, before, result)
 	c.JSON(http.StatusOK, result)
 }
 
@@ -394,9 +525,9 @@ func UpdateResource(c *gin.Context) {
 func DeleteResource(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -420,21 +551,24 @@ func DeleteResource(c *gin.Context) {
 		resource = dynamicClient.Resource(gvr)
 	}
 
+	before, _ := resource.Get(c, name, v1.GetOptions{})
+
 	err = resource.Delete(c, name, v1.DeleteOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	audit.SetDiff(c, before, nil)
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
 }
 
 func UploadYAMLFile(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -456,28 +590,30 @@ func UploadYAMLFile(c *gin.Context) {
 		return
 	}
 
+	if isServerDryRun(c) {
+		respondDryRun(c, previewResources(c, yamlDocs, dynamicClient, discoveryClient))
+		return
+	}
+
 	// Apply resources
-	results, err := applyResources(c, yamlDocs, dynamicClient, discoveryClient)
+	results, conflicts, err := applyResources(c, yamlDocs, dynamicClient, discoveryClient)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondApplyError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusCreated, gin.H{"resources": results})
+	respondApplied(c, results, conflicts)
 }
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+	CheckOrigin: config.CheckOrigin,
 }
 
 func LogWorkloads(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = WDSContext()
 	}
-	clientset, dynamicClient, err := GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -4,51 +4,97 @@ import (
 	"bytes"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/auth"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/gitops"
+	jwtconfig "github.com/kubestellar/ui/jwt"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/middleware"
+	"github.com/kubestellar/ui/postgresql"
+	"github.com/kubestellar/ui/redis"
 	"github.com/kubestellar/ui/routes"
 
-	"github.com/kubestellar/ui/api"
 	"go.uber.org/zap"
 )
 
 func main() {
 	initLogger()
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	k8s.Configure(cfg.Kubernetes)
+	redis.Configure(cfg.Redis)
+	postgresql.Configure(cfg.Postgres)
+	jwtconfig.Configure(cfg.JWT)
+	auth.Configure(cfg.Auth)
+	k8s.ConfigureDeploymentHistory(cfg.DeploymentStore)
+
 	router := gin.Default()
+	// Only believe X-Forwarded-For from the configured proxies, or clients could pick the IP
+	// that login throttling and rate limits count them against
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
 
 	router.Use(ZapMiddleware())
 	log.Println("Debug: KubestellarUI application started")
 
-	// CORS Middleware
-	router.Use(func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
+	router.Use(middleware.CORS(cfg.Server.CORS))
+	router.Use(middleware.HSTS(cfg.Server.HSTS))
 
-		if origin == "http://localhost:5173" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true") // for cookies/auth
-		}
+	routes.SetupRoutes(router, cfg)
 
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Open the audit sinks before serving so no audited action goes unrecorded
+	if err := audit.Init(cfg.Audit); err != nil {
+		log.Printf("Warning: failed to open audit sinks: %v", err)
+	}
 
-		c.Next()
-	})
+	// Open the deployment history store, migrating the ConfigMap history on first use of a database backend
+	if err := k8s.InitDeploymentHistory(); err != nil {
+		log.Printf("Warning: failed to open deployment history: %v", err)
+	}
 
-	routes.SetupRoutes(router)
-	router.POST("api/webhook", api.GitHubWebhookHandler)
+	// Resume periodic reconciles of the stored GitOps sources
+	if err := gitops.StartReconciler(); err != nil {
+		log.Printf("Warning: failed to start GitOps reconciler: %v", err)
+	}
 
-	if err := router.Run(":4000"); err != nil {
+	if err := serve(router, cfg.Server); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// serve listens on the configured address, over HTTPS when a certificate is configured
+func serve(handler http.Handler, cfg config.ServerConfig) error {
+	server := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	if !cfg.TLS.Enabled() {
+		log.Printf("Listening on %s (HTTP)", cfg.Address)
+		return server.ListenAndServe()
+	}
+
+	reloader, err := config.NewCertReloader(cfg.TLS)
+	if err != nil {
+		return err
+	}
+	go reloader.Watch(make(chan struct{}))
+	server.TLSConfig = reloader.TLSConfig()
+
+	log.Printf("Listening on %s (HTTPS, client certificates: %s)", cfg.Address, cfg.TLS.ClientAuth)
+	return server.ListenAndServeTLS("", "")
+}
+
 var logger *zap.Logger
 
 // Initialize Zap Logger
@@ -65,11 +111,11 @@ func ZapMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// Capture Request Body
+		// Capture Request Body, with passwords, tokens and secret data masked
 		var requestBody string
 		if c.Request.Body != nil {
 			bodyBytes, _ := io.ReadAll(c.Request.Body)
-			requestBody = string(bodyBytes)
+			requestBody = audit.RedactBody(bodyBytes)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 		}
 
@@ -80,7 +126,7 @@ func ZapMiddleware() gin.HandlerFunc {
 		responseSize := c.Writer.Size()
 
 		// Capture Request Headers
-		headers := c.Request.Header
+		headers := audit.RedactHeaders(c.Request.Header)
 
 		// Log in structured JSON format
 		logger.Info("HTTP Request",
@@ -90,7 +136,7 @@ func ZapMiddleware() gin.HandlerFunc {
 			zap.Duration("latency", time.Since(start)),
 			zap.String("ip", c.ClientIP()),
 			zap.String("user-agent", c.Request.UserAgent()),
-			zap.Any("query-params", c.Request.URL.Query()),
+			zap.Any("query-params", audit.RedactQuery(c.Request.URL.Query())),
 			zap.String("request-body", requestBody),
 			zap.Any("headers", headers),
 			zap.Int("response-size", responseSize),
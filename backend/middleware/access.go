@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/auth"
+)
+
+// AttributesFunc describes what a request does, for authorization. A request that touches
+// several resources returns one entry per resource; all of them must be allowed.
+type AttributesFunc func(c *gin.Context) ([]auth.Attributes, error)
+
+// CurrentSubject returns the authenticated user of the request
+func CurrentSubject(c *gin.Context) auth.Subject {
+	subject := auth.Subject{Username: c.GetString("username")}
+	if permissions, ok := c.Get("permissions"); ok {
+		subject.Permissions, _ = permissions.([]string)
+	}
+	if groups, ok := c.Get("groups"); ok {
+		subject.Groups, _ = groups.([]string)
+	}
+	return subject
+}
+
+// Authorize decides whether the authenticated user of the request may perform attrs. Requests
+// made with an API token must also be allowed by the token; a personal token is then checked
+// against the full access of its owner.
+func Authorize(c *gin.Context, attrs auth.Attributes) (auth.Decision, error) {
+	subject := CurrentSubject(c)
+	if token, ok := APIToken(c); ok {
+		decision := token.Authorize(attrs)
+		if !decision.Allowed || token.Type != auth.APITokenPersonal {
+			return decision, nil
+		}
+		owner, exists, err := auth.GetUserByUsername(token.Owner)
+		if err != nil {
+			return auth.Decision{}, err
+		}
+		if !exists {
+			return auth.Decision{Allowed: false, Reason: fmt.Sprintf("owner %s of API token %s no longer exists", token.Owner, token.Name)}, nil
+		}
+		subject.Permissions = owner.Permissions
+	}
+	policies, err := auth.PoliciesFor(subject.Username, subject.Groups)
+	if err != nil {
+		return auth.Decision{}, err
+	}
+	return auth.Authorize(subject, attrs, policies), nil
+}
+
+// RequireAccess middleware checks the request against the global permissions and access
+// policies of the user. It must run after AuthenticateMiddleware.
+func RequireAccess(attributes AttributesFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requests, err := attributes(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Audit events name the first resource unless the handler describes the target itself
+		if _, ok := c.Get("audit_target"); !ok && len(requests) > 0 {
+			first := requests[0]
+			audit.SetTarget(c, audit.Target{Verb: first.Verb, Context: first.Context, Namespace: first.Namespace, Kind: first.Kind, Name: first.Name})
+		}
+
+		for _, attrs := range requests {
+			decision, err := Authorize(c, attrs)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load access policies", "details": err.Error()})
+				c.Abort()
+				return
+			}
+			if !decision.Allowed {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions", "details": decision.Reason})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -5,15 +5,19 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/kubestellar/ui/auth"
-	jwtconfig "github.com/kubestellar/ui/jwt"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/utils"
 )
 
-// AuthenticateMiddleware validates JWT token
+// AuthenticateMiddleware validates the JWT or API token of a request
 func AuthenticateMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
+		// Browsers cannot set headers on WebSocket connections, so those may pass the token as a query parameter
+		if tokenString == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			tokenString = c.Query("token")
+		}
 		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
 			c.Abort()
@@ -21,25 +25,39 @@ func AuthenticateMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		claims := jwt.MapClaims{}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtconfig.GetJWTSecret()), nil
-		})
+		if auth.IsAPIToken(tokenString) {
+			authenticateAPIToken(c, tokenString)
+			return
+		}
 
-		if err != nil || !token.Valid {
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil || claims.IsRefresh() {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		username, exists := claims["username"].(string)
-		if !exists {
+		username := claims.Username
+		if username == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token payload"})
 			c.Abort()
 			return
 		}
 
+		// Reject tokens revoked by logout, refresh or user deletion
+		revoked, err := auth.IsTokenRevoked(claims.ID)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Session store unavailable"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
 		// Get user permissions from auth system
 		userConfig, exists, err := auth.GetUserByUsername(username)
 		if err != nil || !exists {
@@ -48,13 +66,91 @@ func AuthenticateMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Until a required password change is made only the password change itself is allowed
+		if userConfig.MustChangePassword && !passwordChangeAllowed(c.FullPath()) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Password change required", "must_change_password": true})
+			c.Abort()
+			return
+		}
+
 		// Store both username and permissions in context
 		c.Set("username", username)
 		c.Set("permissions", userConfig.Permissions)
+		c.Set("groups", userConfig.Groups)
+		// Clients built for the request impersonate the user when impersonation is enabled
+		c.Request = c.Request.WithContext(k8s.WithUser(c.Request.Context(), username, userConfig.Groups))
+		c.Set("token_id", claims.ID)
+		c.Set("session_id", claims.SessionID)
+		if claims.ExpiresAt != nil {
+			c.Set("token_expires_at", claims.ExpiresAt.Time)
+		}
 		c.Next()
 	}
 }
 
+// authenticateAPIToken authenticates a request made with an API token. Personal tokens act as
+// their owner; service account tokens act as "serviceaccount:<name>" in the serviceaccounts group.
+func authenticateAPIToken(c *gin.Context, credential string) {
+	token, err := auth.AuthenticateAPIToken(credential, c.ClientIP())
+	if err == auth.ErrInvalidAPIToken || err == auth.ErrAPITokenExpired {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": err.Error()})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token store unavailable"})
+		c.Abort()
+		return
+	}
+
+	permissions := token.Permissions
+	groups := []string{auth.ServiceAccountGroup}
+	if token.Type == auth.APITokenPersonal {
+		userConfig, exists, err := auth.GetUserByUsername(token.Owner)
+		if err != nil || !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+		if userConfig.MustChangePassword {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Password change required", "must_change_password": true})
+			c.Abort()
+			return
+		}
+		permissions = token.EffectivePermissions(userConfig.Permissions)
+		groups = userConfig.Groups
+	}
+
+	username := token.Username()
+	c.Set("username", username)
+	c.Set("permissions", permissions)
+	c.Set("groups", groups)
+	c.Set("token_id", token.ID)
+	c.Set("api_token", token)
+	c.Request = c.Request.WithContext(k8s.WithUser(c.Request.Context(), username, groups))
+	c.Next()
+}
+
+// APIToken returns the API token a request was authenticated with, if any
+func APIToken(c *gin.Context) (*auth.APIToken, bool) {
+	value, exists := c.Get("api_token")
+	if !exists {
+		return nil, false
+	}
+	token, ok := value.(*auth.APIToken)
+	return token, ok
+}
+
+// passwordChangeAllowed reports whether a route stays reachable while a password change is
+// pending: the user can see who they are, change the password or log out
+func passwordChangeAllowed(path string) bool {
+	switch path {
+	case "/api/me", "/api/me/password", "/api/logout":
+		return true
+	}
+	return false
+}
+
 // RequirePermission middleware checks if the user has a specific permission
 func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
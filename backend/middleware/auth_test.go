@@ -0,0 +1,23 @@
+package middleware
+
+import "testing"
+
+func TestPasswordChangeAllowed(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/api/me", true},
+		{"/api/me/password", true},
+		{"/api/logout", true},
+		{"/api/me/can-i", false},
+		{"/api/tokens", false},
+		{"/api/deploy", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := passwordChangeAllowed(tt.path); got != tt.want {
+			t.Errorf("passwordChangeAllowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
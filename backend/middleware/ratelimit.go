@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/redis"
+)
+
+// rateLimitKeyPrefix is followed by the route group and the client of the sliding window
+const rateLimitKeyPrefix = "ratelimit:"
+
+// DefaultRateLimit applies when neither the route group nor the default limit is configured
+const DefaultRateLimit = "600/1m"
+
+// RateLimitConfig allows Limit requests per Window
+type RateLimitConfig struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// ParseRateLimit parses "<requests>/<window>" such as "100/1m"; "off" or "0" disables the limit
+func ParseRateLimit(value string) (RateLimitConfig, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" || value == "0" {
+		return RateLimitConfig{}, nil
+	}
+	count, window, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimitConfig{}, fmt.Errorf("rate limit %q must look like <requests>/<window>", value)
+	}
+	limit, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || limit < 0 {
+		return RateLimitConfig{}, fmt.Errorf("invalid request count in rate limit %q", value)
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return RateLimitConfig{}, fmt.Errorf("invalid window in rate limit %q", value)
+	}
+	return RateLimitConfig{Limit: limit, Window: duration}, nil
+}
+
+// RateLimitFor returns the configured limit of a route group, falling back to the default
+// limit and then DefaultRateLimit
+func RateLimitFor(group string) RateLimitConfig {
+	limits := config.Current().RateLimits
+	for _, value := range []string{limits.Groups[group], limits.Default, DefaultRateLimit} {
+		if value == "" {
+			continue
+		}
+		limit, err := ParseRateLimit(value)
+		if err != nil {
+			log.Printf("Warning: ignoring rate limit of %s: %v", group, err)
+			continue
+		}
+		return limit
+	}
+	return RateLimitConfig{}
+}
+
+// RateLimit middleware limits the requests of each token to a route group in a sliding
+// window kept in Redis, as configured for the group. Requests are counted per login
+// session, API token or, before authentication, client IP. Refused requests get a 429 with
+// Retry-After; the limit is not enforced while Redis is unavailable.
+func RateLimit(group string) gin.HandlerFunc {
+	limit := RateLimitFor(group)
+	return func(c *gin.Context) {
+		if limit.Limit == 0 {
+			c.Next()
+			return
+		}
+
+		key := rateLimitKeyPrefix + group + ":" + rateLimitClient(c)
+		allowed, count, retryAfter, err := redis.SlidingWindowHit(key, limit.Limit, limit.Window)
+		if err != nil {
+			log.Printf("Warning: failed to rate limit %s: %v", group, err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(limit.Limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(limit.Limit-count, 0), 10))
+		if !allowed {
+			TooManyRequests(c, "Rate limit exceeded", retryAfter)
+			return
+		}
+		c.Next()
+	}
+}
+
+// TooManyRequests aborts a request with a 429 telling the client when to retry
+func TooManyRequests(c *gin.Context, message string, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after": seconds})
+	c.Abort()
+}
+
+// rateLimitClient identifies who a request is counted against
+func rateLimitClient(c *gin.Context) string {
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		return "session:" + sessionID
+	}
+	if tokenID := c.GetString("token_id"); tokenID != "" {
+		return "token:" + tokenID
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    RateLimitConfig
+		wantErr bool
+	}{
+		{value: "100/1m", want: RateLimitConfig{Limit: 100, Window: time.Minute}},
+		{value: " 5/30s ", want: RateLimitConfig{Limit: 5, Window: 30 * time.Second}},
+		{value: "off"},
+		{value: "0"},
+		{value: "100", wantErr: true},
+		{value: "-1/1m", wantErr: true},
+		{value: "ten/1m", wantErr: true},
+		{value: "100/soon", wantErr: true},
+		{value: "100/0s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRateLimit(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRateLimit(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
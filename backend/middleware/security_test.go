@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/config"
+)
+
+// serve runs a request through a router with the middleware and a handler answering 200
+func serve(middleware gin.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware)
+	router.Any("/api", func(c *gin.Context) { c.Status(http.StatusOK) })
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	cors := CORS(config.CORSConfig{
+		AllowedOrigins:   []string{"https://ui.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		wantStatus      int
+		wantAllowOrigin string
+		wantMaxAge      string
+	}{
+		{"allowed origin", http.MethodGet, "https://ui.example.com", http.StatusOK, "https://ui.example.com", ""},
+		{"other origin", http.MethodGet, "https://evil.example.com", http.StatusOK, "", ""},
+		{"no origin", http.MethodGet, "", http.StatusOK, "", ""},
+		{"preflight", http.MethodOptions, "https://ui.example.com", http.StatusNoContent, "https://ui.example.com", "600"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := serve(cors, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowOrigin)
+			}
+			wantCredentials := ""
+			if tt.wantAllowOrigin != "" {
+				wantCredentials = "true"
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, wantCredentials)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, tt.wantMaxAge)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+				t.Errorf("Access-Control-Allow-Methods = %q", got)
+			}
+		})
+	}
+}
+
+func TestHSTS(t *testing.T) {
+	enabled := config.HSTSConfig{Enabled: true, MaxAge: 24 * time.Hour, IncludeSubDomains: true, Preload: true}
+	tests := []struct {
+		name   string
+		config config.HSTSConfig
+		tls    bool
+		proto  string
+		want   string
+	}{
+		{"https", enabled, true, "", "max-age=86400; includeSubDomains; preload"},
+		{"https behind proxy", enabled, false, "HTTPS", "max-age=86400; includeSubDomains; preload"},
+		{"plain http", enabled, false, "", ""},
+		{"plain http behind proxy", enabled, false, "http", ""},
+		{"max age only", config.HSTSConfig{Enabled: true, MaxAge: time.Hour}, true, "", "max-age=3600"},
+		{"disabled", config.HSTSConfig{MaxAge: time.Hour}, true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api", nil)
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if got := serve(HSTS(tt.config), r).Header().Get("Strict-Transport-Security"); got != tt.want {
+				t.Errorf("Strict-Transport-Security = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"log"
 
 	"github.com/kubestellar/ui/auth"
 )
@@ -23,12 +24,14 @@ type Config struct {
 
 // User represents an authenticated user with permissions
 type User struct {
-	Username    string   `json:"username"`
-	Password    string   `json:"-"` // Password is never returned in JSON
-	Permissions []string `json:"permissions"`
+	Username           string   `json:"username"`
+	Password           string   `json:"-"` // Password is never returned in JSON
+	Permissions        []string `json:"permissions"`
+	MustChangePassword bool     `json:"must_change_password"`
 }
 
-// AuthenticateUser authenticates a user against the ConfigMap data
+// AuthenticateUser authenticates a user against the ConfigMap data. Passwords stored in
+// plaintext or with an outdated hash are re-hashed after a successful login.
 func AuthenticateUser(username, password string) (*User, error) {
 	config, err := auth.LoadK8sConfigMap()
 	if err != nil {
@@ -38,20 +41,30 @@ func AuthenticateUser(username, password string) (*User, error) {
 	// Get user configuration
 	userConfig, exists := config.GetUser(username)
 	if !exists {
-		// Use a generic message to avoid username enumeration
+		// Spend the same time as a real check and use a generic message to avoid username enumeration
+		auth.VerifyPassword("", password)
 		return nil, errors.New("invalid credentials")
 	}
 
-	// Check password (skip check if password is empty in config)
-	if userConfig.Password != "" && userConfig.Password != password {
+	ok, needsRehash := auth.VerifyPassword(userConfig.Password, password)
+	if !ok {
 		return nil, errors.New("invalid credentials")
 	}
 
+	if needsRehash {
+		if err := auth.UpgradePasswordHash(username, password); err != nil {
+			log.Printf("Warning: failed to upgrade password hash of user %s: %v", username, err)
+		} else if upgraded, exists, err := auth.GetUserByUsername(username); err == nil && exists {
+			userConfig = upgraded
+		}
+	}
+
 	// Create user object
 	user := &User{
-		Username:    username,
-		Password:    "", // Don't include password in the returned object
-		Permissions: userConfig.Permissions,
+		Username:           username,
+		Password:           "", // Don't include password in the returned object
+		Permissions:        userConfig.Permissions,
+		MustChangePassword: userConfig.MustChangePassword,
 	}
 
 	return user, nil
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/config"
 	"github.com/kubestellar/ui/k8s"
 	"github.com/kubestellar/ui/models"
 	"github.com/kubestellar/ui/redis"
@@ -29,11 +30,13 @@ const (
 	maxConcurrentRequests = 5
 )
 
-// AvailableContexts defines the list of available Kubernetes contexts
-var AvailableContexts = []string{"wds1", "its1"}
+// AvailableContexts returns the configured Kubernetes contexts, the WDS first
+func AvailableContexts() []string {
+	return []string{k8s.WDSContext(), k8s.ITSContext()}
+}
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(_ *http.Request) bool { return true },
+	CheckOrigin: config.CheckOrigin,
 }
 
 // NamespaceDetails holds namespace information and resources
@@ -51,9 +54,18 @@ type ExtendedNamespaceDetails struct {
 	CreationTimestamp time.Time `json:"creationTimestamp"`
 }
 
+// userCacheKey scopes a cache key to the impersonated user of ctx, since what a client sees
+// depends on who it acts as
+func userCacheKey(ctx context.Context, key string) string {
+	if user := k8s.ImpersonatedUser(ctx); user != "" {
+		return key + "_user_" + user
+	}
+	return key
+}
+
 // HasContextPrefix checks if a namespace has a context prefix
 func HasContextPrefix(namespace string) (bool, string, string) {
-	for _, ctxPrefix := range AvailableContexts {
+	for _, ctxPrefix := range AvailableContexts() {
 		prefix := ctxPrefix + "-"
 		if strings.HasPrefix(namespace, prefix) {
 			cleanName := strings.TrimPrefix(namespace, prefix)
@@ -73,12 +85,13 @@ func AddContextPrefix(namespace string, contextName string) string {
 	return namespace
 }
 
-// CreateNamespaceWithContext creates a new namespace in the specified context
-func CreateNamespaceWithContext(contextName string, namespace models.Namespace) error {
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+// CreateNamespaceWithContext creates a new namespace in the specified context as the user of
+// the parent request context
+func CreateNamespaceWithContext(parent context.Context, contextName string, namespace models.Namespace) error {
+	ctx, cancel := context.WithTimeout(parent, requestTimeout)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Kubernetes client with context %s: %w", contextName, err)
 	}
@@ -99,12 +112,13 @@ func CreateNamespaceWithContext(contextName string, namespace models.Namespace)
 	return nil
 }
 
-// UpdateNamespaceWithContext updates namespace labels in the specified context
-func UpdateNamespaceWithContext(contextName string, namespaceName string, labels map[string]string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+// UpdateNamespaceWithContext updates namespace labels in the specified context as the user of
+// the parent request context
+func UpdateNamespaceWithContext(parent context.Context, contextName string, namespaceName string, labels map[string]string) error {
+	ctx, cancel := context.WithTimeout(parent, requestTimeout)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Kubernetes client with context %s: %w", contextName, err)
 	}
@@ -123,12 +137,13 @@ func UpdateNamespaceWithContext(contextName string, namespaceName string, labels
 	return nil
 }
 
-// DeleteNamespaceWithContext removes a namespace from the specified context
-func DeleteNamespaceWithContext(contextName string, namespaceName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+// DeleteNamespaceWithContext removes a namespace from the specified context as the user of the
+// parent request context
+func DeleteNamespaceWithContext(parent context.Context, contextName string, namespaceName string) error {
+	ctx, cancel := context.WithTimeout(parent, requestTimeout)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Kubernetes client with context %s: %w", contextName, err)
 	}
@@ -140,14 +155,15 @@ func DeleteNamespaceWithContext(contextName string, namespaceName string) error
 	return nil
 }
 
-// GetNamespaceResourcesWithContext fetches resources with context
-func GetNamespaceResourcesWithContext(contextName string, namespace string) (*NamespaceDetails, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+// GetNamespaceResourcesWithContext fetches resources with context as the user of the parent
+// request context
+func GetNamespaceResourcesWithContext(parent context.Context, contextName string, namespace string) (*NamespaceDetails, error) {
+	ctx, cancel := context.WithTimeout(parent, requestTimeout)
 	defer cancel()
 
 	// Add context prefix to namespace if needed
 
-	clientset, dynamicClient, err := k8s.GetClientSetWithContext(contextName)
+	clientset, dynamicClient, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Kubernetes client with context %s: %w", contextName, err)
 	}
@@ -174,7 +190,7 @@ func GetNamespaceResourcesWithContext(contextName string, namespace string) (*Na
 
 	// Get discovery info from cache with longer TTL (1 hour)
 	var resources []*metav1.APIResourceList
-	cacheKey := fmt.Sprintf("api_resources_%s", contextName)
+	cacheKey := userCacheKey(parent, fmt.Sprintf("api_resources_%s", contextName))
 	cachedResources, err := redis.GetNamespaceCache(cacheKey)
 	if err == nil && cachedResources != "" {
 		if err := json.Unmarshal([]byte(cachedResources), &resources); err != nil {
@@ -219,7 +235,7 @@ func GetNamespaceResourcesWithContext(contextName string, namespace string) (*Na
 				<-rateLimiter.C         // Wait for rate limiter tick
 				semaphore <- struct{}{} // Acquire semaphore
 
-				cacheKey := fmt.Sprintf("ns_%s_ctx_%s_res_%s_%s_%s", namespace, contextName, gvr.Group, gvr.Version, gvr.Resource)
+				cacheKey := userCacheKey(parent, fmt.Sprintf("ns_%s_ctx_%s_res_%s_%s_%s", namespace, contextName, gvr.Group, gvr.Version, gvr.Resource))
 				resourceKey := fmt.Sprintf("%s.%s/%s", gvr.Group, gvr.Version, gvr.Resource)
 
 				// Try from cache first
@@ -429,9 +445,9 @@ func getFilteredNamespacedResourcesWithContext(clientset kubernetes.Interface) (
 }
 
 // GetAllNamespacesWithContext retrieves all namespaces with their resources for a specific context
-func GetAllNamespacesWithContext(contextName string) ([]NamespaceDetails, error) {
+func GetAllNamespacesWithContext(parent context.Context, contextName string) ([]NamespaceDetails, error) {
 	// Try to get data from cache first with context key
-	cacheKey := fmt.Sprintf("%s_%s", namespaceCacheKey, contextName)
+	cacheKey := userCacheKey(parent, fmt.Sprintf("%s_%s", namespaceCacheKey, contextName))
 	cachedData, err := redis.GetNamespaceCache(cacheKey)
 	if err == nil && cachedData != "" {
 		var result []NamespaceDetails
@@ -440,10 +456,10 @@ func GetAllNamespacesWithContext(contextName string) ([]NamespaceDetails, error)
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout*2)
+	ctx, cancel := context.WithTimeout(parent, requestTimeout*2)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Kubernetes client with context %s: %w", contextName, err)
 	}
@@ -477,7 +493,7 @@ func GetAllNamespacesWithContext(contextName string) ([]NamespaceDetails, error)
 		}
 
 		// Check if we already have this namespace in Redis cache with context key
-		nsKey := fmt.Sprintf("namespace_%s_%s", contextName, ns.Name)
+		nsKey := userCacheKey(parent, fmt.Sprintf("namespace_%s_%s", contextName, ns.Name))
 		cachedNs, err := redis.GetNamespaceCache(nsKey)
 		if err == nil && cachedNs != "" {
 			var details NamespaceDetails
@@ -509,7 +525,7 @@ func GetAllNamespacesWithContext(contextName string) ([]NamespaceDetails, error)
 
 			// Prioritize non-system namespaces
 			if !strings.HasPrefix(ns.Name, "kube-") {
-				nsDetails, err := fetchNamespaceResourcesWithRetryAndContext(ns.Name, contextName)
+				nsDetails, err := fetchNamespaceResourcesWithRetryAndContext(parent, ns.Name, contextName)
 				if err == nil && nsDetails != nil {
 					details = *nsDetails
 					// Cache individual namespace data with context key
@@ -542,7 +558,7 @@ func GetAllNamespacesWithContext(contextName string) ([]NamespaceDetails, error)
 }
 
 // fetchNamespaceResourcesWithRetryAndContext fetches resources with exponential backoff and context
-func fetchNamespaceResourcesWithRetryAndContext(namespace string, contextName string) (*NamespaceDetails, error) {
+func fetchNamespaceResourcesWithRetryAndContext(parent context.Context, namespace string, contextName string) (*NamespaceDetails, error) {
 	var (
 		details *NamespaceDetails
 		err     error
@@ -551,7 +567,7 @@ func fetchNamespaceResourcesWithRetryAndContext(namespace string, contextName st
 	)
 
 	for i := 0; i < retries; i++ {
-		details, err = GetNamespaceResourcesWithContext(contextName, namespace)
+		details, err = GetNamespaceResourcesWithContext(parent, contextName, namespace)
 		if err == nil {
 			return details, nil
 		}
@@ -563,9 +579,9 @@ func fetchNamespaceResourcesWithRetryAndContext(namespace string, contextName st
 }
 
 // getLatestNamespaceDataWithContext gets the latest namespace data with context
-func getLatestNamespaceDataWithContext(contextName string) ([]NamespaceDetails, error) {
+func getLatestNamespaceDataWithContext(parent context.Context, contextName string) ([]NamespaceDetails, error) {
 	// Try cache first with context key
-	cacheKey := fmt.Sprintf("%s_%s", namespaceCacheKey, contextName)
+	cacheKey := userCacheKey(parent, fmt.Sprintf("%s_%s", namespaceCacheKey, contextName))
 	cachedData, err := redis.GetNamespaceCache(cacheKey)
 	if err == nil && cachedData != "" {
 		var result []NamespaceDetails
@@ -575,17 +591,17 @@ func getLatestNamespaceDataWithContext(contextName string) ([]NamespaceDetails,
 	}
 
 	// If not in cache, get fresh data
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 1*time.Second)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
-		return getMinimalNamespaceDataWithContext(contextName)
+		return getMinimalNamespaceDataWithContext(parent, contextName)
 	}
 
 	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return getMinimalNamespaceDataWithContext(contextName)
+		return getMinimalNamespaceDataWithContext(parent, contextName)
 	}
 
 	var (
@@ -622,7 +638,7 @@ func getLatestNamespaceDataWithContext(contextName string) ([]NamespaceDetails,
 			defer func() { <-semaphore }() // Release semaphore
 
 			// Try cache first for this namespace with context
-			nsKey := fmt.Sprintf("namespace_%s_%s", contextName, ns.Name)
+			nsKey := userCacheKey(parent, fmt.Sprintf("namespace_%s_%s", contextName, ns.Name))
 			cachedNs, err := redis.GetNamespaceCache(nsKey)
 			if err == nil && cachedNs != "" {
 				var details NamespaceDetails
@@ -637,7 +653,7 @@ func getLatestNamespaceDataWithContext(contextName string) ([]NamespaceDetails,
 			}
 
 			// Get resource details with context
-			details, err := GetNamespaceResourcesWithContext(contextName, ns.Name)
+			details, err := GetNamespaceResourcesWithContext(parent, contextName, ns.Name)
 			if err != nil {
 				// Fall back to basic namespace info
 				mu.Lock()
@@ -677,11 +693,11 @@ func getLatestNamespaceDataWithContext(contextName string) ([]NamespaceDetails,
 }
 
 // getMinimalNamespaceDataWithContext gets just namespace names with context
-func getMinimalNamespaceDataWithContext(contextName string) ([]NamespaceDetails, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+func getMinimalNamespaceDataWithContext(parent context.Context, contextName string) ([]NamespaceDetails, error) {
+	ctx, cancel := context.WithTimeout(parent, 1*time.Second)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return nil, err
 	}
@@ -720,8 +736,8 @@ func getMinimalNamespaceDataWithContext(contextName string) ([]NamespaceDetails,
 func MultiContextWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract context parameter
 	contextName := r.URL.Query().Get("context")
-	if contextName == "" && len(AvailableContexts) > 0 {
-		contextName = AvailableContexts[0] // Default to first context
+	if contextName == "" && len(AvailableContexts()) > 0 {
+		contextName = AvailableContexts()[0] // Default to first context
 	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -765,7 +781,7 @@ func MultiContextWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// Send initial data immediately with active context
-	initialData, err := getLatestNamespaceDataWithContext(activeContext)
+	initialData, err := getLatestNamespaceDataWithContext(r.Context(), activeContext)
 	if err == nil && initialData != nil {
 		jsonData, _ := json.Marshal(initialData)
 		_ = conn.WriteMessage(websocket.TextMessage, jsonData)
@@ -781,7 +797,7 @@ func MultiContextWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 			return // Stop if client disconnects
 		case <-ticker.C:
 			// Get complete data every time using the active context
-			completeData, err := getLatestNamespaceDataWithContext(activeContext)
+			completeData, err := getLatestNamespaceDataWithContext(r.Context(), activeContext)
 			if err != nil || completeData == nil {
 				continue
 			}
@@ -800,27 +816,27 @@ func MultiContextWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // CreateNamespace creates a new namespace using default context
-func CreateNamespace(namespace models.Namespace) error {
+func CreateNamespace(parent context.Context, namespace models.Namespace) error {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return fmt.Errorf("no available contexts defined")
 	}
 
-	return CreateNamespaceWithContext(AvailableContexts[0], namespace)
+	return CreateNamespaceWithContext(parent, AvailableContexts()[0], namespace)
 }
 
 // GetAllNamespaces fetches all namespaces along with their pods using default context
-func GetAllNamespaces() ([]models.Namespace, error) {
+func GetAllNamespaces(parent context.Context) ([]models.Namespace, error) {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return nil, fmt.Errorf("no available contexts defined")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	ctx, cancel := context.WithTimeout(parent, requestTimeout)
 	defer cancel()
 
-	contextName := AvailableContexts[0]
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	contextName := AvailableContexts()[0]
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Kubernetes client: %w", err)
 	}
@@ -866,47 +882,47 @@ func GetAllNamespaces() ([]models.Namespace, error) {
 }
 
 // UpdateNamespace updates namespace labels using default context
-func UpdateNamespace(namespaceName string, labels map[string]string) error {
+func UpdateNamespace(parent context.Context, namespaceName string, labels map[string]string) error {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return fmt.Errorf("no available contexts defined")
 	}
 
-	return UpdateNamespaceWithContext(AvailableContexts[0], namespaceName, labels)
+	return UpdateNamespaceWithContext(parent, AvailableContexts()[0], namespaceName, labels)
 }
 
 // DeleteNamespace removes a namespace using default context
-func DeleteNamespace(name string) error {
+func DeleteNamespace(parent context.Context, name string) error {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return fmt.Errorf("no available contexts defined")
 	}
 
-	return DeleteNamespaceWithContext(AvailableContexts[0], name)
+	return DeleteNamespaceWithContext(parent, AvailableContexts()[0], name)
 }
 
 // GetAllNamespacesWithResources retrieves all namespaces with their resources using default context
-func GetAllNamespacesWithResources() ([]NamespaceDetails, error) {
+func GetAllNamespacesWithResources(parent context.Context) ([]NamespaceDetails, error) {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return nil, fmt.Errorf("no available contexts defined")
 	}
 
-	return GetAllNamespacesWithContext(AvailableContexts[0])
+	return GetAllNamespacesWithContext(parent, AvailableContexts()[0])
 }
 
 // GetNamespaceResources fetches resources for a namespace using discovery API with default context
-func GetNamespaceResources(namespace string) (*ExtendedNamespaceDetails, error) {
+func GetNamespaceResources(parent context.Context, namespace string) (*ExtendedNamespaceDetails, error) {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return nil, fmt.Errorf("no available contexts defined")
 	}
 
-	contextName := AvailableContexts[0]
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	contextName := AvailableContexts()[0]
+	ctx, cancel := context.WithTimeout(parent, requestTimeout)
 	defer cancel()
 
-	clientset, _, err := k8s.GetClientSetWithContext(contextName)
+	clientset, _, err := k8s.GetClientSetForRequest(parent, contextName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Kubernetes client: %w", err)
 	}
@@ -944,7 +960,7 @@ func NamespaceWebSocketHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetAllContextNamespaces returns namespaces from all available contexts
-func GetAllContextNamespaces() (map[string][]NamespaceDetails, error) {
+func GetAllContextNamespaces(parent context.Context) (map[string][]NamespaceDetails, error) {
 	result := make(map[string][]NamespaceDetails)
 
 	// Use a wait group to parallelize fetches from different contexts
@@ -952,12 +968,12 @@ func GetAllContextNamespaces() (map[string][]NamespaceDetails, error) {
 	var mu sync.Mutex
 	var errs []error
 
-	for _, ctx := range AvailableContexts {
+	for _, ctx := range AvailableContexts() {
 		wg.Add(1)
 		go func(contextName string) {
 			defer wg.Done()
 
-			namespaces, err := getLatestNamespaceDataWithContext(contextName)
+			namespaces, err := getLatestNamespaceDataWithContext(parent, contextName)
 			if err != nil {
 				mu.Lock()
 				errs = append(errs, fmt.Errorf("error fetching namespaces from context %s: %w", contextName, err))
@@ -1002,7 +1018,7 @@ func MultiContextNamespaceWebSocketHandler(w http.ResponseWriter, r *http.Reques
 	}()
 
 	// Send initial data immediately from all contexts
-	initialData, err := GetAllContextNamespaces()
+	initialData, err := GetAllContextNamespaces(r.Context())
 	if err == nil && initialData != nil {
 		jsonData, _ := json.Marshal(initialData)
 		_ = conn.WriteMessage(websocket.TextMessage, jsonData)
@@ -1018,7 +1034,7 @@ func MultiContextNamespaceWebSocketHandler(w http.ResponseWriter, r *http.Reques
 			return // Stop if client disconnects
 		case <-ticker.C:
 			// Get complete data from all contexts
-			completeData, err := GetAllContextNamespaces()
+			completeData, err := GetAllContextNamespaces(r.Context())
 			if err != nil || len(completeData) == 0 {
 				continue
 			}
@@ -1091,9 +1107,9 @@ func WatchAllContextsNamespaces(w http.ResponseWriter, r *http.Request) {
 	// Start data fetching in background
 	go func() {
 		// Send initial data in chunks for faster response
-		for _, contextName := range AvailableContexts {
+		for _, contextName := range AvailableContexts() {
 			go func(ctx context.Context, contextName string) {
-				namespaces, err := getMinimalNamespaceDataWithContext(contextName)
+				namespaces, err := getMinimalNamespaceDataWithContext(ctx, contextName)
 				if err != nil {
 					return
 				}
@@ -1109,9 +1125,9 @@ func WatchAllContextsNamespaces(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Watch for changes in each context
-		for _, contextName := range AvailableContexts {
+		for _, contextName := range AvailableContexts() {
 			go func(ctx context.Context, contextName string) {
-				clientset, _, err := k8s.GetClientSetWithContext(contextName)
+				clientset, _, err := k8s.GetClientSetForRequest(ctx, contextName)
 				if err != nil {
 					return
 				}
@@ -1155,7 +1171,7 @@ func WatchAllContextsNamespaces(w http.ResponseWriter, r *http.Request) {
 			}
 			lastRefresh = time.Now()
 
-			refreshData, err := GetAllContextNamespaces()
+			refreshData, err := GetAllContextNamespaces(r.Context())
 			if err != nil || refreshData == nil {
 				continue
 			}
@@ -1165,7 +1181,7 @@ func WatchAllContextsNamespaces(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
-			redis.SetNamespaceCache("all_contexts_data", string(jsonData), 30*time.Second)
+			redis.SetNamespaceCache(userCacheKey(ctx, "all_contexts_data"), string(jsonData), 30*time.Second)
 			if err := safeWrite(websocket.TextMessage, jsonData); err != nil {
 				return
 			}
@@ -1200,23 +1216,23 @@ func shouldHideNamespace(name string) bool {
 }
 
 // getLatestNamespaceData tries multiple ways to get namespace data
-func getLatestNamespaceData() ([]NamespaceDetails, error) {
+func getLatestNamespaceData(parent context.Context) ([]NamespaceDetails, error) {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return nil, fmt.Errorf("no available contexts defined")
 	}
 
-	return getLatestNamespaceDataWithContext(AvailableContexts[0])
+	return getLatestNamespaceDataWithContext(parent, AvailableContexts()[0])
 }
 
 // getMinimalNamespaceData gets just namespace names without heavy resource details
-func getMinimalNamespaceData() ([]NamespaceDetails, error) {
+func getMinimalNamespaceData(parent context.Context) ([]NamespaceDetails, error) {
 	// Use first available context as default
-	if len(AvailableContexts) == 0 {
+	if len(AvailableContexts()) == 0 {
 		return nil, fmt.Errorf("no available contexts defined")
 	}
 
-	return getMinimalNamespaceDataWithContext(AvailableContexts[0])
+	return getMinimalNamespaceDataWithContext(parent, AvailableContexts()[0])
 }
 
 // WatchNamespaceInContext sets up watch for resources in a namespace in a specific context
@@ -1230,8 +1246,8 @@ func WatchNamespaceInContext(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Use default context if not specified
-	if contextName == "" && len(AvailableContexts) > 0 {
-		contextName = AvailableContexts[0]
+	if contextName == "" && len(AvailableContexts()) > 0 {
+		contextName = AvailableContexts()[0]
 	}
 
 	// Store original namespace (without prefix) for display purposes
@@ -1279,7 +1295,7 @@ func WatchNamespaceInContext(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// Send initial namespace state
-	initialData, err := GetNamespaceResourcesWithContext(contextName, namespace)
+	initialData, err := GetNamespaceResourcesWithContext(ctx, contextName, namespace)
 	if err != nil {
 		sendErrorMsg(conn, fmt.Sprintf("Failed to get initial state: %s", err.Error()))
 	} else if initialData != nil {
@@ -1291,7 +1307,7 @@ func WatchNamespaceInContext(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get dynamic client for watching resources with the specified context
-	_, dynamicClient, err := k8s.GetClientSetWithContext(contextName)
+	_, dynamicClient, err := k8s.GetClientSetForRequest(ctx, contextName)
 	if err != nil {
 		sendErrorMsg(conn, fmt.Sprintf("Failed to initialize Kubernetes client with context %s: %s", contextName, err.Error()))
 		return
@@ -1506,7 +1522,7 @@ func GetAllContextsNamespaces(w http.ResponseWriter, r *http.Request) {
 	detailed := r.URL.Query().Get("detailed") == "true"
 
 	// For each available context, fetch namespaces concurrently
-	for _, ctxName := range AvailableContexts {
+	for _, ctxName := range AvailableContexts() {
 		wg.Add(1)
 		go func(contextName string) {
 			defer wg.Done()
@@ -1516,10 +1532,10 @@ func GetAllContextsNamespaces(w http.ResponseWriter, r *http.Request) {
 
 			if detailed {
 				// Get detailed namespace information including resources
-				namespaces, err = GetAllNamespacesWithContext(contextName)
+				namespaces, err = GetAllNamespacesWithContext(r.Context(), contextName)
 			} else {
 				// Get minimal namespace information (faster)
-				namespaces, err = getMinimalNamespaceDataWithContext(contextName)
+				namespaces, err = getMinimalNamespaceDataWithContext(r.Context(), contextName)
 			}
 
 			mu.Lock()
@@ -1575,12 +1591,12 @@ func GetContextNamespace(w http.ResponseWriter, r *http.Request) {
 
 	// Extract context from query parameters
 	contextName := r.URL.Query().Get("context")
-	if contextName == "" && len(AvailableContexts) > 0 {
-		contextName = AvailableContexts[0]
+	if contextName == "" && len(AvailableContexts()) > 0 {
+		contextName = AvailableContexts()[0]
 	}
 
 	// Get namespace details with resources
-	details, err := GetNamespaceResourcesWithContext(contextName, namespaceName)
+	details, err := GetNamespaceResourcesWithContext(r.Context(), contextName, namespaceName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching namespace: %s", err.Error()), http.StatusNotFound)
 		return
@@ -1624,7 +1640,7 @@ func GetMultiContextNamespaceComparison(w http.ResponseWriter, r *http.Request)
 		fmt.Printf("Using specified contexts: %v\n", contextsToCheck)
 	} else {
 		// Use all available contexts
-		contextsToCheck = AvailableContexts
+		contextsToCheck = AvailableContexts()
 		fmt.Printf("Using all available contexts: %v\n", contextsToCheck)
 	}
 
@@ -1638,7 +1654,7 @@ func GetMultiContextNamespaceComparison(w http.ResponseWriter, r *http.Request)
 			defer wg.Done()
 
 			fmt.Printf("Fetching details for namespace %s in context %s\n", namespaceName, contextName)
-			details, err := GetNamespaceResourcesWithContext(contextName, namespaceName)
+			details, err := GetNamespaceResourcesWithContext(r.Context(), contextName, namespaceName)
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -1731,7 +1747,7 @@ func SynchronizeNamespace(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the source namespace
-	sourceNamespace, err := GetNamespaceResourcesWithContext(options.SourceContext, namespaceName)
+	sourceNamespace, err := GetNamespaceResourcesWithContext(r.Context(), options.SourceContext, namespaceName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching source namespace: %s", err.Error()), http.StatusNotFound)
 		return
@@ -1749,7 +1765,7 @@ func SynchronizeNamespace(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// First, check if the namespace exists in the target context
-		_, err := GetNamespaceResourcesWithContext(targetCtx, namespaceName)
+		_, err := GetNamespaceResourcesWithContext(r.Context(), targetCtx, namespaceName)
 		if err != nil {
 			// Namespace doesn't exist, create it
 			namespace := models.Namespace{
@@ -1757,7 +1773,7 @@ func SynchronizeNamespace(w http.ResponseWriter, r *http.Request) {
 				Labels: sourceNamespace.Labels,
 			}
 
-			if err := CreateNamespaceWithContext(targetCtx, namespace); err != nil {
+			if err := CreateNamespaceWithContext(r.Context(), targetCtx, namespace); err != nil {
 				results[targetCtx] = fmt.Sprintf("Failed to create namespace: %s", err.Error())
 				continue
 			}
@@ -1766,7 +1782,7 @@ func SynchronizeNamespace(w http.ResponseWriter, r *http.Request) {
 		} else {
 			// Namespace exists, update it if needed
 			if options.SyncLabels {
-				if err := UpdateNamespaceWithContext(targetCtx, namespaceName, sourceNamespace.Labels); err != nil {
+				if err := UpdateNamespaceWithContext(r.Context(), targetCtx, namespaceName, sourceNamespace.Labels); err != nil {
 					results[targetCtx] = fmt.Sprintf("Failed to update labels: %s", err.Error())
 					continue
 				}
@@ -16,7 +16,7 @@ func CreateNamespace(c *gin.Context) {
 		return
 	}
 
-	err := ns.CreateNamespace(namespace)
+	err := ns.CreateNamespace(c.Request.Context(), namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create namespace", "details": err.Error()})
 		return
@@ -30,7 +30,7 @@ func CreateNamespace(c *gin.Context) {
 
 // getAllNamespaces retrieves all namespaces with their pods
 func GetAllNamespaces(c *gin.Context) {
-	namespaces, err := ns.GetAllNamespaces()
+	namespaces, err := ns.GetAllNamespaces(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve namespaces", "details": err.Error()})
 		return
@@ -43,7 +43,7 @@ func GetAllNamespaces(c *gin.Context) {
 func GetNamespaceDetails(c *gin.Context) {
 	namespaceName := c.Param("name")
 
-	details, err := ns.GetNamespaceResources(namespaceName)
+	details, err := ns.GetNamespaceResources(c.Request.Context(), namespaceName)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Namespace not found", "details": err.Error()})
 		return
@@ -65,7 +65,7 @@ func UpdateNamespace(c *gin.Context) {
 		return
 	}
 
-	err := ns.UpdateNamespace(namespaceName, labelUpdate.Labels)
+	err := ns.UpdateNamespace(c.Request.Context(), namespaceName, labelUpdate.Labels)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update namespace", "details": err.Error()})
 		return
@@ -82,7 +82,7 @@ func UpdateNamespace(c *gin.Context) {
 func DeleteNamespace(c *gin.Context) {
 	namespaceName := c.Param("name")
 
-	err := ns.DeleteNamespace(namespaceName)
+	err := ns.DeleteNamespace(c.Request.Context(), namespaceName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete namespace", "details": err.Error()})
 		return
@@ -4,41 +4,33 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 
-	"github.com/joho/godotenv"
+	"github.com/kubestellar/ui/config"
 	_ "github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func LoadConfig() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Warning: No .env file found. Using default values.")
-	}
-}
+var dbConfig = config.Default().Postgres
 
-func ConnectDB() {
-	LoadConfig()
-
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("POSTGRES_HOST"), os.Getenv("POSTGRES_PORT"),
-		os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-	)
+// Configure sets the database ConnectDB connects to
+func Configure(cfg config.PostgresConfig) {
+	dbConfig = cfg
+}
 
-	var err error
-	DB, err = sql.Open("postgres", dsn)
+// ConnectDB opens the configured database and checks that it responds
+func ConnectDB() error {
+	db, err := sql.Open("postgres", dbConfig.DSN())
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
-	err = DB.Ping()
-	if err != nil {
-		log.Fatal("Database not responding:", err)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("database not responding: %v", err)
 	}
+	DB = db
 
-	fmt.Println("✅ Connected to PostgreSQL")
+	log.Println("✅ Connected to PostgreSQL")
+	return nil
 }
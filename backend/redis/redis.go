@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"github.com/kubestellar/ui/config"
 	"github.com/kubestellar/ui/log"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -14,8 +16,6 @@ import (
 var ctx = context.Background()
 var rdb *redis.Client
 
-const filePathKey = "filepath"
-
 // SetNamespaceCache sets a namespace data cache in Redis
 func SetNamespaceCache(key string, value string, expiration time.Duration) error {
 	if err := rdb.Set(ctx, key, value, expiration).Err(); err != nil {
@@ -35,116 +35,19 @@ func GetNamespaceCache(key string) (string, error) {
 	return val, nil
 }
 
-// SetFilePath sets the file path in Redis
-func SetFilePath(filepath string) error {
-	if err := rdb.Set(ctx, filePathKey, filepath, 0).Err(); err != nil {
-		return fmt.Errorf("failed to set filepath: %v", err)
-	}
-	return nil
-}
-
-// GetFilePath retrieves the file path from Redis
-func GetFilePath() (string, error) {
-	val, err := rdb.Get(ctx, filePathKey).Result()
-	if err == redis.Nil {
-		return "", nil // Key not found
-	} else if err != nil {
-		return "", fmt.Errorf("failed to get filepath: %v", err)
-	}
-	return val, nil
-}
-
-func SetRepoURL(repoURL string) error {
-	if err := rdb.Set(ctx, "repoURL", repoURL, 0).Err(); err != nil {
-		return fmt.Errorf("failed to set repoURL: %v", err)
-	}
-	return nil
-}
-
-func GetRepoURL() (string, error) {
-	val, err := rdb.Get(ctx, "repoURL").Result()
-	if err == redis.Nil {
-		return "", nil // Key not found
-	} else if err != nil {
-		return "", fmt.Errorf("failed to get repoURL: %v", err)
-	}
-	return val, nil
-}
-
-func SetBranch(branch string) error {
-	if err := rdb.Set(ctx,
-		"branch", branch, 0).Err(); err != nil {
-		return fmt.Errorf("failed to set branch: %v", err)
-	}
-	return nil
-}
-
-func GetBranch() (string, error) {
-	val, err := rdb.Get(ctx, "branch").Result()
-	if err == redis.Nil {
-		return "", nil // Key not found
-	} else if err != nil {
-		return "", fmt.Errorf("failed to get branch: %v", err)
-	}
-	return val, nil
-}
-
-func SetGitToken(token string) error {
-	if err := rdb.Set(ctx, "gitToken", token, 0).Err(); err != nil {
-		return fmt.Errorf("failed to set gitToken: %v", err)
-	}
-	return nil
-}
-
-func GetGitToken() (string, error) {
-	val, err := rdb.Get(ctx, "gitToken").Result()
-	if err == redis.Nil {
-		return "", nil // Key not found
-	} else if err != nil {
-		return "", fmt.Errorf("failed to get gitToken: %v", err)
-	}
-	return val, nil
-}
-
-// stores binding policy
-func SetBpCmd(name string, bpJson string) error {
-	err := rdb.HSet(ctx, "BPS", name, bpJson).Err()
-	if err != nil {
-		return err
-	}
-	return nil
-
-}
-
-// removes binding policy from the hash
-func DeleteBpcmd(name string) error {
-	err := rdb.HDel(ctx, "BPS", name).Err()
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-// returns all BPs in the hash
-func GetallBpCmd() ([]string, error) {
-	v, err := rdb.HGetAll(ctx, "BPS").Result()
-	if err != nil {
-		return nil, err
-	}
-	var bpsSlice []string
-	for _, bp := range v {
-		bpsSlice = append(bpsSlice, bp)
-	}
-	return bpsSlice, nil
-
+// intializes redis client with the default address until the configuration is loaded
+func init() {
+	Configure(config.Default().Redis)
 }
 
-// intializes redis client
-func init() {
+// Configure connects the client to the configured Redis server
+func Configure(cfg config.RedisConfig) {
 	rdb = redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
+		Addr:     cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
-	log.LogInfo("initialized redis client")
+	log.LogInfo("initialized redis client", zap.String("address", cfg.Address))
 	if err := rdb.Ping(ctx).Err(); err != nil {
 		log.LogWarn("pls check if redis is runnnig", zap.String("err", err.Error()))
 	}
@@ -251,12 +154,161 @@ func GetAllJSONHash(hashKey string) (map[string]json.RawMessage, error) {
 	return result, nil
 }
 
-// SetWorkloadLabel stores the workload label in Redis
-func SetWorkloadLabel(label string) error {
-	return rdb.Set(ctx, "workload_label", label, 0).Err()
+// DeleteJSONHash removes a field from a Redis hash
+func DeleteJSONHash(hashKey string, field string) error {
+	if err := rdb.HDel(ctx, hashKey, field).Err(); err != nil {
+		return fmt.Errorf("failed to delete JSON hash value: %v", err)
+	}
+	return nil
+}
+
+// PushJSONList prepends a JSON value to a Redis list and trims the list to maxLen entries
+// listKey: The Redis list key
+// value: Any Go struct or map that can be marshalled to JSON
+// maxLen: Maximum number of entries kept in the list (0 for no limit)
+func PushJSONList(listKey string, value interface{}, maxLen int64) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.LPush(ctx, listKey, string(jsonData))
+	if maxLen > 0 {
+		pipe.LTrim(ctx, listKey, 0, maxLen-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to push JSON list value: %v", err)
+	}
+
+	return nil
+}
+
+// GetJSONList returns the raw JSON entries of a Redis list between start and stop (inclusive)
+func GetJSONList(listKey string, start, stop int64) ([]json.RawMessage, error) {
+	values, err := rdb.LRange(ctx, listKey, start, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get JSON list values: %v", err)
+	}
+
+	result := make([]json.RawMessage, 0, len(values))
+	for _, jsonString := range values {
+		result = append(result, json.RawMessage(jsonString))
+	}
+
+	return result, nil
+}
+
+// DeleteKey removes a key from Redis
+func DeleteKey(key string) error {
+	if err := rdb.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete key: %v", err)
+	}
+	return nil
+}
+
+// SetIfNotExists stores a value only if the key does not exist yet
+// Returns true if the value was stored, false if the key already existed
+func SetIfNotExists(key string, value string, expiration time.Duration) (bool, error) {
+	stored, err := rdb.SetNX(ctx, key, value, expiration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set key: %v", err)
+	}
+	return stored, nil
+}
+
+// KeyExists reports whether a key exists
+func KeyExists(key string) (bool, error) {
+	count, err := rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check key: %v", err)
+	}
+	return count > 0, nil
 }
 
-// GetWorkloadLabel gets the workload label from Redis
-func GetWorkloadLabel() (string, error) {
-	return rdb.Get(ctx, "workload_label").Result()
+// AddToSet adds a member to a Redis set and (re)sets the expiration of the set (0 for no expiration)
+func AddToSet(setKey string, member string, expiration time.Duration) error {
+	pipe := rdb.TxPipeline()
+	pipe.SAdd(ctx, setKey, member)
+	if expiration > 0 {
+		pipe.Expire(ctx, setKey, expiration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add set member: %v", err)
+	}
+	return nil
+}
+
+// GetSetMembers returns the members of a Redis set
+func GetSetMembers(setKey string) ([]string, error) {
+	members, err := rdb.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get set members: %v", err)
+	}
+	return members, nil
+}
+
+// RemoveFromSet removes a member from a Redis set
+func RemoveFromSet(setKey string, member string) error {
+	if err := rdb.SRem(ctx, setKey, member).Err(); err != nil {
+		return fmt.Errorf("failed to remove set member: %v", err)
+	}
+	return nil
+}
+
+// slidingWindowScript drops the hits of a sorted set that left the window, then records a
+// hit for now unless the set already holds limit hits (a limit of 0 always records). It
+// returns whether the hit was recorded, the hits in the window and, for a refused hit, the
+// milliseconds until the oldest hit leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if limit > 0 and count >= limit then
+  local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+  return {0, count, tonumber(oldest[2]) + window - now}
+end
+redis.call('ZADD', KEYS[1], now, ARGV[4])
+redis.call('PEXPIRE', KEYS[1], window)
+return {1, count + 1, 0}
+`)
+
+// SlidingWindowHit records a hit in the sliding window kept under key if fewer than limit
+// hits happened within the window (0 for no limit). It returns whether the hit was allowed,
+// the number of hits in the window and, for a refused hit, how long until one expires.
+func SlidingWindowHit(key string, limit int64, window time.Duration) (bool, int64, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+	result, err := slidingWindowScript.Run(ctx, rdb, []string{key}, now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("failed to update sliding window: %v", err)
+	}
+	return result[0] == 1, result[1], time.Duration(result[2]) * time.Millisecond, nil
+}
+
+// IncrementCounter increments a counter and (re)sets its expiration, returning the new value
+func IncrementCounter(key string, expiration time.Duration) (int64, error) {
+	pipe := rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	if expiration > 0 {
+		pipe.Expire(ctx, key, expiration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %v", err)
+	}
+	return incr.Val(), nil
+}
+
+// GetTTL returns the time until a key expires, or 0 when it does not exist or never expires
+func GetTTL(key string) (time.Duration, error) {
+	ttl, err := rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get TTL: %v", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
 }
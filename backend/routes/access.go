@@ -0,0 +1,249 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/auth"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/middleware"
+	"github.com/kubestellar/ui/wds/bp"
+)
+
+// wdsContext returns the WDS context selected in the UI, as the resource handlers do
+func wdsContext(c *gin.Context) string {
+	if cookieContext, err := c.Cookie("ui-wds-context"); err == nil && cookieContext != "" {
+		return cookieContext
+	}
+	return k8s.WDSContext()
+}
+
+// resourceAccess describes the generic /api/:resourceKind/:namespace[/:name] routes
+func resourceAccess(verb string) gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		name := c.Param("name")
+		if name == "" {
+			name = c.Query("name")
+		}
+		return []auth.Attributes{{
+			Verb:      verb,
+			Context:   wdsContext(c),
+			Namespace: c.Param("namespace"),
+			Kind:      c.Param("resourceKind"),
+			Name:      name,
+		}}, nil
+	})
+}
+
+// bindingPolicyAccess describes the binding policy routes; binding policies are cluster-scoped
+// objects of the WDS the request selects
+func bindingPolicyAccess(verb string) gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		return []auth.Attributes{{
+			Verb:    verb,
+			Context: k8s.RequestWDSContext(c),
+			Kind:    "bindingpolicies",
+			Name:    c.Param("name"),
+		}}, nil
+	})
+}
+
+// bindingPolicyTemplateAccess describes the binding policy template routes. Templates are
+// ConfigMaps of the WDS; instantiating or upgrading one also needs policyVerb on binding policies.
+func bindingPolicyTemplateAccess(verb, policyVerb string) gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		name := c.Param("name")
+		if name != "" {
+			name = bp.TemplateConfigMapName(name)
+		}
+		requests := []auth.Attributes{{
+			Verb:      verb,
+			Context:   k8s.RequestWDSContext(c),
+			Namespace: k8s.KubeStellarNamespace,
+			Kind:      "configmaps",
+			Name:      name,
+		}}
+		if policyVerb != "" {
+			requests = append(requests, auth.Attributes{Verb: policyVerb, Context: k8s.RequestWDSContext(c), Kind: "bindingpolicies"})
+		}
+		return requests, nil
+	})
+}
+
+// podExecAccess describes a shell into a container of a pod
+func podExecAccess() gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		if c.Query("context") == "" {
+			return nil, fmt.Errorf("no context present as query")
+		}
+		return []auth.Attributes{{
+			Verb:      auth.VerbExec,
+			Context:   c.Query("context"),
+			Namespace: c.Param("namespace"),
+			Kind:      "pods",
+			Name:      c.Param("pod"),
+		}}, nil
+	})
+}
+
+// podLogsAccess describes streaming the logs of a pod of a cluster
+func podLogsAccess() gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		return []auth.Attributes{{
+			Verb:      auth.VerbLogs,
+			Context:   c.Query("cluster"),
+			Namespace: c.Query("namespace"),
+			Kind:      "pods",
+			Name:      c.Query("pod"),
+		}}, nil
+	})
+}
+
+// namespaceSyncAccess describes copying a namespace from the source context to the target
+// contexts. Namespaces are matched against the namespaces of a rule by their own name.
+func namespaceSyncAccess() gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		// The handler decodes the body again
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var options struct {
+			SourceContext  string   `json:"sourceContext"`
+			TargetContexts []string `json:"targetContexts"`
+		}
+		if err := json.Unmarshal(body, &options); err != nil {
+			return nil, fmt.Errorf("invalid request body")
+		}
+
+		name := c.Param("name")
+		audit.SetTarget(c, audit.Target{Verb: auth.VerbSync, Context: strings.Join(options.TargetContexts, ","), Namespace: name, Kind: "namespaces", Name: name})
+		requests := []auth.Attributes{{Verb: auth.VerbGet, Context: options.SourceContext, Namespace: name, Kind: "namespaces", Name: name}}
+		for _, target := range options.TargetContexts {
+			requests = append(requests, auth.Attributes{Verb: auth.VerbSync, Context: target, Namespace: name, Kind: "namespaces", Name: name})
+		}
+		return requests, nil
+	})
+}
+
+// helmDeployAccess describes installing a Helm release into a namespace of a WDS
+func helmDeployAccess() gin.HandlerFunc {
+	return middleware.RequireAccess(func(c *gin.Context) ([]auth.Attributes, error) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		// The handler decodes the body again
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var req k8s.HelmDeploymentRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("invalid request body")
+		}
+		if req.Context == "" {
+			req.Context = wdsContext(c)
+		}
+		return []auth.Attributes{{
+			Verb:      auth.VerbCreate,
+			Context:   req.Context,
+			Namespace: req.Namespace,
+			Kind:      "helmreleases",
+			Name:      req.ReleaseName,
+		}}, nil
+	})
+}
+
+// CanIHandler explains whether the current user may perform a request. Without a verb it
+// lists the user's global permissions and the access policies that apply to them.
+func CanIHandler(c *gin.Context) {
+	attrs := auth.Attributes{
+		Verb:      c.Query("verb"),
+		Context:   c.Query("context"),
+		Namespace: c.Query("namespace"),
+		Kind:      c.Query("kind"),
+		Name:      c.Query("name"),
+	}
+
+	if attrs.Verb == "" {
+		subject := middleware.CurrentSubject(c)
+		policies, err := auth.PoliciesFor(subject.Username, subject.Groups)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load access policies", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"username":    subject.Username,
+			"permissions": subject.Permissions,
+			"groups":      subject.Groups,
+			"policies":    policies,
+			"verbs":       auth.AllVerbs(),
+		})
+		return
+	}
+
+	if attrs.Context == "" {
+		attrs.Context = wdsContext(c)
+	}
+	if attrs.Kind == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind is required"})
+		return
+	}
+
+	decision, err := middleware.Authorize(c, attrs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load access policies", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"allowed":    decision.Allowed,
+		"reason":     decision.Reason,
+		"attributes": attrs,
+	})
+}
+
+// ListPoliciesHandler returns all access policies (admin only)
+func ListPoliciesHandler(c *gin.Context) {
+	policies, err := auth.ListPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve policies", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// SavePolicyHandler creates or replaces an access policy (admin only)
+func SavePolicyHandler(c *gin.Context) {
+	var policy auth.Policy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+	policy.Name = c.Param("name")
+	if err := policy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := auth.SavePolicy(policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save policy", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Policy saved successfully", "policy": policy})
+}
+
+// DeletePolicyHandler removes an access policy (admin only)
+func DeletePolicyHandler(c *gin.Context) {
+	if err := auth.DeletePolicy(c.Param("name")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete policy", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Policy deleted successfully", "name": c.Param("name")})
+}
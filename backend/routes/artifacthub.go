@@ -6,6 +6,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kubestellar/ui/api"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/middleware"
 )
 
 func setupArtifactHubRoutes(router *gin.Engine) {
@@ -13,7 +15,7 @@ func setupArtifactHubRoutes(router *gin.Engine) {
 	artifactHub := router.Group("/api/v1/artifact-hub")
 	{
 		// Deploy a Helm chart from Artifact Hub
-		artifactHub.POST("/helm-deploy", api.DeployFromArtifactHub)
+		artifactHub.POST("/helm-deploy", middleware.AuthenticateMiddleware(), middleware.RateLimit("deploy"), audit.Middleware("helm.deploy"), middleware.RequirePermission("write"), api.DeployFromArtifactHub)
 
 		// Search for packages in Artifact Hub
 		artifactHub.POST("/packages/search", api.SearchArtifactHub)
@@ -2,18 +2,35 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/auth"
+	"github.com/kubestellar/ui/middleware"
 	"github.com/kubestellar/ui/wds/bp"
 )
 
 func setupBindingPolicyRoutes(router *gin.Engine) {
-	router.GET("/api/bp", bp.GetAllBp)
-	router.GET("/api/bp/status", bp.GetBpStatus)
-	router.POST("/api/bp/create", bp.CreateBp)
-	router.POST("/api/bp/create-json", bp.CreateBpFromJson)
-	router.POST("/api/bp/quick-connect", bp.CreateQuickBindingPolicy)
-	router.POST("/api/bp/generate-yaml", bp.GenerateQuickBindingPolicyYAML)
-	router.DELETE("/api/bp/delete/:name", bp.DeleteBp)
-	router.DELETE("/api/bp/delete", bp.DeleteAllBp)
-	router.PATCH("/api/bp/update/:name", bp.UpdateBp)
+	bpGroup := router.Group("/api/bp", middleware.AuthenticateMiddleware(), middleware.RateLimit("bp"))
+	{
+		bpGroup.GET("", bindingPolicyAccess(auth.VerbList), bp.GetAllBp)
+		bpGroup.GET("/status", bindingPolicyAccess(auth.VerbGet), bp.GetBpStatus)
+		bpGroup.POST("/create", audit.Middleware("bindingpolicy.create"), bindingPolicyAccess(auth.VerbCreate), bp.CreateBp)
+		bpGroup.POST("/create-json", audit.Middleware("bindingpolicy.create"), bindingPolicyAccess(auth.VerbCreate), bp.CreateBpFromJson)
+		bpGroup.POST("/quick-connect", audit.Middleware("bindingpolicy.create"), bindingPolicyAccess(auth.VerbCreate), bp.CreateQuickBindingPolicy)
+		bpGroup.POST("/generate-yaml", bp.GenerateQuickBindingPolicyYAML)
+		bpGroup.POST("/simulate", bindingPolicyAccess(auth.VerbGet), bp.SimulateBp)
+		bpGroup.GET("/analysis", bindingPolicyAccess(auth.VerbList), bp.AnalyzeBp)
+		bpGroup.GET("/:name/propagation", bindingPolicyAccess(auth.VerbGet), bp.GetBpPropagation)
+		bpGroup.DELETE("/delete/:name", audit.Middleware("bindingpolicy.delete"), bindingPolicyAccess(auth.VerbDelete), bp.DeleteBp)
+		bpGroup.DELETE("/delete", audit.Middleware("bindingpolicy.delete-all"), bindingPolicyAccess(auth.VerbDelete), bp.DeleteAllBp)
+		bpGroup.PATCH("/update/:name", audit.Middleware("bindingpolicy.update"), bindingPolicyAccess(auth.VerbUpdate), bp.UpdateBp)
 
+		templates := bpGroup.Group("/templates")
+		templates.GET("", bindingPolicyTemplateAccess(auth.VerbList, ""), bp.ListBpTemplates)
+		templates.POST("", audit.Middleware("bindingpolicytemplate.save"), bindingPolicyTemplateAccess(auth.VerbCreate, ""), bp.SaveBpTemplate)
+		templates.GET("/:name", bindingPolicyTemplateAccess(auth.VerbGet, ""), bp.GetBpTemplate)
+		templates.DELETE("/:name", audit.Middleware("bindingpolicytemplate.delete"), bindingPolicyTemplateAccess(auth.VerbDelete, ""), bp.DeleteBpTemplate)
+		templates.POST("/:name/instantiate", audit.Middleware("bindingpolicy.create"), bindingPolicyTemplateAccess(auth.VerbGet, auth.VerbCreate), bp.InstantiateBpTemplate)
+		templates.POST("/:name/upgrade", audit.Middleware("bindingpolicy.update"), bindingPolicyTemplateAccess(auth.VerbGet, auth.VerbUpdate), bp.UpgradeBpTemplatePolicies)
+	}
+	router.GET("/ws/bp", middleware.AuthenticateMiddleware(), bindingPolicyAccess(auth.VerbList), bp.WatchBp)
 }
@@ -5,7 +5,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kubestellar/ui/api"
+	"github.com/kubestellar/ui/audit"
 	"github.com/kubestellar/ui/its/manual/handlers"
+	"github.com/kubestellar/ui/middleware"
 )
 
 func setupClusterRoutes(router *gin.Engine) {
@@ -23,10 +25,12 @@ func setupClusterRoutes(router *gin.Engine) {
 		})
 	})
 
-	// Cluster onboarding, status, and detachment
-	router.POST("/clusters/onboard", api.OnboardClusterHandler)
+	// Cluster onboarding, status, and detachment. Joining or removing clusters changes the
+	// hub itself, so it is left to admins.
+	authenticate := middleware.AuthenticateMiddleware()
+	router.POST("/clusters/onboard", authenticate, audit.Middleware("cluster.onboard"), middleware.RequireAdmin(), api.OnboardClusterHandler)
 	router.GET("/clusters/status", api.GetClusterStatusHandler)
-	router.POST("/clusters/detach", api.DetachClusterHandler)
+	router.POST("/clusters/detach", authenticate, audit.Middleware("cluster.detach"), middleware.RequireAdmin(), api.DetachClusterHandler)
 
 	// Logs and WebSocket
 	router.GET("/clusters/onboard/logs/:cluster", api.OnboardingLogsHandler)
@@ -40,12 +44,12 @@ func setupClusterRoutes(router *gin.Engine) {
 	router.GET("/api/clusters/available", handlers.GetAvailableClustersHandler)
 
 	// Managed cluster label update
-	router.PATCH("/api/managedclusters/labels", api.UpdateManagedClusterLabelsHandler)
+	router.PATCH("/api/managedclusters/labels", authenticate, audit.Middleware("cluster.labels.update"), middleware.RequirePermission("write"), api.UpdateManagedClusterLabelsHandler)
 
 	router.GET("/ws/detachment", api.HandleDetachmentWebSocket)
 
 	// Import cluster
-	router.POST("/clusters/import", handlers.ImportClusterHandler)
+	router.POST("/clusters/import", authenticate, audit.Middleware("cluster.import"), middleware.RequireAdmin(), handlers.ImportClusterHandler)
 
 	// Remote Tree View Cluster details
 	router.GET("/api/cluster/details/:name", handlers.GetClusterDetailsHandler)
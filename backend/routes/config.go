@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/middleware"
+)
+
+func setupConfigRoutes(router *gin.Engine, cfg *config.Config) {
+	router.GET("/api/config", middleware.AuthenticateMiddleware(), middleware.RateLimit("api"), middleware.RequireAdmin(), ConfigHandler(cfg))
+}
+
+// ConfigHandler returns the configuration the backend runs with, without its secrets (admin only)
+func ConfigHandler(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		redacted, err := cfg.Redacted().Map()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read configuration", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, redacted)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/config"
 	"github.com/kubestellar/ui/wds"
 	"github.com/kubestellar/ui/wds/deployment"
 	"k8s.io/client-go/informers"
@@ -23,9 +24,7 @@ func setupDeploymentRoutes(router *gin.Engine) {
 	})
 	router.GET("/api/wds/logs", func(ctx *gin.Context) {
 		var upgrader = websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
+			CheckOrigin: config.CheckOrigin,
 		}
 		var w = ctx.Writer
 		var r = ctx.Request
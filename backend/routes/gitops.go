@@ -1,32 +1,65 @@
 package routes
 
 import (
-	"fmt"
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"github.com/kubestellar/ui/api"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/deployments"
 	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/middleware"
 )
 
 // setupGitopsRoutes registers general GitOps deployment routes
 func setupGitopsRoutes(router *gin.Engine) {
-	router.POST("api/deploy", api.DeployHandler)
+	// Also called by CI pipelines with API tokens; manifests may touch any namespace, so the
+	// global write permission is required
+	router.POST("api/deploy", middleware.AuthenticateMiddleware(), middleware.RateLimit("deploy"), audit.Middleware("gitops.deploy"), middleware.RequirePermission("write"), api.DeployHandler)
+
+	// Legacy webhook that syncs every source tracking the pushed repository
+	router.POST("api/webhook", api.GitHubWebhookHandler)
+	// Per-source webhook
+	router.POST("api/webhook/:source", api.SourceWebhookHandler)
+
+	// GitOps sources, their sync status and history. Sources hold repository credentials and
+	// their syncs apply manifests like api/deploy, so changing or syncing one needs write.
+	sources := router.Group("/api/gitops/sources", middleware.AuthenticateMiddleware())
+	{
+		read := middleware.RequirePermission("read")
+		write := middleware.RequirePermission("write")
+		sources.GET("", read, api.ListGitOpsSourcesHandler)
+		sources.POST("", audit.Middleware("gitops.source.create"), write, api.CreateGitOpsSourceHandler)
+		sources.GET("/:name", read, api.GetGitOpsSourceHandler)
+		sources.PUT("/:name", audit.Middleware("gitops.source.update"), write, api.UpdateGitOpsSourceHandler)
+		sources.DELETE("/:name", audit.Middleware("gitops.source.delete"), write, api.DeleteGitOpsSourceHandler)
+		sources.POST("/:name/sync", middleware.RateLimit("deploy"), audit.Middleware("gitops.source.sync"), write, api.SyncGitOpsSourceHandler)
+		sources.GET("/:name/status", read, api.GetGitOpsSourceStatusHandler)
+		sources.GET("/:name/history", read, api.GetGitOpsSourceHistoryHandler)
+		sources.GET("/:name/commits/:commit", read, api.GetGitOpsSourceCommitHandler)
+	}
 }
 
 // setupHelmRoutes registers all Helm chart related routes
 func setupHelmRoutes(router *gin.Engine) {
 	// Route for deploying Helm charts
-	router.POST("/deploy/helm", k8s.HelmDeployHandler)
+	router.POST("/deploy/helm", middleware.AuthenticateMiddleware(), middleware.RateLimit("deploy"), audit.Middleware("helm.deploy"), helmDeployAccess(), k8s.HelmDeployHandler)
 
-	// Routes for retrieving Helm deployments
-	router.GET("/api/deployments/helm/list", k8s.ListHelmDeploymentsHandler)
-	router.GET("/api/deployments/helm/:id", k8s.GetHelmDeploymentHandler)
-	router.GET("/api/deployments/helm/namespace/:namespace", k8s.ListHelmDeploymentsByNamespaceHandler)
-	router.GET("/api/deployments/helm/release/:release", k8s.ListHelmDeploymentsByReleaseHandler)
+	authenticate := middleware.AuthenticateMiddleware()
+	read := middleware.RequirePermission("read")
+	write := middleware.RequirePermission("write")
+
+	// Routes for retrieving Helm deployments. Their chart values are returned redacted.
+	router.GET("/api/deployments/helm/list", authenticate, read, k8s.ListHelmDeploymentsHandler)
+	router.GET("/api/deployments/helm/:id", authenticate, read, k8s.GetHelmDeploymentHandler)
+	router.GET("/api/deployments/helm/namespace/:namespace", authenticate, read, k8s.ListHelmDeploymentsByNamespaceHandler)
+	router.GET("/api/deployments/helm/release/:release", authenticate, read, k8s.ListHelmDeploymentsByReleaseHandler)
 
 	// Route for deleting Helm deployments
-	router.DELETE("/api/deployments/helm/:id", k8s.DeleteHelmDeploymentHandler)
+	router.DELETE("/api/deployments/helm/:id", authenticate, audit.Middleware("helm.delete"), write, k8s.DeleteHelmDeploymentHandler)
+
+	// Routes for upgrading, inspecting and rolling back Helm releases
+	router.POST("/api/deployments/helm/:id/upgrade", authenticate, middleware.RateLimit("deploy"), audit.Middleware("helm.upgrade"), write, api.UpgradeHelmDeploymentHandler)
+	router.GET("/api/deployments/helm/:id/history", authenticate, read, api.HelmDeploymentHistoryHandler)
+	router.POST("/api/deployments/helm/:id/rollback/:revision", authenticate, middleware.RateLimit("deploy"), audit.Middleware("helm.rollback"), write, api.RollbackHelmDeploymentHandler)
 }
 
 // setupGitHubRoutes registers all GitHub related routes
@@ -35,38 +68,20 @@ func setupGitHubRoutes(router *gin.Engine) {
 	router.GET("/api/deployments/github/list", k8s.ListGithubDeployments)
 
 	// Route for deleting GitHub deployments
-	router.DELETE("/api/deployments/github/:id", k8s.DeleteGitHubDeploymentHandler)
+	router.DELETE("/api/deployments/github/:id", middleware.AuthenticateMiddleware(), audit.Middleware("gitops.deployment.delete"), middleware.RequirePermission("write"), k8s.DeleteGitHubDeploymentHandler)
 }
 
 // setupDeploymentHistoryRoutes registers routes for deployment history
 func setupDeploymentHistoryRoutes(router *gin.Engine) {
-	// GitHub config routes - for viewing stored deployment data
-	router.GET("/api/deployments/github", func(c *gin.Context) {
-		config, err := k8s.GetConfigMapData("its1", k8s.GitHubConfigMapName)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get GitHub deployment data: %v", err)})
-			return
-		}
-		c.JSON(http.StatusOK, config)
-	})
+	authenticate := middleware.AuthenticateMiddleware()
+	read := middleware.RequirePermission("read")
 
-	// Helm config routes - for viewing stored deployment data
-	router.GET("/api/deployments/helm", func(c *gin.Context) {
-		config, err := k8s.GetConfigMapData("its1", k8s.HelmConfigMapName)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get Helm deployment data: %v", err)})
-			return
-		}
-		c.JSON(http.StatusOK, config)
-	})
+	// Stored deployments of every kind, filtered by kind, context, namespace, release and date.
+	// Secrets in the stored data are returned redacted.
+	router.GET("/api/deployments/history", authenticate, read, k8s.ListDeploymentHistoryHandler)
 
-	// Manifests config routes - for viewing stored deployment data
-	router.GET("/api/deployments/manifests", func(c *gin.Context) {
-		config, err := k8s.GetConfigMapData("its1", "kubestellar-manifests")
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get manifests deployment data: %v", err)})
-			return
-		}
-		c.JSON(http.StatusOK, config)
-	})
+	// Stored deployments of one kind
+	router.GET("/api/deployments/github", authenticate, read, k8s.DeploymentHistoryByKindHandler(deployments.KindGitHub))
+	router.GET("/api/deployments/helm", authenticate, read, k8s.DeploymentHistoryByKindHandler(deployments.KindHelm))
+	router.GET("/api/deployments/manifests", authenticate, read, k8s.DeploymentHistoryByKindHandler(deployments.KindManifests))
 }
@@ -3,12 +3,14 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/kubestellar/ui/api"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/middleware"
 )
 
 func setupInstallerRoutes(router *gin.Engine) {
 	// API Routes
 	router.GET("/api/prerequisites", api.CheckPrerequisitesHandler)
-	router.POST("/api/install", api.InstallHandler)
+	router.POST("/api/install", middleware.AuthenticateMiddleware(), audit.Middleware("kubestellar.install"), middleware.RequireAdmin(), api.InstallHandler)
 	router.GET("/api/logs/:id", api.GetLogsHandler)
 	router.GET("/api/ws/logs/:id", api.LogsWebSocketHandler)
 	// Add new status check endpoint
@@ -1,28 +1,36 @@
 package routes
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
 	"github.com/kubestellar/ui/auth"
 	"github.com/kubestellar/ui/middleware"
 	"github.com/kubestellar/ui/models"
-	"github.com/kubestellar/ui/utils"
 )
 
 // SetupRoutes initializes all application routes
 func setupAuthRoutes(router *gin.Engine) {
 	// Authentication routes
 	router.POST("/login", LoginHandler)
+	router.POST("/api/refresh", RefreshTokenHandler)
+	setupOIDCRoutes(router)
 
 	// API group for all endpoints
 	api := router.Group("/api")
 
 	// Protected API endpoints requiring authentication
 	protected := api.Group("/")
-	protected.Use(middleware.AuthenticateMiddleware())
+	protected.Use(middleware.AuthenticateMiddleware(), middleware.RateLimit("api"))
 	{
 		protected.GET("/me", CurrentUserHandler)
+		protected.POST("/logout", LogoutHandler)
+		protected.PUT("/me/password", audit.Middleware("user.change-password"), ChangePasswordHandler)
+		protected.GET("/me/can-i", CanIHandler)
 
 		// Read-only endpoints
 		read := protected.Group("/")
@@ -45,9 +53,17 @@ func setupAuthRoutes(router *gin.Engine) {
 		admin.Use(middleware.RequireAdmin())
 		{
 			admin.GET("/users", ListUsersHandler)
-			admin.POST("/users", CreateUserHandler)
-			admin.PUT("/users/:username", UpdateUserHandler)
-			admin.DELETE("/users/:username", DeleteUserHandler)
+			admin.POST("/users", audit.Middleware("user.create"), CreateUserHandler)
+			admin.PUT("/users/:username", audit.Middleware("user.update"), UpdateUserHandler)
+			admin.DELETE("/users/:username", audit.Middleware("user.delete"), DeleteUserHandler)
+			admin.GET("/policies", ListPoliciesHandler)
+			admin.PUT("/policies/:name", audit.Middleware("policy.save"), SavePolicyHandler)
+			admin.DELETE("/policies/:name", audit.Middleware("policy.delete"), DeletePolicyHandler)
+			admin.GET("/audit", audit.ListEventsHandler)
+			admin.GET("/tokens", ListAPITokensHandler)
+			admin.POST("/tokens", audit.Middleware("token.create"), CreateAPITokenHandler)
+			admin.GET("/tokens/:id", GetAPITokenHandler)
+			admin.DELETE("/tokens/:id", audit.Middleware("token.delete"), DeleteAPITokenHandler)
 		}
 	}
 
@@ -72,28 +88,93 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
+	// Throttle password checks per IP and lock out users and IPs with repeated failures
+	throttle := auth.GetLoginThrottle()
+	if !checkLoginThrottle(c, throttle.Check(loginData.Username, c.ClientIP())) {
+		return
+	}
+
 	user, err := models.AuthenticateUser(loginData.Username, loginData.Password)
 	if user == nil || err != nil {
+		if !checkLoginThrottle(c, throttle.RecordFailure(loginData.Username, c.ClientIP())) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
+	throttle.RecordSuccess(user.Username)
 
-	// Fixed: Pass both username and permissions to GenerateToken
-	token, err := utils.GenerateToken(loginData.Username, user.Permissions)
+	// Open a session with an access token and a rotating refresh token
+	tokens, err := auth.StartSession(user.Username, user.Permissions)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_at":         tokens.ExpiresAt,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
 		"user": gin.H{
-			"username":    user.Username,
-			"permissions": user.Permissions,
+			"username":             user.Username,
+			"permissions":          user.Permissions,
+			"must_change_password": user.MustChangePassword,
 		},
 	})
 }
 
+// checkLoginThrottle responds with a 429 and returns false when the login throttle refused the request
+func checkLoginThrottle(c *gin.Context, err error) bool {
+	var throttleErr *auth.ThrottleError
+	if errors.As(err, &throttleErr) {
+		middleware.TooManyRequests(c, "Too many login attempts", throttleErr.RetryAfter)
+		return false
+	}
+	return true
+}
+
+// RefreshTokenHandler exchanges a refresh token for a new access and refresh token.
+// Each refresh token can be used once; reusing one ends its session.
+func RefreshTokenHandler(c *gin.Context) {
+	var refreshData struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&refreshData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	tokens, err := auth.RefreshSession(refreshData.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LogoutHandler ends the session of the presented token
+func LogoutHandler(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if sessionID != "" {
+		if err := auth.EndSession(sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out", "details": err.Error()})
+			return
+		}
+	}
+
+	// Tokens issued without a session can still be revoked individually
+	if expiresAt, ok := c.Get("token_expires_at"); ok {
+		if err := auth.RevokeToken(c.GetString("token_id"), expiresAt.(time.Time)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
 // CurrentUserHandler returns the current user's information
 func CurrentUserHandler(c *gin.Context) {
 	username, exists := c.Get("username")
@@ -114,6 +195,64 @@ func CurrentUserHandler(c *gin.Context) {
 	})
 }
 
+// ChangePasswordHandler lets the current user replace their password
+func ChangePasswordHandler(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var passwordData struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&passwordData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		return
+	}
+
+	// Guessing the current password of a stolen session is throttled like a login
+	throttle := auth.GetLoginThrottle()
+	if !checkLoginThrottle(c, throttle.Check(username, c.ClientIP())) {
+		return
+	}
+
+	err := auth.ChangePassword(username, passwordData.CurrentPassword, passwordData.NewPassword)
+	if err == auth.ErrInvalidCurrentPassword {
+		if !checkLoginThrottle(c, throttle.RecordFailure(username, c.ClientIP())) {
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if isPasswordRejected(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to change password",
+			"details": err.Error(),
+			"policy":  auth.GetPasswordPolicy(),
+		})
+		return
+	}
+
+	// Sign out everywhere else; the session that changed the password stays valid
+	if _, err := auth.RevokeUserSessions(username, c.GetString("session_id")); err != nil {
+		log.Printf("Warning: failed to revoke sessions of user %s: %v", username, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// isPasswordRejected reports whether a password was refused by the password policy rather than failing to be stored
+func isPasswordRejected(err error) bool {
+	var policyErr *auth.PolicyError
+	return errors.As(err, &policyErr) || err == auth.ErrSamePassword || err == auth.ErrSSOPassword
+}
+
 // ListUsersHandler returns a list of all users (admin only)
 func ListUsersHandler(c *gin.Context) {
 	users, err := auth.ListUsersWithPermissions()
@@ -140,7 +279,11 @@ func CreateUserHandler(c *gin.Context) {
 
 	err := auth.AddOrUpdateUser(userData.Username, userData.Password, userData.Permissions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if isPasswordRejected(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error":   "Failed to create user",
 			"details": err.Error(),
 		})
@@ -179,30 +322,42 @@ func UpdateUserHandler(c *gin.Context) {
 		return
 	}
 
-	// Update only provided fields
-	if userData.Password != "" {
-		// Update password if provided
-		userConfig.Password = userData.Password
-	}
-
 	// Update permissions if provided
 	if userData.Permissions != nil {
 		userConfig.Permissions = userData.Permissions
 	}
 
-	// Save updated user
-	err = auth.AddOrUpdateUser(username, userConfig.Password, userConfig.Permissions)
+	// Save updated user; an empty password keeps the current one
+	err = auth.AddOrUpdateUser(username, userData.Password, userConfig.Permissions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if isPasswordRejected(err) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
 			"error":   "Failed to update user",
 			"details": err.Error(),
 		})
 		return
 	}
 
+	// A password reset signs the user out everywhere, except for an admin resetting their own
+	// password from this session
+	revoked := 0
+	if userData.Password != "" {
+		keepSessionID := ""
+		if username == c.GetString("username") {
+			keepSessionID = c.GetString("session_id")
+		}
+		if revoked, err = auth.RevokeUserSessions(username, keepSessionID); err != nil {
+			log.Printf("Warning: failed to revoke sessions of user %s: %v", username, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "User updated successfully",
-		"username": username,
+		"message":          "User updated successfully",
+		"username":         username,
+		"sessions_revoked": revoked,
 	})
 }
 
@@ -219,9 +374,16 @@ func DeleteUserHandler(c *gin.Context) {
 		return
 	}
 
+	// Invalidate the sessions of the deleted user
+	revoked, err := auth.RevokeUserSessions(username, "")
+	if err != nil {
+		log.Printf("Warning: failed to revoke sessions of deleted user %s: %v", username, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "User deleted successfully",
-		"username": username,
+		"message":          "User deleted successfully",
+		"username":         username,
+		"sessions_revoked": revoked,
 	})
 }
 
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/middleware"
 	ns "github.com/kubestellar/ui/namespace"
 	nsresources "github.com/kubestellar/ui/namespace/resources"
 )
@@ -12,9 +14,11 @@ import (
 func setupNamespaceRoutes(router *gin.Engine) {
 	router.GET("/api/namespaces", nsresources.GetAllNamespaces)
 	router.GET("/api/namespaces/:name", nsresources.GetNamespaceDetails)
-	router.POST("/api/namespaces/create", nsresources.CreateNamespace)
-	router.PUT("/api/namespaces/update/:name", nsresources.UpdateNamespace)
-	router.DELETE("/api/namespaces/delete/:name", nsresources.DeleteNamespace)
+	authenticate := middleware.AuthenticateMiddleware()
+	write := middleware.RequirePermission("write")
+	router.POST("/api/namespaces/create", authenticate, audit.Middleware("namespace.create"), write, nsresources.CreateNamespace)
+	router.PUT("/api/namespaces/update/:name", authenticate, audit.Middleware("namespace.update"), write, nsresources.UpdateNamespace)
+	router.DELETE("/api/namespaces/delete/:name", authenticate, audit.Middleware("namespace.delete"), write, nsresources.DeleteNamespace)
 	router.GET("/ws/namespaces", nsresources.NamespaceWebSocketHandler)
 	router.GET("/api/all-contexts/namespaces", func(c *gin.Context) {
 		ns.GetAllContextsNamespaces(c.Writer, c.Request)
@@ -56,12 +60,12 @@ func setupNamespaceRoutes(router *gin.Engine) {
 	})
 
 	// Namespace synchronization endpoint
-	router.POST("/api/sync-namespace/:name", func(c *gin.Context) {
+	router.POST("/api/sync-namespace/:name", authenticate, audit.Middleware("namespace.sync"), namespaceSyncAccess(), func(c *gin.Context) {
 		// Extract the namespace name from URL parameters
 		namespaceName := c.Param("name")
 
 		// Create a new request with the correct path for our handler
-		req, _ := http.NewRequest("POST", "/api/sync-namespace/"+namespaceName, c.Request.Body)
+		req, _ := http.NewRequestWithContext(c.Request.Context(), "POST", "/api/sync-namespace/"+namespaceName, c.Request.Body)
 
 		// Call our synchronization function
 		ns.SynchronizeNamespace(c.Writer, req)
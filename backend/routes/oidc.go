@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/auth"
+	"github.com/kubestellar/ui/config"
+)
+
+// oidcStateCookieName holds the state of the single sign-on login started by the browser
+const oidcStateCookieName = "ui-oidc-state"
+
+// setupOIDCRoutes registers the single sign-on login; password login on /login stays
+// available for local break-glass users
+func setupOIDCRoutes(router *gin.Engine) {
+	oidcGroup := router.Group("/api/auth/oidc")
+	{
+		oidcGroup.GET("/config", OIDCConfigHandler)
+		oidcGroup.GET("/login", OIDCLoginHandler)
+		oidcGroup.GET("/callback", OIDCCallbackHandler)
+	}
+}
+
+// OIDCConfigHandler tells the frontend whether single sign-on is available
+func OIDCConfigHandler(c *gin.Context) {
+	config, err := auth.GetOIDCConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid single sign-on configuration", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":     config.Enabled(),
+		"login_url":   "/api/auth/oidc/login",
+		"local_login": true,
+	})
+}
+
+// OIDCLoginHandler redirects the browser to the identity provider
+func OIDCLoginHandler(c *gin.Context) {
+	provider, err := auth.GetOIDCProvider()
+	if errors.Is(err, auth.ErrOIDCDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Single sign-on unavailable", "details": err.Error()})
+		return
+	}
+
+	authURL, state, err := provider.AuthCodeURL()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login", "details": err.Error()})
+		return
+	}
+	http.SetCookie(c.Writer, oidcStateCookie(state, int(auth.OIDCLoginTimeout().Seconds())))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// oidcStateCookie binds a login to the browser that started it, so a callback carrying
+// someone else's state is refused. It is always HttpOnly and Lax, which is still sent on the
+// redirect back from the identity provider.
+func oidcStateCookie(state string, maxAge int) *http.Cookie {
+	cookie := config.Current().Server.Cookies.Cookie(oidcStateCookieName, state, maxAge)
+	cookie.Path = "/api/auth/oidc"
+	cookie.HttpOnly = true
+	cookie.SameSite = http.SameSiteLaxMode
+	return cookie
+}
+
+// OIDCCallbackHandler finishes a single sign-on login: it verifies the ID token, maps the
+// user's groups and claims to permissions, records the user and opens a session
+func OIDCCallbackHandler(c *gin.Context) {
+	provider, err := auth.GetOIDCProvider()
+	if errors.Is(err, auth.ErrOIDCDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Single sign-on unavailable", "details": err.Error()})
+		return
+	}
+	redirectURL := provider.Config().PostLoginRedirectURL
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		oidcLoginFailed(c, redirectURL, http.StatusUnauthorized, providerErr+": "+c.Query("error_description"))
+		return
+	}
+
+	// The state cookie is single use, like the state it holds
+	state, cookieErr := c.Cookie(oidcStateCookieName)
+	http.SetCookie(c.Writer, oidcStateCookie("", -1))
+	if cookieErr != nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		oidcLoginFailed(c, redirectURL, http.StatusUnauthorized, auth.ErrOIDCLoginExpired.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	identity, err := provider.Exchange(ctx, state, c.Query("code"))
+	if err != nil {
+		oidcLoginFailed(c, redirectURL, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	permissions, err := provider.Config().MapPermissions(identity)
+	if err != nil {
+		oidcLoginFailed(c, redirectURL, http.StatusForbidden, err.Error())
+		return
+	}
+	if err := auth.ProvisionOIDCUser(identity, permissions); err != nil {
+		log.Printf("Single sign-on login of %s refused: %v", identity.Username, err)
+		oidcLoginFailed(c, redirectURL, http.StatusForbidden, err.Error())
+		return
+	}
+
+	tokens, err := auth.StartSession(identity.Username, permissions)
+	if err != nil {
+		oidcLoginFailed(c, redirectURL, http.StatusInternalServerError, "Error generating token")
+		return
+	}
+
+	if redirectURL != "" {
+		// The fragment is not sent to servers, so the tokens stay out of access logs
+		fragment := url.Values{
+			"token":              {tokens.AccessToken},
+			"refresh_token":      {tokens.RefreshToken},
+			"expires_at":         {tokens.ExpiresAt.Format(time.RFC3339)},
+			"refresh_expires_at": {tokens.RefreshExpiresAt.Format(time.RFC3339)},
+		}
+		c.Redirect(http.StatusFound, redirectURL+"#"+fragment.Encode())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_at":         tokens.ExpiresAt,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+		"user": gin.H{
+			"username":    identity.Username,
+			"permissions": permissions,
+			"groups":      identity.Groups,
+			"source":      auth.UserSourceOIDC,
+		},
+	})
+}
+
+// oidcLoginFailed reports a failed login to the frontend, or as JSON without one
+func oidcLoginFailed(c *gin.Context, redirectURL string, status int, message string) {
+	if redirectURL != "" {
+		c.Redirect(http.StatusFound, redirectURL+"#"+url.Values{"error": {message}}.Encode())
+		return
+	}
+	c.JSON(status, gin.H{"error": "Single sign-on failed", "details": message})
+}
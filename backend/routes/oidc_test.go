@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kubestellar/ui/auth"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/redis"
+)
+
+// mockIssuer is an OpenID provider that issues an ID token for the nonce it is given
+type mockIssuer struct {
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	nonce      string
+	tokenCalls atomic.Int32
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                issuer.server.URL,
+			"authorization_endpoint":                issuer.server.URL + "/authorize",
+			"token_endpoint":                        issuer.server.URL + "/token",
+			"jwks_uri":                              issuer.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		encode := base64.RawURLEncoding.EncodeToString
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   encode(key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		issuer.tokenCalls.Add(1)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":                issuer.server.URL,
+			"aud":                "ui",
+			"sub":                "1234",
+			"iat":                time.Now().Unix(),
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"nonce":              issuer.nonce,
+			"preferred_username": "alice",
+		})
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"access_token": "access", "token_type": "Bearer", "expires_in": 3600, "id_token": idToken})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestOIDCCallbackChecksStateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redis.Configure(config.RedisConfig{Address: miniredis.RunT(t).Addr()})
+	issuer := newMockIssuer(t)
+	settings := config.Default().Auth
+	settings.OIDC.IssuerURL = issuer.server.URL
+	settings.OIDC.ClientID = "ui"
+	settings.OIDC.RedirectURL = "http://localhost:4000/api/auth/oidc/callback"
+	auth.Configure(settings)
+	t.Cleanup(func() { auth.Configure(config.Default().Auth) })
+
+	router := gin.New()
+	setupOIDCRoutes(router)
+	serve := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Start a login and keep the state cookie the browser would get
+	login := serve("/api/auth/oidc/login")
+	if login.Code != http.StatusFound {
+		t.Fatalf("login = %d: %s", login.Code, login.Body.String())
+	}
+	location, err := url.Parse(login.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := location.Query().Get("state")
+	issuer.nonce = location.Query().Get("nonce")
+	var stateCookie *http.Cookie
+	for _, cookie := range login.Result().Cookies() {
+		if cookie.Name == oidcStateCookieName {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil {
+		t.Fatal("login did not set the state cookie")
+	}
+	if !stateCookie.HttpOnly || stateCookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("state cookie HttpOnly = %v, SameSite = %v, want HttpOnly and Lax", stateCookie.HttpOnly, stateCookie.SameSite)
+	}
+
+	callback := "/api/auth/oidc/callback?code=abc&state=" + url.QueryEscape(state)
+
+	// A callback forced on a browser that did not start the login is refused before the code
+	// is redeemed, and leaves the login usable
+	if rec := serve(callback); rec.Code != http.StatusUnauthorized {
+		t.Errorf("callback without cookie = %d, want 401", rec.Code)
+	}
+	if rec := serve(callback, &http.Cookie{Name: oidcStateCookieName, Value: "attacker-state"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("callback with another state cookie = %d, want 401", rec.Code)
+	}
+	if calls := issuer.tokenCalls.Load(); calls != 0 {
+		t.Fatalf("token endpoint called %d times before the state was checked", calls)
+	}
+
+	// With the cookie the code is redeemed and the ID token verified; no permissions are mapped,
+	// so the login stops there
+	rec := serve(callback, &http.Cookie{Name: oidcStateCookieName, Value: stateCookie.Value})
+	if calls := issuer.tokenCalls.Load(); calls != 1 {
+		t.Fatalf("token endpoint called %d times, want 1", calls)
+	}
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), auth.ErrOIDCNoPermissions.Error()) {
+		t.Errorf("callback with cookie = %d: %s, want 403 for unmapped permissions", rec.Code, rec.Body.String())
+	}
+}
@@ -2,7 +2,10 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/auth"
 	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/middleware"
 	"github.com/kubestellar/ui/wds"
 )
 
@@ -19,12 +22,17 @@ func setupResourceRoutes(router *gin.Engine) {
 		})
 		api.GET("/wds/list-sse", wds.ListAllResourcesDetailsSSE)
 		api.GET("/wds/list/:namespace", wds.ListAllResourcesByNamespace)
-		api.GET("/:resourceKind/:namespace/log", k8s.LogWorkloads)
-		api.POST("/resources", k8s.CreateResource)                        // Create a new resource
-		api.POST("/resource/upload", k8s.UploadYAMLFile)                  // Upload any k8s resource file with "wds" key
-		api.GET("/:resourceKind/:namespace", k8s.ListResources)           // List all resources
-		api.GET("/:resourceKind/:namespace/:name", k8s.GetResource)       // Get a resource
-		api.PUT("/:resourceKind/:namespace/:name", k8s.UpdateResource)    // Update a resource
-		api.DELETE("/:resourceKind/:namespace/:name", k8s.DeleteResource) // Delete a resource
+		// Generic resource routes are authorized per context, namespace and kind
+		authenticate := middleware.AuthenticateMiddleware()
+		rateLimit := middleware.RateLimit("resources")
+
+		// Manifests may hold any kind, so creating them needs write or a rule granting create on all kinds
+		api.POST("/resources", authenticate, rateLimit, audit.Middleware("resource.create"), resourceAccess(auth.VerbCreate), k8s.CreateResource)       // Create a new resource
+		api.POST("/resource/upload", authenticate, rateLimit, audit.Middleware("resource.upload"), resourceAccess(auth.VerbCreate), k8s.UploadYAMLFile) // Upload any k8s resource file with "wds" key
+		api.GET("/:resourceKind/:namespace/log", authenticate, rateLimit, resourceAccess(auth.VerbLogs), k8s.LogWorkloads)
+		api.GET("/:resourceKind/:namespace", authenticate, rateLimit, resourceAccess(auth.VerbList), k8s.ListResources)                                                  // List all resources
+		api.GET("/:resourceKind/:namespace/:name", authenticate, rateLimit, resourceAccess(auth.VerbGet), k8s.GetResource)                                               // Get a resource
+		api.PUT("/:resourceKind/:namespace/:name", authenticate, rateLimit, audit.Middleware("resource.update"), resourceAccess(auth.VerbUpdate), k8s.UpdateResource)    // Update a resource
+		api.DELETE("/:resourceKind/:namespace/:name", authenticate, rateLimit, audit.Middleware("resource.delete"), resourceAccess(auth.VerbDelete), k8s.DeleteResource) // Delete a resource
 	}
 }
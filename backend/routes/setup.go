@@ -2,10 +2,11 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/config"
 	"github.com/kubestellar/ui/plugin/plugins"
 )
 
-func SetupRoutes(router *gin.Engine) {
+func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 	// Initialize all route groups
 	setupClusterRoutes(router)
 	setupDeploymentRoutes(router)
@@ -22,5 +23,6 @@ func SetupRoutes(router *gin.Engine) {
 	plugins.Pm.SetupPluginsRoutes(router)
 
 	setupAuthRoutes(router)
+	setupConfigRoutes(router, cfg)
 	setupArtifactHubRoutes(router)
 }
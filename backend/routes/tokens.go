@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/ui/audit"
+	"github.com/kubestellar/ui/auth"
+)
+
+// ListAPITokensHandler returns the API tokens, optionally of one owner (admin only)
+func ListAPITokensHandler(c *gin.Context) {
+	tokens, err := auth.ListAPITokens(c.Query("owner"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API tokens", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// GetAPITokenHandler returns one API token with its last use (admin only)
+func GetAPITokenHandler(c *gin.Context) {
+	token, exists, err := auth.GetAPIToken(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API token", "details": err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// CreateAPITokenHandler creates a personal or service account token (admin only). The token
+// is only ever returned in this response.
+func CreateAPITokenHandler(c *gin.Context) {
+	var request auth.APITokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		return
+	}
+
+	info, token, err := auth.CreateAPIToken(request, c.GetString("username"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		var requestErr *auth.TokenRequestError
+		if errors.As(err, &requestErr) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Failed to create API token", "details": err.Error()})
+		return
+	}
+	audit.SetTarget(c, audit.Target{Verb: auth.VerbCreate, Kind: "apitokens", Name: info.ID})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "API token created, it will not be shown again",
+		"token":    token,
+		"metadata": info,
+	})
+}
+
+// DeleteAPITokenHandler revokes an API token (admin only)
+func DeleteAPITokenHandler(c *gin.Context) {
+	id := c.Param("id")
+	audit.SetTarget(c, audit.Target{Verb: auth.VerbDelete, Kind: "apitokens", Name: id})
+
+	if err := auth.DeleteAPIToken(id); err != nil {
+		status := http.StatusInternalServerError
+		if strings.HasSuffix(err.Error(), "does not exist") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": "Failed to delete API token", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API token deleted successfully", "id": id})
+}
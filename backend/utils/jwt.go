@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -8,30 +10,82 @@ import (
 	jwtconfig "github.com/kubestellar/ui/jwt"
 )
 
+// Token types
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // TokenClaims represents the JWT token claims
 type TokenClaims struct {
 	Username    string   `json:"username"`
 	Permissions []string `json:"permissions,omitempty"`
+	TokenType   string   `json:"typ,omitempty"` // Tokens issued before refresh tokens existed have no type and are access tokens
+	SessionID   string   `json:"sid,omitempty"` // Login session the token belongs to
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user with specified permissions
-func GenerateToken(username string, permissions []string) (string, error) {
+// IsRefresh reports whether the claims belong to a refresh token
+func (c *TokenClaims) IsRefresh() bool {
+	return c.TokenType == TokenTypeRefresh
+}
+
+// IssuedToken is a signed token with its ID (jti) and expiry
+type IssuedToken struct {
+	Token     string
+	ID        string
+	ExpiresAt time.Time
+}
+
+// NewTokenID returns a random identifier for a token or session
+func NewTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(fmt.Sprintf("failed to generate token ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// GenerateAccessToken creates a JWT access token of a session
+func GenerateAccessToken(username string, permissions []string, sessionID string) (*IssuedToken, error) {
 	// Set token expiration time (from environment or default to 24 hours)
 	expTime := jwtconfig.GetTokenExpiration()
 	if expTime <= 0 {
 		expTime = 24 * time.Hour // Default to 24 hours
 	}
 
-	// Create the claims
-	claims := TokenClaims{
+	return signToken(TokenClaims{
 		Username:    username,
 		Permissions: permissions,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expTime)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "kubestellar-ui",
-		},
+		TokenType:   TokenTypeAccess,
+		SessionID:   sessionID,
+	}, expTime)
+}
+
+// GenerateRefreshToken creates a JWT refresh token of a session. It carries no permissions;
+// they are re-read when the token is exchanged.
+func GenerateRefreshToken(username string, sessionID string) (*IssuedToken, error) {
+	expTime := jwtconfig.GetRefreshTokenExpiration()
+	if expTime <= 0 {
+		expTime = jwtconfig.DefaultRefreshTokenExpiration
+	}
+
+	return signToken(TokenClaims{
+		Username:  username,
+		TokenType: TokenTypeRefresh,
+		SessionID: sessionID,
+	}, expTime)
+}
+
+// signToken sets the ID, issue and expiry times of the claims and signs them
+func signToken(claims TokenClaims, expTime time.Duration) (*IssuedToken, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        NewTokenID(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expTime)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    "kubestellar-ui",
 	}
 
 	// Create token with claims
@@ -40,10 +94,10 @@ func GenerateToken(username string, permissions []string) (string, error) {
 	// Sign token with secret
 	tokenString, err := token.SignedString([]byte(jwtconfig.GetJWTSecret()))
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %v", err)
+		return nil, fmt.Errorf("failed to sign token: %v", err)
 	}
 
-	return tokenString, nil
+	return &IssuedToken{Token: tokenString, ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}, nil
 }
 
 // ValidateToken validates a JWT token and returns the parsed claims
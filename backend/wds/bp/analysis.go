@@ -0,0 +1,287 @@
+package bp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/log"
+	"go.uber.org/zap"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of PolicyFinding
+const (
+	FindingConflict         = "conflict"          // Policies send an object to a cluster with different createOnly or status collectors
+	FindingOverlap          = "overlap"           // Policies send an object to a cluster with the same settings
+	FindingShadowed         = "shadowed"          // Everything a policy sends is also sent by another policy
+	FindingNoClusters       = "no-clusters"       // The policy selects no cluster
+	FindingNoObjects        = "no-objects"        // The policy selects no object
+	FindingMissingNamespace = "missing-namespace" // The policy names a namespace that does not exist in the WDS
+)
+
+// Severities of PolicyFinding
+const (
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// PolicyFinding is a problem found by the policy analysis
+type PolicyFinding struct {
+	Type     string   `json:"type"`
+	Severity string   `json:"severity"`
+	Policies []string `json:"policies"`
+	Message  string   `json:"message"`
+	Objects  []string `json:"objects,omitempty"` // Keys of the objects concerned, see SimulationObject.Key
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// PolicyAnalysis reports the findings over a set of binding policies
+type PolicyAnalysis struct {
+	Policies int             `json:"policies"`
+	Findings []PolicyFinding `json:"findings"`
+}
+
+// AnalyzeBp analyzes all binding policies of the WDS
+func AnalyzeBp(ctx *gin.Context) {
+	c, err := getClientForRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	bpList, err := c.BindingPolicies().List(ctx.Request.Context(), v1.ListOptions{})
+	if err != nil {
+		log.LogError("failed to list binding policies", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	analysis, err := AnalyzeBindingPolicies(ctx.Request.Context(), k8s.RequestWDSContext(ctx), bpList.Items)
+	if err != nil {
+		log.LogError("failed to analyze binding policies", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyze binding policies", "details": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, analysis)
+}
+
+// AnalyzeBindingPolicies evaluates the policies against the live clusters and the objects of a
+// WDS and reports overlaps, conflicts, shadowed policies, policies that select nothing and
+// policies that name missing namespaces
+func AnalyzeBindingPolicies(ctx context.Context, wdsContext string, policies []v1alpha1.BindingPolicy) (*PolicyAnalysis, error) {
+	e, err := newPolicyEvaluator(ctx, wdsContext)
+	if err != nil {
+		return nil, err
+	}
+	return e.analyze(policies)
+}
+
+// bindingPolicyWarnings returns the findings about a policy being created or updated among the
+// other policies of the WDS. Warnings never block the change, so failures are only logged.
+func bindingPolicyWarnings(ctx *gin.Context, bp *v1alpha1.BindingPolicy) []string {
+	c, err := getClientForRequest(ctx)
+	if err != nil {
+		log.LogWarn("failed to analyze binding policy", zap.String("name", bp.Name), zap.Error(err))
+		return nil
+	}
+	bpList, err := c.BindingPolicies().List(ctx.Request.Context(), v1.ListOptions{})
+	if err != nil {
+		log.LogWarn("failed to analyze binding policy", zap.String("name", bp.Name), zap.Error(err))
+		return nil
+	}
+
+	policies := []v1alpha1.BindingPolicy{*bp}
+	for _, other := range bpList.Items {
+		if other.Name != bp.Name {
+			policies = append(policies, other)
+		}
+	}
+	analysis, err := AnalyzeBindingPolicies(ctx.Request.Context(), k8s.RequestWDSContext(ctx), policies)
+	if err != nil {
+		log.LogWarn("failed to analyze binding policy", zap.String("name", bp.Name), zap.Error(err))
+		return nil
+	}
+
+	warnings := []string{}
+	for _, finding := range analysis.Findings {
+		if contains(finding.Policies, bp.Name) {
+			warnings = append(warnings, finding.Message)
+		}
+	}
+	return warnings
+}
+
+// analyze evaluates every policy once and compares each pair
+func (e *policyEvaluator) analyze(policies []v1alpha1.BindingPolicy) (*PolicyAnalysis, error) {
+	sorted := append([]v1alpha1.BindingPolicy{}, policies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	analysis := &PolicyAnalysis{Policies: len(sorted), Findings: []PolicyFinding{}}
+	results := make([]*SimulationResult, len(sorted))
+	for i := range sorted {
+		result, err := e.evaluate(&sorted[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate binding policy %s: %v", sorted[i].Name, err)
+		}
+		results[i] = result
+		analysis.Findings = append(analysis.Findings, e.policyFindings(&sorted[i], result)...)
+	}
+
+	for i := range sorted {
+		for j := i + 1; j < len(sorted); j++ {
+			analysis.Findings = append(analysis.Findings, pairFindings(&sorted[i], results[i], &sorted[j], results[j])...)
+		}
+	}
+	return analysis, nil
+}
+
+// policyFindings reports a policy that selects no cluster or object or names missing namespaces
+func (e *policyEvaluator) policyFindings(bp *v1alpha1.BindingPolicy, result *SimulationResult) []PolicyFinding {
+	var findings []PolicyFinding
+	if len(result.SelectedClusters()) == 0 {
+		findings = append(findings, PolicyFinding{
+			Type:     FindingNoClusters,
+			Severity: SeverityWarning,
+			Policies: []string{bp.Name},
+			Message:  fmt.Sprintf("BindingPolicy %s matches no cluster", bp.Name),
+		})
+	}
+	if len(result.Objects) == 0 {
+		findings = append(findings, PolicyFinding{
+			Type:     FindingNoObjects,
+			Severity: SeverityWarning,
+			Policies: []string{bp.Name},
+			Message:  fmt.Sprintf("BindingPolicy %s matches no object", bp.Name),
+		})
+	}
+
+	var missing []string
+	for _, clause := range bp.Spec.Downsync {
+		for _, namespace := range clause.Namespaces {
+			if _, exists := e.namespaceLabels[namespace]; !exists && namespace != "*" && !contains(missing, namespace) {
+				missing = append(missing, namespace)
+			}
+		}
+	}
+	for _, namespace := range missing {
+		findings = append(findings, PolicyFinding{
+			Type:     FindingMissingNamespace,
+			Severity: SeverityWarning,
+			Policies: []string{bp.Name},
+			Message:  fmt.Sprintf("BindingPolicy %s references namespace %s, which does not exist in the WDS", bp.Name, namespace),
+		})
+	}
+	return findings
+}
+
+// pairFindings compares what two policies send: an object sent to a cluster by both is an
+// overlap, or a conflict when they disagree on createOnly or status collectors, and a policy
+// is shadowed when everything it sends is also sent by the other
+func pairFindings(a *v1alpha1.BindingPolicy, resultA *SimulationResult, b *v1alpha1.BindingPolicy, resultB *SimulationResult) []PolicyFinding {
+	clustersA, clustersB := resultA.SelectedClusters(), resultB.SelectedClusters()
+	sharedClusters := intersect(clustersA, clustersB)
+	if len(sharedClusters) == 0 {
+		return nil
+	}
+
+	objectsB := map[string]SimulationObject{}
+	for _, object := range resultB.Objects {
+		objectsB[object.Key()] = object
+	}
+	var overlapping, conflicting []string
+	for _, object := range resultA.Objects {
+		other, ok := objectsB[object.Key()]
+		if !ok {
+			continue
+		}
+		if object.CreateOnly != other.CreateOnly || !reflect.DeepEqual(object.StatusCollectors, other.StatusCollectors) {
+			conflicting = append(conflicting, object.Key())
+		} else {
+			overlapping = append(overlapping, object.Key())
+		}
+	}
+	if len(overlapping)+len(conflicting) == 0 {
+		return nil
+	}
+
+	names := []string{a.Name, b.Name}
+	var findings []PolicyFinding
+	if len(conflicting) > 0 {
+		findings = append(findings, PolicyFinding{
+			Type:     FindingConflict,
+			Severity: SeverityWarning,
+			Policies: names,
+			Message: fmt.Sprintf("BindingPolicies %s and %s send %d object(s) to %d cluster(s) with different createOnly or status collector settings",
+				a.Name, b.Name, len(conflicting), len(sharedClusters)),
+			Objects:  conflicting,
+			Clusters: sharedClusters,
+		})
+	}
+	if len(overlapping) > 0 {
+		findings = append(findings, PolicyFinding{
+			Type:     FindingOverlap,
+			Severity: SeverityInfo,
+			Policies: names,
+			Message:  fmt.Sprintf("BindingPolicies %s and %s both send %d object(s) to %d cluster(s)", a.Name, b.Name, len(overlapping), len(sharedClusters)),
+			Objects:  overlapping,
+			Clusters: sharedClusters,
+		})
+	}
+
+	// A policy is redundant when the other sends each of its objects to each of its clusters
+	// with the same settings; conflicting policies both have an effect
+	aCovered := len(conflicting) == 0 && len(overlapping) == len(resultA.Objects) && len(sharedClusters) == len(clustersA)
+	bCovered := len(conflicting) == 0 && len(overlapping) == len(resultB.Objects) && len(sharedClusters) == len(clustersB)
+	if aCovered && bCovered {
+		// Identical effect: the newer policy is the redundant one
+		if createdBefore(b, a) {
+			bCovered = false
+		} else {
+			aCovered = false
+		}
+	}
+	if aCovered {
+		findings = append(findings, shadowedFinding(a.Name, b.Name))
+	}
+	if bCovered {
+		findings = append(findings, shadowedFinding(b.Name, a.Name))
+	}
+	return findings
+}
+
+// createdBefore reports whether x was created before y. A policy without a creation time has
+// not been stored yet and counts as the newest.
+func createdBefore(x, y *v1alpha1.BindingPolicy) bool {
+	if x.CreationTimestamp.IsZero() {
+		return false
+	}
+	if y.CreationTimestamp.IsZero() {
+		return true
+	}
+	return x.CreationTimestamp.Before(&y.CreationTimestamp)
+}
+
+func shadowedFinding(shadowed, by string) PolicyFinding {
+	return PolicyFinding{
+		Type:     FindingShadowed,
+		Severity: SeverityWarning,
+		Policies: []string{shadowed, by},
+		Message:  fmt.Sprintf("BindingPolicy %s is shadowed by %s, which already sends all of its objects to all of its clusters", shadowed, by),
+	}
+}
+
+// intersect returns the names in both lists, in the order of the first
+func intersect(a, b []string) []string {
+	shared := []string{}
+	for _, name := range a {
+		if contains(b, name) {
+			shared = append(shared, name)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,119 @@
+package bp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func policyCreated(name string, created time.Time) *v1alpha1.BindingPolicy {
+	bp := &v1alpha1.BindingPolicy{}
+	bp.Name = name
+	if !created.IsZero() {
+		bp.CreationTimestamp = metav1.NewTime(created)
+	}
+	return bp
+}
+
+func result(clusters []string, objects ...SimulationObject) *SimulationResult {
+	r := &SimulationResult{Objects: objects}
+	for _, name := range clusters {
+		r.Clusters = append(r.Clusters, SimulationCluster{Name: name, Selected: true})
+	}
+	// Clusters the policy does not select are ignored
+	r.Clusters = append(r.Clusters, SimulationCluster{Name: "unselected"})
+	return r
+}
+
+func TestPairFindings(t *testing.T) {
+	older, newer := time.Now().Add(-time.Hour), time.Now()
+	web := SimulationObject{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespace: "demo", Name: "web"}
+	webCreateOnly := web
+	webCreateOnly.CreateOnly = true
+	db := SimulationObject{Group: "apps", Version: "v1", Resource: "statefulsets", Kind: "StatefulSet", Namespace: "demo", Name: "db"}
+
+	type finding struct{ Type, Severity string }
+	tests := []struct {
+		name     string
+		aCreated time.Time
+		resultA  *SimulationResult
+		bCreated time.Time
+		resultB  *SimulationResult
+		want     []finding
+		shadowed string // policy reported as shadowed, if any
+	}{
+		{
+			name:    "no shared cluster",
+			resultA: result([]string{"c1"}, web),
+			resultB: result([]string{"c2"}, web),
+		},
+		{
+			name:    "no shared object",
+			resultA: result([]string{"c1"}, web),
+			resultB: result([]string{"c1"}, db),
+		},
+		{
+			name:    "different createOnly conflicts",
+			resultA: result([]string{"c1"}, web),
+			resultB: result([]string{"c1"}, webCreateOnly),
+			want:    []finding{{FindingConflict, SeverityWarning}},
+		},
+		{
+			name:     "partial overlap shadows the covered policy",
+			aCreated: newer,
+			resultA:  result([]string{"c1"}, web),
+			bCreated: older,
+			resultB:  result([]string{"c1", "c2"}, web, db),
+			want:     []finding{{FindingOverlap, SeverityInfo}, {FindingShadowed, SeverityWarning}},
+			shadowed: "a",
+		},
+		{
+			name:     "identical policies shadow the newer one",
+			aCreated: older,
+			resultA:  result([]string{"c1"}, web),
+			bCreated: newer,
+			resultB:  result([]string{"c1"}, web),
+			want:     []finding{{FindingOverlap, SeverityInfo}, {FindingShadowed, SeverityWarning}},
+			shadowed: "b",
+		},
+		{
+			name:     "identical policies shadow the one not created yet",
+			resultA:  result([]string{"c1"}, web),
+			bCreated: older,
+			resultB:  result([]string{"c1"}, web),
+			want:     []finding{{FindingOverlap, SeverityInfo}, {FindingShadowed, SeverityWarning}},
+			shadowed: "a",
+		},
+		{
+			name:     "stored policy is older than one not created yet",
+			aCreated: older,
+			resultA:  result([]string{"c1"}, web),
+			resultB:  result([]string{"c1"}, web),
+			want:     []finding{{FindingOverlap, SeverityInfo}, {FindingShadowed, SeverityWarning}},
+			shadowed: "b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := pairFindings(policyCreated("a", tt.aCreated), tt.resultA, policyCreated("b", tt.bCreated), tt.resultB)
+
+			var got []finding
+			shadowed := ""
+			for _, f := range findings {
+				got = append(got, finding{f.Type, f.Severity})
+				if f.Type == FindingShadowed {
+					shadowed = f.Policies[0]
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pairFindings() = %v, want %v", got, tt.want)
+			}
+			if shadowed != tt.shadowed {
+				t.Errorf("shadowed policy = %q, want %q", shadowed, tt.shadowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package bp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/log"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	bindingPolicyGVR = schema.GroupVersionResource{Group: "control.kubestellar.io", Version: "v1alpha1", Resource: "bindingpolicies"}
+	bindingGVR       = schema.GroupVersionResource{Group: "control.kubestellar.io", Version: "v1alpha1", Resource: "bindings"}
+)
+
+// Types of PolicyEvent
+const (
+	PolicyAdded    = "ADDED"
+	PolicyModified = "MODIFIED"
+	PolicyDeleted  = "DELETED"
+)
+
+const (
+	policyCacheResync      = 10 * time.Minute
+	policyCacheSyncTimeout = 30 * time.Second
+	policyEventBuffer      = 64
+)
+
+// PolicyEvent is a change of a binding policy or of its computed status
+type PolicyEvent struct {
+	Type   string                 `json:"type"`
+	Name   string                 `json:"name"`
+	Policy map[string]interface{} `json:"policy,omitempty"` // as in the list response, absent on delete
+}
+
+// policyCache keeps the binding policies of a WDS with their computed status, fed by shared
+// informers on BindingPolicies and on the Bindings KubeStellar derives from them. The informers
+// use the backend identity, so the routes serving the cache check that the user may list
+// binding policies.
+type policyCache struct {
+	policies cache.SharedIndexInformer
+	bindings cache.SharedIndexInformer
+
+	mu          sync.RWMutex
+	statuses    map[string]BindingPolicyWithStatus
+	subscribers map[chan PolicyEvent]struct{}
+}
+
+var (
+	policyCaches     = make(map[string]*policyCache)
+	policyCachesLock sync.Mutex
+)
+
+// policyCacheFor returns the cache of a WDS context, starting its informers on first use, once
+// it has synced
+func policyCacheFor(contextName string) (*policyCache, error) {
+	policyCachesLock.Lock()
+	pc, exists := policyCaches[contextName]
+	if !exists {
+		_, dynamicClient, err := k8s.GetClientSetWithContext(contextName)
+		if err != nil {
+			policyCachesLock.Unlock()
+			return nil, fmt.Errorf("failed to create client for context %s: %v", contextName, err)
+		}
+		pc = newPolicyCache(dynamicClient)
+		policyCaches[contextName] = pc
+		log.LogInfo("started binding policy cache", zap.String("context", contextName))
+	}
+	policyCachesLock.Unlock()
+
+	if err := pc.waitForSync(); err != nil {
+		return nil, fmt.Errorf("binding policy cache of context %s: %v", contextName, err)
+	}
+	return pc, nil
+}
+
+// newPolicyCache starts the informers of a cache; they run for the life of the process
+func newPolicyCache(client dynamic.Interface) *policyCache {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, policyCacheResync)
+	pc := &policyCache{
+		policies:    factory.ForResource(bindingPolicyGVR).Informer(),
+		bindings:    factory.ForResource(bindingGVR).Informer(),
+		statuses:    make(map[string]BindingPolicyWithStatus),
+		subscribers: make(map[chan PolicyEvent]struct{}),
+	}
+
+	// A Binding has the name of the BindingPolicy it comes from, so both refresh that policy
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    pc.refreshObject,
+		UpdateFunc: func(_, obj interface{}) { pc.refreshObject(obj) },
+		DeleteFunc: pc.refreshObject,
+	}
+	if _, err := pc.policies.AddEventHandler(handler); err != nil {
+		log.LogError("failed to watch binding policies", zap.Error(err))
+	}
+	if _, err := pc.bindings.AddEventHandler(handler); err != nil {
+		log.LogError("failed to watch bindings", zap.Error(err))
+	}
+
+	factory.Start(wait.NeverStop)
+	return pc
+}
+
+func (pc *policyCache) waitForSync() error {
+	stop := make(chan struct{})
+	timer := time.AfterFunc(policyCacheSyncTimeout, func() { close(stop) })
+	defer timer.Stop()
+	if !cache.WaitForCacheSync(stop, pc.policies.HasSynced, pc.bindings.HasSynced) {
+		return fmt.Errorf("timed out waiting for the informers to sync")
+	}
+	return nil
+}
+
+// List returns the binding policies with their status, sorted by name
+func (pc *policyCache) List() []BindingPolicyWithStatus {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	return pc.list()
+}
+
+func (pc *policyCache) list() []BindingPolicyWithStatus {
+	policies := make([]BindingPolicyWithStatus, 0, len(pc.statuses))
+	for _, bp := range pc.statuses {
+		policies = append(policies, bp)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies
+}
+
+// Get returns a binding policy with its status
+func (pc *policyCache) Get(name string) (BindingPolicyWithStatus, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	bp, exists := pc.statuses[name]
+	return bp, exists
+}
+
+// Subscribe returns the binding policies and a channel of the events that follow them. The
+// channel is closed by cancel, or when the subscriber falls too far behind.
+func (pc *policyCache) Subscribe() ([]BindingPolicyWithStatus, <-chan PolicyEvent, func()) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	events := make(chan PolicyEvent, policyEventBuffer)
+	pc.subscribers[events] = struct{}{}
+	cancel := func() {
+		pc.mu.Lock()
+		defer pc.mu.Unlock()
+		if _, exists := pc.subscribers[events]; exists {
+			delete(pc.subscribers, events)
+			close(events)
+		}
+	}
+	return pc.list(), events, cancel
+}
+
+func (pc *policyCache) refreshObject(obj interface{}) {
+	name, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.LogWarn("failed to get key of binding policy event", zap.Error(err))
+		return
+	}
+	pc.refresh(name)
+}
+
+// refresh recomputes the status of a binding policy from the informer stores and tells the
+// subscribers when it changed
+func (pc *policyCache) refresh(name string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	_, existed := pc.statuses[name]
+	policy := &v1alpha1.BindingPolicy{}
+	found, err := fromStore(pc.policies.GetStore(), name, policy)
+	if err != nil {
+		log.LogError("failed to read binding policy from cache", zap.String("name", name), zap.Error(err))
+		return
+	}
+	if !found {
+		if existed {
+			delete(pc.statuses, name)
+			pc.publish(PolicyEvent{Type: PolicyDeleted, Name: name})
+		}
+		return
+	}
+
+	bpWithStatus, err := withStatus(*policy, pc.bindings.GetStore())
+	if err != nil {
+		log.LogError("failed to compute binding policy status", zap.String("name", name), zap.Error(err))
+		return
+	}
+	binding := &v1alpha1.Binding{}
+	if found, err := fromStore(pc.bindings.GetStore(), name, binding); err != nil {
+		log.LogWarn("failed to read binding from cache", zap.String("name", name), zap.Error(err))
+	} else if found {
+		for _, destination := range binding.Spec.Destinations {
+			bpWithStatus.Destinations = append(bpWithStatus.Destinations, destination.ClusterId)
+		}
+	}
+
+	pc.statuses[name] = bpWithStatus
+	eventType := PolicyAdded
+	if existed {
+		eventType = PolicyModified
+	}
+	pc.publish(PolicyEvent{Type: eventType, Name: name, Policy: bindingPolicyResponse(bpWithStatus)})
+}
+
+// publish sends an event to every subscriber; a subscriber whose buffer is full is dropped,
+// since it can no longer follow the changes, and resubscribes to get a fresh list
+func (pc *policyCache) publish(event PolicyEvent) {
+	for events := range pc.subscribers {
+		select {
+		case events <- event:
+		default:
+			log.LogWarn("dropping slow binding policy subscriber")
+			delete(pc.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// fromStore converts the object of an informer store into a typed object
+func fromStore(store cache.Store, name string, into interface{}) (bool, error) {
+	obj, exists, err := store.GetByKey(name)
+	if err != nil || !exists {
+		return false, err
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected object type %T", obj)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), into); err != nil {
+		return false, fmt.Errorf("failed to convert %s: %v", name, err)
+	}
+	return true, nil
+}
@@ -7,16 +7,18 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
 	"github.com/kubestellar/ui/log"
 	"github.com/kubestellar/ui/utils"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v2"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 )
 
 type StoredBindingPolicy struct {
@@ -36,8 +38,26 @@ type WorkloadInfo struct {
 	Namespace  string `json:"namespace"`
 }
 
-// Global store for binding policies created via the UI
-var uiCreatedPolicies = make(map[string]*StoredBindingPolicy)
+// Global store for binding policies created via the UI, also read by the policy cache
+var (
+	uiCreatedPolicies     = make(map[string]*StoredBindingPolicy)
+	uiCreatedPoliciesLock sync.RWMutex
+)
+
+// storedPolicy returns the data stored for a binding policy created via the UI
+func storedPolicy(name string) (*StoredBindingPolicy, bool) {
+	uiCreatedPoliciesLock.RLock()
+	defer uiCreatedPoliciesLock.RUnlock()
+	storedBP, exists := uiCreatedPolicies[name]
+	return storedBP, exists
+}
+
+// storePolicy records a binding policy created via the UI
+func storePolicy(storedBP *StoredBindingPolicy) {
+	uiCreatedPoliciesLock.Lock()
+	defer uiCreatedPoliciesLock.Unlock()
+	uiCreatedPolicies[storedBP.Name] = storedBP
+}
 
 // BindingPolicyWithStatus adds status information to the BindingPolicy
 type BindingPolicyWithStatus struct {
@@ -46,97 +66,104 @@ type BindingPolicyWithStatus struct {
 	BindingMode            string   `json:"bindingMode"`
 	Clusters               []string `json:"clusters"`
 	Workloads              []string `json:"workloads"`
+	Destinations           []string `json:"destinations"` // clusters of the policy's Binding
 }
 
 // GetAllBp retrieves all BindingPolicies with enhanced information
 func GetAllBp(ctx *gin.Context) {
 	log.LogDebug("retrieving all binding policies")
-	log.LogDebug("Using wds context: ", zap.String("wds_context", os.Getenv("wds_context")))
 
-	c, err := getClientForBp()
+	pc, err := policyCacheFor(k8s.RequestWDSContext(ctx))
 	if err != nil {
-		log.LogError("failed to create client for Bp", zap.String("error", err.Error()))
+		log.LogError("failed to get binding policy cache", zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	bpsWithStatus := pc.List()
 
-	// Optional namespace filter
-	namespace := ctx.Query("namespace")
-	listOptions := v1.ListOptions{}
+	// Filter by namespace if specified
+	if namespace := ctx.Query("namespace"); namespace != "" {
+		log.LogDebug("filtering by namespace", zap.String("namespace", namespace))
+		filteredBPs := filterBPsByNamespace(bpsWithStatus, namespace)
+		ctx.JSON(http.StatusOK, gin.H{
+			"bindingPolicies": filteredBPs,
+			"count":           len(filteredBPs),
+		})
+		return
+	}
 
-	// Get all binding policies
-	bpList, err := c.BindingPolicies().List(context.TODO(), listOptions)
+	responseArray := make([]map[string]interface{}, len(bpsWithStatus))
+	for i, bp := range bpsWithStatus {
+		responseArray[i] = bindingPolicyResponse(bp)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"bindingPolicies": responseArray,
+		"count":           len(responseArray),
+	})
+}
+
+// withStatus derives the status, clusters, workloads and YAML shown for a binding policy
+func withStatus(policy v1alpha1.BindingPolicy, bindings cache.Store) (BindingPolicyWithStatus, error) {
+	yamlData, err := yaml.Marshal(policy)
 	if err != nil {
-		log.LogError("failed to list binding policies", zap.Error(err))
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		log.LogError("Yaml Marshal faled", zap.String("error", err.Error()))
+		return BindingPolicyWithStatus{}, err
+	}
+
+	// Initialize annotations map if it doesn't exist
+	if policy.Annotations == nil {
+		policy.Annotations = make(map[string]string)
 	}
 
-	// Create a slice to hold the enhanced binding policies
-	bpsWithStatus := make([]BindingPolicyWithStatus, 0, len(bpList.Items))
+	// Add the YAML as a string to the policy
+	policy.Annotations["yaml"] = string(yamlData)
 
-	// Add YAML representation and status to each policy
-	for i := range bpList.Items {
-		yamlData, err := yaml.Marshal(bpList.Items[i])
-		if err != nil {
-			log.LogError("Yaml Marshal faled", zap.String("error", err.Error()))
-			continue
-		}
+	status := "inactive"
+	if policy.ObjectMeta.Generation == policy.Status.ObservedGeneration {
+		status = "active"
+	}
 
-		// Initialize annotations map if it doesn't exist
-		if bpList.Items[i].Annotations == nil {
-			bpList.Items[i].Annotations = make(map[string]string)
-		}
+	// Extract binding mode
+	bindingMode := "Downsync" // Default to Downsync since KubeStellar currently only supports Downsync
 
-		// Add the YAML as a string to the policy
-		bpList.Items[i].Annotations["yaml"] = string(yamlData)
+	// Extract target clusters from ClusterSelectors and try multiple sources for completeness
+	clusters := extractTargetClusters(&policy)
 
-		status := "inactive"
-		if bpList.Items[i].ObjectMeta.Generation == bpList.Items[i].Status.ObservedGeneration {
-			status = "active"
-		}
+	// Check if we have stored data for this policy that might have more details
+	policyName := policy.Name
+	storedBP, exists := storedPolicy(policyName)
 
-		// Extract binding mode
-		bindingMode := "Downsync" // Default to Downsync since KubeStellar currently only supports Downsync
-
-		// Extract target clusters from ClusterSelectors and try multiple sources for completeness
-		clusters := extractTargetClusters(&bpList.Items[i])
-
-		// Check if we have stored data for this policy that might have more details
-		policyName := bpList.Items[i].Name
-		storedBP, exists := uiCreatedPolicies[policyName]
-
-		if exists {
-			log.LogDebug("GetAllBp - Found stored BP in memory with key", zap.String("key", policyName))
-			// Use the stored cluster selectors for more detailed information
-			if len(storedBP.ClusterSelectors) > 0 {
-				for _, selector := range storedBP.ClusterSelectors {
-					if clusterName, ok := selector["kubernetes.io/cluster-name"]; ok {
-						// Check if already in the clusters array
-						if !contains(clusters, clusterName) {
-							clusters = append(clusters, clusterName)
-							log.LogDebug("GetAllBp - Added cluster from stored data", zap.String("ClusterLabels", clusterName))
-						}
+	if exists {
+		log.LogDebug("withStatus - Found stored BP in memory with key", zap.String("key", policyName))
+		// Use the stored cluster selectors for more detailed information
+		if len(storedBP.ClusterSelectors) > 0 {
+			for _, selector := range storedBP.ClusterSelectors {
+				if clusterName, ok := selector["kubernetes.io/cluster-name"]; ok {
+					// Check if already in the clusters array
+					if !contains(clusters, clusterName) {
+						clusters = append(clusters, clusterName)
+						log.LogDebug("withStatus - Added cluster from stored data", zap.String("ClusterLabels", clusterName))
 					}
 				}
 			}
+		}
 
-			// If we still have no clusters but have YAML data, try to parse it
-			if len(clusters) == 0 && storedBP.RawYAML != "" {
-				log.LogDebug("GetAllBp - Trying to parse stored raw YAML for clusters")
-				var yamlMap map[string]interface{}
-				if err := yaml.Unmarshal([]byte(storedBP.RawYAML), &yamlMap); err == nil {
-					if spec, ok := yamlMap["spec"].(map[interface{}]interface{}); ok {
-						if selectors, ok := spec["clusterSelectors"].([]interface{}); ok {
-							for _, selectorObj := range selectors {
-								if selector, ok := selectorObj.(map[interface{}]interface{}); ok {
-									if matchLabels, ok := selector["matchLabels"].(map[interface{}]interface{}); ok {
-										for k, v := range matchLabels {
-											if kStr, ok := k.(string); ok && kStr == "kubernetes.io/cluster-name" {
-												if vStr, ok := v.(string); ok && !contains(clusters, vStr) {
-													clusters = append(clusters, vStr)
-													log.LogDebug("GetAllBp - Added cluster from YAML", zap.String("cluster", vStr))
-												}
+		// If we still have no clusters but have YAML data, try to parse it
+		if len(clusters) == 0 && storedBP.RawYAML != "" {
+			log.LogDebug("withStatus - Trying to parse stored raw YAML for clusters")
+			var yamlMap map[string]interface{}
+			if err := yaml.Unmarshal([]byte(storedBP.RawYAML), &yamlMap); err == nil {
+				if spec, ok := yamlMap["spec"].(map[interface{}]interface{}); ok {
+					if selectors, ok := spec["clusterSelectors"].([]interface{}); ok {
+						for _, selectorObj := range selectors {
+							if selector, ok := selectorObj.(map[interface{}]interface{}); ok {
+								if matchLabels, ok := selector["matchLabels"].(map[interface{}]interface{}); ok {
+									for k, v := range matchLabels {
+										if kStr, ok := k.(string); ok && kStr == "kubernetes.io/cluster-name" {
+											if vStr, ok := v.(string); ok && !contains(clusters, vStr) {
+												clusters = append(clusters, vStr)
+												log.LogDebug("withStatus - Added cluster from YAML", zap.String("cluster", vStr))
 											}
 										}
 									}
@@ -147,336 +174,320 @@ func GetAllBp(ctx *gin.Context) {
 				}
 			}
 		}
+	}
 
-		// Extract workloads from Downsync using a comprehensive approach similar to GetBpStatus
-		workloads := []string{}
-
-		// If we have stored data for workloads, use it first for detailed information
-		if exists {
-			log.LogDebug("GetAllBp - Using stored policy data for workloads")
-			// Try to use stored API groups and resources for more detail
-			for i, apiGroup := range storedBP.APIGroups {
-				if i < len(storedBP.Resources) {
-					resourceLower := strings.ToLower(storedBP.Resources[i])
-					workloadType := fmt.Sprintf("%s/%s", apiGroup, resourceLower)
-
-					// Add namespaces if specified
-					if len(storedBP.Namespaces) > 0 {
-						for _, ns := range storedBP.Namespaces {
-							workloadItem := fmt.Sprintf("%s (ns:%s)", workloadType, ns)
-							if !contains(workloads, workloadItem) {
-								workloads = append(workloads, workloadItem)
-								log.LogDebug("GetAllBp - Added workload from stored data", zap.String("workloadItem", workloadItem))
-							}
+	// Extract workloads from Downsync using a comprehensive approach similar to GetBpStatus
+	workloads := []string{}
+
+	// If we have stored data for workloads, use it first for detailed information
+	if exists {
+		log.LogDebug("withStatus - Using stored policy data for workloads")
+		// Try to use stored API groups and resources for more detail
+		for i, apiGroup := range storedBP.APIGroups {
+			if i < len(storedBP.Resources) {
+				resourceLower := strings.ToLower(storedBP.Resources[i])
+				workloadType := fmt.Sprintf("%s/%s", apiGroup, resourceLower)
+
+				// Add namespaces if specified
+				if len(storedBP.Namespaces) > 0 {
+					for _, ns := range storedBP.Namespaces {
+						workloadItem := fmt.Sprintf("%s (ns:%s)", workloadType, ns)
+						if !contains(workloads, workloadItem) {
+							workloads = append(workloads, workloadItem)
+							log.LogDebug("withStatus - Added workload from stored data", zap.String("workloadItem", workloadItem))
 						}
-					} else if !contains(workloads, workloadType) {
-						workloads = append(workloads, workloadType)
-						log.LogDebug("GetAllBp - Added workload from stored data", zap.String("workloadType", workloadType))
 					}
+				} else if !contains(workloads, workloadType) {
+					workloads = append(workloads, workloadType)
+					log.LogDebug("withStatus - Added workload from stored data", zap.String("workloadType", workloadType))
 				}
 			}
+		}
 
-			// Add specific workloads from stored data
-			for _, workload := range storedBP.SpecificWorkloads {
-				workloadDesc := fmt.Sprintf("Specific: %s/%s", workload.APIVersion, workload.Kind)
-				if workload.Name != "" {
-					workloadDesc += fmt.Sprintf(": %s", workload.Name)
-				}
-				if workload.Namespace != "" {
-					workloadDesc += fmt.Sprintf(" (ns:%s)", workload.Namespace)
-				}
-				if !contains(workloads, workloadDesc) {
-					workloads = append(workloads, workloadDesc)
-					log.LogDebug("GetAllBp - Added specific workload from stored data", zap.String("workloadDesc", workloadDesc))
-				}
+		// Add specific workloads from stored data
+		for _, workload := range storedBP.SpecificWorkloads {
+			workloadDesc := fmt.Sprintf("Specific: %s/%s", workload.APIVersion, workload.Kind)
+			if workload.Name != "" {
+				workloadDesc += fmt.Sprintf(": %s", workload.Name)
+			}
+			if workload.Namespace != "" {
+				workloadDesc += fmt.Sprintf(" (ns:%s)", workload.Namespace)
+			}
+			if !contains(workloads, workloadDesc) {
+				workloads = append(workloads, workloadDesc)
+				log.LogDebug("withStatus - Added specific workload from stored data", zap.String("workloadDesc", workloadDesc))
+			}
+		}
+	} else {
+		// If no stored data, extract from BP directly
+		log.LogDebug("withStatus - Extracting workloads from API response for", zap.String("policyName", policyName))
+
+		// Extract from the policy's downsync field
+		for i, ds := range policy.Spec.Downsync {
+			apiGroupValue := "core" // Default to core
+			if ds.APIGroup != nil && *ds.APIGroup != "" {
+				apiGroupValue = *ds.APIGroup
 			}
-		} else {
-			// If no stored data, extract from BP directly
-			log.LogDebug("GetAllBp - Extracting workloads from API response for", zap.String("policyName", policyName))
-
-			// Extract from the policy's downsync field
-			for i, ds := range bpList.Items[i].Spec.Downsync {
-				apiGroupValue := "core" // Default to core
-				if ds.APIGroup != nil && *ds.APIGroup != "" {
-					apiGroupValue = *ds.APIGroup
-				}
 
-				log.LogDebug("GetAllBp - extract from the policy's downsync", zap.Int("index", i),
-					zap.String("apiGroup", apiGroupValue), zap.Any("resources", ds.Resources), zap.Any("namespace", ds.Namespaces))
+			log.LogDebug("withStatus - extract from the policy's downsync", zap.Int("index", i),
+				zap.String("apiGroup", apiGroupValue), zap.Any("resources", ds.Resources), zap.Any("namespace", ds.Namespaces))
 
-				for _, resource := range ds.Resources {
-					// Convert resource to lowercase for consistent handling
-					resourceLower := strings.ToLower(resource)
-					workloadType := fmt.Sprintf("%s/%s", apiGroupValue, resourceLower)
+			for _, resource := range ds.Resources {
+				// Convert resource to lowercase for consistent handling
+				resourceLower := strings.ToLower(resource)
+				workloadType := fmt.Sprintf("%s/%s", apiGroupValue, resourceLower)
 
-					if len(ds.Namespaces) > 0 {
-						for _, ns := range ds.Namespaces {
-							workloadItem := fmt.Sprintf("%s (ns:%s)", workloadType, ns)
-							if !contains(workloads, workloadItem) {
-								workloads = append(workloads, workloadItem)
-								log.LogDebug("GetAllBp - Added workload from API", zap.String("workloadItem", workloadItem))
-							}
+				if len(ds.Namespaces) > 0 {
+					for _, ns := range ds.Namespaces {
+						workloadItem := fmt.Sprintf("%s (ns:%s)", workloadType, ns)
+						if !contains(workloads, workloadItem) {
+							workloads = append(workloads, workloadItem)
+							log.LogDebug("withStatus - Added workload from API", zap.String("workloadItem", workloadItem))
 						}
-					} else if !contains(workloads, workloadType) {
-						workloads = append(workloads, workloadType)
-						log.LogDebug("GetAllBp - Added workload from API", zap.String("workloadType", workloadType))
 					}
+				} else if !contains(workloads, workloadType) {
+					workloads = append(workloads, workloadType)
+					log.LogDebug("withStatus - Added workload from API", zap.String("workloadType", workloadType))
 				}
 			}
+		}
 
-			// Try to extract from annotations if there's any workload info
-			if annotations := bpList.Items[i].Annotations; annotations != nil {
-				if specificWorkload, ok := annotations["specific-workload-name"]; ok && specificWorkload != "" {
-					// Try to determine API group and kind from annotations
-					apiVersion := annotations["workload-api-version"]
-					if apiVersion == "" {
-						apiVersion = "apps/v1" // Default to apps/v1 if not specified
-					}
+		// Try to extract from annotations if there's any workload info
+		if annotations := policy.Annotations; annotations != nil {
+			if specificWorkload, ok := annotations["specific-workload-name"]; ok && specificWorkload != "" {
+				// Try to determine API group and kind from annotations
+				apiVersion := annotations["workload-api-version"]
+				if apiVersion == "" {
+					apiVersion = "apps/v1" // Default to apps/v1 if not specified
+				}
 
-					kind := annotations["workload-kind"]
-					if kind == "" {
-						// Try to guess from the specific workload name pattern
-						if strings.Contains(specificWorkload, "-deployment") {
-							kind = "Deployment"
-						} else if strings.Contains(specificWorkload, "-statefulset") {
-							kind = "StatefulSet"
-						} else {
-							kind = "Deployment" // Default
-						}
+				kind := annotations["workload-kind"]
+				if kind == "" {
+					// Try to guess from the specific workload name pattern
+					if strings.Contains(specificWorkload, "-deployment") {
+						kind = "Deployment"
+					} else if strings.Contains(specificWorkload, "-statefulset") {
+						kind = "StatefulSet"
+					} else {
+						kind = "Deployment" // Default
 					}
+				}
 
-					workloadNamespace := annotations["workload-namespace"]
-					if workloadNamespace == "" {
-						workloadNamespace = "default"
-					}
+				workloadNamespace := annotations["workload-namespace"]
+				if workloadNamespace == "" {
+					workloadNamespace = "default"
+				}
 
-					workloadDesc := fmt.Sprintf("Specific: %s/%s: %s (ns:%s)",
-						apiVersion, kind, specificWorkload, workloadNamespace)
+				workloadDesc := fmt.Sprintf("Specific: %s/%s: %s (ns:%s)",
+					apiVersion, kind, specificWorkload, workloadNamespace)
 
-					if !contains(workloads, workloadDesc) {
-						workloads = append(workloads, workloadDesc)
-						log.LogDebug("GetAllBp - Added specific workload from annotations", zap.String("workloadDesc", workloadDesc))
-					}
+				if !contains(workloads, workloadDesc) {
+					workloads = append(workloads, workloadDesc)
+					log.LogDebug("withStatus - Added specific workload from annotations", zap.String("workloadDesc", workloadDesc))
 				}
+			}
 
-				// Check for workload-id annotation (used in quick binding policies)
-				if workloadId, ok := annotations["workload-id"]; ok && workloadId != "" && !contains(workloads, workloadId) {
-					workloads = append(workloads, workloadId)
-					log.LogDebug("GetAllBp - Added workload from workload-id annotation", zap.String("workloadId", workloadId))
-				}
+			// Check for workload-id annotation (used in quick binding policies)
+			if workloadId, ok := annotations["workload-id"]; ok && workloadId != "" && !contains(workloads, workloadId) {
+				workloads = append(workloads, workloadId)
+				log.LogDebug("withStatus - Added workload from workload-id annotation", zap.String("workloadId", workloadId))
 			}
 		}
+	}
 
-		// If we still don't have workloads, fallback to a general extraction method
-		if len(workloads) == 0 {
-			workloads = extractWorkloads(&bpList.Items[i])
-		}
+	// If we still don't have workloads, fallback to a general extraction method
+	if len(workloads) == 0 {
+		workloads = extractWorkloads(&policy, bindings)
+	}
 
-		// If still no workloads after all attempts, add a default
-		if len(workloads) == 0 {
-			workloads = append(workloads, "No workload specified")
-			log.LogDebug("GetAllBp - No workloads found, adding default")
-		}
+	// If still no workloads after all attempts, add a default
+	if len(workloads) == 0 {
+		workloads = append(workloads, "No workload specified")
+		log.LogDebug("withStatus - No workloads found, adding default")
+	}
 
-		// Ensure we have cluster count consistent with the array
-		clustersCount := len(clusters)
-
-		// Set explicit cluster count for clarity in logs
-		log.LogInfo("GetAllBp - Found clusters and workloads for policy",
-			zap.String("policy", policyName),
-			zap.Int("clustersCount", clustersCount),
-			zap.Int("workloadsCount", len(workloads)),
-		)
-
-		// Create the enhanced policy with status
-		bpWithStatus := BindingPolicyWithStatus{
-			BindingPolicy: bpList.Items[i],
-			Status:        status,
-			BindingMode:   bindingMode,
-			Clusters:      clusters,
-			Workloads:     workloads,
-		}
+	// Ensure we have cluster count consistent with the array
+	clustersCount := len(clusters)
 
-		// Store the YAML content in annotations if not already present
-		if bpWithStatus.Annotations == nil {
-			bpWithStatus.Annotations = make(map[string]string)
-		}
-		if _, exists := bpWithStatus.Annotations["yaml"]; !exists {
-			// Check if this is a quick connect policy by looking for the annotation
-			if storedBP, exists := uiCreatedPolicies[bpWithStatus.Name]; exists && storedBP.RawYAML != "" {
-				// Use the original YAML for quick connect policies
-				bpWithStatus.Annotations["yaml"] = storedBP.RawYAML
-			} else {
-				// Create a minimal version of the binding policy for YAML
-				cleanBP := map[string]interface{}{
-					"apiVersion": "control.kubestellar.io/v1alpha1",
-					"kind":       "BindingPolicy",
-					"metadata": map[string]interface{}{
-						"name": bpWithStatus.Name,
-					},
-					"spec": map[string]interface{}{},
-				}
+	// Set explicit cluster count for clarity in logs
+	log.LogInfo("withStatus - Found clusters and workloads for policy",
+		zap.String("policy", policyName),
+		zap.Int("clustersCount", clustersCount),
+		zap.Int("workloadsCount", len(workloads)),
+	)
 
-				// Add namespace if not empty
-				if bpWithStatus.Namespace != "" {
-					cleanBP["metadata"].(map[string]interface{})["namespace"] = bpWithStatus.Namespace
-				}
+	// Create the enhanced policy with status
+	bpWithStatus := BindingPolicyWithStatus{
+		BindingPolicy: policy,
+		Status:        status,
+		BindingMode:   bindingMode,
+		Clusters:      clusters,
+		Workloads:     workloads,
+	}
 
-				// Add only essential annotations
-				if len(bpWithStatus.Annotations) > 0 {
-					relevantAnnotations := map[string]string{}
-					for k, v := range bpWithStatus.Annotations {
-						if k == "created-by" || k == "creation-timestamp" ||
-							(!strings.HasPrefix(k, "kubectl.kubernetes.io/") &&
-								!strings.HasPrefix(k, "kubernetes.io/") &&
-								k != "yaml" &&
-								!strings.Contains(k, "managedFields")) {
-							relevantAnnotations[k] = v
-						}
-					}
-					if len(relevantAnnotations) > 0 {
-						cleanBP["metadata"].(map[string]interface{})["annotations"] = relevantAnnotations
+	// Store the YAML content in annotations if not already present
+	if bpWithStatus.Annotations == nil {
+		bpWithStatus.Annotations = make(map[string]string)
+	}
+	if _, exists := bpWithStatus.Annotations["yaml"]; !exists {
+		// Check if this is a quick connect policy by looking for the annotation
+		if storedBP, exists := storedPolicy(bpWithStatus.Name); exists && storedBP.RawYAML != "" {
+			// Use the original YAML for quick connect policies
+			bpWithStatus.Annotations["yaml"] = storedBP.RawYAML
+		} else {
+			// Create a minimal version of the binding policy for YAML
+			cleanBP := map[string]interface{}{
+				"apiVersion": "control.kubestellar.io/v1alpha1",
+				"kind":       "BindingPolicy",
+				"metadata": map[string]interface{}{
+					"name": bpWithStatus.Name,
+				},
+				"spec": map[string]interface{}{},
+			}
+
+			// Add namespace if not empty
+			if bpWithStatus.Namespace != "" {
+				cleanBP["metadata"].(map[string]interface{})["namespace"] = bpWithStatus.Namespace
+			}
+
+			// Add only essential annotations
+			if len(bpWithStatus.Annotations) > 0 {
+				relevantAnnotations := map[string]string{}
+				for k, v := range bpWithStatus.Annotations {
+					if k == "created-by" || k == "creation-timestamp" ||
+						(!strings.HasPrefix(k, "kubectl.kubernetes.io/") &&
+							!strings.HasPrefix(k, "kubernetes.io/") &&
+							k != "yaml" &&
+							!strings.Contains(k, "managedFields")) {
+						relevantAnnotations[k] = v
 					}
 				}
+				if len(relevantAnnotations) > 0 {
+					cleanBP["metadata"].(map[string]interface{})["annotations"] = relevantAnnotations
+				}
+			}
 
-				// Add non-empty labels only
-				if len(bpWithStatus.Labels) > 0 {
-					relevantLabels := map[string]string{}
-					for k, v := range bpWithStatus.Labels {
-						if v != "" {
-							relevantLabels[k] = v
-						}
-					}
-					if len(relevantLabels) > 0 {
-						cleanBP["metadata"].(map[string]interface{})["labels"] = relevantLabels
+			// Add non-empty labels only
+			if len(bpWithStatus.Labels) > 0 {
+				relevantLabels := map[string]string{}
+				for k, v := range bpWithStatus.Labels {
+					if v != "" {
+						relevantLabels[k] = v
 					}
 				}
+				if len(relevantLabels) > 0 {
+					cleanBP["metadata"].(map[string]interface{})["labels"] = relevantLabels
+				}
+			}
 
-				// Add cluster selectors (properly formatted)
-				if len(bpWithStatus.Spec.ClusterSelectors) > 0 {
-					cleanSelectors := []map[string]interface{}{}
-					for _, selector := range bpWithStatus.Spec.ClusterSelectors {
-						if len(selector.MatchLabels) > 0 {
-							cleanSelector := map[string]interface{}{
-								"matchLabels": selector.MatchLabels,
-							}
-							cleanSelectors = append(cleanSelectors, cleanSelector)
+			// Add cluster selectors (properly formatted)
+			if len(bpWithStatus.Spec.ClusterSelectors) > 0 {
+				cleanSelectors := []map[string]interface{}{}
+				for _, selector := range bpWithStatus.Spec.ClusterSelectors {
+					if len(selector.MatchLabels) > 0 {
+						cleanSelector := map[string]interface{}{
+							"matchLabels": selector.MatchLabels,
 						}
+						cleanSelectors = append(cleanSelectors, cleanSelector)
 					}
-					if len(cleanSelectors) > 0 {
-						specMap := cleanBP["spec"].(map[string]interface{})
-						specMap["clusterSelectors"] = cleanSelectors
-					}
 				}
+				if len(cleanSelectors) > 0 {
+					specMap := cleanBP["spec"].(map[string]interface{})
+					specMap["clusterSelectors"] = cleanSelectors
+				}
+			}
 
-				// Add downsync rules (properly formatted)
-				if len(bpWithStatus.Spec.Downsync) > 0 {
-					cleanDownsync := []map[string]interface{}{}
-					for _, ds := range bpWithStatus.Spec.Downsync {
-						cleanDs := map[string]interface{}{}
+			// Add downsync rules (properly formatted)
+			if len(bpWithStatus.Spec.Downsync) > 0 {
+				cleanDownsync := []map[string]interface{}{}
+				for _, ds := range bpWithStatus.Spec.Downsync {
+					cleanDs := map[string]interface{}{}
 
-						// Add resources
-						if len(ds.Resources) > 0 {
-							cleanDs["resources"] = ds.Resources
-						}
+					// Add resources
+					if len(ds.Resources) > 0 {
+						cleanDs["resources"] = ds.Resources
+					}
 
-						// Add API group only if not empty
-						if ds.APIGroup != nil && *ds.APIGroup != "" {
-							cleanDs["apiGroup"] = *ds.APIGroup
-						}
+					// Add API group only if not empty
+					if ds.APIGroup != nil && *ds.APIGroup != "" {
+						cleanDs["apiGroup"] = *ds.APIGroup
+					}
 
-						// Add namespaces only if not empty
-						if len(ds.Namespaces) > 0 {
-							cleanDs["namespaces"] = ds.Namespaces
-						}
+					// Add namespaces only if not empty
+					if len(ds.Namespaces) > 0 {
+						cleanDs["namespaces"] = ds.Namespaces
+					}
 
-						// Add object selectors only if they have matchLabels
-						if len(ds.ObjectSelectors) > 0 {
-							cleanObjSelectors := []map[string]interface{}{}
-							for _, objSelector := range ds.ObjectSelectors {
-								if len(objSelector.MatchLabels) > 0 {
-									cleanObjSelectors = append(cleanObjSelectors, map[string]interface{}{
-										"matchLabels": objSelector.MatchLabels,
-									})
-								}
-							}
-							if len(cleanObjSelectors) > 0 {
-								cleanDs["objectSelectors"] = cleanObjSelectors
+					// Add object selectors only if they have matchLabels
+					if len(ds.ObjectSelectors) > 0 {
+						cleanObjSelectors := []map[string]interface{}{}
+						for _, objSelector := range ds.ObjectSelectors {
+							if len(objSelector.MatchLabels) > 0 {
+								cleanObjSelectors = append(cleanObjSelectors, map[string]interface{}{
+									"matchLabels": objSelector.MatchLabels,
+								})
 							}
 						}
-
-						// Add createOnly flag only if true
-						if ds.CreateOnly {
-							cleanDs["createOnly"] = true
+						if len(cleanObjSelectors) > 0 {
+							cleanDs["objectSelectors"] = cleanObjSelectors
 						}
+					}
 
-						if len(cleanDs) > 0 {
-							cleanDownsync = append(cleanDownsync, cleanDs)
-						}
+					// Add createOnly flag only if true
+					if ds.CreateOnly {
+						cleanDs["createOnly"] = true
 					}
 
-					if len(cleanDownsync) > 0 {
-						specMap := cleanBP["spec"].(map[string]interface{})
-						specMap["downsync"] = cleanDownsync
+					if len(cleanDs) > 0 {
+						cleanDownsync = append(cleanDownsync, cleanDs)
 					}
 				}
 
-				// Convert to YAML
-				yamlBytes, err := yaml.Marshal(cleanBP)
-				if err == nil {
-					bpWithStatus.Annotations["yaml"] = string(yamlBytes)
+				if len(cleanDownsync) > 0 {
+					specMap := cleanBP["spec"].(map[string]interface{})
+					specMap["downsync"] = cleanDownsync
 				}
 			}
-		}
-
-		bpsWithStatus = append(bpsWithStatus, bpWithStatus)
-	}
-
-	// Filter by namespace if specified
-	if namespace != "" {
-		log.LogDebug("filtering by namespace", zap.String("namespace", namespace))
-		filteredBPs := filterBPsByNamespace(bpsWithStatus, namespace)
-		ctx.JSON(http.StatusOK, gin.H{
-			"bindingPolicies": filteredBPs,
-			"count":           len(filteredBPs),
-		})
-		return
-	}
 
-	// Before sending the response, ensure each policy has proper clustersCount and workloadsCount
-	responseArray := make([]map[string]interface{}, len(bpsWithStatus))
-	for i, bp := range bpsWithStatus {
-		// Convert each binding policy to a map for customization
-		policyMap := map[string]interface{}{
-			"name":           bp.Name,
-			"namespace":      bp.Namespace,
-			"status":         bp.Status,
-			"bindingMode":    bp.BindingMode,
-			"clusters":       bp.Clusters,
-			"clusterList":    bp.Clusters, // For backward compatibility
-			"workloads":      bp.Workloads,
-			"workloadList":   bp.Workloads,      // For backward compatibility
-			"clustersCount":  len(bp.Clusters),  // Explicitly set based on clusters array
-			"workloadsCount": len(bp.Workloads), // Explicitly set based on workloads array
-			// Include other fields that might be needed in the response
-			"creationTimestamp": bp.CreationTimestamp,
-			"conditions":        bp.BindingPolicy.Status.Conditions,
+			// Convert to YAML
+			yamlBytes, err := yaml.Marshal(cleanBP)
+			if err == nil {
+				bpWithStatus.Annotations["yaml"] = string(yamlBytes)
+			}
 		}
+	}
 
-		// Check if this is a quick connect policy and use its original YAML
-		if storedBP, exists := uiCreatedPolicies[bp.Name]; exists && storedBP.RawYAML != "" {
-			policyMap["yaml"] = storedBP.RawYAML
-		} else {
-			policyMap["yaml"] = bp.Annotations["yaml"]
-		}
+	return bpWithStatus, nil
+}
 
-		responseArray[i] = policyMap
+// bindingPolicyResponse is the JSON shape of a binding policy in the list and status responses
+func bindingPolicyResponse(bp BindingPolicyWithStatus) map[string]interface{} {
+	// Convert each binding policy to a map for customization
+	policyMap := map[string]interface{}{
+		"name":           bp.Name,
+		"namespace":      bp.Namespace,
+		"status":         bp.Status,
+		"bindingMode":    bp.BindingMode,
+		"clusters":       bp.Clusters,
+		"clusterList":    bp.Clusters, // For backward compatibility
+		"workloads":      bp.Workloads,
+		"workloadList":   bp.Workloads,      // For backward compatibility
+		"clustersCount":  len(bp.Clusters),  // Explicitly set based on clusters array
+		"workloadsCount": len(bp.Workloads), // Explicitly set based on workloads array
+		"destinations":   bp.Destinations,
+		// Include other fields that might be needed in the response
+		"creationTimestamp": bp.CreationTimestamp,
+		"conditions":        bp.BindingPolicy.Status.Conditions,
+	}
+
+	// Check if this is a quick connect policy and use its original YAML
+	if storedBP, exists := storedPolicy(bp.Name); exists && storedBP.RawYAML != "" {
+		policyMap["yaml"] = storedBP.RawYAML
+	} else {
+		policyMap["yaml"] = bp.Annotations["yaml"]
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"bindingPolicies": responseArray,
-		"count":           len(responseArray),
-	})
+	return policyMap
 }
 
 // CreateBp creates a new BindingPolicy
@@ -531,18 +542,23 @@ func CreateBp(ctx *gin.Context) {
 		return
 
 	}
-	c, err := getClientForBp()
+	c, err := getClientForRequest(ctx)
 	if err != nil {
 		log.LogInfo(err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	_, err = c.BindingPolicies().Create(context.TODO(), bp, v1.CreateOptions{})
+	created, err := c.BindingPolicies().Create(context.TODO(), bp, v1.CreateOptions{})
 	if err != nil {
 		log.LogError(err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Created binding policy '%s' successfully", bp.Name),
+		"warnings": bindingPolicyWarnings(ctx, created),
+	})
 }
 
 // DeleteBp deletes a BindingPolicy by name and namespace
@@ -554,7 +570,7 @@ func DeleteBp(ctx *gin.Context) {
 		return
 	}
 	log.LogInfo("", zap.String("deleting bp: ", name))
-	c, err := getClientForBp()
+	c, err := getClientForRequest(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -571,410 +587,64 @@ func DeleteBp(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("deleted %s", name)})
 
-}
-
-// DeleteAllBp deletes all BindingPolicies
-func DeleteAllBp(ctx *gin.Context) {
-	c, err := getClientForBp()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Optional namespace filter
-	namespace := ctx.Query("namespace")
-	listOptions := v1.ListOptions{}
-
-	err = c.BindingPolicies().DeleteCollection(context.TODO(), v1.DeleteOptions{}, listOptions)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to delete binding policies: %v", err),
-		})
-		return
-	}
-
-	message := "Deleted all binding policies"
-	if namespace != "" {
-		message = fmt.Sprintf("Deleted all binding policies in namespace '%s'", namespace)
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{"message": message})
-}
-
-// GetBpStatus retrieves the status of a specific BindingPolicy
-func GetBpStatus(ctx *gin.Context) {
-	name := ctx.Query("name")
-	namespace := ctx.Query("namespace")
-
-	log.LogDebug("GetBpStatus - Received request",
-		zap.String("name", name), zap.String("namespace", namespace))
-
-	if name == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "name parameter is required"})
-		return
-	}
-
-	if namespace == "" {
-		namespace = "default" // Set default namespace
-	}
-
-	log.LogDebug("GetBpStatus - Using namespace", zap.String("namespace", namespace))
-
-	c, err := getClientForBp()
-	if err != nil {
-		log.LogError("GetBpStatus - Client error", zap.Error(err))
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Try to get binding policy directly
-	bp, err := c.BindingPolicies().Get(context.TODO(), name, v1.GetOptions{})
-	if err != nil {
-		log.LogDebug("GetBpStatus - Direct Get error", zap.Error(err))
-
-		// Try to list all binding policies to see if it exists
-		bpList, listErr := c.BindingPolicies().List(context.TODO(), v1.ListOptions{})
-		if listErr != nil {
-			log.LogError("GetBpStatus - List error", zap.Error(listErr))
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error": fmt.Sprintf("Binding policy '%s' not found and failed to list policies: %v", name, listErr),
-			})
-			return
-		}
-
-		// Check if we can find the policy with the given name
-		var foundBP *v1alpha1.BindingPolicy
-		log.LogInfo("GetBpStatus - Listing all BPs to find", zap.String("name", name))
-		for i, item := range bpList.Items {
-			log.LogDebug("inspecting binding policy", zap.Int("index", i), zap.String("namespace", namespace), zap.String("name", name))
-			if item.Name == name {
-				foundBP = &bpList.Items[i]
-				break
-			}
-		}
-
-		if foundBP == nil {
-			ctx.JSON(http.StatusNotFound, gin.H{
-				"error": fmt.Sprintf("Binding policy '%s' not found in any namespace", name),
-			})
-			return
-		}
-
-		bp = foundBP
-		log.LogDebug("GetBpStatus - Found BP with matching name in namespace", zap.String("namespace", bp.Namespace))
-	}
-
-	// Look for this binding policy in the uiCreatedPolicies map
-	storedBP, exists := uiCreatedPolicies[name]
-	if exists {
-		log.LogDebug("GetBpStatus - Found stored BP in memory with key", zap.String("name", name))
-		// Debug the stored policy
-		log.LogDebug(" Stored BP ClusterSelectors", zap.Any("clustersSelectors", storedBP.ClusterSelectors))
-	} else {
-		log.LogDebug("GetBpStatus - No stored BP found in memory with key", zap.String("name", name))
-	}
-
-	// Determine if the policy is active based on status fields
-	status := "inactive"
-	if bp.ObjectMeta.Generation == bp.Status.ObservedGeneration {
-		status = "active"
-	}
-	// Initialize clusters and workloads slices
-	clusters := []string{}
-	workloads := []string{}
-
-	// If we have a stored policy with cluster selectors, use that
-	if exists && len(storedBP.ClusterSelectors) > 0 {
-		log.LogDebug("GetBpStatus - Using cluster selectors from stored policy")
-		for i, selector := range storedBP.ClusterSelectors {
-			if clusterName, ok := selector["kubernetes.io/cluster-name"]; ok {
-				log.LogDebug("GetBpStatus - Found cluster from stored data", zap.String("clusterName", clusterName))
-				clusters = append(clusters, clusterName)
-			} else {
-				log.LogDebug("GetBpStatus - Selector missing cluster-name", zap.Int("index", i), zap.Any("selector", selector))
-			}
-		}
-
-		// Use stored API groups and resources
-		for i, apiGroup := range storedBP.APIGroups {
-			if i < len(storedBP.Resources) {
-				// Convert resource to lowercase for consistent handling
-				resourceLower := strings.ToLower(storedBP.Resources[i])
-				workloadType := fmt.Sprintf("%s/%s", apiGroup, resourceLower)
-
-				// Add namespaces if specified
-				if len(storedBP.Namespaces) > 0 {
-					for _, ns := range storedBP.Namespaces {
-						workloads = append(workloads, fmt.Sprintf("%s (ns:%s)", workloadType, ns))
-					}
-				} else {
-					workloads = append(workloads, workloadType)
-				}
-			}
-		}
-
-		// Add specific workloads from stored data
-		for _, workload := range storedBP.SpecificWorkloads {
-			workloadDesc := fmt.Sprintf("Specific: %s/%s", workload.APIVersion, workload.Kind)
-			if workload.Name != "" {
-				workloadDesc += fmt.Sprintf(": %s", workload.Name)
-			}
-			if workload.Namespace != "" {
-				workloadDesc += fmt.Sprintf(" (ns:%s)", workload.Namespace)
-			}
-			log.LogDebug("GetAllBp - Adding specific workload from storage", zap.String("workloadDesc", workloadDesc))
-			workloads = append(workloads, workloadDesc)
-		}
-	} else {
-		// Try to extract from the API response
-		log.LogInfo("GetAllBp - Trying to extract from API response")
-
-		// Extract clusters from BP
-		for i, selector := range bp.Spec.ClusterSelectors {
-			if selector.MatchLabels == nil {
-				continue
-			}
-
-			log.LogDebug("GetAllBp - Processing cluster selector",
-				zap.Int("index", i), zap.Any("matchLabels", selector.MatchLabels))
-
-			// Check for kubernetes.io/cluster-name label
-			if clusterName, ok := selector.MatchLabels["kubernetes.io/cluster-name"]; ok {
-				log.LogDebug("GetAllBp - Found cluster from API", zap.String("clusterName", clusterName))
-				clusters = append(clusters, clusterName)
-			}
-		}
-
-		// Extract workloads from BP
-		for i, ds := range bp.Spec.Downsync {
-			apiGroupValue := "core" // Default to core
-			if ds.APIGroup != nil && *ds.APIGroup != "" {
-				apiGroupValue = *ds.APIGroup
-			}
-
-			log.LogDebug("GetAllBp - Processing Downsync entry",
-				zap.Int("index", i), zap.String("apiGroup", apiGroupValue),
-				zap.Any("resources", ds.Resources), zap.Any("namespaces", ds.Namespaces))
-
-			for _, resource := range ds.Resources {
-				// Convert resource to lowercase for consistent handling
-				resourceLower := strings.ToLower(resource)
-				workloadType := fmt.Sprintf("%s/%s", apiGroupValue, resourceLower)
-
-				if len(ds.Namespaces) > 0 {
-					for _, ns := range ds.Namespaces {
-						workloadItem := fmt.Sprintf("%s (ns:%s)", workloadType, ns)
-						// Check if already in the list
-						alreadyExists := false
-						for _, w := range workloads {
-							if w == workloadItem {
-								alreadyExists = true
-								break
-							}
-						}
-						if !alreadyExists {
-							workloads = append(workloads, workloadItem)
-						}
-					}
-				} else {
-					workloads = append(workloads, workloadType)
-				}
-			}
-		}
-
-	}
-
-	// If we still don't have clusters or workloads, try to parse the stored rawYAML if available
-	if (len(clusters) == 0 || len(workloads) == 0) && exists && storedBP.RawYAML != "" {
-		log.LogDebug("GetAllBp - Trying to parse stored raw YAML")
-		// Parse the raw YAML to extract information
-		var yamlMap map[string]interface{}
-		if err := yaml.Unmarshal([]byte(storedBP.RawYAML), &yamlMap); err != nil {
-			log.LogDebug("GetAllBp - Failed to parse raw YAML", zap.Error(err))
-
-		} else {
-			// Try to extract cluster selectors from YAML
-			if spec, ok := yamlMap["spec"].(map[interface{}]interface{}); ok {
-				if selectors, ok := spec["clusterSelectors"].([]interface{}); ok {
-					log.LogDebug("GetAllBp - Found cluster selectors in YAML", zap.Int("count", len(selectors)))
-					for _, selectorObj := range selectors {
-						if selector, ok := selectorObj.(map[interface{}]interface{}); ok {
-							if matchLabels, ok := selector["matchLabels"].(map[interface{}]interface{}); ok {
-								for k, v := range matchLabels {
-									if kStr, ok := k.(string); ok && kStr == "kubernetes.io/cluster-name" {
-										if vStr, ok := v.(string); ok {
-											log.LogDebug("GetAllBp - Found cluster from YAML", zap.String("cluster", vStr))
-											// Check if already in the list
-											alreadyExists := false
-											for _, c := range clusters {
-												if c == vStr {
-													alreadyExists = true
-													break
-												}
-											}
-											if !alreadyExists {
-												clusters = append(clusters, vStr)
-											}
-										}
-									}
-								}
-							}
-						}
-					}
-				}
-
-				// Try to extract downsync resources from YAML
-				if downsyncList, ok := spec["downsync"].([]interface{}); ok {
-					log.LogDebug("GetAllBp - Found downsync entries in YAML", zap.Int("count", len(downsyncList)))
-					for _, downsyncObj := range downsyncList {
-						if downsync, ok := downsyncObj.(map[interface{}]interface{}); ok {
-							// Extract API group
-							apiGroupValue := "core" // Default
-							if apiGroup, ok := downsync["apiGroup"].(string); ok && apiGroup != "" {
-								apiGroupValue = apiGroup
-							}
+}
 
-							// Extract resources
-							var resources []string
-							if rawResources, ok := downsync["resources"].([]interface{}); ok {
-								for _, r := range rawResources {
-									if resource, ok := r.(string); ok {
-										// Convert resource to lowercase
-										resourceLower := strings.ToLower(resource)
-										resources = append(resources, resourceLower)
-									}
-								}
-							}
+// DeleteAllBp deletes all BindingPolicies
+func DeleteAllBp(ctx *gin.Context) {
+	c, err := getClientForRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-							// Extract namespaces
-							var namespaces []string
-							if rawNamespaces, ok := downsync["namespaces"].([]interface{}); ok {
-								for _, n := range rawNamespaces {
-									if ns, ok := n.(string); ok {
-										namespaces = append(namespaces, ns)
-									}
-								}
-							}
+	// Optional namespace filter
+	namespace := ctx.Query("namespace")
+	listOptions := v1.ListOptions{}
 
-							// Create workload entries
-							for _, resource := range resources {
-								workloadType := fmt.Sprintf("%s/%s", apiGroupValue, resource)
-
-								if len(namespaces) > 0 {
-									for _, ns := range namespaces {
-										workloadItem := fmt.Sprintf("%s (ns:%s)", workloadType, ns)
-										// Check if already in the list
-										alreadyExists := false
-										for _, w := range workloads {
-											if w == workloadItem {
-												alreadyExists = true
-												break
-											}
-										}
-										if !alreadyExists {
-											workloads = append(workloads, workloadItem)
-										}
-									}
-								} else {
-									// Check if already in the list
-									alreadyExists := false
-									for _, w := range workloads {
-										if w == workloadType {
-											alreadyExists = true
-											break
-										}
-									}
-									if !alreadyExists {
-										workloads = append(workloads, workloadType)
-									}
-								}
-							}
-						}
-					}
-				}
+	err = c.BindingPolicies().DeleteCollection(context.TODO(), v1.DeleteOptions{}, listOptions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to delete binding policies: %v", err),
+		})
+		return
+	}
 
-				// Try to extract specific workloads from YAML
-				if workloadsList, ok := spec["workloads"].([]interface{}); ok {
-					log.LogDebug("GetAllBp - Found specific workloads in YAML", zap.Int("count", len(workloadsList)))
-					for i, workloadObj := range workloadsList {
-						if workload, ok := workloadObj.(map[interface{}]interface{}); ok {
-							// Extract apiVersion
-							apiVersion := "unknown"
-							if av, ok := workload["apiVersion"].(string); ok {
-								apiVersion = av
-							}
+	message := "Deleted all binding policies"
+	if namespace != "" {
+		message = fmt.Sprintf("Deleted all binding policies in namespace '%s'", namespace)
+	}
 
-							// Extract kind
-							kind := "unknown"
-							if k, ok := workload["kind"].(string); ok {
-								kind = k
-							}
+	ctx.JSON(http.StatusOK, gin.H{"message": message})
+}
 
-							// Extract name and namespace from metadata
-							name := ""
-							namespace := ""
-							if metaObj, ok := workload["metadata"].(map[interface{}]interface{}); ok {
-								if n, ok := metaObj["name"].(string); ok {
-									name = n
-								}
-								if ns, ok := metaObj["namespace"].(string); ok {
-									namespace = ns
-								}
-							}
+// GetBpStatus retrieves the status of a specific BindingPolicy
+func GetBpStatus(ctx *gin.Context) {
+	name := ctx.Query("name")
 
-							// Only add if we have at least some identifying information
-							if name != "" || (apiVersion != "unknown" && kind != "unknown") {
-								workloadDesc := fmt.Sprintf("Specific: %s/%s", apiVersion, kind)
-								if name != "" {
-									workloadDesc += fmt.Sprintf(": %s", name)
-								}
-								if namespace != "" {
-									workloadDesc += fmt.Sprintf(" (ns:%s)", namespace)
-								}
+	log.LogDebug("GetBpStatus - Received request", zap.String("name", name))
 
-								log.LogDebug("GetAllBp - Found specific workload in YAML", zap.Int("index", i), zap.String("workload", workloadDesc))
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "name parameter is required"})
+		return
+	}
 
-								// Check if already in the list
-								alreadyExists := false
-								for _, w := range workloads {
-									if w == workloadDesc {
-										alreadyExists = true
-										break
-									}
-								}
-								if !alreadyExists {
-									workloads = append(workloads, workloadDesc)
-								}
-							}
-						}
-					}
-				}
-			}
-		}
+	pc, err := policyCacheFor(k8s.RequestWDSContext(ctx))
+	if err != nil {
+		log.LogError("GetBpStatus - Cache error", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Print debug info before returning
-	log.LogDebug("GetAllBp - Returning response",
-		zap.String("name", bp.Name),
-		zap.String("namespace", bp.Namespace),
-		zap.Int("clusters_count", len(clusters)),
-		zap.Any("clusters", clusters),
-		zap.Int("workloads_count", len(workloads)),
-		zap.Any("workloads", workloads))
+	// Binding policies are cluster-scoped, so the namespace parameter is not needed to find one
+	bp, ok := pc.Get(name)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("Binding policy '%s' not found", name),
+		})
+		return
+	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"name":              bp.Name,
-		"namespace":         bp.Namespace,
-		"status":            status,
-		"conditions":        bp.Status.Conditions,
-		"bindingMode":       "Downsync", // KubeStellar only supports Downsync currently
-		"clusters":          clusters,
-		"workloads":         workloads,
-		"clustersCount":     len(clusters),
-		"workloadsCount":    len(workloads),
-		"creationTimestamp": bp.CreationTimestamp,   // Add creation timestamp for consistency with GetAllBp
-		"yaml":              bp.Annotations["yaml"], // Also include YAML for completeness
-	})
+	ctx.JSON(http.StatusOK, bindingPolicyResponse(bp))
 }
 
 // Updates the Binding policy with the given name, Assuming that it exists
@@ -990,7 +660,7 @@ func UpdateBp(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 
-	c, err := getClientForBp()
+	c, err := getClientForRequest(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1000,14 +670,14 @@ func UpdateBp(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("updated %s", updatedBp.Name)})
+	ctx.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("updated %s", updatedBp.Name), "warnings": bindingPolicyWarnings(ctx, updatedBp)})
 
 }
 
 // CreateBpFromJson creates a new BindingPolicy from JSON data sent by the UI
 func CreateBpFromJson(ctx *gin.Context) {
 	log.LogInfo("Starting CreateBpFromJson handler")
-	log.LogDebug("KUBECONFIG", zap.String("KUBECONFIG", os.Getenv("KUBECONFIG")))
+	log.LogDebug("KUBECONFIG", zap.String("KUBECONFIG", k8s.KubeconfigPath()))
 	log.LogDebug("wds_context", zap.String("wds_context", os.Getenv("wds_context")))
 
 	// Check Content-Type header
@@ -1284,11 +954,11 @@ func CreateBpFromJson(ctx *gin.Context) {
 	}
 
 	// Store policy before API call
-	uiCreatedPolicies[newBP.Name] = storedBP
+	storePolicy(storedBP)
 	log.LogInfo("Stored policy in memory cache", zap.String("key", newBP.Name))
 
 	// Get client
-	c, err := getClientForBp()
+	c, err := getClientForRequest(ctx)
 	if err != nil {
 		log.LogError("Client creation error", zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create client: %s", err.Error())})
@@ -1296,7 +966,7 @@ func CreateBpFromJson(ctx *gin.Context) {
 	}
 
 	// Create the binding policy
-	_, err = c.BindingPolicies().Create(context.TODO(), newBP, v1.CreateOptions{})
+	created, err := c.BindingPolicies().Create(context.TODO(), newBP, v1.CreateOptions{})
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
 			ctx.JSON(http.StatusConflict, gin.H{
@@ -1370,6 +1040,7 @@ func CreateBpFromJson(ctx *gin.Context) {
 			"workloadsCount": len(workloads),
 			"yaml":           rawYAML,
 		},
+		"warnings": bindingPolicyWarnings(ctx, created),
 	})
 }
 
@@ -1387,25 +1058,6 @@ func contains(slice []string, str string) bool {
 func CreateQuickBindingPolicy(ctx *gin.Context) {
 	log.LogInfo("Starting CreateQuickBindingPolicy handler")
 
-	// Define a struct to parse the quick connection request
-	type ResourceConfig struct {
-		Type       string `json:"type"`       // Resource type (e.g., "deployments", "namespaces")
-		CreateOnly bool   `json:"createOnly"` // Whether to use createOnly mode for this resource
-		APIGroup   string `json:"apiGroup"`   // Optional API group for the resource (for CRDs)
-	}
-
-	type QuickBindingPolicyRequest struct {
-		WorkloadLabels   map[string]string `json:"workloadLabels"`   // Labels to select workloads
-		ClusterLabels    map[string]string `json:"clusterLabels"`    // Labels to select clusters
-		Resources        []ResourceConfig  `json:"resources"`        // Resources with their configurations
-		NamespacesToSync []string          `json:"namespacesToSync"` // Namespaces to sync resources from
-		PolicyName       string            `json:"policyName"`       // Optional custom name for the policy
-		Namespace        string            `json:"namespace"`        // Optional namespace
-		// For backward compatibility
-		ResourceTypes []string `json:"resourceTypes"` // Legacy: Resource types to sync
-		CreateOnly    bool     `json:"createOnly"`    // Legacy: Whether to use createOnly mode for all resources
-	}
-
 	var request QuickBindingPolicyRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		log.LogError("JSON binding error", zap.Error(err))
@@ -1415,251 +1067,21 @@ func CreateQuickBindingPolicy(ctx *gin.Context) {
 
 	log.LogDebug("Received request", zap.Any("request", request))
 
-	// Validate required fields
-	if len(request.WorkloadLabels) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "workloadLabels are required"})
-		return
-	}
-
-	if len(request.ClusterLabels) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "clusterLabels are required"})
+	if request.Template != "" {
+		instantiateTemplate(ctx, request.Template, request.templateInstance(false))
 		return
 	}
 
-	// Handle both new and legacy resource specifications
-	resourceConfigs := request.Resources
-	if len(resourceConfigs) == 0 && len(request.ResourceTypes) > 0 {
-		// Convert legacy format to new format
-		for _, resType := range request.ResourceTypes {
-			// Skip pods in legacy format
-			if strings.ToLower(resType) == "pods" {
-				continue
-			}
-			resourceConfigs = append(resourceConfigs, ResourceConfig{
-				Type:       resType,
-				CreateOnly: request.CreateOnly,
-			})
-		}
-	}
-
-	// Filter out pods from resources instead of rejecting entire request
-	filteredResources := []ResourceConfig{}
-	podsDetected := false
-
-	for _, resourceCfg := range resourceConfigs {
-		if strings.ToLower(resourceCfg.Type) == "pods" {
-			podsDetected = true
-			continue
-		}
-		filteredResources = append(filteredResources, resourceCfg)
-	}
-
-	resourceConfigs = filteredResources
-
-	if len(resourceConfigs) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one valid resource type is required (pods are not allowed)"})
+	quick, err := buildQuickBindingPolicy(request, "kubestellar-ui-quick-create")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Set default namespace if not provided
-	namespace := "default"
-	if request.Namespace != "" {
-		namespace = request.Namespace
-	}
-
-	// Generate a policy name if not provided
-	policyName := request.PolicyName
-	if policyName == "" {
-		// Create a name based on the first workload label and first cluster label
-		firstWorkloadKey, firstWorkloadValue := getFirstMapEntry(request.WorkloadLabels)
-		firstClusterKey, firstClusterValue := getFirstMapEntry(request.ClusterLabels)
-
-		policyName = fmt.Sprintf("%s-%s-to-%s-%s", firstWorkloadKey, firstWorkloadValue,
-			firstClusterKey, firstClusterValue)
-
-		// Clean up the name to be valid for Kubernetes
-		policyName = strings.ReplaceAll(policyName, "/", "-")
-		policyName = strings.ReplaceAll(policyName, ":", "-")
-		policyName = strings.ReplaceAll(policyName, ".", "-")
-		policyName = strings.ToLower(policyName)
-	}
-
-	// Create a policy as a generic map that we'll convert to YAML
-	policyObj := map[string]interface{}{
-		"apiVersion": "control.kubestellar.io/v1alpha1",
-		"kind":       "BindingPolicy",
-		"metadata": map[string]interface{}{
-			"name":      policyName,
-			"namespace": namespace,
-			"annotations": map[string]string{
-				"created-by":         "kubestellar-ui-quick-create",
-				"creation-timestamp": time.Now().Format(time.RFC3339),
-			},
-		},
-		"spec": map[string]interface{}{
-			"clusterSelectors": []interface{}{
-				map[string]interface{}{
-					"matchLabels": request.ClusterLabels,
-				},
-			},
-			"downsync": []interface{}{},
-		},
-	}
-
-	// Determine namespaces to sync
-	namespacesToSync := request.NamespacesToSync
-	if len(namespacesToSync) == 0 {
-		namespacesToSync = []string{namespace}
-	}
-
-	// Always add a namespaces sync rule first (without createOnly)
-	namespaceRule := map[string]interface{}{
-		"resources": []string{"namespaces"},
-		"objectSelectors": []interface{}{
-			map[string]interface{}{
-				"matchLabels": request.WorkloadLabels,
-			},
-		},
-	}
-
-	downsyncRules := []interface{}{namespaceRule}
-
-	// Track if we have CRDs to add
-	hasCRDs := false
-	crdAPIGroups := make(map[string]string)
-
-	// First check for custom resources that will need CRDs
-	for _, resourceCfg := range resourceConfigs {
-		resource := resourceCfg.Type
-
-		if isKubernetesBuiltInResource(resource) {
-			continue
-		}
-
-		// Found a custom resource, track its API group for CRD handling
-		apiGroup := ""
-		// Check if original request has apiGroup in the matching resource
-		for _, origRes := range request.Resources {
-			if origRes.Type == resource && origRes.APIGroup != "" {
-				apiGroup = origRes.APIGroup
-				break
-			}
-		}
-
-		// If no explicit apiGroup provided, use a heuristic to determine it
-		if apiGroup == "" {
-			singular := strings.TrimSuffix(resource, "s")
-
-			if strings.HasSuffix(resource, "cds") || strings.HasSuffix(resource, "eds") {
-				rootName := singular[:len(singular)-1]
-				apiGroup = fmt.Sprintf("%s.io", rootName)
-			} else {
-				apiGroup = fmt.Sprintf("%s.k8s.io", singular)
-			}
-		}
-
-		crdAPIGroups[resource] = apiGroup
-		hasCRDs = true
-	}
-
-	// If we have custom resources, add rule(s) for CustomResourceDefinitions
-	if hasCRDs {
-		log.LogInfo("Adding CustomResourceDefinitions to binding policy")
-
-		// Check if customresourcedefinitions is already in the resources list
-		hasExplicitCRDResource := false
-		for _, res := range resourceConfigs {
-			if res.Type == "customresourcedefinitions" {
-				hasExplicitCRDResource = true
-				break
-			}
-		}
-
-		if hasExplicitCRDResource {
-			log.LogInfo("User explicitly specified CustomResourceDefinitions resource, using workload labels")
-			crdRule := map[string]interface{}{
-				"apiGroup":  "apiextensions.k8s.io",
-				"resources": []string{"customresourcedefinitions"},
-				"objectSelectors": []interface{}{
-					map[string]interface{}{
-						"matchLabels": request.WorkloadLabels,
-					},
-				},
-			}
-			downsyncRules = append([]interface{}{crdRule}, downsyncRules...)
-		} else {
-			specificCRDNames := getCRDNamesFromResources(crdAPIGroups)
-
-			if len(specificCRDNames) > 0 {
-				log.LogDebug("Adding specific CRDs to binding policy", zap.Any("specificCRDNames", specificCRDNames))
-
-				for _, crdName := range specificCRDNames {
-					log.LogDebug("Adding individual CRD rule", zap.String("crdName", crdName))
-
-					// Individual CRD rule with explicit name matching
-					crdRule := map[string]interface{}{
-						"apiGroup":  "apiextensions.k8s.io",
-						"resources": []string{"customresourcedefinitions"},
-						"objectSelectors": []interface{}{
-							map[string]interface{}{
-								"matchNames": []string{crdName},
-							},
-						},
-					}
-
-					// Add individual CRD rule (at the beginning, so CRDs are created first)
-					downsyncRules = append([]interface{}{crdRule}, downsyncRules...)
-				}
-			}
-		}
-	}
-
-	// Now add other resources
-	for _, resourceCfg := range resourceConfigs {
-		resource := resourceCfg.Type
-
-		// Skip if this is namespaces - we've already added it
-		if resource == "namespaces" {
-			continue
-		}
-
-		// Create a separate downsync rule for this resource
-		downsyncRule := map[string]interface{}{
-			"resources": []string{resource},
-			"objectSelectors": []interface{}{
-				map[string]interface{}{
-					"matchLabels": request.WorkloadLabels,
-				},
-			},
-		}
-
-		// Handle custom resources by detecting non-standard resource types
-		if !isKubernetesBuiltInResource(resource) {
-			// For CRDs, we need to specify the apiGroup
-			apiGroup := crdAPIGroups[resource]
-			downsyncRule["apiGroup"] = apiGroup
-			log.LogDebug("Adding CRD resource with apiGroup", zap.String("resource", resource), zap.String("apiGroup", apiGroup))
-		}
-
-		// Only add createOnly if it's true
-		if resourceCfg.CreateOnly {
-			downsyncRule["createOnly"] = true
-		}
-
-		// Add namespaces to the rule
-		if len(namespacesToSync) > 0 {
-			downsyncRule["namespaces"] = namespacesToSync
-		}
-
-		// Add this downsync rule
-		downsyncRules = append(downsyncRules, downsyncRule)
-	}
-
-	// Set the downsync rules in the policy
-	policyObj["spec"].(map[string]interface{})["downsync"] = downsyncRules
+	policyName, namespace := quick.Name, quick.Namespace
+	resourceConfigs, podsDetected := quick.Resources, quick.PodsDetected
 
 	// Generate YAML for the policy object
-	yamlData, err := yaml.Marshal(policyObj)
+	yamlData, err := yaml.Marshal(quick.Policy)
 	if err != nil {
 		log.LogError("YAML marshaling error", zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate YAML: %s", err.Error())})
@@ -1682,10 +1104,10 @@ func CreateQuickBindingPolicy(ctx *gin.Context) {
 		Namespace: namespace,
 		RawYAML:   rawYAML,
 	}
-	uiCreatedPolicies[policyName] = storedBP
+	storePolicy(storedBP)
 
 	// Get client and create the binding policy
-	c, err := getClientForBp()
+	c, err := getClientForRequest(ctx)
 	if err != nil {
 		log.LogError("Client creation error", zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create client: %s", err.Error())})
@@ -1693,7 +1115,7 @@ func CreateQuickBindingPolicy(ctx *gin.Context) {
 	}
 
 	// Create the binding policy
-	_, err = c.BindingPolicies().Create(context.TODO(), newBP, v1.CreateOptions{})
+	created, err := c.BindingPolicies().Create(context.TODO(), newBP, v1.CreateOptions{})
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
 			ctx.JSON(http.StatusConflict, gin.H{
@@ -1749,6 +1171,7 @@ func CreateQuickBindingPolicy(ctx *gin.Context) {
 	if podsDetected {
 		response["warning"] = "Pods were excluded from the binding policy as they should be managed through higher-level controllers"
 	}
+	response["warnings"] = bindingPolicyWarnings(ctx, created)
 
 	ctx.JSON(http.StatusOK, response)
 }
@@ -1766,25 +1189,6 @@ func getFirstMapEntry(m map[string]string) (string, string) {
 func GenerateQuickBindingPolicyYAML(ctx *gin.Context) {
 	log.LogInfo("Starting GenerateQuickBindingPolicyYAML handler")
 
-	// Define a struct to parse the request - same as CreateQuickBindingPolicy
-	type ResourceConfig struct {
-		Type       string `json:"type"`       // Resource type (e.g., "deployments", "namespaces")
-		CreateOnly bool   `json:"createOnly"` // Whether to use createOnly mode for this resource
-		APIGroup   string `json:"apiGroup"`   // Optional API group for the resource (for CRDs)
-	}
-
-	type QuickBindingPolicyRequest struct {
-		WorkloadLabels   map[string]string `json:"workloadLabels"`   // Labels to select workloads
-		ClusterLabels    map[string]string `json:"clusterLabels"`    // Labels to select clusters
-		Resources        []ResourceConfig  `json:"resources"`        // Resources with their configurations
-		NamespacesToSync []string          `json:"namespacesToSync"` // Namespaces to sync resources from
-		PolicyName       string            `json:"policyName"`       // Optional custom name for the policy
-		Namespace        string            `json:"namespace"`        // Optional namespace
-		// For backward compatibility
-		ResourceTypes []string `json:"resourceTypes"` // Legacy: Resource types to sync
-		CreateOnly    bool     `json:"createOnly"`    // Legacy: Whether to use createOnly mode for all resources
-	}
-
 	var request QuickBindingPolicyRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		log.LogError("JSON binding error", zap.Error(err))
@@ -1794,248 +1198,21 @@ func GenerateQuickBindingPolicyYAML(ctx *gin.Context) {
 
 	log.LogError("Receiced request", zap.Any("request", request))
 
-	// Validate required fields
-	if len(request.WorkloadLabels) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "workloadLabels are required"})
-		return
-	}
-
-	if len(request.ClusterLabels) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "clusterLabels are required"})
+	if request.Template != "" {
+		instantiateTemplate(ctx, request.Template, request.templateInstance(true))
 		return
 	}
 
-	// Handle both new and legacy resource specifications
-	resourceConfigs := request.Resources
-	if len(resourceConfigs) == 0 && len(request.ResourceTypes) > 0 {
-		// Convert legacy format to new format
-		for _, resType := range request.ResourceTypes {
-			// Skip pods in legacy format
-			if strings.ToLower(resType) == "pods" {
-				continue
-			}
-			resourceConfigs = append(resourceConfigs, ResourceConfig{
-				Type:       resType,
-				CreateOnly: request.CreateOnly,
-			})
-		}
-	}
-
-	// Filter out pods from resources instead of rejecting entire request
-	filteredResources := []ResourceConfig{}
-	podsDetected := false
-
-	for _, resourceCfg := range resourceConfigs {
-		if strings.ToLower(resourceCfg.Type) == "pods" {
-			podsDetected = true
-			continue
-		}
-		filteredResources = append(filteredResources, resourceCfg)
-	}
-
-	resourceConfigs = filteredResources
-
-	if len(resourceConfigs) == 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one valid resource type is required (pods are not allowed)"})
+	quick, err := buildQuickBindingPolicy(request, "kubestellar-ui-yaml-generator")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Set default namespace if not provided
-	namespace := "default"
-	if request.Namespace != "" {
-		namespace = request.Namespace
-	}
-
-	// Generate a policy name if not provided
-	policyName := request.PolicyName
-	if policyName == "" {
-		// Create a name based on the first workload label and first cluster label
-		firstWorkloadKey, firstWorkloadValue := getFirstMapEntry(request.WorkloadLabels)
-		firstClusterKey, firstClusterValue := getFirstMapEntry(request.ClusterLabels)
-
-		policyName = fmt.Sprintf("%s-%s-to-%s-%s", firstWorkloadKey, firstWorkloadValue,
-			firstClusterKey, firstClusterValue)
-
-		// Clean up the name to be valid for Kubernetes
-		policyName = strings.ReplaceAll(policyName, "/", "-")
-		policyName = strings.ReplaceAll(policyName, ":", "-")
-		policyName = strings.ReplaceAll(policyName, ".", "-")
-		policyName = strings.ToLower(policyName)
-	}
-
-	// Create a policy as a generic map that we'll convert to YAML
-	policyObj := map[string]interface{}{
-		"apiVersion": "control.kubestellar.io/v1alpha1",
-		"kind":       "BindingPolicy",
-		"metadata": map[string]interface{}{
-			"name":      policyName,
-			"namespace": namespace,
-			"annotations": map[string]string{
-				"created-by":         "kubestellar-ui-yaml-generator",
-				"creation-timestamp": time.Now().Format(time.RFC3339),
-			},
-		},
-		"spec": map[string]interface{}{
-			"clusterSelectors": []interface{}{
-				map[string]interface{}{
-					"matchLabels": request.ClusterLabels,
-				},
-			},
-			"downsync": []interface{}{},
-		},
-	}
-
-	// Determine namespaces to sync
-	namespacesToSync := request.NamespacesToSync
-	if len(namespacesToSync) == 0 {
-		namespacesToSync = []string{namespace}
-	}
-
-	// Always add a namespaces sync rule first (without createOnly)
-	namespaceRule := map[string]interface{}{
-		"resources": []string{"namespaces"},
-		"objectSelectors": []interface{}{
-			map[string]interface{}{
-				"matchLabels": request.WorkloadLabels,
-			},
-		},
-	}
-
-	downsyncRules := []interface{}{namespaceRule}
-
-	// Track if we have CRDs to add
-	hasCRDs := false
-	crdAPIGroups := make(map[string]string)
-
-	// First check for custom resources that will need CRDs
-	for _, resourceCfg := range resourceConfigs {
-		resource := resourceCfg.Type
-
-		if isKubernetesBuiltInResource(resource) {
-			continue
-		}
-
-		apiGroup := ""
-		// Check if original request has apiGroup in the matching resource
-		for _, origRes := range request.Resources {
-			if origRes.Type == resource && origRes.APIGroup != "" {
-				apiGroup = origRes.APIGroup
-				break
-			}
-		}
-
-		if apiGroup == "" {
-			singular := strings.TrimSuffix(resource, "s")
-			if strings.HasSuffix(resource, "cds") || strings.HasSuffix(resource, "eds") {
-				rootName := singular[:len(singular)-1]
-				apiGroup = fmt.Sprintf("%s.io", rootName)
-			} else {
-				apiGroup = fmt.Sprintf("%s.k8s.io", singular)
-			}
-		}
-
-		crdAPIGroups[resource] = apiGroup
-		hasCRDs = true
-	}
-
-	// If we have custom resources, add rule(s) for CustomResourceDefinitions
-	if hasCRDs {
-		log.LogDebug("Adding CustomResourceDefinitions to binding policy")
-
-		// Check if customresourcedefinitions is already in the resources list
-		hasExplicitCRDResource := false
-		for _, res := range resourceConfigs {
-			if res.Type == "customresourcedefinitions" {
-				hasExplicitCRDResource = true
-				break
-			}
-		}
-
-		if hasExplicitCRDResource {
-			log.LogDebug("User explicitly specified CustomResourceDefinitions resource, using workload labels")
-
-			crdRule := map[string]interface{}{
-				"apiGroup":  "apiextensions.k8s.io",
-				"resources": []string{"customresourcedefinitions"},
-				"objectSelectors": []interface{}{
-					map[string]interface{}{
-						"matchLabels": request.WorkloadLabels,
-					},
-				},
-			}
-			downsyncRules = append([]interface{}{crdRule}, downsyncRules...)
-		} else {
-			specificCRDNames := getCRDNamesFromResources(crdAPIGroups)
-
-			if len(specificCRDNames) > 0 {
-				log.LogDebug("Adding specific CRDs to binding policy", zap.Any("specificCRDNames", specificCRDNames))
-
-				for _, crdName := range specificCRDNames {
-					log.LogDebug("Adding individual CRD rule", zap.String("crdName", crdName))
-
-					// Individual CRD rule with explicit name matching
-					crdRule := map[string]interface{}{
-						"apiGroup":  "apiextensions.k8s.io",
-						"resources": []string{"customresourcedefinitions"},
-						"objectSelectors": []interface{}{
-							map[string]interface{}{
-								"matchNames": []string{crdName},
-							},
-						},
-					}
-
-					// Add individual CRD rule (at the beginning, so CRDs are created first)
-					downsyncRules = append([]interface{}{crdRule}, downsyncRules...)
-				}
-			}
-		}
-	}
-
-	// Now add other resources
-	for _, resourceCfg := range resourceConfigs {
-		resource := resourceCfg.Type
-
-		// Skip if this is namespaces - we've already added it
-		if resource == "namespaces" {
-			continue
-		}
-
-		// Create a separate downsync rule for this resource
-		downsyncRule := map[string]interface{}{
-			"resources": []string{resource},
-			"objectSelectors": []interface{}{
-				map[string]interface{}{
-					"matchLabels": request.WorkloadLabels,
-				},
-			},
-		}
-
-		if !isKubernetesBuiltInResource(resource) {
-			// For CRDs, we need to specify the apiGroup
-			apiGroup := crdAPIGroups[resource]
-			downsyncRule["apiGroup"] = apiGroup
-			log.LogDebug("Adding CRD resource with apiGroup", zap.String("resource", resource), zap.String("apiGroup", apiGroup))
-		}
-
-		// Only add createOnly if it's true
-		if resourceCfg.CreateOnly {
-			downsyncRule["createOnly"] = true
-		}
-
-		// Add namespaces to the rule
-		if len(namespacesToSync) > 0 {
-			downsyncRule["namespaces"] = namespacesToSync
-		}
-
-		// Add this downsync rule
-		downsyncRules = append(downsyncRules, downsyncRule)
-	}
-
-	// Set the downsync rules in the policy
-	policyObj["spec"].(map[string]interface{})["downsync"] = downsyncRules
+	policyName, namespace := quick.Name, quick.Namespace
+	resourceConfigs, podsDetected := quick.Resources, quick.PodsDetected
 
 	// Generate YAML for the policy object
-	yamlData, err := yaml.Marshal(policyObj)
+	yamlData, err := yaml.Marshal(quick.Policy)
 	if err != nil {
 		log.LogError("YAML marshaling error", zap.Error(err))
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate YAML: %s", err.Error())})
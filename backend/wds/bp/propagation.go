@@ -0,0 +1,333 @@
+package bp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/log"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	// manifestWorkGVR is the OCM resource the transport puts in the ITS namespace of each WEC
+	manifestWorkGVR = schema.GroupVersionResource{Group: "work.open-cluster-management.io", Version: "v1", Resource: "manifestworks"}
+	// workStatusGVR is the resource the status agent of each WEC reports object statuses with
+	workStatusGVR = schema.GroupVersionResource{Group: "control.kubestellar.io", Version: "v1alpha1", Resource: "workstatuses"}
+	// combinedStatusGVR is the WDS resource combining the statuses of an object across WECs
+	combinedStatusGVR = schema.GroupVersionResource{Group: "control.kubestellar.io", Version: "v1alpha1", Resource: "combinedstatuses"}
+)
+
+// Labels KubeStellar puts on the ManifestWorks of a Binding and on CombinedStatus objects
+const (
+	bindingKeyLabel               = "transport.kubestellar.io/originOwnerReferenceBindingKey"
+	combinedStatusPolicyLabel     = "status.kubestellar.io/bindingpolicy"
+	combinedStatusGroupLabel      = "status.kubestellar.io/api-group"
+	combinedStatusResourceLabel   = "status.kubestellar.io/resource"
+	combinedStatusNamespaceLabel  = "status.kubestellar.io/namespace"
+	combinedStatusObjectNameLabel = "status.kubestellar.io/name"
+)
+
+// States of PropagationRow
+const (
+	PropagationSynced  = "Synced"  // Applied on the cluster and available
+	PropagationPending = "Pending" // The cluster has not reported the object yet
+	PropagationFailed  = "Failed"  // Applying the object or making it available failed
+	PropagationMissing = "Missing" // No ManifestWork carries the Binding to the cluster
+	PropagationUnknown = "Unknown" // The ITS could not be read for the cluster
+)
+
+// PropagationRow is the state of one object of a Binding on one of its clusters
+type PropagationRow struct {
+	Group              string                 `json:"group"`
+	Version            string                 `json:"version"`
+	Resource           string                 `json:"resource"`
+	Namespace          string                 `json:"namespace,omitempty"`
+	Name               string                 `json:"name"`
+	Cluster            string                 `json:"cluster"`
+	State              string                 `json:"state"`
+	AppliedGeneration  int64                  `json:"appliedGeneration,omitempty"`  // Generation of the ManifestWork last applied on the cluster
+	ReportedGeneration int64                  `json:"reportedGeneration,omitempty"` // observedGeneration reported by the object on the cluster
+	Message            string                 `json:"message,omitempty"`
+	Status             map[string]interface{} `json:"status,omitempty"` // Status of the object on the cluster, from its WorkStatus
+}
+
+// Key identifies the object like SimulationObject.Key
+func (r PropagationRow) Key() string {
+	return objectKey(r.Group, r.Resource, r.Namespace, r.Name)
+}
+
+// PropagationStatus is the state of every object of a binding policy on every cluster
+type PropagationStatus struct {
+	Policy           string                 `json:"policy"`
+	Clusters         []string               `json:"clusters"`
+	Objects          int                    `json:"objects"`
+	Summary          map[string]int         `json:"summary"` // Rows per state
+	Rows             []PropagationRow       `json:"rows"`
+	CombinedStatuses map[string]interface{} `json:"combinedStatuses,omitempty"` // CombinedStatus results per object key
+	Errors           []string               `json:"errors,omitempty"`           // Errors of the policy and its Binding
+}
+
+// GetBpPropagation reports the propagation of a binding policy per object and cluster
+func GetBpPropagation(ctx *gin.Context) {
+	name := ctx.Param("name")
+	reqCtx := ctx.Request.Context()
+
+	_, wdsClient, err := k8s.GetClientSetForRequest(reqCtx, k8s.RequestWDSContext(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to WDS", "details": err.Error()})
+		return
+	}
+	_, itsClient, err := k8s.GetClientSetForRequest(reqCtx, k8s.ITSContext())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to ITS", "details": err.Error()})
+		return
+	}
+
+	policy := &v1alpha1.BindingPolicy{}
+	if err := getTyped(reqCtx, wdsClient.Resource(bindingPolicyGVR), name, policy); err != nil {
+		if errors.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Binding policy '%s' not found", name)})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	binding := &v1alpha1.Binding{}
+	if err := getTyped(reqCtx, wdsClient.Resource(bindingGVR), name, binding); err != nil {
+		if errors.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Binding policy '%s' has no Binding yet", name)})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	propagation, err := BindingPropagation(reqCtx, policy, binding, itsClient, wdsClient)
+	if err != nil {
+		log.LogError("failed to get binding policy propagation", zap.String("name", name), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get propagation status", "details": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, propagation)
+}
+
+// BindingPropagation combines the Binding of a policy, the ManifestWorks and WorkStatuses of
+// its clusters in the ITS and the CombinedStatuses of its objects in the WDS into a row per
+// object and cluster
+func BindingPropagation(ctx context.Context, policy *v1alpha1.BindingPolicy, binding *v1alpha1.Binding, itsClient, wdsClient dynamic.Interface) (*PropagationStatus, error) {
+	objects := bindingObjects(binding)
+	result := &PropagationStatus{
+		Policy:   policy.Name,
+		Clusters: []string{},
+		Objects:  len(objects),
+		Summary:  map[string]int{},
+		Rows:     []PropagationRow{},
+		Errors:   append(append([]string{}, policy.Status.Errors...), binding.Status.Errors...),
+	}
+
+	for _, destination := range binding.Spec.Destinations {
+		cluster := destination.ClusterId
+		result.Clusters = append(result.Clusters, cluster)
+		for _, row := range clusterPropagation(ctx, itsClient, binding.Name, cluster, objects) {
+			result.Summary[row.State]++
+			result.Rows = append(result.Rows, row)
+		}
+	}
+
+	combined, err := wdsClient.Resource(combinedStatusGVR).List(ctx, metav1.ListOptions{
+		LabelSelector: combinedStatusPolicyLabel + "=" + policy.Name,
+	})
+	switch {
+	case err == nil:
+		for _, item := range combined.Items {
+			labels := item.GetLabels()
+			key := objectKey(labels[combinedStatusGroupLabel], labels[combinedStatusResourceLabel],
+				labels[combinedStatusNamespaceLabel], labels[combinedStatusObjectNameLabel])
+			if results, ok := item.Object["results"]; ok {
+				if result.CombinedStatuses == nil {
+					result.CombinedStatuses = map[string]interface{}{}
+				}
+				result.CombinedStatuses[key] = results
+			}
+		}
+	case errors.IsNotFound(err):
+		// Status combination is not installed in this WDS
+	default:
+		return nil, fmt.Errorf("failed to list combined statuses: %v", err)
+	}
+	return result, nil
+}
+
+// bindingObjects returns a row without cluster or state for every object of a Binding
+func bindingObjects(binding *v1alpha1.Binding) []PropagationRow {
+	var objects []PropagationRow
+	for _, clause := range binding.Spec.Workload.ClusterScope {
+		objects = append(objects, PropagationRow{
+			Group:    clause.Group,
+			Version:  clause.Version,
+			Resource: clause.Resource,
+			Name:     clause.Name,
+		})
+	}
+	for _, clause := range binding.Spec.Workload.NamespaceScope {
+		objects = append(objects, PropagationRow{
+			Group:     clause.Group,
+			Version:   clause.Version,
+			Resource:  clause.Resource,
+			Namespace: clause.Namespace,
+			Name:      clause.Name,
+		})
+	}
+	return objects
+}
+
+// clusterPropagation returns the state of each object on a cluster from the ManifestWork of the
+// Binding and the WorkStatuses in the ITS namespace of the cluster
+func clusterPropagation(ctx context.Context, itsClient dynamic.Interface, bindingName, cluster string, objects []PropagationRow) []PropagationRow {
+	rows := make([]PropagationRow, len(objects))
+	for i, object := range objects {
+		object.Cluster = cluster
+		rows[i] = object
+	}
+
+	works, err := itsClient.Resource(manifestWorkGVR).Namespace(cluster).List(ctx, metav1.ListOptions{
+		LabelSelector: bindingKeyLabel + "=" + bindingName,
+	})
+	if err != nil {
+		for i := range rows {
+			rows[i].State = PropagationUnknown
+			rows[i].Message = fmt.Sprintf("failed to list ManifestWorks of cluster %s: %v", cluster, err)
+		}
+		return rows
+	}
+	if len(works.Items) == 0 {
+		for i := range rows {
+			rows[i].State = PropagationMissing
+			rows[i].Message = fmt.Sprintf("no ManifestWork of Binding %s in namespace %s of the ITS", bindingName, cluster)
+		}
+		return rows
+	}
+
+	// Manifest conditions per object, with the generation of the work applying them
+	type manifestState struct {
+		conditions []interface{}
+		generation int64
+	}
+	manifests := map[string]manifestState{}
+	for _, work := range works.Items {
+		var workGeneration int64
+		workConditions, _, _ := unstructured.NestedSlice(work.Object, "status", "conditions")
+		if applied := findCondition(workConditions, "Applied"); applied != nil {
+			workGeneration, _, _ = unstructured.NestedInt64(applied, "observedGeneration")
+		}
+		statuses, _, _ := unstructured.NestedSlice(work.Object, "status", "resourceStatus", "manifests")
+		for _, status := range statuses {
+			manifest, ok := status.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			meta, _, _ := unstructured.NestedStringMap(manifest, "resourceMeta")
+			conditions, _, _ := unstructured.NestedSlice(manifest, "conditions")
+			manifests[objectKey(meta["group"], meta["resource"], meta["namespace"], meta["name"])] = manifestState{conditions, workGeneration}
+		}
+	}
+
+	// WorkStatuses are optional: they only exist for objects whose status is collected
+	reported := map[string]map[string]interface{}{}
+	workStatuses, err := itsClient.Resource(workStatusGVR).Namespace(cluster).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.LogWarn("failed to list work statuses", zap.String("cluster", cluster), zap.Error(err))
+	} else {
+		for _, workStatus := range workStatuses.Items {
+			ref, _, _ := unstructured.NestedStringMap(workStatus.Object, "spec", "sourceRef")
+			if status, ok, _ := unstructured.NestedMap(workStatus.Object, "status"); ok {
+				reported[objectKey(ref["group"], ref["resource"], ref["namespace"], ref["name"])] = status
+			}
+		}
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if status, ok := reported[row.Key()]; ok {
+			row.Status = status
+			row.ReportedGeneration, _, _ = unstructured.NestedInt64(status, "observedGeneration")
+		}
+
+		manifest, ok := manifests[row.Key()]
+		if !ok {
+			row.State = PropagationPending
+			row.Message = "the cluster has not reported the object yet"
+			continue
+		}
+		applied := findCondition(manifest.conditions, "Applied")
+		available := findCondition(manifest.conditions, "Available")
+		row.AppliedGeneration = manifest.generation
+		if applied != nil {
+			if generation, ok, _ := unstructured.NestedInt64(applied, "observedGeneration"); ok && generation > 0 {
+				row.AppliedGeneration = generation
+			}
+		}
+		switch {
+		case applied == nil:
+			row.State = PropagationPending
+			row.Message = "the object has not been applied yet"
+		case applied["status"] != string(metav1.ConditionTrue):
+			row.State = PropagationFailed
+			row.Message = conditionMessage(applied)
+		case available != nil && available["status"] == string(metav1.ConditionFalse):
+			row.State = PropagationFailed
+			row.Message = conditionMessage(available)
+		default:
+			row.State = PropagationSynced
+		}
+	}
+	return rows
+}
+
+// getTyped gets a cluster-scoped object with a dynamic client and converts it
+func getTyped(ctx context.Context, resource dynamic.NamespaceableResourceInterface, name string, into interface{}) error {
+	obj, err := resource.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), into); err != nil {
+		return fmt.Errorf("failed to convert %s: %v", name, err)
+	}
+	return nil
+}
+
+// findCondition returns the condition of the given type from an unstructured condition list
+func findCondition(conditions []interface{}, conditionType string) map[string]interface{} {
+	for _, c := range conditions {
+		if condition, ok := c.(map[string]interface{}); ok && condition["type"] == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+// conditionMessage describes a failed condition by its reason and message
+func conditionMessage(condition map[string]interface{}) string {
+	reason, _ := condition["reason"].(string)
+	message, _ := condition["message"].(string)
+	if reason != "" && message != "" {
+		return reason + ": " + message
+	}
+	return reason + message
+}
+
+// objectKey identifies an object by group, resource, namespace and name
+func objectKey(group, resource, namespace, name string) string {
+	return strings.Join([]string{group, resource, namespace, name}, "/")
+}
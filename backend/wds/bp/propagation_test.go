@@ -0,0 +1,228 @@
+package bp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func testBinding() *v1alpha1.Binding {
+	binding := &v1alpha1.Binding{}
+	binding.Name = "web-policy"
+	binding.Spec.Workload.ClusterScope = []v1alpha1.ClusterScopeDownsyncClause{{
+		ClusterScopeDownsyncObject: v1alpha1.ClusterScopeDownsyncObject{
+			GroupVersionResource: metav1.GroupVersionResource{Version: "v1", Resource: "namespaces"}, Name: "demo",
+		},
+	}}
+	for _, object := range []struct{ group, resource, name string }{
+		{"apps", "deployments", "web"},
+		{"apps", "deployments", "broken"},
+		{"", "configmaps", "rejected"},
+		{"", "configmaps", "new"},
+	} {
+		binding.Spec.Workload.NamespaceScope = append(binding.Spec.Workload.NamespaceScope, v1alpha1.NamespaceScopeDownsyncClause{
+			NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{
+				GroupVersionResource: metav1.GroupVersionResource{Group: object.group, Version: "v1", Resource: object.resource},
+				Namespace:            "demo",
+				Name:                 object.name,
+			},
+		})
+	}
+	binding.Spec.Destinations = []v1alpha1.Destination{{ClusterId: "cluster1"}, {ClusterId: "cluster2"}, {ClusterId: "cluster3"}}
+	binding.Status.Errors = []string{"binding error"}
+	return binding
+}
+
+// manifestStatus is the status of one manifest of a ManifestWork
+func manifestStatus(group, resource, namespace, name string, conditions ...interface{}) interface{} {
+	return map[string]interface{}{
+		"resourceMeta": map[string]interface{}{"group": group, "resource": resource, "namespace": namespace, "name": name},
+		"conditions":   conditions,
+	}
+}
+
+func condition(conditionType, status string, extra map[string]interface{}) interface{} {
+	c := map[string]interface{}{"type": conditionType, "status": status}
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+func testPropagationClients(t *testing.T) (its, wds *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	work := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "work.open-cluster-management.io/v1",
+		"kind":       "ManifestWork",
+		"metadata": map[string]interface{}{
+			"name": "web-policy-work", "namespace": "cluster1",
+			"labels": map[string]interface{}{bindingKeyLabel: "web-policy"},
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{condition("Applied", "True", map[string]interface{}{"observedGeneration": int64(3)})},
+			"resourceStatus": map[string]interface{}{"manifests": []interface{}{
+				manifestStatus("", "namespaces", "", "demo", condition("Applied", "True", nil), condition("Available", "True", nil)),
+				manifestStatus("apps", "deployments", "demo", "web",
+					condition("Applied", "True", map[string]interface{}{"observedGeneration": int64(4)}), condition("Available", "True", nil)),
+				manifestStatus("apps", "deployments", "demo", "broken",
+					condition("Applied", "True", nil), condition("Available", "False", map[string]interface{}{"reason": "Unavailable", "message": "0/1 ready"})),
+				manifestStatus("", "configmaps", "demo", "rejected",
+					condition("Applied", "False", map[string]interface{}{"reason": "AppliedManifestFailed"})),
+			}},
+		},
+	}}
+	otherWork := work.DeepCopy()
+	otherWork.SetName("other-policy-work")
+	otherWork.SetLabels(map[string]string{bindingKeyLabel: "other-policy"})
+	otherWork.SetNamespace("cluster2")
+	workStatus := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "WorkStatus",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "cluster1"},
+		"spec": map[string]interface{}{"sourceRef": map[string]interface{}{
+			"group": "apps", "resource": "deployments", "namespace": "demo", "name": "web",
+		}},
+		"status": map[string]interface{}{"observedGeneration": int64(4), "readyReplicas": int64(1)},
+	}}
+	its = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{manifestWorkGVR: "ManifestWorkList", workStatusGVR: "WorkStatusList"},
+		work, otherWork, workStatus)
+	its.PrependReactor("list", "manifestworks", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "cluster3" {
+			return true, nil, fmt.Errorf("connection refused")
+		}
+		return false, nil, nil
+	})
+
+	combined := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "CombinedStatus",
+		"metadata": map[string]interface{}{
+			"name": "web-status", "namespace": "demo",
+			"labels": map[string]interface{}{
+				combinedStatusPolicyLabel: "web-policy", combinedStatusGroupLabel: "apps", combinedStatusResourceLabel: "deployments",
+				combinedStatusNamespaceLabel: "demo", combinedStatusObjectNameLabel: "web",
+			},
+		},
+		"results": []interface{}{map[string]interface{}{"name": "ready", "rows": int64(2)}},
+	}}
+	wds = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{combinedStatusGVR: "CombinedStatusList"}, combined)
+	return its, wds
+}
+
+func TestBindingPropagation(t *testing.T) {
+	policy := &v1alpha1.BindingPolicy{}
+	policy.Name = "web-policy"
+	policy.Status.Errors = []string{"policy error"}
+	its, wds := testPropagationClients(t)
+
+	status, err := BindingPropagation(context.Background(), policy, testBinding(), its, wds)
+	if err != nil {
+		t.Fatalf("BindingPropagation failed: %v", err)
+	}
+	if !reflect.DeepEqual(status.Clusters, []string{"cluster1", "cluster2", "cluster3"}) || status.Objects != 5 || len(status.Rows) != 15 {
+		t.Fatalf("clusters %v, %d objects and %d rows, want 3 clusters, 5 objects and 15 rows", status.Clusters, status.Objects, len(status.Rows))
+	}
+	wantSummary := map[string]int{PropagationSynced: 2, PropagationFailed: 2, PropagationPending: 1, PropagationMissing: 5, PropagationUnknown: 5}
+	if !reflect.DeepEqual(status.Summary, wantSummary) {
+		t.Errorf("summary = %v, want %v", status.Summary, wantSummary)
+	}
+	if !reflect.DeepEqual(status.Errors, []string{"policy error", "binding error"}) {
+		t.Errorf("errors = %v", status.Errors)
+	}
+	if _, ok := status.CombinedStatuses["apps/deployments/demo/web"]; !ok || len(status.CombinedStatuses) != 1 {
+		t.Errorf("combined statuses = %v, want the web deployment", status.CombinedStatuses)
+	}
+
+	rows := map[string]PropagationRow{}
+	for _, row := range status.Rows {
+		rows[row.Cluster+" "+row.Key()] = row
+	}
+	tests := []struct {
+		row                string
+		state              string
+		appliedGeneration  int64
+		reportedGeneration int64
+		message            string
+	}{
+		{"cluster1 /namespaces//demo", PropagationSynced, 3, 0, ""},
+		{"cluster1 apps/deployments/demo/web", PropagationSynced, 4, 4, ""},
+		{"cluster1 apps/deployments/demo/broken", PropagationFailed, 3, 0, "Unavailable: 0/1 ready"},
+		{"cluster1 /configmaps/demo/rejected", PropagationFailed, 3, 0, "AppliedManifestFailed"},
+		{"cluster1 /configmaps/demo/new", PropagationPending, 0, 0, "the cluster has not reported the object yet"},
+		{"cluster2 apps/deployments/demo/web", PropagationMissing, 0, 0, "no ManifestWork of Binding web-policy in namespace cluster2 of the ITS"},
+		{"cluster3 apps/deployments/demo/web", PropagationUnknown, 0, 0, "failed to list ManifestWorks of cluster cluster3: connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.row, func(t *testing.T) {
+			row, ok := rows[tt.row]
+			if !ok {
+				t.Fatalf("no row %s", tt.row)
+			}
+			if row.State != tt.state || row.AppliedGeneration != tt.appliedGeneration || row.ReportedGeneration != tt.reportedGeneration || row.Message != tt.message {
+				t.Errorf("row = %+v, want state %s, generations %d/%d and message %q",
+					row, tt.state, tt.appliedGeneration, tt.reportedGeneration, tt.message)
+			}
+		})
+	}
+	if status := rows["cluster1 apps/deployments/demo/web"].Status; status["readyReplicas"] != int64(1) {
+		t.Errorf("reported status = %v, want the WorkStatus status", status)
+	}
+}
+
+func TestBindingPropagationWithoutCombinedStatus(t *testing.T) {
+	policy := &v1alpha1.BindingPolicy{}
+	policy.Name = "web-policy"
+	its, wds := testPropagationClients(t)
+	wds.PrependReactor("list", "combinedstatuses", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(combinedStatusGVR.GroupResource(), "")
+	})
+
+	status, err := BindingPropagation(context.Background(), policy, testBinding(), its, wds)
+	if err != nil {
+		t.Fatalf("BindingPropagation failed without status combination: %v", err)
+	}
+	if status.CombinedStatuses != nil {
+		t.Errorf("combined statuses = %v, want none", status.CombinedStatuses)
+	}
+
+	wds.PrependReactor("list", "combinedstatuses", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("forbidden")
+	})
+	if _, err := BindingPropagation(context.Background(), policy, testBinding(), its, wds); err == nil {
+		t.Error("BindingPropagation ignored a failure to list combined statuses")
+	}
+}
+
+func TestConditionMessage(t *testing.T) {
+	tests := []struct {
+		condition map[string]interface{}
+		want      string
+	}{
+		{map[string]interface{}{"reason": "Failed", "message": "quota exceeded"}, "Failed: quota exceeded"},
+		{map[string]interface{}{"reason": "Failed"}, "Failed"},
+		{map[string]interface{}{"message": "quota exceeded"}, "quota exceeded"},
+		{map[string]interface{}{}, ""},
+	}
+	for _, tt := range tests {
+		if got := conditionMessage(tt.condition); got != tt.want {
+			t.Errorf("conditionMessage(%v) = %q, want %q", tt.condition, got, tt.want)
+		}
+	}
+
+	conditions := []interface{}{"not a condition", condition("Applied", "True", nil)}
+	if findCondition(conditions, "Applied") == nil || findCondition(conditions, "Available") != nil {
+		t.Error("findCondition did not find the condition by type")
+	}
+}
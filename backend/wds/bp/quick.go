@@ -0,0 +1,305 @@
+package bp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/ui/log"
+	"go.uber.org/zap"
+)
+
+// ResourceConfig is a resource type synced by a quick binding policy
+type ResourceConfig struct {
+	Type       string `json:"type"`       // Resource type (e.g., "deployments", "namespaces")
+	CreateOnly bool   `json:"createOnly"` // Whether to use createOnly mode for this resource
+	APIGroup   string `json:"apiGroup"`   // Optional API group for the resource (for CRDs)
+}
+
+// QuickBindingPolicyRequest connects workloads to clusters by their labels
+type QuickBindingPolicyRequest struct {
+	WorkloadLabels   map[string]string `json:"workloadLabels"`   // Labels to select workloads
+	ClusterLabels    map[string]string `json:"clusterLabels"`    // Labels to select clusters
+	Resources        []ResourceConfig  `json:"resources"`        // Resources with their configurations
+	NamespacesToSync []string          `json:"namespacesToSync"` // Namespaces to sync resources from
+	PolicyName       string            `json:"policyName"`       // Optional custom name for the policy
+	Namespace        string            `json:"namespace"`        // Optional namespace
+	// For backward compatibility
+	ResourceTypes []string `json:"resourceTypes"` // Legacy: Resource types to sync
+	CreateOnly    bool     `json:"createOnly"`    // Legacy: Whether to use createOnly mode for all resources
+	// Parameterized quick connection: render this binding policy template instead of the labels
+	Template        string                 `json:"template"`
+	TemplateVersion int                    `json:"templateVersion"` // Latest when 0
+	Parameters      map[string]interface{} `json:"parameters"`
+}
+
+// templateInstance returns the template instantiation a parameterized request asks for
+func (r QuickBindingPolicyRequest) templateInstance(dryRun bool) TemplateInstanceRequest {
+	return TemplateInstanceRequest{
+		PolicyName: r.PolicyName,
+		Version:    r.TemplateVersion,
+		Parameters: r.Parameters,
+		DryRun:     dryRun,
+	}
+}
+
+// quickBindingPolicy is the policy generated for a QuickBindingPolicyRequest
+type quickBindingPolicy struct {
+	Name         string
+	Namespace    string
+	Resources    []ResourceConfig // Requested resources, without pods
+	PodsDetected bool             // Pods were requested and left out
+	Policy       map[string]interface{}
+}
+
+// buildQuickBindingPolicy generates the BindingPolicy of a quick connection request; createdBy
+// is recorded in its annotations. The error describes what is wrong with the request.
+func buildQuickBindingPolicy(request QuickBindingPolicyRequest, createdBy string) (*quickBindingPolicy, error) {
+	// Validate required fields
+	if len(request.WorkloadLabels) == 0 {
+		return nil, fmt.Errorf("workloadLabels are required")
+	}
+
+	if len(request.ClusterLabels) == 0 {
+		return nil, fmt.Errorf("clusterLabels are required")
+	}
+
+	// Handle both new and legacy resource specifications
+	resourceConfigs := request.Resources
+	if len(resourceConfigs) == 0 && len(request.ResourceTypes) > 0 {
+		// Convert legacy format to new format
+		for _, resType := range request.ResourceTypes {
+			// Skip pods in legacy format
+			if strings.ToLower(resType) == "pods" {
+				continue
+			}
+			resourceConfigs = append(resourceConfigs, ResourceConfig{
+				Type:       resType,
+				CreateOnly: request.CreateOnly,
+			})
+		}
+	}
+
+	// Filter out pods from resources instead of rejecting entire request
+	filteredResources := []ResourceConfig{}
+	podsDetected := false
+
+	for _, resourceCfg := range resourceConfigs {
+		if strings.ToLower(resourceCfg.Type) == "pods" {
+			podsDetected = true
+			continue
+		}
+		filteredResources = append(filteredResources, resourceCfg)
+	}
+
+	resourceConfigs = filteredResources
+
+	if len(resourceConfigs) == 0 {
+		return nil, fmt.Errorf("at least one valid resource type is required (pods are not allowed)")
+	}
+
+	// Set default namespace if not provided
+	namespace := "default"
+	if request.Namespace != "" {
+		namespace = request.Namespace
+	}
+
+	// Generate a policy name if not provided
+	policyName := request.PolicyName
+	if policyName == "" {
+		// Create a name based on the first workload label and first cluster label
+		firstWorkloadKey, firstWorkloadValue := getFirstMapEntry(request.WorkloadLabels)
+		firstClusterKey, firstClusterValue := getFirstMapEntry(request.ClusterLabels)
+
+		policyName = fmt.Sprintf("%s-%s-to-%s-%s", firstWorkloadKey, firstWorkloadValue,
+			firstClusterKey, firstClusterValue)
+
+		// Clean up the name to be valid for Kubernetes
+		policyName = strings.ReplaceAll(policyName, "/", "-")
+		policyName = strings.ReplaceAll(policyName, ":", "-")
+		policyName = strings.ReplaceAll(policyName, ".", "-")
+		policyName = strings.ToLower(policyName)
+	}
+
+	// Create a policy as a generic map that we'll convert to YAML
+	policyObj := map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "BindingPolicy",
+		"metadata": map[string]interface{}{
+			"name":      policyName,
+			"namespace": namespace,
+			"annotations": map[string]string{
+				"created-by":         createdBy,
+				"creation-timestamp": time.Now().Format(time.RFC3339),
+			},
+		},
+		"spec": map[string]interface{}{
+			"clusterSelectors": []interface{}{
+				map[string]interface{}{
+					"matchLabels": request.ClusterLabels,
+				},
+			},
+			"downsync": []interface{}{},
+		},
+	}
+
+	// Determine namespaces to sync
+	namespacesToSync := request.NamespacesToSync
+	if len(namespacesToSync) == 0 {
+		namespacesToSync = []string{namespace}
+	}
+
+	// Always add a namespaces sync rule first (without createOnly)
+	namespaceRule := map[string]interface{}{
+		"resources": []string{"namespaces"},
+		"objectSelectors": []interface{}{
+			map[string]interface{}{
+				"matchLabels": request.WorkloadLabels,
+			},
+		},
+	}
+
+	downsyncRules := []interface{}{namespaceRule}
+
+	// Track if we have CRDs to add
+	hasCRDs := false
+	crdAPIGroups := make(map[string]string)
+
+	// First check for custom resources that will need CRDs
+	for _, resourceCfg := range resourceConfigs {
+		resource := resourceCfg.Type
+
+		if isKubernetesBuiltInResource(resource) {
+			continue
+		}
+
+		// Found a custom resource, track its API group for CRD handling
+		apiGroup := ""
+		// Check if original request has apiGroup in the matching resource
+		for _, origRes := range request.Resources {
+			if origRes.Type == resource && origRes.APIGroup != "" {
+				apiGroup = origRes.APIGroup
+				break
+			}
+		}
+
+		// If no explicit apiGroup provided, use a heuristic to determine it
+		if apiGroup == "" {
+			singular := strings.TrimSuffix(resource, "s")
+
+			if strings.HasSuffix(resource, "cds") || strings.HasSuffix(resource, "eds") {
+				rootName := singular[:len(singular)-1]
+				apiGroup = fmt.Sprintf("%s.io", rootName)
+			} else {
+				apiGroup = fmt.Sprintf("%s.k8s.io", singular)
+			}
+		}
+
+		crdAPIGroups[resource] = apiGroup
+		hasCRDs = true
+	}
+
+	// If we have custom resources, add rule(s) for CustomResourceDefinitions
+	if hasCRDs {
+		log.LogInfo("Adding CustomResourceDefinitions to binding policy")
+
+		// Check if customresourcedefinitions is already in the resources list
+		hasExplicitCRDResource := false
+		for _, res := range resourceConfigs {
+			if res.Type == "customresourcedefinitions" {
+				hasExplicitCRDResource = true
+				break
+			}
+		}
+
+		if hasExplicitCRDResource {
+			log.LogInfo("User explicitly specified CustomResourceDefinitions resource, using workload labels")
+			crdRule := map[string]interface{}{
+				"apiGroup":  "apiextensions.k8s.io",
+				"resources": []string{"customresourcedefinitions"},
+				"objectSelectors": []interface{}{
+					map[string]interface{}{
+						"matchLabels": request.WorkloadLabels,
+					},
+				},
+			}
+			downsyncRules = append([]interface{}{crdRule}, downsyncRules...)
+		} else {
+			specificCRDNames := getCRDNamesFromResources(crdAPIGroups)
+
+			if len(specificCRDNames) > 0 {
+				log.LogDebug("Adding specific CRDs to binding policy", zap.Any("specificCRDNames", specificCRDNames))
+
+				for _, crdName := range specificCRDNames {
+					log.LogDebug("Adding individual CRD rule", zap.String("crdName", crdName))
+
+					// Individual CRD rule with explicit name matching
+					crdRule := map[string]interface{}{
+						"apiGroup":  "apiextensions.k8s.io",
+						"resources": []string{"customresourcedefinitions"},
+						"objectSelectors": []interface{}{
+							map[string]interface{}{
+								"matchNames": []string{crdName},
+							},
+						},
+					}
+
+					// Add individual CRD rule (at the beginning, so CRDs are created first)
+					downsyncRules = append([]interface{}{crdRule}, downsyncRules...)
+				}
+			}
+		}
+	}
+
+	// Now add other resources
+	for _, resourceCfg := range resourceConfigs {
+		resource := resourceCfg.Type
+
+		// Skip if this is namespaces - we've already added it
+		if resource == "namespaces" {
+			continue
+		}
+
+		// Create a separate downsync rule for this resource
+		downsyncRule := map[string]interface{}{
+			"resources": []string{resource},
+			"objectSelectors": []interface{}{
+				map[string]interface{}{
+					"matchLabels": request.WorkloadLabels,
+				},
+			},
+		}
+
+		// Handle custom resources by detecting non-standard resource types
+		if !isKubernetesBuiltInResource(resource) {
+			// For CRDs, we need to specify the apiGroup
+			apiGroup := crdAPIGroups[resource]
+			downsyncRule["apiGroup"] = apiGroup
+			log.LogDebug("Adding CRD resource with apiGroup", zap.String("resource", resource), zap.String("apiGroup", apiGroup))
+		}
+
+		// Only add createOnly if it's true
+		if resourceCfg.CreateOnly {
+			downsyncRule["createOnly"] = true
+		}
+
+		// Add namespaces to the rule
+		if len(namespacesToSync) > 0 {
+			downsyncRule["namespaces"] = namespacesToSync
+		}
+
+		// Add this downsync rule
+		downsyncRules = append(downsyncRules, downsyncRule)
+	}
+
+	// Set the downsync rules in the policy
+	policyObj["spec"].(map[string]interface{})["downsync"] = downsyncRules
+
+	return &quickBindingPolicy{
+		Name:         policyName,
+		Namespace:    namespace,
+		Resources:    resourceConfigs,
+		PodsDetected: podsDetected,
+		Policy:       policyObj,
+	}, nil
+}
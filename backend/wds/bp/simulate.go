@@ -0,0 +1,418 @@
+package bp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/log"
+	"github.com/kubestellar/ui/utils"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// managedClusterGVR is the OCM resource of the clusters registered with the ITS
+var managedClusterGVR = schema.GroupVersionResource{
+	Group:    "cluster.open-cluster-management.io",
+	Version:  "v1",
+	Resource: "managedclusters",
+}
+
+// SimulationCluster is a ManagedCluster of the ITS and whether the policy selects it
+type SimulationCluster struct {
+	Name     string            `json:"name"`
+	Labels   map[string]string `json:"labels"`
+	Selected bool              `json:"selected"`
+}
+
+// SimulationObject is a WDS object the policy selects and the clusters it would be sent to
+type SimulationObject struct {
+	Group            string   `json:"group"`
+	Version          string   `json:"version"`
+	Resource         string   `json:"resource"`
+	Kind             string   `json:"kind"`
+	Namespace        string   `json:"namespace,omitempty"`
+	Name             string   `json:"name"`
+	CreateOnly       bool     `json:"createOnly"`
+	StatusCollectors []string `json:"statusCollectors,omitempty"`
+	Clusters         []string `json:"clusters"`
+}
+
+// Key identifies the object by group, resource, namespace and name
+func (o SimulationObject) Key() string {
+	return objectKey(o.Group, o.Resource, o.Namespace, o.Name)
+}
+
+// SimulationResult is the effect a BindingPolicy would have: every selected object is sent to
+// every selected cluster
+type SimulationResult struct {
+	Policy   string              `json:"policy"`
+	Clusters []SimulationCluster `json:"clusters"`
+	Objects  []SimulationObject  `json:"objects"`
+	Bindings int                 `json:"bindings"` // Object × cluster pairs
+	Warnings []string            `json:"warnings,omitempty"`
+}
+
+// SelectedClusters returns the names of the clusters the policy selects
+func (r *SimulationResult) SelectedClusters() []string {
+	selected := []string{}
+	for _, cluster := range r.Clusters {
+		if cluster.Selected {
+			selected = append(selected, cluster.Name)
+		}
+	}
+	return selected
+}
+
+// SimulateBp evaluates a draft BindingPolicy, given as YAML like CreateBp or as JSON like
+// CreateQuickBindingPolicy, against the live clusters and WDS objects without creating it
+func SimulateBp(ctx *gin.Context) {
+	bp, err := draftBpFromRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := SimulateBindingPolicy(ctx.Request.Context(), k8s.RequestWDSContext(ctx), bp)
+	if err != nil {
+		log.LogError("failed to simulate binding policy", zap.String("name", bp.Name), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate binding policy", "details": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// draftBpFromRequest reads a policy from a YAML body, a bpYaml form file or a quick
+// connection request
+func draftBpFromRequest(ctx *gin.Context) (*v1alpha1.BindingPolicy, error) {
+	contentType := ctx.ContentType()
+	switch contentType {
+	case "application/json":
+		var request QuickBindingPolicyRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			return nil, fmt.Errorf("invalid JSON format: %s", err.Error())
+		}
+		quick, err := buildQuickBindingPolicy(request, "kubestellar-ui-simulation")
+		if err != nil {
+			return nil, err
+		}
+		yamlData, err := yaml.Marshal(quick.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate YAML: %s", err.Error())
+		}
+		return getBpObjFromYaml(yamlData)
+	case "application/yaml":
+		bpRawYamlBytes, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading yaml input: %v", err)
+		}
+		return getBpObjFromYaml(bpRawYamlBytes)
+	case "multipart/form-data":
+		bpRawYamlBytes, err := utils.GetFormFileBytes("bpYaml", ctx)
+		if err != nil {
+			return nil, err
+		}
+		return getBpObjFromYaml(bpRawYamlBytes)
+	default:
+		return nil, fmt.Errorf("content-type not supported")
+	}
+}
+
+// SimulateBindingPolicy matches the policy's cluster selectors against the ManagedCluster labels
+// of the ITS and its downsync clauses against the objects of a WDS, as the user of ctx
+func SimulateBindingPolicy(ctx context.Context, wdsContext string, bp *v1alpha1.BindingPolicy) (*SimulationResult, error) {
+	e, err := newPolicyEvaluator(ctx, wdsContext)
+	if err != nil {
+		return nil, err
+	}
+	return e.evaluate(bp)
+}
+
+// policyEvaluator matches policies against the clusters of the ITS and the objects of the WDS,
+// listing each resource once so that many policies can be evaluated together
+type policyEvaluator struct {
+	ctx             context.Context
+	client          dynamic.Interface
+	clusters        []unstructured.Unstructured
+	resourceLists   []*metav1.APIResourceList
+	namespaceLabels map[string]map[string]string
+	objects         map[schema.GroupVersionResource][]unstructured.Unstructured
+	listErrors      map[schema.GroupVersionResource]error
+}
+
+// newPolicyEvaluator connects to the ITS and a WDS as the user of ctx
+func newPolicyEvaluator(ctx context.Context, wdsContext string) (*policyEvaluator, error) {
+	_, itsClient, err := k8s.GetClientSetForRequest(ctx, k8s.ITSContext())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ITS: %v", err)
+	}
+	wdsClientset, wdsClient, err := k8s.GetClientSetForRequest(ctx, wdsContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to WDS: %v", err)
+	}
+	return loadPolicyEvaluator(ctx, itsClient, wdsClientset.Discovery(), wdsClient)
+}
+
+// loadPolicyEvaluator lists the managed clusters, the WDS resource types and namespaces
+func loadPolicyEvaluator(ctx context.Context, itsClient dynamic.Interface, disco discovery.DiscoveryInterface, wdsClient dynamic.Interface) (*policyEvaluator, error) {
+	clusters, err := itsClient.Resource(managedClusterGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list managed clusters: %v", err)
+	}
+
+	resourceLists, err := discovery.ServerPreferredResources(disco)
+	if err != nil && len(resourceLists) == 0 {
+		return nil, fmt.Errorf("failed to discover WDS resources: %v", err)
+	}
+
+	namespaces, err := wdsClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	namespaceLabels := map[string]map[string]string{}
+	for _, ns := range namespaces.Items {
+		namespaceLabels[ns.GetName()] = ns.GetLabels()
+	}
+
+	return &policyEvaluator{
+		ctx:             ctx,
+		client:          wdsClient,
+		clusters:        clusters.Items,
+		resourceLists:   resourceLists,
+		namespaceLabels: namespaceLabels,
+		objects:         map[schema.GroupVersionResource][]unstructured.Unstructured{},
+		listErrors:      map[schema.GroupVersionResource]error{},
+	}, nil
+}
+
+// evaluate returns the clusters and objects a policy selects
+func (e *policyEvaluator) evaluate(bp *v1alpha1.BindingPolicy) (*SimulationResult, error) {
+	result := &SimulationResult{Policy: bp.Name, Clusters: []SimulationCluster{}, Objects: []SimulationObject{}}
+
+	selectors, err := labelSelectors(bp.Spec.ClusterSelectors)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector: %v", err)
+	}
+	for _, cluster := range e.clusters {
+		result.Clusters = append(result.Clusters, SimulationCluster{
+			Name:     cluster.GetName(),
+			Labels:   cluster.GetLabels(),
+			Selected: matchesAny(selectors, cluster.GetLabels()),
+		})
+	}
+	sort.Slice(result.Clusters, func(i, j int) bool { return result.Clusters[i].Name < result.Clusters[j].Name })
+	selected := result.SelectedClusters()
+	if len(bp.Spec.ClusterSelectors) == 0 {
+		result.Warnings = append(result.Warnings, "the policy has no cluster selectors and selects no clusters")
+	} else if len(selected) == 0 {
+		result.Warnings = append(result.Warnings, "no managed cluster matches the cluster selectors")
+	}
+
+	objects, warnings, err := e.selectDownsyncObjects(bp.Spec.Downsync)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	if len(bp.Spec.Downsync) > 0 && len(objects) == 0 {
+		result.Warnings = append(result.Warnings, "no WDS object matches the downsync rules")
+	}
+
+	for _, object := range objects {
+		object.Clusters = selected
+		result.Objects = append(result.Objects, object)
+	}
+	result.Bindings = len(result.Objects) * len(selected)
+	return result, nil
+}
+
+// list returns the WDS objects of a resource, listing it on first use
+func (e *policyEvaluator) list(gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	if items, ok := e.objects[gvr]; ok {
+		return items, nil
+	}
+	if err, ok := e.listErrors[gvr]; ok {
+		return nil, err
+	}
+	items, err := e.client.Resource(gvr).List(e.ctx, metav1.ListOptions{})
+	if err != nil {
+		e.listErrors[gvr] = err
+		return nil, err
+	}
+	e.objects[gvr] = items.Items
+	return items.Items, nil
+}
+
+// selectDownsyncObjects lists the WDS objects matched by any downsync clause. An object matched
+// by several clauses is returned once, createOnly when any of its clauses is and with the
+// status collectors of all of them.
+func (e *policyEvaluator) selectDownsyncObjects(clauses []v1alpha1.DownsyncPolicyClause) ([]SimulationObject, []string, error) {
+	var warnings []string
+	if len(clauses) == 0 {
+		return nil, []string{"the policy has no downsync rules and selects no objects"}, nil
+	}
+
+	found := map[string]*SimulationObject{}
+	for i, clause := range clauses {
+		objectSelectors, err := labelSelectors(clause.ObjectSelectors)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid object selector in downsync rule %d: %v", i+1, err)
+		}
+		namespaceSelectors, err := labelSelectors(clause.NamespaceSelectors)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid namespace selector in downsync rule %d: %v", i+1, err)
+		}
+
+		matchedResources := map[string]bool{}
+		for _, list := range e.resourceLists {
+			gv, err := schema.ParseGroupVersion(list.GroupVersion)
+			if err != nil {
+				continue
+			}
+			if clause.APIGroup != nil && *clause.APIGroup != gv.Group {
+				continue
+			}
+			for _, resource := range list.APIResources {
+				if strings.Contains(resource.Name, "/") || !containsVerb(resource.Verbs, "list") || !matchesName(clause.Resources, resource.Name) {
+					continue
+				}
+				matchedResources[resource.Name] = true
+
+				gvr := gv.WithResource(resource.Name)
+				items, err := e.list(gvr)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("cannot list %s: %v", gvr.GroupResource(), err))
+					continue
+				}
+				for i := range items {
+					item := &items[i]
+					if !objectMatches(clause.DownsyncObjectTest, objectSelectors, namespaceSelectors, e.namespaceLabels, resource.Namespaced, item) {
+						continue
+					}
+					key := strings.Join([]string{gvr.Group, gvr.Resource, item.GetNamespace(), item.GetName()}, "/")
+					if existing, ok := found[key]; ok {
+						existing.CreateOnly = existing.CreateOnly || clause.CreateOnly
+						existing.StatusCollectors = mergeNames(existing.StatusCollectors, clause.StatusCollectors)
+						continue
+					}
+					found[key] = &SimulationObject{
+						Group:            gvr.Group,
+						Version:          gvr.Version,
+						Resource:         gvr.Resource,
+						Kind:             resource.Kind,
+						Namespace:        item.GetNamespace(),
+						Name:             item.GetName(),
+						CreateOnly:       clause.CreateOnly,
+						StatusCollectors: mergeNames(nil, clause.StatusCollectors),
+					}
+				}
+			}
+		}
+		for _, resource := range clause.Resources {
+			if resource != "*" && !matchedResources[resource] {
+				warnings = append(warnings, fmt.Sprintf("downsync rule %d: resource %q is not served by the WDS", i+1, resource))
+			}
+		}
+	}
+
+	objects := make([]SimulationObject, 0, len(found))
+	for _, object := range found {
+		objects = append(objects, *object)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key() < objects[j].Key() })
+	return objects, warnings, nil
+}
+
+// objectMatches applies the namespace, name and label tests of a downsync clause; tests that
+// are not set match everything, and namespace tests only apply to namespaced objects
+func objectMatches(test v1alpha1.DownsyncObjectTest, objectSelectors, namespaceSelectors []labels.Selector, namespaceLabels map[string]map[string]string, namespaced bool, obj *unstructured.Unstructured) bool {
+	if namespaced {
+		if len(test.Namespaces) > 0 && !matchesName(test.Namespaces, obj.GetNamespace()) {
+			return false
+		}
+		if len(namespaceSelectors) > 0 && !matchesAny(namespaceSelectors, namespaceLabels[obj.GetNamespace()]) {
+			return false
+		}
+	}
+	if len(test.ObjectNames) > 0 && !matchesName(test.ObjectNames, obj.GetName()) {
+		return false
+	}
+	if len(objectSelectors) > 0 && !matchesAny(objectSelectors, obj.GetLabels()) {
+		return false
+	}
+	return true
+}
+
+// labelSelectors converts the selectors of a policy
+func labelSelectors(selectors []metav1.LabelSelector) ([]labels.Selector, error) {
+	converted := make([]labels.Selector, 0, len(selectors))
+	for i := range selectors {
+		selector, err := metav1.LabelSelectorAsSelector(&selectors[i])
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, selector)
+	}
+	return converted, nil
+}
+
+// matchesAny reports whether any of the selectors matches the labels
+func matchesAny(selectors []labels.Selector, objectLabels map[string]string) bool {
+	for _, selector := range selectors {
+		if selector.Matches(labels.Set(objectLabels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesName reports whether a name is in a list that may contain the "*" wildcard; an empty
+// list matches every name
+func matchesName(names []string, name string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == "*" || n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeNames returns the sorted union of two lists of names
+func mergeNames(a, b []string) []string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	set := map[string]bool{}
+	for _, name := range append(append([]string{}, a...), b...) {
+		set[name] = true
+	}
+	merged := make([]string, 0, len(set))
+	for name := range set {
+		merged = append(merged, name)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+func containsVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
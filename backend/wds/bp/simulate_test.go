@@ -0,0 +1,263 @@
+package bp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func testObject(apiVersion, kind, namespace, name string, objectLabels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetLabels(objectLabels)
+	return obj
+}
+
+func TestObjectMatches(t *testing.T) {
+	selector := func(matchLabels map[string]string) []metav1.LabelSelector {
+		return []metav1.LabelSelector{{MatchLabels: matchLabels}}
+	}
+	namespaceLabels := map[string]map[string]string{"team-a": {"team": "a"}, "team-b": {"team": "b"}}
+	web := testObject("apps/v1", "Deployment", "team-a", "web", map[string]string{"app": "web"})
+
+	tests := []struct {
+		name       string
+		test       v1alpha1.DownsyncObjectTest
+		namespaced bool
+		want       bool
+	}{
+		{"no tests", v1alpha1.DownsyncObjectTest{}, true, true},
+		{"namespace", v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-a"}}, true, true},
+		{"other namespace", v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-b"}}, true, false},
+		{"namespace wildcard", v1alpha1.DownsyncObjectTest{Namespaces: []string{"*"}}, true, true},
+		{"namespace test on cluster-scoped object", v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-b"}}, false, true},
+		{"namespace selector", v1alpha1.DownsyncObjectTest{NamespaceSelectors: selector(map[string]string{"team": "a"})}, true, true},
+		{"other namespace selector", v1alpha1.DownsyncObjectTest{NamespaceSelectors: selector(map[string]string{"team": "b"})}, true, false},
+		{"object name", v1alpha1.DownsyncObjectTest{ObjectNames: []string{"db", "web"}}, true, true},
+		{"other object name", v1alpha1.DownsyncObjectTest{ObjectNames: []string{"db"}}, true, false},
+		{"object selector", v1alpha1.DownsyncObjectTest{ObjectSelectors: selector(map[string]string{"app": "web"})}, true, true},
+		{"other object selector", v1alpha1.DownsyncObjectTest{ObjectSelectors: selector(map[string]string{"app": "db"})}, true, false},
+		{"any object selector", v1alpha1.DownsyncObjectTest{ObjectSelectors: append(selector(map[string]string{"app": "db"}), selector(map[string]string{"app": "web"})...)}, true, true},
+		{"all tests must pass", v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-a"}, ObjectNames: []string{"db"}}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objectSelectors, err := labelSelectors(tt.test.ObjectSelectors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			namespaceSelectors, err := labelSelectors(tt.test.NamespaceSelectors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := objectMatches(tt.test, objectSelectors, namespaceSelectors, namespaceLabels, tt.namespaced, web); got != tt.want {
+				t.Errorf("objectMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Near"}}}}
+	if _, err := labelSelectors(invalid); err == nil {
+		t.Error("labelSelectors accepted an unknown operator")
+	}
+}
+
+func TestMergeNames(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{"both empty", nil, nil, nil},
+		{"one empty", nil, []string{"b", "a"}, []string{"a", "b"}},
+		{"union", []string{"a", "c"}, []string{"b", "c"}, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeNames(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testEvaluator is a policy evaluator over two managed clusters and a WDS serving deployments,
+// config maps and namespaces
+func testEvaluator(t *testing.T) *policyEvaluator {
+	t.Helper()
+	its := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{managedClusterGVR: "ManagedClusterList"},
+		testObject("cluster.open-cluster-management.io/v1", "ManagedCluster", "", "cluster2", map[string]string{"location-group": "edge", "env": "prod"}),
+		testObject("cluster.open-cluster-management.io/v1", "ManagedCluster", "", "cluster1", map[string]string{"location-group": "edge", "env": "dev"}),
+	)
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	wds := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{deployments: "DeploymentList", configMaps: "ConfigMapList", namespaces: "NamespaceList"},
+		testObject("v1", "Namespace", "", "demo", map[string]string{"team": "demo"}),
+		testObject("v1", "Namespace", "", "other", nil),
+		testObject("apps/v1", "Deployment", "demo", "web", map[string]string{"app": "web"}),
+		testObject("apps/v1", "Deployment", "other", "web", map[string]string{"app": "web"}),
+		testObject("v1", "ConfigMap", "demo", "web-config", map[string]string{"app": "web"}),
+		testObject("v1", "ConfigMap", "demo", "unrelated", nil),
+	)
+
+	disco := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "namespaces", Kind: "Namespace", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+		}},
+	}}}
+
+	e, err := loadPolicyEvaluator(context.Background(), its, disco, wds)
+	if err != nil {
+		t.Fatalf("loadPolicyEvaluator failed: %v", err)
+	}
+	return e
+}
+
+func TestEvaluate(t *testing.T) {
+	apps, core := "apps", ""
+	edge := []metav1.LabelSelector{{MatchLabels: map[string]string{"location-group": "edge"}}}
+	prod := []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "prod"}}}
+	appWeb := []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "web"}}}
+	clause := func(test v1alpha1.DownsyncObjectTest, modulation v1alpha1.DownsyncModulation) v1alpha1.DownsyncPolicyClause {
+		return v1alpha1.DownsyncPolicyClause{DownsyncObjectTest: test, DownsyncModulation: modulation}
+	}
+
+	tests := []struct {
+		name         string
+		clusters     []metav1.LabelSelector
+		downsync     []v1alpha1.DownsyncPolicyClause
+		wantClusters []string
+		wantObjects  []string
+		wantWarnings int
+	}{
+		{
+			name:         "labelled objects to edge clusters",
+			clusters:     edge,
+			downsync:     []v1alpha1.DownsyncPolicyClause{clause(v1alpha1.DownsyncObjectTest{ObjectSelectors: appWeb}, v1alpha1.DownsyncModulation{})},
+			wantClusters: []string{"cluster1", "cluster2"},
+			wantObjects:  []string{"/configmaps/demo/web-config", "apps/deployments/demo/web", "apps/deployments/other/web"},
+		},
+		{
+			name:     "api group, resource and namespace selector",
+			clusters: prod,
+			downsync: []v1alpha1.DownsyncPolicyClause{clause(v1alpha1.DownsyncObjectTest{
+				APIGroup:           &apps,
+				Resources:          []string{"deployments"},
+				NamespaceSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"team": "demo"}}},
+			}, v1alpha1.DownsyncModulation{})},
+			wantClusters: []string{"cluster2"},
+			wantObjects:  []string{"apps/deployments/demo/web"},
+		},
+		{
+			name:     "core group by name",
+			clusters: edge,
+			downsync: []v1alpha1.DownsyncPolicyClause{clause(v1alpha1.DownsyncObjectTest{
+				APIGroup: &core, Resources: []string{"configmaps"}, Namespaces: []string{"demo"}, ObjectNames: []string{"unrelated"},
+			}, v1alpha1.DownsyncModulation{})},
+			wantClusters: []string{"cluster1", "cluster2"},
+			wantObjects:  []string{"/configmaps/demo/unrelated"},
+		},
+		{
+			name:         "no matching cluster",
+			clusters:     []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "staging"}}},
+			downsync:     []v1alpha1.DownsyncPolicyClause{clause(v1alpha1.DownsyncObjectTest{ObjectNames: []string{"web-config"}}, v1alpha1.DownsyncModulation{})},
+			wantClusters: []string{},
+			wantObjects:  []string{"/configmaps/demo/web-config"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "unserved resource",
+			clusters:     edge,
+			downsync:     []v1alpha1.DownsyncPolicyClause{clause(v1alpha1.DownsyncObjectTest{Resources: []string{"widgets"}}, v1alpha1.DownsyncModulation{})},
+			wantClusters: []string{"cluster1", "cluster2"},
+			wantObjects:  []string{},
+			wantWarnings: 2,
+		},
+		{
+			name:         "no selectors or rules",
+			wantClusters: []string{},
+			wantObjects:  []string{},
+			wantWarnings: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := &v1alpha1.BindingPolicy{}
+			bp.Name = "test"
+			bp.Spec.ClusterSelectors = tt.clusters
+			bp.Spec.Downsync = tt.downsync
+
+			result, err := testEvaluator(t).evaluate(bp)
+			if err != nil {
+				t.Fatalf("evaluate failed: %v", err)
+			}
+			if got := result.SelectedClusters(); !reflect.DeepEqual(got, tt.wantClusters) {
+				t.Errorf("selected clusters = %v, want %v", got, tt.wantClusters)
+			}
+			objects := []string{}
+			for _, object := range result.Objects {
+				objects = append(objects, object.Key())
+				if !reflect.DeepEqual(object.Clusters, tt.wantClusters) {
+					t.Errorf("object %s goes to %v, want %v", object.Key(), object.Clusters, tt.wantClusters)
+				}
+			}
+			if !reflect.DeepEqual(objects, tt.wantObjects) {
+				t.Errorf("objects = %v, want %v", objects, tt.wantObjects)
+			}
+			if result.Bindings != len(tt.wantObjects)*len(tt.wantClusters) {
+				t.Errorf("bindings = %d, want %d", result.Bindings, len(tt.wantObjects)*len(tt.wantClusters))
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestEvaluateMergesClauses(t *testing.T) {
+	bp := &v1alpha1.BindingPolicy{}
+	bp.Spec.ClusterSelectors = []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "dev"}}}
+	bp.Spec.Downsync = []v1alpha1.DownsyncPolicyClause{
+		{
+			DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Resources: []string{"deployments"}, Namespaces: []string{"demo"}},
+			DownsyncModulation: v1alpha1.DownsyncModulation{StatusCollectors: []string{"replicas"}},
+		},
+		{
+			DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectNames: []string{"web"}, Namespaces: []string{"demo"}},
+			DownsyncModulation: v1alpha1.DownsyncModulation{CreateOnly: true, StatusCollectors: []string{"health", "replicas"}},
+		},
+	}
+
+	result, err := testEvaluator(t).evaluate(bp)
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if len(result.Objects) != 1 {
+		t.Fatalf("objects = %+v, want the demo deployment once", result.Objects)
+	}
+	web := result.Objects[0]
+	if !web.CreateOnly || !reflect.DeepEqual(web.StatusCollectors, []string{"health", "replicas"}) || web.Kind != "Deployment" {
+		t.Errorf("merged object = %+v, want createOnly with both status collectors", web)
+	}
+}
@@ -0,0 +1,368 @@
+package bp
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/log"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Statuses of TemplateUpgradeResult
+const (
+	UpgradeUpgraded = "upgraded"
+	UpgradeUpToDate = "up-to-date"
+	UpgradeFailed   = "failed"
+)
+
+// TemplateInstanceRequest renders a template into a binding policy
+type TemplateInstanceRequest struct {
+	PolicyName string                 `json:"policyName"` // Optional when the template names the policy
+	Version    int                    `json:"version"`    // Latest when 0
+	Parameters map[string]interface{} `json:"parameters"`
+	DryRun     bool                   `json:"dryRun"` // Return the policy without creating it
+}
+
+// TemplateUpgradeRequest re-renders the policies of a template with another version of it
+type TemplateUpgradeRequest struct {
+	Policies []string `json:"policies"` // Every policy of the template when empty
+	Version  int      `json:"version"`  // Latest when 0
+	DryRun   bool     `json:"dryRun"`
+}
+
+// TemplatePolicy is a binding policy rendered from a template
+type TemplatePolicy struct {
+	Name     string `json:"name"`
+	Version  int    `json:"version"`
+	Outdated bool   `json:"outdated"` // Rendered from a version older than the latest
+}
+
+// TemplateUpgradeResult is the outcome of upgrading one policy
+type TemplateUpgradeResult struct {
+	Policy      string `json:"policy"`
+	FromVersion int    `json:"fromVersion,omitempty"`
+	ToVersion   int    `json:"toVersion"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	YAML        string `json:"yaml,omitempty"` // The upgraded policy on a dry run
+}
+
+// templateStoreFor returns the template store of the WDS acting as the user of the request
+func templateStoreFor(ctx *gin.Context) (*templateStore, error) {
+	clientset, _, err := k8s.GetClientSetForRequest(ctx.Request.Context(), k8s.RequestWDSContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to WDS: %v", err)
+	}
+	return &templateStore{client: clientset, namespace: k8s.KubeStellarNamespace}, nil
+}
+
+func templateError(ctx *gin.Context, err error) {
+	if errors.IsNotFound(err) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	log.LogError("binding policy template error", zap.Error(err))
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ListBpTemplates lists the binding policy templates of the WDS with their latest version
+func ListBpTemplates(ctx *gin.Context) {
+	store, err := templateStoreFor(ctx)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	templates, err := store.List(ctx.Request.Context())
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"templates": templates, "count": len(templates)})
+}
+
+// SaveBpTemplate stores a template, as version 1 of a new template or as the next version of
+// an existing one
+func SaveBpTemplate(ctx *gin.Context) {
+	var t PolicyTemplate
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid JSON format: %s", err.Error())})
+		return
+	}
+	if err := t.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template", "details": err.Error()})
+		return
+	}
+	t.CreatedBy = ctx.GetString("username")
+
+	store, err := templateStoreFor(ctx)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	if err := store.Save(ctx.Request.Context(), &t); err != nil {
+		templateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":  fmt.Sprintf("Saved version %d of template %s", t.Version, t.Name),
+		"template": t,
+	})
+}
+
+// GetBpTemplate returns a template, its latest version or the one given as ?version, and the
+// policies rendered from it
+func GetBpTemplate(ctx *gin.Context) {
+	name := ctx.Param("name")
+	version := 0
+	if v := ctx.Query("version"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+			return
+		}
+		version = parsed
+	}
+
+	store, err := templateStoreFor(ctx)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	info, err := store.Info(ctx.Request.Context(), name)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	if version != 0 {
+		t, err := store.Get(ctx.Request.Context(), name, version)
+		if err != nil {
+			templateError(ctx, err)
+			return
+		}
+		info.PolicyTemplate = *t
+	}
+
+	c, err := getClientForRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	bpList, err := c.BindingPolicies().List(ctx.Request.Context(), v1.ListOptions{LabelSelector: TemplateLabel + "=" + name})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list binding policies", "details": err.Error()})
+		return
+	}
+	latest := info.Versions[len(info.Versions)-1]
+	policies := []TemplatePolicy{}
+	for i := range bpList.Items {
+		if _, policyVersion, ok := templateSource(&bpList.Items[i]); ok {
+			policies = append(policies, TemplatePolicy{Name: bpList.Items[i].Name, Version: policyVersion, Outdated: policyVersion < latest})
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+
+	ctx.JSON(http.StatusOK, gin.H{"template": info, "policies": policies})
+}
+
+// DeleteBpTemplate deletes a template with all its versions; the policies rendered from it
+// are kept
+func DeleteBpTemplate(ctx *gin.Context) {
+	name := ctx.Param("name")
+	store, err := templateStoreFor(ctx)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	if err := store.Delete(ctx.Request.Context(), name); err != nil {
+		templateError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("deleted template %s", name)})
+}
+
+// InstantiateBpTemplate renders a template with the given parameters and creates the policy
+func InstantiateBpTemplate(ctx *gin.Context) {
+	var request TemplateInstanceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid JSON format: %s", err.Error())})
+		return
+	}
+	instantiateTemplate(ctx, ctx.Param("name"), request)
+}
+
+// instantiateTemplate renders a template and, unless on a dry run, creates the policy
+func instantiateTemplate(ctx *gin.Context, name string, request TemplateInstanceRequest) {
+	store, err := templateStoreFor(ctx)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	t, err := store.Get(ctx.Request.Context(), name, request.Version)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	newBP, err := t.Render(request.PolicyName, request.Parameters)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to render template", "details": err.Error()})
+		return
+	}
+	newBP.Annotations["created-by"] = "kubestellar-ui-template"
+
+	rawYAML, err := policyYAML(newBP)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := gin.H{
+		"yaml":          rawYAML,
+		"bindingPolicy": newBP.Name,
+		"template":      t.Name,
+		"version":       t.Version,
+	}
+	if request.DryRun {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	c, err := getClientForRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := c.BindingPolicies().Create(ctx.Request.Context(), newBP, v1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error":  fmt.Sprintf("BindingPolicy '%s' already exists", newBP.Name),
+				"status": "exists",
+			})
+			return
+		}
+		log.LogError("failed to create binding policy from template", zap.String("template", t.Name), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create binding policy: %s", err.Error())})
+		return
+	}
+
+	response["message"] = fmt.Sprintf("Created binding policy '%s' from version %d of template %s", newBP.Name, t.Version, t.Name)
+	response["warnings"] = bindingPolicyWarnings(ctx, created)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UpgradeBpTemplatePolicies re-renders policies of a template with their recorded parameters
+// and a version of the template, the latest by default, and updates them
+func UpgradeBpTemplatePolicies(ctx *gin.Context) {
+	name := ctx.Param("name")
+	var request TemplateUpgradeRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid JSON format: %s", err.Error())})
+			return
+		}
+	}
+
+	store, err := templateStoreFor(ctx)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	t, err := store.Get(ctx.Request.Context(), name, request.Version)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+
+	c, err := getClientForRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	bpList, err := c.BindingPolicies().List(ctx.Request.Context(), v1.ListOptions{LabelSelector: TemplateLabel + "=" + name})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list binding policies", "details": err.Error()})
+		return
+	}
+	sort.Slice(bpList.Items, func(i, j int) bool { return bpList.Items[i].Name < bpList.Items[j].Name })
+
+	results := []TemplateUpgradeResult{}
+	seen := map[string]bool{}
+	for i := range bpList.Items {
+		policy := &bpList.Items[i]
+		if len(request.Policies) > 0 && !contains(request.Policies, policy.Name) {
+			continue
+		}
+		seen[policy.Name] = true
+		_, fromVersion, _ := templateSource(policy)
+		result := TemplateUpgradeResult{Policy: policy.Name, FromVersion: fromVersion, ToVersion: t.Version}
+		if fromVersion == t.Version {
+			result.Status = UpgradeUpToDate
+			results = append(results, result)
+			continue
+		}
+
+		err := rerender(t, policy)
+		if err == nil && request.DryRun {
+			result.YAML, err = policyYAML(policy)
+		} else if err == nil {
+			_, err = c.BindingPolicies().Update(ctx.Request.Context(), policy, v1.UpdateOptions{})
+		}
+		if err != nil {
+			result.Status = UpgradeFailed
+			result.Error = err.Error()
+		} else {
+			result.Status = UpgradeUpgraded
+		}
+		results = append(results, result)
+	}
+	for _, policyName := range request.Policies {
+		if !seen[policyName] {
+			results = append(results, TemplateUpgradeResult{
+				Policy:    policyName,
+				ToVersion: t.Version,
+				Status:    UpgradeFailed,
+				Error:     fmt.Sprintf("binding policy %s was not rendered from template %s", policyName, name),
+			})
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"template": t.Name,
+		"version":  t.Version,
+		"dryRun":   request.DryRun,
+		"results":  results,
+	})
+}
+
+// rerender replaces the spec of a policy with a rendering of the template with the parameter
+// values the policy records, keeping its other labels and annotations
+func rerender(t *PolicyTemplate, policy *v1alpha1.BindingPolicy) error {
+	values, err := templateParameters(policy)
+	if err != nil {
+		return err
+	}
+	rendered, err := t.Render(policy.Name, values)
+	if err != nil {
+		return err
+	}
+	policy.Spec = rendered.Spec
+	if policy.Labels == nil {
+		policy.Labels = map[string]string{}
+	}
+	if policy.Annotations == nil {
+		policy.Annotations = map[string]string{}
+	}
+	for k, v := range rendered.Labels {
+		policy.Labels[k] = v
+	}
+	for k, v := range rendered.Annotations {
+		policy.Annotations[k] = v
+	}
+	return nil
+}
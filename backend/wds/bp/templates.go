@@ -0,0 +1,480 @@
+package bp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+// Templates are kept as ConfigMaps in the WDS, one per template with a data key per version.
+// Policies rendered from a template carry its name as a label and the version and parameter
+// values they were rendered with as annotations.
+const (
+	TemplateLabel                = "kubestellar.io/bp-template"
+	TemplateVersionAnnotation    = "kubestellar.io/bp-template-version"
+	TemplateParametersAnnotation = "kubestellar.io/bp-template-parameters"
+	templateConfigMapPrefix      = "bp-template-"
+	templateVersionKeyPrefix     = "v"
+)
+
+// Types of TemplateParameter
+const (
+	ParameterString  = "string"
+	ParameterInteger = "integer"
+	ParameterBoolean = "boolean"
+	ParameterList    = "list" // List of strings
+)
+
+var templateGroupResource = schema.GroupResource{Group: "ui.kubestellar.io", Resource: "bindingpolicytemplates"}
+
+// Parameters are used as template fields, so their names must be Go identifiers
+var parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PolicyTemplate is one version of a binding policy template. Policy is the YAML of a
+// BindingPolicy written as a Go text/template of the parameters, with toJson and quote to
+// write values as YAML, for example:
+//
+//	spec:
+//	  clusterSelectors:
+//	  - matchLabels:
+//	      region: {{ quote .region }}
+//	  downsync:
+//	  - resources: ["namespaces"]
+//	    objectNames: {{ toJson .namespaces }}
+type PolicyTemplate struct {
+	Name        string              `json:"name"`
+	Version     int                 `json:"version"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []TemplateParameter `json:"parameters"`
+	Policy      string              `json:"policy"`
+	CreatedBy   string              `json:"createdBy,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+}
+
+// TemplateParameter is a typed parameter of a template. A parameter without a value takes its
+// default, or the zero value of its type when it is not required.
+type TemplateParameter struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// TemplateInfo is the latest version of a template and the versions it has
+type TemplateInfo struct {
+	PolicyTemplate `json:",inline"`
+	Versions       []int `json:"versions"`
+}
+
+// TemplateConfigMapName returns the name of the ConfigMap holding a template
+func TemplateConfigMapName(name string) string {
+	return templateConfigMapPrefix + name
+}
+
+var templateFuncs = template.FuncMap{
+	"toJson": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"quote": func(value interface{}) (string, error) {
+		data, err := json.Marshal(fmt.Sprint(value))
+		return string(data), err
+	},
+}
+
+// validate checks the name and parameters of a template and that its policy renders
+func (t *PolicyTemplate) validate() error {
+	if errs := validation.IsDNS1123Label(TemplateConfigMapName(t.Name)); t.Name == "" || len(errs) > 0 {
+		return fmt.Errorf("template name %q must be a lowercase DNS label of at most %d characters",
+			t.Name, validation.DNS1123LabelMaxLength-len(templateConfigMapPrefix))
+	}
+	if strings.TrimSpace(t.Policy) == "" {
+		return fmt.Errorf("policy is required")
+	}
+
+	seen := map[string]bool{}
+	samples := map[string]interface{}{}
+	for _, p := range t.Parameters {
+		if !parameterNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("parameter name %q must be letters, digits and underscores", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("parameter %s is declared twice", p.Name)
+		}
+		seen[p.Name] = true
+		if _, err := coerceParameter(p.Type, zeroValue(p.Type)); err != nil {
+			return fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		if p.Default != nil {
+			if _, err := coerceParameter(p.Type, p.Default); err != nil {
+				return fmt.Errorf("default of parameter %s: %v", p.Name, err)
+			}
+		} else if p.Required {
+			samples[p.Name] = sampleValue(p.Type)
+		}
+	}
+
+	if _, err := t.Render("template-validation", samples); err != nil {
+		return fmt.Errorf("policy does not render: %v", err)
+	}
+	return nil
+}
+
+// Render resolves the parameter values and renders the policy of the template. The policy gets
+// policyName, unless it is empty and the template names the policy itself, and is labeled and
+// annotated with the template version and the given values.
+func (t *PolicyTemplate) Render(policyName string, values map[string]interface{}) (*v1alpha1.BindingPolicy, error) {
+	resolved, err := t.resolveParameters(values)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(t.Name).Option("missingkey=error").Funcs(templateFuncs).Parse(t.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, resolved); err != nil {
+		return nil, fmt.Errorf("failed to render template: %v", err)
+	}
+	bp, err := getBpObjFromYaml(rendered.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if policyName != "" {
+		bp.Name = policyName
+	}
+	if bp.Name == "" {
+		return nil, fmt.Errorf("policy name is required")
+	}
+	parameters, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal parameters: %v", err)
+	}
+	if bp.Labels == nil {
+		bp.Labels = map[string]string{}
+	}
+	if bp.Annotations == nil {
+		bp.Annotations = map[string]string{}
+	}
+	bp.Labels[TemplateLabel] = t.Name
+	bp.Annotations[TemplateVersionAnnotation] = strconv.Itoa(t.Version)
+	bp.Annotations[TemplateParametersAnnotation] = string(parameters)
+	return bp, nil
+}
+
+// resolveParameters checks the values against the parameters and fills in the missing ones
+func (t *PolicyTemplate) resolveParameters(values map[string]interface{}) (map[string]interface{}, error) {
+	declared := map[string]bool{}
+	for _, p := range t.Parameters {
+		declared[p.Name] = true
+	}
+	for name := range values {
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+
+	resolved := map[string]interface{}{}
+	for _, p := range t.Parameters {
+		value := values[p.Name]
+		if value == nil {
+			value = p.Default
+		}
+		if value == nil {
+			if p.Required {
+				return nil, fmt.Errorf("parameter %s is required", p.Name)
+			}
+			value = zeroValue(p.Type)
+		}
+		coerced, err := coerceParameter(p.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		resolved[p.Name] = coerced
+	}
+	return resolved, nil
+}
+
+// coerceParameter converts a value decoded from JSON to the Go type of a parameter type
+func coerceParameter(parameterType string, value interface{}) (interface{}, error) {
+	switch parameterType {
+	case ParameterString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case ParameterInteger:
+		switch n := value.(type) {
+		case int:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int64(n), nil
+			}
+		}
+	case ParameterBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case ParameterList:
+		switch list := value.(type) {
+		case []string:
+			return list, nil
+		case []interface{}:
+			items := make([]string, 0, len(list))
+			for _, item := range list {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected a list of strings")
+				}
+				items = append(items, s)
+			}
+			return items, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q", parameterType)
+	}
+	return nil, fmt.Errorf("expected a value of type %s, got %v", parameterType, value)
+}
+
+func zeroValue(parameterType string) interface{} {
+	switch parameterType {
+	case ParameterInteger:
+		return int64(0)
+	case ParameterBoolean:
+		return false
+	case ParameterList:
+		return []string{}
+	}
+	return ""
+}
+
+// sampleValue stands in for a required parameter when a template is validated
+func sampleValue(parameterType string) interface{} {
+	switch parameterType {
+	case ParameterString:
+		return "example"
+	case ParameterInteger:
+		return int64(1)
+	case ParameterList:
+		return []string{"example"}
+	}
+	return zeroValue(parameterType)
+}
+
+// templateSource returns the template name and version a policy was rendered from
+func templateSource(bp *v1alpha1.BindingPolicy) (string, int, bool) {
+	name := bp.Labels[TemplateLabel]
+	version, err := strconv.Atoi(bp.Annotations[TemplateVersionAnnotation])
+	if name == "" || err != nil {
+		return "", 0, false
+	}
+	return name, version, true
+}
+
+// templateParameters returns the parameter values a policy was rendered with
+func templateParameters(bp *v1alpha1.BindingPolicy) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if raw := bp.Annotations[TemplateParametersAnnotation]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %v", TemplateParametersAnnotation, err)
+		}
+	}
+	return values, nil
+}
+
+// templateStore keeps the versions of templates as ConfigMaps of a namespace
+type templateStore struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// load returns the ConfigMap of a template and its versions, oldest first
+func (s *templateStore) load(ctx context.Context, name string) (*corev1.ConfigMap, []PolicyTemplate, error) {
+	configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, TemplateConfigMapName(name), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, errors.NewNotFound(templateGroupResource, name)
+		}
+		return nil, nil, fmt.Errorf("failed to get template %s: %v", name, err)
+	}
+	versions, err := templateVersions(configMap)
+	return configMap, versions, err
+}
+
+func templateVersions(configMap *corev1.ConfigMap) ([]PolicyTemplate, error) {
+	var versions []PolicyTemplate
+	for key, data := range configMap.Data {
+		if !strings.HasPrefix(key, templateVersionKeyPrefix) {
+			continue
+		}
+		var t PolicyTemplate
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of ConfigMap %s: %v", key, configMap.Name, err)
+		}
+		versions = append(versions, t)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, nil
+}
+
+// Get returns a version of a template, or its latest version when version is 0
+func (s *templateStore) Get(ctx context.Context, name string, version int) (*PolicyTemplate, error) {
+	_, versions, err := s.load(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if version == 0 || versions[i].Version == version {
+			return &versions[i], nil
+		}
+	}
+	return nil, errors.NewNotFound(templateGroupResource, fmt.Sprintf("%s version %d", name, version))
+}
+
+// Info returns the latest version of a template and the versions it has
+func (s *templateStore) Info(ctx context.Context, name string) (*TemplateInfo, error) {
+	_, versions, err := s.load(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errors.NewNotFound(templateGroupResource, name)
+	}
+	return templateInfo(versions), nil
+}
+
+func templateInfo(versions []PolicyTemplate) *TemplateInfo {
+	info := &TemplateInfo{PolicyTemplate: versions[len(versions)-1], Versions: []int{}}
+	for _, t := range versions {
+		info.Versions = append(info.Versions, t.Version)
+	}
+	return info
+}
+
+// List returns every template, sorted by name
+func (s *templateStore) List(ctx context.Context) ([]TemplateInfo, error) {
+	configMaps, err := s.client.CoreV1().ConfigMaps(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: TemplateLabel})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []TemplateInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to list templates: %v", err)
+	}
+	templates := []TemplateInfo{}
+	for i := range configMaps.Items {
+		versions, err := templateVersions(&configMaps.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) > 0 {
+			templates = append(templates, *templateInfo(versions))
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// Save stores a template as the version after its latest one, creating it with version 1
+func (s *templateStore) Save(ctx context.Context, t *PolicyTemplate) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, versions, err := s.load(ctx, t.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		t.Version = 1
+		if len(versions) > 0 {
+			t.Version = versions[len(versions)-1].Version + 1
+		}
+		t.CreatedAt = time.Now().UTC()
+		data, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Errorf("failed to marshal template: %v", err)
+		}
+		key := templateVersionKeyPrefix + strconv.Itoa(t.Version)
+
+		if configMap == nil {
+			if err := s.ensureNamespace(ctx); err != nil {
+				return err
+			}
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      TemplateConfigMapName(t.Name),
+					Namespace: s.namespace,
+					Labels:    map[string]string{TemplateLabel: t.Name},
+				},
+				Data: map[string]string{key: string(data)},
+			}
+			_, err = s.client.CoreV1().ConfigMaps(s.namespace).Create(ctx, configMap, metav1.CreateOptions{})
+			if errors.IsAlreadyExists(err) {
+				// Saved concurrently; retry as a new version of it
+				return errors.NewConflict(templateGroupResource, t.Name, err)
+			}
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[key] = string(data)
+		_, err = s.client.CoreV1().ConfigMaps(s.namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Delete removes a template with all its versions
+func (s *templateStore) Delete(ctx context.Context, name string) error {
+	err := s.client.CoreV1().ConfigMaps(s.namespace).Delete(ctx, TemplateConfigMapName(name), metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return errors.NewNotFound(templateGroupResource, name)
+	}
+	return err
+}
+
+func (s *templateStore) ensureNamespace(ctx context.Context) error {
+	_, err := s.client.CoreV1().Namespaces().Get(ctx, s.namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: s.namespace}}
+		_, err = s.client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to ensure namespace %s: %v", s.namespace, err)
+	}
+	return nil
+}
+
+// policyYAML returns the YAML of a policy as kubectl would write it
+func policyYAML(bp *v1alpha1.BindingPolicy) (string, error) {
+	data, err := yaml.Marshal(bp)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal binding policy: %v", err)
+	}
+	return string(data), nil
+}
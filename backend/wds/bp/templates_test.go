@@ -0,0 +1,226 @@
+package bp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCoerceParameter(t *testing.T) {
+	tests := []struct {
+		name          string
+		parameterType string
+		value         interface{}
+		want          interface{}
+		wantErr       bool
+	}{
+		{"string", ParameterString, "eu", "eu", false},
+		{"number as string", ParameterString, 1.0, nil, true},
+		{"integer from JSON", ParameterInteger, 3.0, int64(3), false},
+		{"integer", ParameterInteger, 3, int64(3), false},
+		{"int64", ParameterInteger, int64(3), int64(3), false},
+		{"fraction", ParameterInteger, 2.5, nil, true},
+		{"integer from string", ParameterInteger, "3", nil, true},
+		{"boolean", ParameterBoolean, true, true, false},
+		{"boolean from string", ParameterBoolean, "true", nil, true},
+		{"list from JSON", ParameterList, []interface{}{"a", "b"}, []string{"a", "b"}, false},
+		{"list", ParameterList, []string{"a"}, []string{"a"}, false},
+		{"list of numbers", ParameterList, []interface{}{"a", 1.0}, nil, true},
+		{"list from string", ParameterList, "a,b", nil, true},
+		{"unknown type", "map", map[string]interface{}{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerceParameter(tt.parameterType, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("coerceParameter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coerceParameter() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// regionTemplate sends namespaces to the clusters of a region
+func regionTemplate() *PolicyTemplate {
+	return &PolicyTemplate{
+		Name:    "region",
+		Version: 2,
+		Parameters: []TemplateParameter{
+			{Name: "region", Type: ParameterString, Required: true},
+			{Name: "namespaces", Type: ParameterList, Default: []interface{}{"default"}},
+			{Name: "createOnly", Type: ParameterBoolean},
+		},
+		Policy: `apiVersion: control.kubestellar.io/v1alpha1
+kind: BindingPolicy
+metadata:
+  name: region-policy
+spec:
+  clusterSelectors:
+  - matchLabels:
+      region: {{ quote .region }}
+  downsync:
+  - resources: ["namespaces"]
+    objectNames: {{ toJson .namespaces }}
+    createOnly: {{ .createOnly }}
+`,
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name           string
+		policyName     string
+		values         map[string]interface{}
+		wantName       string
+		wantRegion     string
+		wantNamespaces []string
+		wantCreateOnly bool
+		wantErr        string
+	}{
+		{
+			name:           "defaults",
+			values:         map[string]interface{}{"region": "eu"},
+			wantName:       "region-policy",
+			wantRegion:     "eu",
+			wantNamespaces: []string{"default"},
+		},
+		{
+			name:           "all values",
+			policyName:     "eu-apps",
+			values:         map[string]interface{}{"region": "eu: west", "namespaces": []interface{}{"apps", "web"}, "createOnly": true},
+			wantName:       "eu-apps",
+			wantRegion:     "eu: west",
+			wantNamespaces: []string{"apps", "web"},
+			wantCreateOnly: true,
+		},
+		{name: "missing required", values: map[string]interface{}{}, wantErr: "parameter region is required"},
+		{name: "unknown parameter", values: map[string]interface{}{"region": "eu", "zone": "a"}, wantErr: "unknown parameter zone"},
+		{name: "wrong type", values: map[string]interface{}{"region": "eu", "createOnly": "yes"}, wantErr: "parameter createOnly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := regionTemplate().Render(tt.policyName, tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if bp.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", bp.Name, tt.wantName)
+			}
+			if got := bp.Spec.ClusterSelectors[0].MatchLabels["region"]; got != tt.wantRegion {
+				t.Errorf("region = %q, want %q", got, tt.wantRegion)
+			}
+			clause := bp.Spec.Downsync[0]
+			if !reflect.DeepEqual(clause.ObjectNames, tt.wantNamespaces) || clause.CreateOnly != tt.wantCreateOnly {
+				t.Errorf("downsync = %+v, want namespaces %v and createOnly %v", clause, tt.wantNamespaces, tt.wantCreateOnly)
+			}
+
+			name, version, ok := templateSource(bp)
+			if !ok || name != "region" || version != 2 {
+				t.Errorf("templateSource() = %s, %d, %v", name, version, ok)
+			}
+			values, err := templateParameters(bp)
+			if err != nil || len(values) != len(tt.values) || values["region"] != tt.values["region"] {
+				t.Errorf("templateParameters() = %v, %v, want the given values %v", values, err, tt.values)
+			}
+		})
+	}
+
+	unnamed := regionTemplate()
+	unnamed.Policy = strings.Replace(unnamed.Policy, "name: region-policy", "labels: {}", 1)
+	if _, err := unnamed.Render("", map[string]interface{}{"region": "eu"}); err == nil {
+		t.Error("Render accepted a policy without a name")
+	}
+}
+
+func TestTemplateValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(t *PolicyTemplate)
+		wantErr bool
+	}{
+		{"valid", func(t *PolicyTemplate) {}, false},
+		{"empty name", func(t *PolicyTemplate) { t.Name = "" }, true},
+		{"upper case name", func(t *PolicyTemplate) { t.Name = "Region" }, true},
+		{"long name", func(t *PolicyTemplate) { t.Name = strings.Repeat("a", 60) }, true},
+		{"empty policy", func(t *PolicyTemplate) { t.Policy = " \n" }, true},
+		{"invalid parameter name", func(t *PolicyTemplate) { t.Parameters[0].Name = "the-region" }, true},
+		{"duplicate parameter", func(t *PolicyTemplate) { t.Parameters = append(t.Parameters, t.Parameters[0]) }, true},
+		{"unknown parameter type", func(t *PolicyTemplate) { t.Parameters[0].Type = "map" }, true},
+		{"wrong default type", func(t *PolicyTemplate) { t.Parameters[1].Default = "default" }, true},
+		{"undeclared field", func(t *PolicyTemplate) { t.Policy += "# {{ .zone }}\n" }, true},
+		{"invalid template", func(t *PolicyTemplate) { t.Policy += "{{ if }}" }, true},
+		{"not a binding policy", func(t *PolicyTemplate) { t.Policy = "apiVersion: v1\nkind: ConfigMap\n" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := regionTemplate()
+			tt.modify(template)
+			if err := template.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplateStore(t *testing.T) {
+	ctx := context.Background()
+	store := &templateStore{client: fake.NewSimpleClientset(), namespace: "kubestellar-templates"}
+
+	if _, err := store.Get(ctx, "region", 0); !errors.IsNotFound(err) {
+		t.Fatalf("Get of a missing template returned %v, want not found", err)
+	}
+	for i := 0; i < 2; i++ {
+		template := regionTemplate()
+		template.Description = []string{"first", "second"}[i]
+		if err := store.Save(ctx, template); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		if template.Version != i+1 {
+			t.Errorf("saved version %d, want %d", template.Version, i+1)
+		}
+	}
+	other := regionTemplate()
+	other.Name = "apps"
+	if err := store.Save(ctx, other); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	latest, err := store.Get(ctx, "region", 0)
+	if err != nil || latest.Version != 2 || latest.Description != "second" {
+		t.Errorf("Get latest = %+v, %v", latest, err)
+	}
+	first, err := store.Get(ctx, "region", 1)
+	if err != nil || first.Description != "first" {
+		t.Errorf("Get version 1 = %+v, %v", first, err)
+	}
+	if _, err := store.Get(ctx, "region", 3); !errors.IsNotFound(err) {
+		t.Errorf("Get of a missing version returned %v, want not found", err)
+	}
+
+	templates, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(templates) != 2 || templates[0].Name != "apps" || !reflect.DeepEqual(templates[1].Versions, []int{1, 2}) {
+		t.Errorf("List() = %+v, want apps and region with versions 1 and 2", templates)
+	}
+
+	if err := store.Delete(ctx, "region"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "region"); !errors.IsNotFound(err) {
+		t.Errorf("second Delete returned %v, want not found", err)
+	}
+}
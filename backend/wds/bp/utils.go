@@ -1,33 +1,27 @@
 package bp
 
 import (
-	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/gin-gonic/gin"
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/scheme"
 	bpv1alpha1 "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/typed/control/v1alpha1"
+	"github.com/kubestellar/ui/k8s"
 	"github.com/kubestellar/ui/log"
-	"github.com/kubestellar/ui/redis"
 	"go.uber.org/zap"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
 )
 
-// DefaultWDSContext is the default context to use for the workload distribution service
-const DefaultWDSContext = "wds1"
-
-// clientCache caches the BP client to avoid recreating it for each request
+// clientCache caches the BP client and its REST config to avoid recreating it for each request
 var (
 	clientCache     *bpv1alpha1.ControlV1alpha1Client
+	restConfigCache *rest.Config
 	clientCacheLock sync.Mutex
 )
 
@@ -41,15 +35,10 @@ func getClientForBp() (*bpv1alpha1.ControlV1alpha1Client, error) {
 		return clientCache, nil
 	}
 
-	// Set wds context to "wds1"
-	wdsContext := "wds1"
+	wdsContext := k8s.WDSContext()
 	log.LogDebug("Using wds context", zap.String("context", wdsContext))
 
-	// Get kubeconfig path
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		kubeconfig = filepath.Join(homedir.HomeDir(), ".kube", "config")
-	}
+	kubeconfig := k8s.KubeconfigPath()
 	log.LogDebug("Creating client for BP", zap.String("kubeconfig path", kubeconfig))
 
 	// Load the kubeconfig file
@@ -106,10 +95,38 @@ func getClientForBp() (*bpv1alpha1.ControlV1alpha1Client, error) {
 
 	// Cache the client for future use
 	clientCache = c
+	restConfigCache = restcnfg
 
 	return c, nil
 }
 
+// getClientForRequest returns the BindingPolicy client of the WDS selected by the request,
+// acting as its user when impersonation is enabled
+func getClientForRequest(ctx *gin.Context) (*bpv1alpha1.ControlV1alpha1Client, error) {
+	// Only the default WDS uses the cached client and its fallback to other contexts
+	if contextName := k8s.RequestWDSContext(ctx); contextName != k8s.WDSContext() {
+		_, restConfig, err := k8s.GetClientSetWithConfigForRequest(ctx.Request.Context(), contextName)
+		if err != nil {
+			return nil, err
+		}
+		return bpv1alpha1.NewForConfig(restConfig)
+	}
+
+	c, err := getClientForBp()
+	if err != nil || !k8s.ImpersonationEnabled() {
+		return c, err
+	}
+
+	clientCacheLock.Lock()
+	restConfig := restConfigCache
+	clientCacheLock.Unlock()
+	impersonated, err := k8s.Impersonate(ctx.Request.Context(), restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return bpv1alpha1.NewForConfig(impersonated)
+}
+
 // get BP struct from YAML
 func getBpObjFromYaml(bpRawYamlBytes []byte) (*v1alpha1.BindingPolicy, error) {
 	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(bpRawYamlBytes, nil, nil)
@@ -134,8 +151,9 @@ func containsAny(s string, substrings []string) bool {
 	return false
 }
 
-// extractWorkloads gets a list of workloads affected by this BP
-func extractWorkloads(bp *v1alpha1.BindingPolicy) []string {
+// extractWorkloads lists the workloads of the Binding KubeStellar derived from this BP, read
+// from the bindings store of the BP's WDS
+func extractWorkloads(bp *v1alpha1.BindingPolicy, bindings cache.Store) []string {
 	workloads := []string{}
 
 	// Safety check
@@ -146,87 +164,39 @@ func extractWorkloads(bp *v1alpha1.BindingPolicy) []string {
 
 	log.LogDebug("extractWorkloads - Processing downsync rules", zap.Int("downsyncCount", len(bp.Spec.Downsync)))
 
-	// Load kubeconfig
-	kubeconfigPath := clientcmd.RecommendedHomeFile
-
-	// Load raw kubeconfig and select context "wds1"
-	rawConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
-	if err != nil {
-		log.LogError("failed to load kubeconfig file", zap.String("path", kubeconfigPath), zap.Error(err))
-		return workloads // Return an empty list of workloads on failure
-	}
-
-	// Explicitly set context to "wds1"
-	rawConfig.CurrentContext = "wds1"
-
-	// Build config from modified rawConfig
-	config, err := clientcmd.NewDefaultClientConfig(
-		*rawConfig,
-		&clientcmd.ConfigOverrides{CurrentContext: "wds1"},
-	).ClientConfig()
-	if err != nil {
-		log.LogError("failed to load kubeconfig for context 'wds1'", zap.Error(err))
-		return workloads // Return an empty list of workloads on failure
-	}
-
-	// Create dynamic client
-	dynClient, err := dynamic.NewForConfig(config)
+	// A Binding has the name of the BindingPolicy it comes from
+	obj, exists, err := bindings.GetByKey(bp.Name)
 	if err != nil {
-		log.LogError("failed to create dynamic client", zap.Error(err))
+		log.LogError("failed to read binding from cache", zap.String("name", bp.Name), zap.Error(err))
 		return workloads // Return an empty list of workloads on failure
 	}
-
-	// Define GVR for the Binding CRD
-	bindingGVR := schema.GroupVersionResource{
-		Group:    "control.kubestellar.io",
-		Version:  "v1alpha1",
-		Resource: "bindings",
+	binding, ok := obj.(*unstructured.Unstructured)
+	if !exists || !ok {
+		log.LogDebug("extractWorkloads - no binding found", zap.String("binding", bp.Name))
+		return workloads
 	}
 
-	// List all Bindings across all namespaces
-	bindings, err := dynClient.Resource(bindingGVR).List(context.TODO(), v1.ListOptions{})
-	if err != nil {
-		log.LogError("failed to list bindings", zap.Error(err))
-		return workloads // Return an empty list of workloads on failure
+	// Extract .spec.workload
+	workload, found, err := unstructured.NestedMap(binding.Object, "spec", "workload")
+	if err != nil || !found {
+		log.LogDebug("extractWorkloads - no workload found in binding", zap.String("binding", binding.GetName()))
+		return workloads
 	}
 
 	var results []string
 
-	for _, binding := range bindings.Items {
-		if bp.Name != binding.GetName() {
-			continue
-		}
-		// Extract .spec.workload
-		workload, found, err := unstructured.NestedMap(binding.Object, "spec", "workload")
+	// Process clusterScope[] and namespaceScope[]
+	for _, scope := range []string{"clusterScope", "namespaceScope"} {
+		items, found, err := unstructured.NestedSlice(workload, scope)
 		if err != nil || !found {
-			log.LogDebug("extractWorkloads - no workload found in binding", zap.String("binding", binding.GetName()))
 			continue
 		}
-
-		// Process clusterScope[]
-		clusterScope, found, err := unstructured.NestedSlice(workload, "clusterScope")
-		if err == nil && found {
-			for _, item := range clusterScope {
-				if obj, ok := item.(map[string]interface{}); ok {
-					resource, _ := obj["resource"].(string)
-					name, _ := obj["name"].(string)
-					if resource != "" && name != "" {
-						results = append(results, fmt.Sprintf("%s: %s", resource, name))
-					}
-				}
-			}
-		}
-
-		// Process namespaceScope[]
-		namespaceScope, found, err := unstructured.NestedSlice(workload, "namespaceScope")
-		if err == nil && found {
-			for _, item := range namespaceScope {
-				if obj, ok := item.(map[string]interface{}); ok {
-					resource, _ := obj["resource"].(string)
-					name, _ := obj["name"].(string)
-					if resource != "" && name != "" {
-						results = append(results, fmt.Sprintf("%s: %s", resource, name))
-					}
+		for _, item := range items {
+			if obj, ok := item.(map[string]interface{}); ok {
+				resource, _ := obj["resource"].(string)
+				name, _ := obj["name"].(string)
+				if resource != "" && name != "" {
+					results = append(results, fmt.Sprintf("%s: %s", resource, name))
 				}
 			}
 		}
@@ -234,10 +204,6 @@ func extractWorkloads(bp *v1alpha1.BindingPolicy) []string {
 
 	workloads = results
 
-	// Print as formatted JSON array
-	// formatted, _ := json.MarshalIndent(results, "", "  ")
-	// fmt.Println(string(formatted))
-
 	log.LogInfo("extractWorkloads - extracted workloads",
 		zap.Int("count", len(workloads)), zap.Strings("workloads", workloads))
 	return workloads
@@ -329,53 +295,3 @@ func contentTypeValid(t string) bool {
 	}
 	return false
 }
-
-// watches on all binding policy resources , PROTOTYPE just for now
-func watchOnBps() {
-	c, err := getClientForBp()
-	if err != nil {
-		log.LogError("failed to watch on BP", zap.String("error", err.Error()))
-		return
-	}
-
-	for {
-
-		w, err := c.BindingPolicies().Watch(context.TODO(), v1.ListOptions{})
-		if err != nil {
-			log.LogError("failed to watch on BP", zap.String("error", err.Error()))
-			return
-		}
-		eventChan := w.ResultChan()
-		for event := range eventChan {
-			switch event.Type {
-			case "MODIFIED":
-				bp, _ := event.Object.(*v1alpha1.BindingPolicy)
-				if bp.ObjectMeta.Generation == bp.Status.ObservedGeneration {
-					log.LogInfo("reconciled successfully", zap.String("name", bp.Name))
-				} else {
-					log.LogInfo("reconciling...", zap.String("name", bp.Name))
-				}
-				log.LogInfo("BP modified: ", zap.String("name", bp.Name))
-
-			case "ADDED":
-				bp, _ := event.Object.(*v1alpha1.BindingPolicy)
-				log.LogInfo("BP added: ", zap.String("name", bp.Name))
-
-			case "DELETED":
-				bp, _ := event.Object.(*v1alpha1.BindingPolicy)
-				err := redis.DeleteBpcmd(bp.Name)
-				if err != nil {
-					log.LogError("Error deleting bp from redis", zap.String("error", err.Error()))
-				}
-				log.LogInfo("BP deleted: ", zap.String("name", bp.Name))
-			case "ERROR":
-				log.LogWarn("Some error occured while watching ON BP")
-			}
-		}
-
-	}
-}
-func init() {
-
-	go watchOnBps()
-}
@@ -0,0 +1,44 @@
+package bp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestExtractWorkloads(t *testing.T) {
+	bindings := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "Binding",
+		"metadata":   map[string]interface{}{"name": "nginx"},
+		"spec": map[string]interface{}{
+			"workload": map[string]interface{}{
+				"clusterScope": []interface{}{
+					map[string]interface{}{"resource": "namespaces", "name": "nginx"},
+				},
+				"namespaceScope": []interface{}{
+					map[string]interface{}{"resource": "deployments", "namespace": "nginx", "name": "web"},
+					map[string]interface{}{"resource": "services"},
+				},
+			},
+		},
+	}}
+	if err := bindings.Add(binding); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := func(name string) *v1alpha1.BindingPolicy {
+		return &v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	if got, want := extractWorkloads(policy("nginx"), bindings), []string{"namespaces: nginx", "deployments: web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("extractWorkloads() = %v, want %v", got, want)
+	}
+	if got := extractWorkloads(policy("other"), bindings); len(got) != 0 {
+		t.Errorf("extractWorkloads() without a binding = %v, want none", got)
+	}
+}
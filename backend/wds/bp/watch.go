@@ -0,0 +1,82 @@
+package bp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/k8s"
+	"github.com/kubestellar/ui/log"
+	"go.uber.org/zap"
+)
+
+const watchPingInterval = 30 * time.Second
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: config.CheckOrigin,
+}
+
+// WatchBp streams the binding policies over a WebSocket: a SNAPSHOT message with every policy,
+// in the shape of the list response, then a PolicyEvent per change. The connection is closed
+// when the client falls behind; it reconnects to get a new snapshot.
+func WatchBp(ctx *gin.Context) {
+	pc, err := policyCacheFor(k8s.RequestWDSContext(ctx))
+	if err != nil {
+		log.LogError("failed to get binding policy cache", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		log.LogError("failed to upgrade binding policy watch", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	policies, events, cancel := pc.Subscribe()
+	defer cancel()
+
+	snapshot := make([]map[string]interface{}, len(policies))
+	for i, bp := range policies {
+		snapshot[i] = bindingPolicyResponse(bp)
+	}
+	if err := conn.WriteJSON(gin.H{"type": "SNAPSHOT", "bindingPolicies": snapshot, "count": len(snapshot)}); err != nil {
+		return
+	}
+
+	// The client sends nothing; reading notices when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(watchPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too many pending events"), time.Now().Add(time.Second))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
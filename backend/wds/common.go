@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/config"
+	"github.com/kubestellar/ui/k8s"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 	"log"
 	"net/http"
-	"os"
 	"os/exec"
 	"strings"
 )
@@ -17,29 +18,15 @@ import (
 /*
 Load the KubeConfig file and return the kubernetes clientset which gives you access to play with the k8s api
 */
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
-	}
-	return os.Getenv("USERPROFILE") // windows
-}
-
 func getKubeConfig() (*api.Config, error) {
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		if home := homeDir(); home != "" {
-			kubeconfig = fmt.Sprintf("%s/.kube/config", home)
-		}
-	}
-
-	config, err := clientcmd.LoadFromFile(kubeconfig)
+	config, err := clientcmd.LoadFromFile(k8s.KubeconfigPath())
 	if err != nil {
 		return nil, err
 	}
 	return config, nil
 }
 
-// only for wds1
+// only for the default WDS
 func GetClientSetKubeConfig() (*kubernetes.Clientset, error) {
 	config, err := getKubeConfig()
 	if err != nil {
@@ -47,8 +34,9 @@ func GetClientSetKubeConfig() (*kubernetes.Clientset, error) {
 		return nil, fmt.Errorf("failed to load kubeconfig")
 	}
 
-	// Use WDS1 context specifically
-	ctxContext := config.Contexts["wds1"]
+	// Use the WDS context specifically
+	wdsContext := k8s.WDSContext()
+	ctxContext := config.Contexts[wdsContext]
 	if ctxContext == nil {
 		// c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ctxConfig"})
 		return nil, fmt.Errorf("failed to create ctxConfig")
@@ -58,7 +46,7 @@ func GetClientSetKubeConfig() (*kubernetes.Clientset, error) {
 	clientConfig := clientcmd.NewDefaultClientConfig(
 		*config,
 		&clientcmd.ConfigOverrides{
-			CurrentContext: "wds1",
+			CurrentContext: wdsContext,
 		},
 	)
 
@@ -91,9 +79,7 @@ func ListContexts() (string, []string, error) {
 }
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+	CheckOrigin: config.CheckOrigin,
 }
 
 func writeMessage(conn *websocket.Conn, message string) {
@@ -130,7 +116,7 @@ func SetWdsContextCookies(c *gin.Context) {
 		})
 		return
 	}
-	c.SetCookie("ui-wds-context", request.Context, 3600, "/", "", false, true)
+	http.SetCookie(c.Writer, config.Current().Server.Cookies.Cookie("ui-wds-context", request.Context, 3600))
 	msg := fmt.Sprintf("switched to %s context", request.Context)
 	c.JSON(http.StatusOK, gin.H{
 		"message":            msg,
@@ -151,7 +137,7 @@ func GetWdsContextCookies(c *gin.Context) {
 		if strings.Contains("wds", currentContext) {
 			cookieContext = currentContext // Default to Kubernetes API context
 		} else {
-			cookieContext = "wds1"
+			cookieContext = k8s.WDSContext()
 		}
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -67,9 +67,9 @@ func GetDeploymentByName(c *gin.Context) {
 func GetWDSWorkloads(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = k8s.WDSContext()
 	}
-	clientset, _, err := k8s.GetClientSetWithContext(cookieContext)
+	clientset, _, err := k8s.GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "failed to create Kubernetes clientset",
@@ -40,9 +40,9 @@ type ResourceListResponse struct {
 func ListAllResourcesByNamespace(c *gin.Context) {
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = k8s.WDSContext()
 	}
-	clientset, dynamicClient, err := k8s.GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := k8s.GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -139,7 +139,7 @@ func ListAllResourcesDetailsSSE(c *gin.Context) {
 
 	cookieContext, err := c.Cookie("ui-wds-context")
 	if err != nil {
-		cookieContext = "wds1"
+		cookieContext = k8s.WDSContext()
 	}
 	cacheKey := getCacheKey(cookieContext, "list")
 	result := ResourceListResponse{
@@ -154,7 +154,7 @@ func ListAllResourcesDetailsSSE(c *gin.Context) {
 		return
 	}
 
-	clientset, dynamicClient, err := k8s.GetClientSetWithContext(cookieContext)
+	clientset, dynamicClient, err := k8s.GetClientSetForRequest(c.Request.Context(), cookieContext)
 	if err != nil {
 		sendEvent("error", gin.H{"error": err.Error()})
 		return
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/config"
 	"github.com/kubestellar/ui/k8s"
 	"io"
 	authorizationv1 "k8s.io/api/authorization/v1"
@@ -30,9 +31,7 @@ import (
 // Todo: Websocket improvement and remove the error message like "Connection closed"
 
 var upgrader1 = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+	CheckOrigin: config.CheckOrigin,
 }
 
 type TerminalSession struct {
@@ -98,7 +97,7 @@ func GetAllPodContainersName(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no context present as query"})
 		return
 	}
-	clientSet, _, err := k8s.GetClientSetWithContext(context)
+	clientSet, _, err := k8s.GetClientSetForRequest(c.Request.Context(), context)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get kube context"})
 		return
@@ -208,7 +207,7 @@ func HandlePodExecShell(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no context present as query"})
 		return
 	}
-	clientset, restConfig, err := k8s.GetClientSetWithConfigContext(context)
+	clientset, restConfig, err := k8s.GetClientSetWithConfigForRequest(c.Request.Context(), context)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get kube context"})
 		return
@@ -8,7 +8,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"sort"
 	"strings"
 	"sync"
@@ -16,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/kubestellar/ui/config"
 	"github.com/kubestellar/ui/its/manual/handlers"
 	"github.com/kubestellar/ui/k8s"
 	"github.com/kubestellar/ui/redis"
@@ -35,7 +35,7 @@ import (
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin: config.CheckOrigin,
 }
 
 // Cache expiration durations
@@ -99,13 +99,7 @@ func getITSData() ([]handlers.ManagedClusterInfo, error) {
 		return cachedClusters, nil
 	}
 
-	kubeconfig := os.Getenv("KUBECONFIG")
-	if kubeconfig == "" {
-		if home := handlers.HomeDir(); home != "" {
-			kubeconfig = fmt.Sprintf("%s/.kube/config", home)
-		}
-	}
-	config, err := clientcmd.LoadFromFile(kubeconfig)
+	config, err := clientcmd.LoadFromFile(k8s.KubeconfigPath())
 	if err != nil {
 		return nil, err
 	}
@@ -263,7 +257,7 @@ func StreamK8sDataChronologically(c *gin.Context) {
 							LastUpdated: time.Now(),
 						}
 
-						clientset, _, err := k8s.GetClientSetWithContext(ci.Name)
+						clientset, _, err := k8s.GetClientSetForRequest(c.Request.Context(), ci.Name)
 						if err != nil {
 							return
 						}
@@ -1098,7 +1092,7 @@ func StreamPodLogs(c *gin.Context) {
 	}()
 
 	// Retrieve the clientset using the specified cluster context.
-	clientset, _, err := k8s.GetClientSetWithContext(cluster)
+	clientset, _, err := k8s.GetClientSetForRequest(c.Request.Context(), cluster)
 	if err != nil {
 		log.Printf("Error getting clientset for cluster/context %s: %v", cluster, err)
 		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("error: %v", err)))
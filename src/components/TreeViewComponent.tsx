@@ -59,7 +59,7 @@ import { useQuery } from '@tanstack/react-query';
 import { isEqual } from 'lodash';
 import { useWebSocket } from '../context/webSocketExports';
 import useTheme from '../stores/themeStore';
-import axios from 'axios';
+import { api } from '../lib/api';
 import WarningAmberIcon from '@mui/icons-material/WarningAmber';
 import ListViewComponent from '../components/ListViewComponent';
 import ContextDropdown from '../components/ContextDropdown';
@@ -1850,14 +1850,14 @@ const TreeViewComponent = (_props: TreeViewComponentProps) => {
         let endpoint: string;
 
         if (nodeType.toLowerCase() === 'namespace') {
-          endpoint = `${process.env.VITE_BASE_URL}/api/namespaces/delete/${namespace}`;
+          endpoint = `/api/namespaces/delete/${namespace}`;
         } else {
           const kind = nodeType.charAt(0).toUpperCase() + nodeType.slice(1);
           const pluralForm = kindToPluralMap[kind] || `${nodeType.toLowerCase()}s`;
-          endpoint = `${process.env.VITE_BASE_URL}/api/${pluralForm}/${namespace}/${nodeName}`;
+          endpoint = `/api/${pluralForm}/${namespace}/${nodeName}`;
         }
 
-        await axios.delete(endpoint);
+        await api.delete(endpoint);
 
         const descendantNodeIds = findDescendantNodes(nodeId, edges);
         const nodesToDelete = [nodeId, ...descendantNodeIds];
@@ -330,12 +330,15 @@ const WecsDetailsPanel = ({
     if (!wsParamsRef.current || !isOpen) return;
 
     const { cluster, namespace, pod } = wsParamsRef.current;
-    const wsUrl = getWebSocketUrl(`/ws/logs?cluster=${cluster}&namespace=${namespace}&pod=${pod}`);
+    const logsUrl = getWebSocketUrl(`/ws/logs?cluster=${cluster}&namespace=${namespace}&pod=${pod}`);
+    // WebSockets cannot send headers, so the token goes in the query; it is kept out of the logs
+    const token = localStorage.getItem('jwtToken') || '';
+    const wsUrl = `${logsUrl}&token=${encodeURIComponent(token)}`;
 
     setLogs(prev => [
       ...prev,
       `\x1b[33m[Connecting] WebSocket Request\x1b[0m`,
-      `URL: ${wsUrl}`,
+      `URL: ${logsUrl}`,
       `Timestamp: ${new Date().toISOString()}`,
       `-----------------------------------`,
     ]);
@@ -624,9 +627,11 @@ const WecsDetailsPanel = ({
       // Use selectedContainer if available, otherwise use fallback
       const containerName = selectedContainer || 'container';
 
-      const wsUrl = getWebSocketUrl(
+      const shellUrl = getWebSocketUrl(
         `/ws/pod/${encodeURIComponent(namespace)}/${encodeURIComponent(name)}/shell/${encodeURIComponent(containerName)}?context=${encodeURIComponent(cluster)}&shell=sh`
       );
+      const token = localStorage.getItem('jwtToken') || '';
+      const wsUrl = `${shellUrl}&token=${encodeURIComponent(token)}`;
 
       // Show a minimal connecting message with a spinner effect
       term.writeln(`\x1b[33mConnecting to pod shell in container ${containerName}...\x1b[0m`);
@@ -637,7 +642,7 @@ const WecsDetailsPanel = ({
         namespace,
         container: containerName,
         context: cluster,
-        url: wsUrl,
+        url: shellUrl,
       });
 
       const socket = new WebSocket(wsUrl);